@@ -0,0 +1,584 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-cabundle inspects and drives the cabundle-operator from kubectl,
+// e.g. `kubectl cabundle status`.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/shanmugara/cabundle-operator/internal/controller"
+)
+
+// options are the flags shared by every subcommand.
+type options struct {
+	targetNamespace string
+	configMapName   string
+	timeout         time.Duration
+}
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	o := &options{}
+	root := &cobra.Command{
+		Use:          "kubectl-cabundle",
+		Short:        "Inspect and sync the CA bundles distributed by cabundle-operator",
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().StringVar(&o.targetNamespace, "target-namespace", "cert-manager",
+		"The namespace of the operator's source ConfigMap, as passed to the operator.")
+	root.PersistentFlags().StringVar(&o.configMapName, "configmap-name", "periodic-cabundle-enqueue",
+		"The name of the operator's source ConfigMap, as passed to the operator.")
+	root.PersistentFlags().DurationVar(&o.timeout, "timeout", 5*time.Minute, "How long to wait for the cluster and bundle downloads.")
+	// --kubeconfig, read by ctrl.GetConfig.
+	root.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "status [namespace/name]",
+			Short: "Show the sync state of every bundle source, or a single one",
+			Args:  cobra.MaximumNArgs(1),
+			RunE:  o.run(status),
+		},
+		&cobra.Command{
+			Use:   "sync [namespace/name]",
+			Short: "Sync every bundle source now, or a single one",
+			Args:  cobra.MaximumNArgs(1),
+			RunE:  o.run(sync),
+		},
+		&cobra.Command{
+			Use:   "diff [namespace/name]",
+			Short: "Show the ConfigMap changes the next sync would make",
+			Args:  cobra.MaximumNArgs(1),
+			RunE:  o.run(diff),
+		},
+		&cobra.Command{
+			Use:   "certs [namespace/name]",
+			Short: "List the distributed certificates and their expiry",
+			Args:  cobra.MaximumNArgs(1),
+			RunE:  o.run(certs),
+		},
+		newRenderCommand(o),
+		newExportCommand(o),
+		newValidateSourceCommand(o),
+		newReportCommand(o),
+		newRollbackCommand(o),
+		&cobra.Command{
+			Use:   "unpin namespace/name",
+			Short: "Resume the forward syncs of a source pinned by rollback",
+			Args:  cobra.ExactArgs(1),
+			RunE: o.run(func(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error {
+				return pin(ctx, r, &sources[0], 0)
+			}),
+		},
+		&cobra.Command{
+			Use:   "acknowledge namespace/name",
+			Short: "Apply the upstream change a source with change_policy alert holds back",
+			Args:  cobra.ExactArgs(1),
+			RunE:  o.run(acknowledge),
+		},
+	)
+	return root
+}
+
+// newRenderCommand returns the render subcommand, which runs the download
+// pipeline of a source without a cluster.
+func newRenderCommand(o *options) *cobra.Command {
+	var filename, outputDir, format string
+	var namespaces []string
+	cmd := &cobra.Command{
+		Use:   "render -f SOURCE -o DIR",
+		Short: "Render the ConfigMaps of a source ConfigMap manifest into a directory, without a cluster",
+		Long: "Render downloads and validates the bundle of the source ConfigMap in the manifest and writes the " +
+			"managed ConfigMaps to DIR/<namespace>/<name>.yaml, or with --format=pem the bundle files to DIR, " +
+			"so GitOps pipelines can commit the rendered trust instead of running the operator.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, cancel := context.WithTimeout(cmd.Context(), o.timeout)
+			defer cancel()
+			return render(ctx, o, filename, outputDir, format, namespaces)
+		},
+	}
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "The manifest of the source ConfigMap.")
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "The directory the rendered files are written to.")
+	cmd.Flags().StringVar(&format, "format", "manifests", "What to write: manifests or pem.")
+	cmd.Flags().StringSliceVarP(&namespaces, "namespace", "n", nil,
+		"The namespaces to render ConfigMaps for, by default those in target_namespaces.")
+	_ = cmd.MarkFlagRequired("filename")
+	_ = cmd.MarkFlagRequired("output-dir")
+	return cmd
+}
+
+// newExportCommand returns the export subcommand, which writes the managed
+// ConfigMaps as a single multi-document YAML.
+func newExportCommand(o *options) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "export [namespace/name]",
+		Short: "Export the managed ConfigMaps of every bundle source, or a single one, as multi-document YAML",
+		Long: "Export writes the ConfigMaps distributed by the sources as a single multi-document YAML, without " +
+			"the fields set by the API server, for backups, reviews or seeding air-gapped clusters with " +
+			"`kubectl apply -f`.",
+		Args: cobra.MaximumNArgs(1),
+	}
+	cmd.RunE = o.run(func(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error {
+		return export(ctx, r, sources, output)
+	})
+	cmd.Flags().StringVarP(&output, "output", "o", "-", "The file the manifests are written to, - for stdout.")
+	return cmd
+}
+
+// newValidateSourceCommand returns the validate-source subcommand, which
+// checks a publishing endpoint without a cluster.
+func newValidateSourceCommand(o *options) *cobra.Command {
+	var expiryWarning, maxFileSize string
+	cmd := &cobra.Command{
+		Use:   "validate-source URL",
+		Short: "Check the bundle files served at URL before pointing a source at it",
+		Long: "Validate-source fetches the index at URL, downloads every bundle file listed, parses every " +
+			"certificate and reports policy violations: files failing to download or parse, certificates that " +
+			"aren't CAs, are expired, expiring within --expiry-warning or distributed by several files. " +
+			"It exits non-zero if any violation is found.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(cmd.Context(), o.timeout)
+			defer cancel()
+			src := &corev1.ConfigMap{Data: map[string]string{controller.BundleURLKey: args[0]}}
+			if expiryWarning != "" {
+				src.Data[controller.ExpiryWarningKey] = expiryWarning
+			}
+			if maxFileSize != "" {
+				src.Data[controller.MaxFileSizeKey] = maxFileSize
+			}
+			return validateSource(ctx, src)
+		},
+	}
+	cmd.Flags().StringVar(&expiryWarning, "expiry-warning", "",
+		"How long before their expiry certificates are reported, as expiry_warning of a source. Defaults to 720h.")
+	cmd.Flags().StringVar(&maxFileSize, "max-file-size", "", "The largest file downloaded, as max_file_size of a source. Defaults to 1Mi.")
+	return cmd
+}
+
+// newReportCommand returns the report subcommand, which writes the trust
+// inventory of the cluster.
+func newReportCommand(o *options) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "report [namespace/name]",
+		Short: "Report every distributed certificate and where it is distributed, as JSON or CSV",
+		Long: "Report lists every certificate in the managed ConfigMaps of the sources once, with its subject, " +
+			"issuer, SHA-256 fingerprint, expiry and the sources, namespaces and ConfigMaps distributing it, " +
+			"for audits and compliance reviews.",
+		Args: cobra.MaximumNArgs(1),
+	}
+	cmd.RunE = o.run(func(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error {
+		return report(ctx, r, sources, format)
+	})
+	cmd.Flags().StringVarP(&format, "output", "o", "json", "The format of the report: json or csv.")
+	return cmd
+}
+
+// newRollbackCommand returns the rollback subcommand, which pins a source to
+// a retained revision.
+func newRollbackCommand(o *options) *cobra.Command {
+	var revision int64
+	cmd := &cobra.Command{
+		Use:   "rollback namespace/name --revision N",
+		Short: "Restore the managed ConfigMaps of a source to a retained revision and pause its forward syncs",
+		Long: "Rollback sets pinned_revision on the source: the operator restores its managed ConfigMaps to the " +
+			"revision retained by retain_revisions and stops syncing new bundles until `kubectl cabundle unpin`.",
+		Args: cobra.ExactArgs(1),
+	}
+	cmd.RunE = o.run(func(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error {
+		if revision < 1 {
+			return fmt.Errorf("--revision must be a revision, got %d", revision)
+		}
+		return pin(ctx, r, &sources[0], revision)
+	})
+	cmd.Flags().Int64Var(&revision, "revision", 0, "The retained revision to restore, see the cabundle.io/revision annotation.")
+	_ = cmd.MarkFlagRequired("revision")
+	return cmd
+}
+
+// subcommand runs against the selected sources.
+type subcommand func(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error
+
+// run connects to the cluster and resolves the sources selected by args
+// before running the subcommand.
+func (o *options) run(sub subcommand) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(cmd.Context(), o.timeout)
+		defer cancel()
+
+		cfg, err := ctrl.GetConfig()
+		if err != nil {
+			return err
+		}
+		c, err := client.New(cfg, client.Options{Scheme: clientgoscheme.Scheme})
+		if err != nil {
+			return err
+		}
+		r := &controller.CABundleReconciler{
+			Client:          c,
+			Scheme:          clientgoscheme.Scheme,
+			TargetNamespace: o.targetNamespace,
+			ConfigMapName:   o.configMapName,
+		}
+
+		sources, err := o.sources(ctx, r, args)
+		if err != nil {
+			return err
+		}
+		return sub(ctx, r, sources)
+	}
+}
+
+// sources returns the source named by args, or every source.
+func (o *options) sources(ctx context.Context, r *controller.CABundleReconciler, args []string) ([]corev1.ConfigMap, error) {
+	if len(args) == 0 {
+		return r.ListSources(ctx)
+	}
+	ns, name, ok := strings.Cut(args[0], "/")
+	if !ok || ns == "" || name == "" {
+		return nil, fmt.Errorf("source must be <namespace>/<name>, got %q", args[0])
+	}
+	cm := corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, &cm); err != nil {
+		return nil, err
+	}
+	return []corev1.ConfigMap{cm}, nil
+}
+
+func render(ctx context.Context, o *options, filename, outputDir, format string, namespaces []string) error {
+	if format != "manifests" && format != "pem" {
+		return fmt.Errorf("unknown format %q, must be manifests or pem", format)
+	}
+	manifest, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	src := &corev1.ConfigMap{}
+	if err := yaml.UnmarshalStrict(manifest, src); err != nil {
+		return fmt.Errorf("reading %s: %w", filename, err)
+	}
+	if src.Namespace == "" {
+		src.Namespace = o.targetNamespace
+	}
+
+	r := &controller.CABundleReconciler{TargetNamespace: o.targetNamespace, ConfigMapName: o.configMapName}
+	cms, bundles, err := r.Render(ctx, src, namespaces)
+	if err != nil {
+		return err
+	}
+
+	if format == "pem" {
+		if err := os.MkdirAll(outputDir, 0o755); err != nil {
+			return err
+		}
+		for _, b := range bundles {
+			path := filepath.Join(outputDir, b.Filename)
+			if err := os.WriteFile(path, b.Content, 0o644); err != nil {
+				return err
+			}
+			fmt.Println(path)
+		}
+		return nil
+	}
+	for i := range cms {
+		cms[i].APIVersion, cms[i].Kind = "v1", "ConfigMap"
+		out, err := yaml.Marshal(&cms[i])
+		if err != nil {
+			return err
+		}
+		dir := filepath.Join(outputDir, cms[i].Namespace)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, cms[i].Name+".yaml")
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			return err
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
+
+func export(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap, output string) error {
+	var cms []corev1.ConfigMap
+	for i := range sources {
+		exported, err := r.Manifests(ctx, &sources[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", client.ObjectKeyFromObject(&sources[i]), err)
+		}
+		cms = append(cms, exported...)
+	}
+	if output == "-" {
+		return controller.WriteManifests(os.Stdout, cms)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := controller.WriteManifests(f, cms); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func validateSource(ctx context.Context, src *corev1.ConfigMap) error {
+	report, err := controller.ValidateSource(ctx, src)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tSIZE\tSUBJECT\tNOT AFTER\tSHA256")
+	for _, f := range report.Files {
+		if len(f.Certificates) == 0 {
+			fmt.Fprintf(w, "%s\t%d\t\t\t\n", f.Filename, f.Size)
+		}
+		for _, c := range f.Certificates {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", f.Filename, f.Size, c.Subject, c.NotAfter.UTC().Format(time.RFC3339), c.Fingerprint[:16])
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(report.Violations) == 0 {
+		fmt.Printf("\n%d files valid\n", len(report.Files))
+		return nil
+	}
+	fmt.Printf("\n%d policy violations:\n", len(report.Violations))
+	for _, v := range report.Violations {
+		fmt.Printf("  %s\n", v)
+	}
+	return fmt.Errorf("%d policy violations", len(report.Violations))
+}
+
+func report(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap, format string) error {
+	if format != "json" && format != "csv" {
+		return fmt.Errorf("unknown output format %q, must be json or csv", format)
+	}
+	inventory, err := r.Inventory(ctx, sources)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(inventory)
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	_ = w.Write([]string{"subject", "issuer", "sha256", "not_after", "sources", "namespaces", "configmaps"})
+	for _, e := range inventory {
+		_ = w.Write([]string{e.Subject, e.Issuer, e.Fingerprint, e.NotAfter.UTC().Format(time.RFC3339),
+			strings.Join(e.Sources, " "), strings.Join(e.Namespaces, " "), strings.Join(e.ConfigMaps, " ")})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func status(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tREADY\tHEALTH\tLAST SYNC\tNEXT SYNC\tNAMESPACES\tSOONEST EXPIRY\tMESSAGE")
+	for i := range sources {
+		st, err := r.Status(ctx, &sources[i])
+		if err != nil {
+			return err
+		}
+		synced := 0
+		for _, ns := range st.Namespaces {
+			if ns.Synced {
+				synced++
+			}
+		}
+		ready, message := "Unknown", ""
+		if len(st.History) > 0 {
+			last := st.History[len(st.History)-1]
+			ready = fmt.Sprint(last.Outcome == controller.SyncSucceeded)
+			message = last.Error
+		}
+		health := "Unknown"
+		if st.Health != nil {
+			health = st.Health.Status
+		}
+		if c := meta.FindStatusCondition(st.Conditions, controller.ConditionCircuitOpen); c != nil && c.Status == metav1.ConditionTrue {
+			message = c.Message
+		}
+		if c := meta.FindStatusCondition(st.Conditions, controller.ConditionPinned); c != nil && c.Status == metav1.ConditionTrue {
+			message = c.Message
+		}
+		if st.Plan != nil {
+			message = fmt.Sprintf("plan %s with %d changes awaits approval", st.Plan.ID, len(st.Plan.Changes))
+		}
+		if c := meta.FindStatusCondition(st.Conditions, controller.ConditionChangeUnacknowledged); c != nil && c.Status == metav1.ConditionTrue {
+			message = c.Message
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d/%d\t%s\t%s\n", client.ObjectKeyFromObject(&sources[i]), ready, health,
+			ago(st.LastSyncTime), ago(st.NextSyncTime), synced, len(st.Namespaces), ago(st.SoonestExpiry), message)
+	}
+	return w.Flush()
+}
+
+func sync(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error {
+	now := fmt.Sprint(time.Now().Unix())
+	for i := range sources {
+		patch := client.MergeFrom(sources[i].DeepCopy())
+		if sources[i].Annotations == nil {
+			sources[i].Annotations = map[string]string{}
+		}
+		sources[i].Annotations[controller.SyncNowAnnotation] = now
+		if err := r.Patch(ctx, &sources[i], patch); err != nil {
+			return err
+		}
+		fmt.Printf("%s sync requested\n", client.ObjectKeyFromObject(&sources[i]))
+	}
+	return nil
+}
+
+// pin sets the pinned_revision of the source, or removes it for revision 0.
+func pin(ctx context.Context, r *controller.CABundleReconciler, src *corev1.ConfigMap, revision int64) error {
+	patch := client.MergeFrom(src.DeepCopy())
+	if revision == 0 {
+		delete(src.Data, controller.PinnedRevisionKey)
+	} else {
+		if src.Data == nil {
+			src.Data = map[string]string{}
+		}
+		src.Data[controller.PinnedRevisionKey] = fmt.Sprint(revision)
+	}
+	if err := r.Patch(ctx, src, patch); err != nil {
+		return err
+	}
+	if revision == 0 {
+		fmt.Printf("%s unpinned\n", client.ObjectKeyFromObject(src))
+	} else {
+		fmt.Printf("%s pinned to revision %d\n", client.ObjectKeyFromObject(src), revision)
+	}
+	return nil
+}
+
+// acknowledge annotates the source with the hash of the upstream change it
+// holds back.
+func acknowledge(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error {
+	src := &sources[0]
+	st, err := r.Status(ctx, src)
+	if err != nil {
+		return err
+	}
+	if st.UnacknowledgedChange == nil {
+		return fmt.Errorf("%s holds back no upstream change", client.ObjectKeyFromObject(src))
+	}
+	patch := client.MergeFrom(src.DeepCopy())
+	if src.Annotations == nil {
+		src.Annotations = map[string]string{}
+	}
+	src.Annotations[controller.AcknowledgeChangeAnnotation] = st.UnacknowledgedChange.Hash
+	if err := r.Patch(ctx, src, patch); err != nil {
+		return err
+	}
+	fmt.Printf("%s change %.12s acknowledged\n", client.ObjectKeyFromObject(src), st.UnacknowledgedChange.Hash)
+	return nil
+}
+
+func diff(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error {
+	for i := range sources {
+		changes, err := r.Diff(ctx, &sources[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", client.ObjectKeyFromObject(&sources[i]), err)
+		}
+		fmt.Printf("# %s: %d changes\n", client.ObjectKeyFromObject(&sources[i]), len(changes))
+		for _, c := range changes {
+			fmt.Printf("%s configmap %s/%s", c.Action, c.Namespace, c.Name)
+			if c.Trust != "" {
+				fmt.Printf(": %s", c.Trust)
+			}
+			fmt.Println()
+		}
+	}
+	return nil
+}
+
+func certs(ctx context.Context, r *controller.CABundleReconciler, sources []corev1.ConfigMap) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tNAMESPACE\tCONFIGMAP\tSUBJECT\tNOT AFTER\tEXPIRES\tSHA256")
+	for i := range sources {
+		certs, err := r.Certificates(ctx, &sources[i])
+		if err != nil {
+			return err
+		}
+		for _, c := range certs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", client.ObjectKeyFromObject(&sources[i]), c.Namespace, c.ConfigMap,
+				c.Subject, c.NotAfter.UTC().Format(time.RFC3339), ago(&metav1.Time{Time: c.NotAfter}), c.Fingerprint[:16])
+		}
+	}
+	return w.Flush()
+}
+
+// ago formats a time relative to now, e.g. 5m ago or in 30d.
+func ago(t *metav1.Time) string {
+	if t == nil {
+		return "-"
+	}
+	d := time.Until(t.Time).Round(time.Second)
+	if d < 0 {
+		return humanDuration(-d) + " ago"
+	}
+	return "in " + humanDuration(d)
+}
+
+// humanDuration rounds a duration to its largest unit, as kubectl does.
+func humanDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
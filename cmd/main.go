@@ -19,24 +19,44 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	"github.com/shanmugara/cabundle-operator/internal/nodeagent"
+	"github.com/shanmugara/cabundle-operator/internal/notify"
 	"github.com/shanmugara/cabundle-operator/internal/periodic"
+	"github.com/shanmugara/cabundle-operator/internal/tracing"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
-	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -44,8 +64,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/shanmugara/cabundle-operator/internal/controller"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	webhookv1 "github.com/shanmugara/cabundle-operator/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -56,6 +75,8 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+	utilruntime.Must(apiregistrationv1.AddToScheme(scheme))
 
 	// +kubebuilder:scaffold:scheme
 }
@@ -71,9 +92,9 @@ func main() {
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
 
-	var interval time.Duration = 1 * time.Hour
 	var targetNamespace string
 	var configMapName string
+	var enablePodInjection bool
 
 	// flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 	// 	"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -103,6 +124,13 @@ func main() {
 	pflag.String("health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	pflag.Bool("leader-elect", false, "Enable leader election for controller manager. "+
 		"Enabling this will ensure there is only one active controller manager.")
+	pflag.String("leader-election-namespace", "",
+		"The namespace of the leader election lease, by default the namespace the operator runs in.")
+	pflag.Duration("leader-election-lease-duration", 15*time.Second,
+		"How long standby replicas wait before taking over a lease that wasn't renewed.")
+	pflag.Duration("leader-election-renew-deadline", 10*time.Second,
+		"How long the leader retries renewing its lease before stepping down.")
+	pflag.Duration("leader-election-retry-period", 2*time.Second, "How often replicas try to acquire or renew the lease.")
 	pflag.Bool("metrics-secure", true, "If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	pflag.String("webhook-cert-path", "", "The directory that contains the webhook certificate.")
 	pflag.String("webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
@@ -115,12 +143,158 @@ func main() {
 	pflag.StringVar(&targetNamespace, "target-namespace", "cert-manager", "The target namespace to create bundle ConfigMaps in.")
 	pflag.StringVar(&configMapName, "configmap-name", "periodic-cabundle-enqueue", "The name of the ConfigMap containing operator configuration.")
 
+	pflag.Bool("enable-pod-injection", false, "If set, the mutating webhook mounting the CA bundle into annotated pods is enabled.")
+	pflag.String("pod-injection-source", "",
+		"The bundle source, as <namespace>/<name>, whose aggregate_configmap is mounted into annotated pods. "+
+			"Defaults to --configmap-name in --target-namespace.")
+	pflag.String("pod-injection-truststore-secret", "",
+		"The Secret in the pod's namespace the JKS truststore password is read from, under the password key, "+
+			"for pods annotated cabundle.io/inject-java-opts. If unset, the JVM loads the truststore without a password.")
+	pflag.String("pod-injection-mount-path", "/etc/cabundle", "The directory the CA bundle is mounted at in annotated pods.")
+
+	pflag.Bool("enable-ca-injection", false,
+		"If set, webhook configurations, CRDs and APIServices annotated cabundle.io/inject-ca-from get their caBundle kept in sync "+
+			"with the managed bundle ConfigMap it names.")
+	pflag.Duration("sync-interval", time.Hour,
+		"The interval bundle sources are synced at, unless they set sync_interval or sync_schedule. Must be at least 10s.")
+	pflag.String("sync-schedule", "",
+		"If set, a cron expression (e.g. \"0 2 * * 1-5\") bundle sources are synced on instead of --sync-interval, unless they set sync_interval or sync_schedule.")
+	pflag.Float64("sync-jitter", 0.1,
+		"The periodic sync interval is stretched by a random fraction of up to this factor, so replicas and sources don't sync in lockstep.")
+	pflag.Bool("sync-stagger", true,
+		"Spread sources sharing a sync interval across it at fixed offsets derived from their names, instead of syncing them together, also right after startup.")
+	pflag.Int("sync-backoff-threshold", 3,
+		"After this many consecutive failed syncs of a source its schedule is stretched, doubling with every further failure. Zero disables backoff.")
+	pflag.Duration("sync-backoff-max", 6*time.Hour, "The longest delay between syncs of a failing source.")
+	pflag.Duration("error-backoff-base", 5*time.Second,
+		"The delay before a failed sync of a source is retried, doubling with every consecutive failure. Zero leaves retries to the controller's rate limiter.")
+	pflag.Duration("error-backoff-max", controller.DefaultErrorBackoffMax, "The longest delay before a failed sync is retried.")
+	pflag.Duration("download-timeout", controller.DefaultDownloadTimeout, "How long the downloads of a single sync may take.")
+	pflag.Duration("http-timeout", time.Minute, "How long a single request to a bundle URL may take. Zero disables the timeout.")
+	pflag.Duration("degraded-retry", controller.DefaultDegradedRetry, "The delay before the failed targets of a partially synced bundle are retried.")
+	pflag.Duration("full-sync-interval", controller.DefaultFullSyncInterval,
+		"How long syncs of a source whose index is unchanged may skip the downloads, before managed ConfigMaps are compared again.")
+	pflag.Int("max-concurrent-reconciles", 4,
+		"The number of sources synced in parallel. Sources sharing a URL and writes to the same namespace are still serialized.")
+	pflag.Bool("dry-run", false,
+		"If set, the managed ConfigMaps that would be created, updated and deleted are logged instead of written, "+
+			"and every other write is only validated by the API server.")
+	pflag.Bool("uncached-cleanup-reads", false,
+		"If set, managed ConfigMaps are read from the API server rather than the informer cache when deciding which are stale.")
+	pflag.Duration("reconcile-base-delay", 5*time.Millisecond,
+		"The delay before the controller retries a failed request, doubling with every failure up to --reconcile-max-delay.")
+	pflag.Duration("reconcile-max-delay", 1000*time.Second, "The longest delay before the controller retries a failed request.")
+	pflag.Float64("reconcile-qps", 10, "The overall rate of requests the controller processes per second.")
+	pflag.Int("reconcile-burst", 100, "The number of requests the controller processes in a burst above --reconcile-qps.")
+	pflag.Float64("kube-api-qps", 20, "The rate of requests per second to the Kubernetes API server.")
+	pflag.Int("kube-api-burst", 30, "The number of requests to the Kubernetes API server in a burst above --kube-api-qps.")
+	pflag.Int("circuit-breaker-threshold", 5,
+		"After this many consecutive failed downloads from a source, downloads from it are suspended for the cooldown. Zero disables the circuit breaker.")
+	pflag.Duration("circuit-breaker-cooldown", 10*time.Minute,
+		"How long downloads from a failing source are suspended before a single download is tried again.")
+	pflag.Duration("sync-staleness-threshold", 0,
+		"If set, readiness fails once any bundle source hasn't synced successfully for this long; changes deliberately "+
+			"held back, e.g. by a maintenance window or awaiting approval, count as synced. Zero disables the check.")
+	pflag.Duration("source-probe-interval", 0,
+		"If set, the interval bundle URLs are probed for reachability between syncs, e.g. 5m. Zero disables probing.")
+	pflag.Duration("source-probe-timeout", controller.DefaultProbeTimeout, "How long a single reachability probe may take.")
+	pflag.Bool("enable-debug-endpoint", false,
+		"If set, the operator's view of its sources is served as JSON at /debug/cabundle on the metrics server. "+
+			"Use with --metrics-secure so the endpoint requires authentication; access is granted by the debug-reader ClusterRole.")
+	pflag.Bool("enable-sync-trigger", false,
+		"If set, a POST to /trigger/cabundle on the metrics server syncs every source, or the one given as ?source=<namespace>/<name>. "+
+			"Use with --metrics-secure so the endpoint requires authentication.")
+	pflag.Duration("event-dedup-window", 5*time.Minute,
+		"Identical Events on a source are collapsed within this window, the number suppressed reported once it expires. "+
+			"Zero disables deduplication.")
+	pflag.String("tracing-endpoint", "",
+		"If set, OTLP/gRPC endpoint (host:port) spans of the sync phases are exported to.")
+	pflag.Bool("tracing-insecure", false, "If set, the tracing endpoint is reached without TLS.")
+	pflag.StringSlice("notify-webhook-url", nil,
+		"URLs a JSON notification is POSTed to when the trust of a bundle changes, its syncs keep failing, or cleanup deletes ConfigMaps.")
+	pflag.StringSlice("notify-slack-webhook-url", nil,
+		"Slack incoming webhook URLs the same notifications are posted to as messages. Prefer the CABO_NOTIFY_SLACK_WEBHOOK_URL "+
+			"environment variable from a Secret, since the URL is a credential.")
+	pflag.String("notify-smtp-address", "",
+		"If set, SMTP server (host:port) emails about expiring certificates, failing syncs and unacknowledged changes are sent through, to "+
+			"--notify-smtp-to and the notify_email addresses of the source. Set the SMTP settings from a Secret as "+
+			"CABO_NOTIFY_SMTP_* environment variables.")
+	pflag.String("notify-smtp-username", "", "The username to authenticate to the SMTP server with, if any.")
+	pflag.String("notify-smtp-password-file", "",
+		"A file holding the password to authenticate to the SMTP server with, e.g. from a mounted Secret. The password can "+
+			"also be set as the CABO_NOTIFY_SMTP_PASSWORD environment variable, but not as a flag.")
+	pflag.String("notify-smtp-from", "", "The sender address of notification emails.")
+	pflag.StringSlice("notify-smtp-to", nil, "Addresses every notification email is sent to, besides the source's notify_email.")
+	pflag.String("notify-pagerduty-routing-key", "",
+		"If set, critical notifications, e.g. a source whose downloads are suspended, trigger a PagerDuty incident "+
+			"through the Events API v2 with this integration key, resolved once the source syncs again.")
+	pflag.String("notify-opsgenie-api-key", "",
+		"If set, critical notifications create a P1 Opsgenie alert with this API key, closed once the source syncs again.")
+	pflag.String("notify-opsgenie-url", notify.DefaultOpsgenieURL,
+		"The Opsgenie Alert API endpoint, e.g. https://api.eu.opsgenie.com/v2/alerts for EU accounts.")
+	pflag.String("cloudevents-sink-url", "",
+		"If set, URL CloudEvents (bundle synced, certificate added and removed, sync failed) are POSTed to in structured mode.")
+	pflag.StringSlice("cloudevents-kafka-brokers", nil,
+		"If set, Kafka brokers (host:port) the same CloudEvents are written to, to --cloudevents-kafka-topic.")
+	pflag.String("cloudevents-kafka-topic", "", "The Kafka topic CloudEvents are written to, keyed by source.")
+	pflag.Int("notify-failure-threshold", controller.DefaultNotifyFailureThreshold,
+		"The number of consecutive failed syncs of a source after which a notification is sent.")
+	pflag.Bool("node-agent", false, "If set, run as the node agent writing the CA bundle into the node trust store instead of the operator.")
+	pflag.String("node-agent-source", "/etc/cabundle/ca.crt", "The mounted aggregated bundle file the node agent reads.")
+	pflag.String("node-agent-host-root", "/host", "The path the node's root filesystem is mounted at.")
+	pflag.String("node-agent-anchors-dir", "/etc/pki/ca-trust/source/anchors", "The node's trust anchors directory.")
+	pflag.String("node-agent-update-command", "/usr/bin/update-ca-trust extract", "The command run on the node after the anchors changed.")
+	pflag.Duration("node-agent-interval", time.Minute, "The interval the node agent checks the bundle for changes.")
+	pflag.Bool("node-agent-cleanup", false,
+		"If set with --node-agent, remove the CA bundle from the node trust store and exit, e.g. from the agent's preStop hook.")
+	pflag.String("config", "",
+		"If set, a YAML file (e.g. a mounted ConfigMap) of settings named like these flags. It is watched, and changes "+
+			"to the sync interval, schedule and jitter, backoffs, timeouts, circuit breaker and log level apply without a restart.")
+	pflag.Bool("dev", false,
+		"If set, run for development against the current kubeconfig: short sync, probe, retry and backoff intervals "+
+			"and verbose logging, unless set explicitly.")
+	pflag.String("dev-namespace", "",
+		"If set with --dev, only sources in this namespace are synced, and only into this namespace.")
+	pflag.StringSlice("source-namespaces", nil,
+		"Namespaces besides --target-namespace ConfigMaps labeled cabundle.io/bundle-source=true are synced from, or * for "+
+			"every namespace. A source decides which namespaces trust its bundle, so by default only --target-namespace is trusted.")
+	pflag.Bool("allow-namespace-creation", false,
+		"If set, sources with create_namespaces create their missing target namespaces. Grant the operator permission to create Namespaces too.")
+	pflag.String("field-manager", controller.DefaultFieldManager,
+		"The field manager recorded in managedFields for every write, e.g. to tell operator instances or a "+
+			"blue/green upgrade apart.")
+	pflag.String("cleanup-mode", controller.CleanupDelete,
+		"How stale and untargeted managed ConfigMaps are cleaned up: delete, report to only log them, or disabled. A "+
+			"source's cleanup key can only make it more conservative.")
+	pflag.Duration("cleanup-grace-period", 0,
+		"If set, cleanup only reports for this long after startup, e.g. to review what it would delete when adopting "+
+			"the operator in a cluster with existing ConfigMaps.")
+	pflag.StringSlice("watch-namespaces", nil,
+		"If set, the manager only caches objects in these namespaces, so it needs no cluster-wide ConfigMap access: "+
+			"sources are only read, and bundles only written, there. Must include --target-namespace.")
+	pflag.String("log-format", "console",
+		"The format of the logs: console, or json for one object per line with the bundle, source URL, target namespace, "+
+			"file and ConfigMap as fields, e.g. for Loki or Elasticsearch.")
+	pflag.String("log-level", "",
+		"If set, the log level (debug, info, error or a verbosity like 2) replacing --zap-log-level, reloaded along with --config.")
+
+	// The zap and kubeconfig flags are parsed along with the rest.
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(flag.CommandLine)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
 
-	viper.BindPFlags(pflag.CommandLine)
-	viper.SetEnvPrefix("CABO")
-	viper.AutomaticEnv()
+	bindSettings(pflag.CommandLine)
+	if file := viper.GetString("config"); file != "" {
+		viper.SetConfigFile(file)
+		viper.SetConfigType("yaml")
+		if err := viper.ReadInConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to read configuration file %s: %v\n", file, err)
+			os.Exit(1)
+		}
+	}
 
 	metricsAddr = viper.GetString("metrics-bind-address")
 	probeAddr = viper.GetString("health-probe-bind-address")
@@ -136,15 +310,71 @@ func main() {
 
 	targetNamespace = viper.GetString("target-namespace")
 	configMapName = viper.GetString("configmap-name")
+	enablePodInjection = viper.GetBool("enable-pod-injection")
 
-	opts := zap.Options{
-		Development: true,
+	if viper.GetBool("dev") {
+		setDevDefaults()
 	}
-	opts.BindFlags(flag.CommandLine)
-	flag.Parse()
 
+	// The log level is kept atomic so reloading the configuration file can
+	// change it.
+	logLevel, ok := opts.Level.(uberzap.AtomicLevel)
+	if !ok {
+		logLevel = uberzap.NewAtomicLevelAt(zapcore.DebugLevel)
+		opts.Level = logLevel
+	}
+	if viper.GetString("log-format") == "json" {
+		timeEncoder := opts.TimeEncoder
+		if timeEncoder == nil {
+			timeEncoder = zapcore.RFC3339TimeEncoder
+		}
+		zap.JSONEncoder(func(c *zapcore.EncoderConfig) { c.EncodeTime = timeEncoder })(&opts)
+	}
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := validateConfig(); err != nil {
+		setupLog.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+	setLogLevel(logLevel)
+
+	if viper.GetBool("node-agent") {
+		agent, err := nodeagent.New(
+			nodeagent.WithSourcePath(viper.GetString("node-agent-source")),
+			nodeagent.WithHostRoot(viper.GetString("node-agent-host-root")),
+			nodeagent.WithAnchorsDir(viper.GetString("node-agent-anchors-dir")),
+			nodeagent.WithUpdateCommand(strings.Fields(viper.GetString("node-agent-update-command"))),
+			nodeagent.WithInterval(viper.GetDuration("node-agent-interval")),
+		)
+		if err != nil {
+			setupLog.Error(err, "unable to create node agent")
+			os.Exit(1)
+		}
+		if viper.GetBool("node-agent-cleanup") {
+			if err := agent.Cleanup(ctrl.LoggerInto(context.Background(), setupLog)); err != nil {
+				setupLog.Error(err, "unable to remove the CA bundle from the node trust store")
+				os.Exit(1)
+			}
+			return
+		}
+		setupLog.Info("starting node agent")
+		if err := agent.Start(ctrl.SetupSignalHandler()); err != nil {
+			setupLog.Error(err, "problem running node agent")
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing := func(context.Context) error { return nil }
+	if endpoint := viper.GetString("tracing-endpoint"); endpoint != "" {
+		var err error
+		shutdownTracing, err = tracing.Setup(context.Background(), endpoint, viper.GetBool("tracing-insecure"))
+		if err != nil {
+			setupLog.Error(err, "unable to set up tracing")
+			os.Exit(1)
+		}
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -212,72 +442,118 @@ func main() {
 		metricsServerOptions.KeyName = metricsCertKey
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		Metrics:                metricsServerOptions,
-		WebhookServer:          webhookServer,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "d8c731f1.omegahome.net",
-		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
-		// when the Manager ends. This requires the binary to immediately end when the
-		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
-		// speeds up voluntary leader transitions as the new leader don't have to wait
-		// LeaseDuration time first.
-		//
-		// In the default scaffold provided, the program ends immediately after
-		// the manager stops, so would be fine to enable this option. However,
-		// if you are doing or is intended to do any operation such as perform cleanups
-		// after the manager stops then its usage might be unsafe.
-		// LeaderElectionReleaseOnCancel: true,
+	leaseDuration := viper.GetDuration("leader-election-lease-duration")
+	renewDeadline := viper.GetDuration("leader-election-renew-deadline")
+	retryPeriod := viper.GetDuration("leader-election-retry-period")
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = float32(viper.GetFloat64("kube-api-qps"))
+	restConfig.Burst = viper.GetInt("kube-api-burst")
+	var cacheOptions cache.Options
+	if namespaces := viper.GetStringSlice("watch-namespaces"); len(namespaces) > 0 {
+		setupLog.Info("Restricting the cache to the watched namespaces", "namespaces", namespaces)
+		cacheOptions.DefaultNamespaces = map[string]cache.Config{}
+		for _, ns := range namespaces {
+			cacheOptions.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+		Scheme:                  scheme,
+		Cache:                   cacheOptions,
+		Metrics:                 metricsServerOptions,
+		WebhookServer:           webhookServer,
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        "d8c731f1.omegahome.net",
+		LeaderElectionNamespace: viper.GetString("leader-election-namespace"),
+		LeaseDuration:           &leaseDuration,
+		RenewDeadline:           &renewDeadline,
+		RetryPeriod:             &retryPeriod,
+		// The leader steps down as soon as the manager stops, handing over
+		// to a standby replica without waiting for the lease to expire.
+		// This is safe since nothing writes to the cluster after the
+		// manager stops.
+		LeaderElectionReleaseOnCancel: true,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	// Setup the periodic runner
-	eventCh := make(chan event.GenericEvent)
+	eventCh := controller.NewSyncQueue()
+
+	syncSchedule, syncInterval := syncSettings()
 
-	// For initial startup reconciliation triggering
-	cm := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      configMapName,
-			Namespace: targetNamespace,
+	var recorder record.EventRecorder = mgr.GetEventRecorderFor("cabundle-operator")
+	if window := viper.GetDuration("event-dedup-window"); window > 0 {
+		dedup := controller.NewDedupingRecorder(recorder, window)
+		if err := mgr.Add(dedup); err != nil {
+			setupLog.Error(err, "unable to add event deduplication")
+			os.Exit(1)
+		}
+		recorder = dedup
+	}
+	// A dry run sends every write as a server-side dry run, the bundle
+	// reconciler logging the ConfigMap changes it skips.
+	dryRun := viper.GetBool("dry-run")
+	fieldManager := viper.GetString("field-manager")
+	writer := client.WithFieldOwner(mgr.GetClient(), fieldManager)
+	if dryRun {
+		setupLog.Info("Dry run, no changes are written to the cluster")
+		writer = client.NewDryRunClient(writer)
+	}
+	bundleReconciler := &controller.CABundleReconciler{
+		Client:                  writer,
+		DryRun:                  dryRun,
+		Scheme:                  mgr.GetScheme(),
+		TargetNamespace:         targetNamespace,
+		ConfigMapName:           configMapName,
+		EventCh:                 eventCh,
+		Recorder:                recorder,
+		MaxConcurrentReconciles: viper.GetInt("max-concurrent-reconciles"),
+		OnlyNamespace:           viper.GetString("dev-namespace"),
+		SourceNamespaces:        viper.GetStringSlice("source-namespaces"),
+		WatchNamespaces:         viper.GetStringSlice("watch-namespaces"),
+		FieldManager:            fieldManager,
+		CleanupMode:             viper.GetString("cleanup-mode"),
+		CleanupGracePeriod:      viper.GetDuration("cleanup-grace-period"),
+		AllowNamespaceCreation:  viper.GetBool("allow-namespace-creation"),
+		SecretReader:            mgr.GetAPIReader(),
+		// Bundles are downloaded and sources probed with their own client,
+		// leaving http.DefaultClient to the notification sinks.
+		HTTPClient: &http.Client{Timeout: viper.GetDuration("http-timeout")},
+		RateLimiter: controller.NewRateLimiter(viper.GetDuration("reconcile-base-delay"), viper.GetDuration("reconcile-max-delay"),
+			viper.GetFloat64("reconcile-qps"), viper.GetInt("reconcile-burst")),
+		VerboseLogger: func(v int) logr.Logger {
+			return zap.New(zap.UseFlagOptions(&opts), zap.Level(zapcore.Level(-v)))
 		},
 	}
-
-	// fetch the config
-	directCLient, err := client.New(config.GetConfigOrDie(), client.Options{Scheme: scheme})
-	if err != nil {
-		setupLog.Error(err, "unable to create direct client to fetch operator configuration")
-		os.Exit(1)
+	bundleReconciler.SetTuning(tuningFromConfig())
+	if notifier := notifierFromConfig(); notifier != nil {
+		bundleReconciler.Notifier = notifier
 	}
-
-	err = directCLient.Get(context.Background(), client.ObjectKey{Name: configMapName, Namespace: targetNamespace}, cm)
-	if err != nil {
-		setupLog.Error(err, "unable to fetch operator configuration from ConfigMap", "name", configMapName, "namespace", targetNamespace)
-		os.Exit(1)
+	if viper.GetBool("uncached-cleanup-reads") {
+		bundleReconciler.UncachedReader = mgr.GetAPIReader()
 	}
-
-	syncInterval, err := time.ParseDuration(cm.Data["sync_interval"])
-
-	if err != nil {
-		setupLog.Error(err, "unable to parse sync-interval. Using default", "interval", interval)
-		syncInterval = interval
+	if err := bundleReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ConfigMap")
+		os.Exit(1)
 	}
 
-	// send to event channel in a separate goroutine to avoid blocking
-	go func() {
-		eventCh <- event.GenericEvent{Object: cm}
-	}()
-
+	// Setup the periodic runner
 	runner, err := periodic.New(
 		periodic.WithClient(mgr.GetClient()),
 		periodic.WithInterval(syncInterval),
+		periodic.WithSchedule(syncSchedule),
+		periodic.WithJitter(viper.GetFloat64("sync-jitter")),
+		periodic.WithStagger(viper.GetBool("sync-stagger")),
 		periodic.WithTargetNamespace(targetNamespace),
 		periodic.WithConfigMapName(configMapName),
 		periodic.WithEventChannel(eventCh),
+		periodic.WithSources(bundleReconciler.ListSources),
+		periodic.WithInFlight(bundleReconciler.InFlight),
+		periodic.WithNextSync(bundleReconciler.SetNextSync),
+		periodic.WithBackoff(viper.GetInt("sync-backoff-threshold"), viper.GetDuration("sync-backoff-max"),
+			bundleReconciler.ConsecutiveFailures),
 	)
 	if err != nil {
 		setupLog.Error(err, "unable to create periodic runner", "controller", "Pod")
@@ -288,15 +564,65 @@ func main() {
 	}
 	// End periodic runner setup
 
-	if err := (&controller.CABundleReconciler{
-		Client:          mgr.GetClient(),
-		Scheme:          mgr.GetScheme(),
-		TargetNamespace: targetNamespace,
-		EventCh:         eventCh,
+	if viper.GetBool("enable-debug-endpoint") {
+		if !secureMetrics {
+			setupLog.Info("WARNING: the debug endpoint is served without authentication, set --metrics-secure")
+		}
+		if err := mgr.AddMetricsServerExtraHandler(controller.DebugPath, bundleReconciler.DebugHandler()); err != nil {
+			setupLog.Error(err, "unable to add debug endpoint")
+			os.Exit(1)
+		}
+	}
+	if viper.GetBool("enable-sync-trigger") {
+		if !secureMetrics {
+			setupLog.Info("WARNING: the sync trigger endpoint is served without authentication, set --metrics-secure")
+		}
+		if err := mgr.AddMetricsServerExtraHandler(controller.TriggerPath, bundleReconciler.TriggerHandler(mgr.Elected())); err != nil {
+			setupLog.Error(err, "unable to add sync trigger endpoint")
+			os.Exit(1)
+		}
+	}
+	if interval := viper.GetDuration("source-probe-interval"); interval > 0 {
+		if err := mgr.Add(&controller.SourceProber{Reconciler: bundleReconciler, Interval: interval,
+			Timeout: viper.GetDuration("source-probe-timeout")}); err != nil {
+			setupLog.Error(err, "unable to add source prober")
+			os.Exit(1)
+		}
+	}
+	if err := (&controller.NamespaceReconciler{
+		CABundleReconciler: bundleReconciler,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ConfigMap")
+		setupLog.Error(err, "unable to create controller", "controller", "Namespace")
 		os.Exit(1)
 	}
+	if viper.GetBool("enable-ca-injection") {
+		for _, injector := range []*controller.CAInjectorReconciler{
+			controller.NewValidatingWebhookInjector(writer),
+			controller.NewMutatingWebhookInjector(writer),
+			controller.NewCRDConversionInjector(writer),
+			controller.NewAPIServiceInjector(writer),
+		} {
+			if err := injector.SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "CAInjector", "kind", injector.Kind)
+				os.Exit(1)
+			}
+		}
+	}
+	if enablePodInjection {
+		source := types.NamespacedName{Namespace: targetNamespace, Name: configMapName}
+		if v := viper.GetString("pod-injection-source"); v != "" {
+			source.Namespace, source.Name, _ = strings.Cut(v, "/")
+		}
+		if err := webhookv1.SetupPodWebhookWithManager(mgr, &webhookv1.PodCustomDefaulter{
+			Reader:           mgr.GetClient(),
+			Source:           source,
+			MountPath:        viper.GetString("pod-injection-mount-path"),
+			TruststoreSecret: viper.GetString("pod-injection-truststore-secret"),
+		}); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Pod")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -307,11 +633,322 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if threshold := viper.GetDuration("sync-staleness-threshold"); threshold > 0 {
+		if err := mgr.AddReadyzCheck("sync-freshness", bundleReconciler.SyncFreshnessCheck(threshold, mgr.Elected())); err != nil {
+			setupLog.Error(err, "unable to set up sync freshness check")
+			os.Exit(1)
+		}
+	}
 
-	setupLog.Info("starting manager with options", "base_url", cm.Data["bundle_url"], "sync_interval", syncInterval.String())
+	if viper.ConfigFileUsed() != "" {
+		viper.OnConfigChange(func(e fsnotify.Event) {
+			reloadConfig(bundleReconciler, runner, logLevel)
+		})
+		viper.WatchConfig()
+	}
+
+	setupLog.Info("starting manager with options", "configmap", targetNamespace+"/"+configMapName, "sync_interval", syncInterval.String(), "sync_schedule", syncSchedule)
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "problem flushing traces")
+	}
+
+}
+
+// bindSettings reads the settings from the flags, overridden by CABO_
+// environment variables named like them, e.g. CABO_SYNC_INTERVAL.
+func bindSettings(flags *pflag.FlagSet) {
+	_ = viper.BindPFlags(flags)
+	viper.SetEnvPrefix("CABO")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
+}
+
+// setDevDefaults replaces the defaults of the settings for --dev, so
+// changes to sources show up within seconds. Flags, CABO_ variables and the
+// configuration file still take precedence.
+func setDevDefaults() {
+	for name, value := range map[string]any{
+		"sync-interval":            time.Minute,
+		"sync-jitter":              0,
+		"sync-backoff-max":         5 * time.Minute,
+		"error-backoff-base":       time.Second,
+		"error-backoff-max":        30 * time.Second,
+		"degraded-retry":           10 * time.Second,
+		"full-sync-interval":       5 * time.Minute,
+		"circuit-breaker-cooldown": time.Minute,
+		"source-probe-interval":    15 * time.Second,
+		"log-level":                "2",
+	} {
+		viper.SetDefault(name, value)
+	}
+}
 
+// syncSettings returns the schedule and interval of the periodic runner,
+// those of every source without its own sync_schedule or sync_interval. The
+// operator ConfigMap's keys, like any source's, only apply to itself.
+func syncSettings() (string, time.Duration) {
+	return viper.GetString("sync-schedule"), viper.GetDuration("sync-interval")
+}
+
+// notifierFromConfig returns the notifier sending to the configured sinks, or
+// nil if there are none.
+func notifierFromConfig() *notify.Notifier {
+	var sinks []notify.Sink
+	for _, u := range viper.GetStringSlice("notify-webhook-url") {
+		sinks = append(sinks, &notify.Webhook{URL: u})
+	}
+	for _, u := range viper.GetStringSlice("notify-slack-webhook-url") {
+		sinks = append(sinks, &notify.Slack{URL: u})
+	}
+	if addr := viper.GetString("notify-smtp-address"); addr != "" {
+		// The password was validated to be readable by validateConfig.
+		password, _ := smtpPassword()
+		sinks = append(sinks, &notify.Email{
+			Addr:     addr,
+			Username: viper.GetString("notify-smtp-username"),
+			Password: password,
+			From:     viper.GetString("notify-smtp-from"),
+			To:       viper.GetStringSlice("notify-smtp-to"),
+		})
+	}
+	if key := viper.GetString("notify-pagerduty-routing-key"); key != "" {
+		sinks = append(sinks, &notify.PagerDuty{RoutingKey: key})
+	}
+	if key := viper.GetString("notify-opsgenie-api-key"); key != "" {
+		sinks = append(sinks, &notify.Opsgenie{APIKey: key, URL: viper.GetString("notify-opsgenie-url")})
+	}
+	if u := viper.GetString("cloudevents-sink-url"); u != "" {
+		sinks = append(sinks, &notify.CloudEventsHTTP{URL: u})
+	}
+	if brokers := viper.GetStringSlice("cloudevents-kafka-brokers"); len(brokers) > 0 {
+		sinks = append(sinks, notify.NewCloudEventsKafka(brokers, viper.GetString("cloudevents-kafka-topic")))
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+	return &notify.Notifier{Sinks: sinks}
+}
+
+// smtpPassword returns the SMTP password read from --notify-smtp-password-file,
+// else from the CABO_NOTIFY_SMTP_PASSWORD variable or the configuration file.
+// It has no flag, so it doesn't show in the process list.
+func smtpPassword() (string, error) {
+	file := viper.GetString("notify-smtp-password-file")
+	if file == "" {
+		return viper.GetString("notify-smtp-password"), nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// tuningFromConfig returns the reconciler tuning from the settings.
+func tuningFromConfig() controller.Tuning {
+	return controller.Tuning{
+		CircuitBreakerThreshold: viper.GetInt("circuit-breaker-threshold"),
+		CircuitBreakerCooldown:  viper.GetDuration("circuit-breaker-cooldown"),
+		ErrorBackoffBase:        viper.GetDuration("error-backoff-base"),
+		ErrorBackoffMax:         viper.GetDuration("error-backoff-max"),
+		DownloadTimeout:         viper.GetDuration("download-timeout"),
+		DegradedRetry:           viper.GetDuration("degraded-retry"),
+		FullSyncInterval:        viper.GetDuration("full-sync-interval"),
+		NotifyFailureThreshold:  viper.GetInt("notify-failure-threshold"),
+	}
+}
+
+// setLogLevel applies --log-level, if set, to the level of the logger.
+// The level was validated by validateConfig.
+func setLogLevel(level uberzap.AtomicLevel) {
+	if l, err := parseLogLevel(viper.GetString("log-level")); err == nil && l != nil {
+		level.SetLevel(*l)
+	}
+}
+
+// parseLogLevel parses a log level name or a verbosity, nil if empty.
+func parseLogLevel(s string) (*zapcore.Level, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if v, err := strconv.Atoi(s); err == nil && v > 0 {
+		l := zapcore.Level(-v)
+		return &l, nil
+	}
+	l, err := zapcore.ParseLevel(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q", s)
+	}
+	return &l, nil
+}
+
+// reloadConfig applies the settings of the changed configuration file that
+// don't need a restart. An invalid configuration keeps the previous
+// settings.
+func reloadConfig(r *controller.CABundleReconciler, runner *periodic.Runner, logLevel uberzap.AtomicLevel) {
+	if err := validateConfig(); err != nil {
+		setupLog.Error(err, "invalid configuration file, keeping the previous settings", "file", viper.ConfigFileUsed())
+		return
+	}
+	syncSchedule, syncInterval := syncSettings()
+	if err := runner.Reconfigure(
+		periodic.WithInterval(syncInterval),
+		periodic.WithSchedule(syncSchedule),
+		periodic.WithJitter(viper.GetFloat64("sync-jitter")),
+	); err != nil {
+		setupLog.Error(err, "unable to reconfigure periodic runner, keeping the previous schedule")
+	}
+	r.SetTuning(tuningFromConfig())
+	setLogLevel(logLevel)
+	setupLog.Info("Reloaded configuration file", "file", viper.ConfigFileUsed(),
+		"sync_interval", syncInterval.String(), "sync_schedule", syncSchedule)
+}
+
+// validateConfig checks the settings from flags, CABO_ environment
+// variables and the configuration file, reporting every invalid one.
+func validateConfig() error {
+	var errs []error
+	invalid := func(name string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("--%s %s", name, fmt.Sprintf(format, args...)))
+	}
+
+	for _, name := range []string{"target-namespace", "configmap-name", "field-manager"} {
+		if viper.GetString(name) == "" {
+			invalid(name, "must be set")
+		}
+	}
+	if n := len(viper.GetString("field-manager")); n > controller.MaxFieldManagerLength {
+		invalid("field-manager", "is %d characters long, must be at most %d", n, controller.MaxFieldManagerLength)
+	}
+	if _, err := controller.ParseCleanupMode(viper.GetString("cleanup-mode")); err != nil {
+		invalid("cleanup-mode", "is invalid: %v", err)
+	}
+	watched := viper.GetStringSlice("watch-namespaces")
+	for _, name := range []string{"source-namespaces", "watch-namespaces"} {
+		for _, ns := range viper.GetStringSlice(name) {
+			if name == "source-namespaces" && ns == controller.AllSourceNamespaces {
+				if len(watched) > 0 {
+					invalid(name, "entry %q is not allowed with --watch-namespaces, list them instead", ns)
+				}
+				continue
+			}
+			if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+				invalid(name, "entry %q is invalid: %s", ns, strings.Join(errs, ", "))
+			} else if len(watched) > 0 && !slices.Contains(watched, ns) {
+				invalid(name, "entry %q is not in --watch-namespaces", ns)
+			}
+		}
+	}
+	for _, name := range []string{"target-namespace", "dev-namespace"} {
+		if ns := viper.GetString(name); ns != "" && len(watched) > 0 && !slices.Contains(watched, ns) {
+			invalid(name, "%q is not in --watch-namespaces", ns)
+		}
+	}
+	if v := viper.GetString("pod-injection-source"); v != "" {
+		if ns, name, ok := strings.Cut(v, "/"); !ok || ns == "" || name == "" {
+			invalid("pod-injection-source", "%q must be <namespace>/<name>", v)
+		}
+	}
+	if d := viper.GetDuration("sync-interval"); d < periodic.MinInterval {
+		invalid("sync-interval", "is %s, must be at least %s", d, periodic.MinInterval)
+	}
+	if j := viper.GetFloat64("sync-jitter"); j < 0 || j > 1 {
+		invalid("sync-jitter", "is %g, must be between 0 and 1", j)
+	}
+	for _, name := range []string{"download-timeout", "degraded-retry", "full-sync-interval", "source-probe-timeout",
+		"reconcile-base-delay", "reconcile-max-delay", "leader-election-lease-duration",
+		"leader-election-renew-deadline", "leader-election-retry-period"} {
+		if d := viper.GetDuration(name); d <= 0 {
+			invalid(name, "is %s, must be positive", d)
+		}
+	}
+	for _, name := range []string{"http-timeout", "sync-backoff-max", "error-backoff-base", "error-backoff-max",
+		"circuit-breaker-cooldown", "sync-staleness-threshold", "source-probe-interval", "event-dedup-window",
+		"cleanup-grace-period"} {
+		if d := viper.GetDuration(name); d < 0 {
+			invalid(name, "is %s, must not be negative", d)
+		}
+	}
+	for _, name := range []string{"max-concurrent-reconciles", "reconcile-burst", "kube-api-burst", "notify-failure-threshold"} {
+		if n := viper.GetInt(name); n < 1 {
+			invalid(name, "is %d, must be at least 1", n)
+		}
+	}
+	for _, name := range []string{"sync-backoff-threshold", "circuit-breaker-threshold"} {
+		if n := viper.GetInt(name); n < 0 {
+			invalid(name, "is %d, must not be negative", n)
+		}
+	}
+	for _, name := range []string{"notify-webhook-url", "notify-slack-webhook-url", "notify-opsgenie-url", "cloudevents-sink-url"} {
+		for _, u := range viper.GetStringSlice(name) {
+			// The URL isn't repeated since it may embed a token.
+			if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				invalid(name, "must be http or https URLs")
+			}
+		}
+	}
+	if brokers := viper.GetStringSlice("cloudevents-kafka-brokers"); len(brokers) > 0 {
+		for _, b := range brokers {
+			if _, _, err := net.SplitHostPort(b); err != nil {
+				invalid("cloudevents-kafka-brokers", "entry %q must be host:port", b)
+			}
+		}
+		if viper.GetString("cloudevents-kafka-topic") == "" {
+			invalid("cloudevents-kafka-topic", "must be set with --cloudevents-kafka-brokers")
+		}
+	}
+	if addr := viper.GetString("notify-smtp-address"); addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			invalid("notify-smtp-address", "is %q, must be host:port", addr)
+		}
+		if _, err := smtpPassword(); err != nil {
+			invalid("notify-smtp-password-file", "is unreadable: %v", err)
+		}
+		if _, err := mail.ParseAddress(viper.GetString("notify-smtp-from")); err != nil {
+			invalid("notify-smtp-from", "must be an email address with --notify-smtp-address: %v", err)
+		}
+		for _, to := range viper.GetStringSlice("notify-smtp-to") {
+			if _, err := mail.ParseAddress(to); err != nil {
+				invalid("notify-smtp-to", "entry %q is invalid: %v", to, err)
+			}
+		}
+	}
+	for _, name := range []string{"reconcile-qps", "kube-api-qps"} {
+		if q := viper.GetFloat64(name); q <= 0 {
+			invalid(name, "is %g, must be positive", q)
+		}
+	}
+	if base, limit := viper.GetDuration("reconcile-base-delay"), viper.GetDuration("reconcile-max-delay"); limit < base {
+		invalid("reconcile-max-delay", "is %s, must be at least --reconcile-base-delay %s", limit, base)
+	}
+	if base, limit := viper.GetDuration("error-backoff-base"), viper.GetDuration("error-backoff-max"); limit < base {
+		invalid("error-backoff-max", "is %s, must be at least --error-backoff-base %s", limit, base)
+	}
+	if spec := viper.GetString("sync-schedule"); spec != "" {
+		if _, err := periodic.ParseSchedule(spec); err != nil {
+			invalid("sync-schedule", "is invalid: %v", err)
+		}
+	}
+	if viper.GetString("dev-namespace") != "" && !viper.GetBool("dev") {
+		invalid("dev-namespace", "requires --dev")
+	}
+	if f := viper.GetString("log-format"); f != "console" && f != "json" {
+		invalid("log-format", "is %q, must be console or json", f)
+	}
+	if _, err := parseLogLevel(viper.GetString("log-level")); err != nil {
+		invalid("log-level", "is invalid: %v", err)
+	}
+	// As required by client-go's leader election.
+	lease, renew := viper.GetDuration("leader-election-lease-duration"), viper.GetDuration("leader-election-renew-deadline")
+	if lease <= renew {
+		invalid("leader-election-lease-duration", "is %s, must be longer than --leader-election-renew-deadline %s", lease, renew)
+	}
+	if retry := viper.GetDuration("leader-election-retry-period"); float64(renew) <= 1.2*float64(retry) {
+		invalid("leader-election-renew-deadline", "is %s, must be longer than 1.2 times --leader-election-retry-period %s", renew, retry)
+	}
+	return errors.Join(errs...)
 }
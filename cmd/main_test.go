@@ -0,0 +1,108 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/shanmugara/cabundle-operator/internal/controller"
+)
+
+var _ = Describe("Sync interval", func() {
+	BeforeEach(func() {
+		viper.Reset()
+		DeferCleanup(viper.Reset)
+	})
+
+	bind := func(args ...string) {
+		flags := pflag.NewFlagSet("cabundle-operator", pflag.ContinueOnError)
+		flags.Duration("sync-interval", time.Hour, "")
+		Expect(flags.Parse(args)).To(Succeed())
+		bindSettings(flags)
+	}
+
+	It("defaults to the flag's value", func() {
+		bind()
+		_, interval := syncSettings()
+		Expect(interval).To(Equal(time.Hour))
+		Expect(validateConfig()).NotTo(MatchError(ContainSubstring("--sync-interval")))
+	})
+
+	It("is set by CABO_SYNC_INTERVAL unless the flag is given", func() {
+		GinkgoT().Setenv("CABO_SYNC_INTERVAL", "2h")
+		bind()
+		_, interval := syncSettings()
+		Expect(interval).To(Equal(2 * time.Hour))
+
+		viper.Reset()
+		bind("--sync-interval=30m")
+		_, interval = syncSettings()
+		Expect(interval).To(Equal(30 * time.Minute))
+	})
+
+	It("rejects intervals shorter than the minimum", func() {
+		GinkgoT().Setenv("CABO_SYNC_INTERVAL", "5s")
+		bind()
+		Expect(validateConfig()).To(MatchError(ContainSubstring("--sync-interval is 5s, must be at least 10s")))
+	})
+})
+
+var _ = Describe("Rate limits", func() {
+	BeforeEach(func() {
+		viper.Reset()
+		DeferCleanup(viper.Reset)
+	})
+
+	It("rejects a rate limiter slower to start than it may back off", func() {
+		viper.Set("reconcile-base-delay", time.Minute)
+		viper.Set("reconcile-max-delay", time.Second)
+		Expect(validateConfig()).To(MatchError(ContainSubstring("--reconcile-max-delay is 1s, must be at least --reconcile-base-delay 1m0s")))
+	})
+
+	It("requires positive rates and bursts", func() {
+		viper.Set("reconcile-qps", 0)
+		viper.Set("kube-api-qps", -1)
+		viper.Set("reconcile-burst", 0)
+		err := validateConfig()
+		Expect(err).To(MatchError(ContainSubstring("--reconcile-qps is 0, must be positive")))
+		Expect(err).To(MatchError(ContainSubstring("--kube-api-qps is -1, must be positive")))
+		Expect(err).To(MatchError(ContainSubstring("--reconcile-burst is 0, must be at least 1")))
+	})
+})
+
+var _ = Describe("Field manager", func() {
+	BeforeEach(func() {
+		viper.Reset()
+		DeferCleanup(viper.Reset)
+	})
+
+	It("must be set and fit in managedFields", func() {
+		Expect(validateConfig()).To(MatchError(ContainSubstring("--field-manager must be set")))
+
+		viper.Set("field-manager", strings.Repeat("x", controller.MaxFieldManagerLength+1))
+		Expect(validateConfig()).To(MatchError(ContainSubstring("--field-manager is 129 characters long, must be at most 128")))
+
+		viper.Set("field-manager", "cabundle-operator-blue")
+		Expect(validateConfig()).NotTo(MatchError(ContainSubstring("--field-manager")))
+	})
+})
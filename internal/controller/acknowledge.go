@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/shanmugara/cabundle-operator/internal/notify"
+)
+
+// Change policies, set by the change_policy key of a source, for new
+// versions of the upstream bundle.
+const (
+	// ChangeApply distributes new versions right away.
+	ChangeApply = "apply"
+	// ChangeAlert reports new versions and holds them back until
+	// acknowledged through AcknowledgeChangeAnnotation.
+	ChangeAlert = "alert"
+)
+
+// AcknowledgeChangeAnnotation on a source acknowledges the bundle version
+// with the given hash, or its first 12 characters as reported, e.g.
+// `kubectl cabundle acknowledge <namespace>/<name>`.
+const AcknowledgeChangeAnnotation = "cabundle.io/acknowledge-change"
+
+// ConditionChangeUnacknowledged reports that a new version of the upstream
+// bundle awaits acknowledgement, see ChangeAlert.
+const ConditionChangeUnacknowledged = "ChangeUnacknowledged"
+
+// ReasonUpstreamChanged is recorded when a new version of the upstream
+// bundle is held back for acknowledgement.
+const ReasonUpstreamChanged = "UpstreamChanged"
+
+// UnacknowledgedChange is the bundle version held back for acknowledgement,
+// and the certificates it adds to and removes from the managed ConfigMaps.
+type UnacknowledgedChange struct {
+	Hash       string      `json:"hash"`
+	DetectedAt metav1.Time `json:"detectedAt"`
+	Added      []string    `json:"added,omitempty"`
+	Removed    []string    `json:"removed,omitempty"`
+}
+
+// changeAcknowledged reports whether the bundle version may be applied: the
+// source applies changes, the version is already distributed or the first
+// one, or the source acknowledges it.
+func changeAcknowledged(src *corev1.ConfigMap, cfg *BundleConfig, hash string, prev *BundleStatus) bool {
+	if cfg.ChangePolicy != ChangeAlert || prev.RolledOutHash == "" || prev.RolledOutHash == hash {
+		return true
+	}
+	ack := src.Annotations[AcknowledgeChangeAnnotation]
+	return ack == hash || ack == shortHash(hash)
+}
+
+// holdChange reports the bundle version awaiting acknowledgement. A new
+// version is recorded as an Event and sent as a notification once, along
+// with the certificates it adds and removes.
+func (r *CABundleReconciler) holdChange(ctx context.Context, src *corev1.ConfigMap, cfg *BundleConfig, namespaces []string, bundles []PEMFile, hash string, prev *BundleStatus) (ctrl.Result, error) {
+	status := *prev
+	status.Conditions = slices.Clone(prev.Conditions)
+	change := prev.UnacknowledgedChange
+	if change == nil || change.Hash != hash {
+		changes, err := r.diffTargets(ctx, cfg, namespaces, bundles)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		change = &UnacknowledgedChange{Hash: hash, DetectedAt: metav1.Now()}
+		for _, c := range changes {
+			change.Added = append(change.Added, c.trust.Added...)
+			change.Removed = append(change.Removed, c.trust.Removed...)
+		}
+		slices.Sort(change.Added)
+		change.Added = slices.Compact(change.Added)
+		slices.Sort(change.Removed)
+		change.Removed = slices.Compact(change.Removed)
+
+		logf.FromContext(ctx).Info("Holding upstream change for acknowledgement", "hash", hash, "added", change.Added, "removed", change.Removed)
+		r.eventf(cfg, corev1.EventTypeWarning, ReasonUpstreamChanged, "Bundle %s changed upstream, annotate the source with %s=%s to apply it",
+			shortHash(hash), AcknowledgeChangeAnnotation, shortHash(hash))
+		r.notify(ctx, notify.Notification{
+			Event:     notify.UpstreamChanged,
+			Severity:  notify.SeverityWarning,
+			Source:    cfg.SourceNamespace + "/" + cfg.SourceName,
+			SourceURL: cfg.sourceURL(),
+			Message:   fmt.Sprintf("Bundle %s changed upstream and awaits acknowledgement", shortHash(hash)),
+			Hash:      hash,
+			Added:     change.Added,
+			Removed:   change.Removed,
+			Contacts:  cfg.NotifyEmail,
+		})
+	}
+	status.UnacknowledgedChange = change
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    ConditionChangeUnacknowledged,
+		Status:  metav1.ConditionTrue,
+		Reason:  "AwaitingAcknowledgement",
+		Message: fmt.Sprintf("Bundle %s awaits acknowledgement, %d certificates added and %d removed", shortHash(hash), len(change.Added), len(change.Removed)),
+	})
+	meta.SetStatusCondition(&status.Conditions, sourceReachableCondition(nil))
+
+	status.NextSyncTime = r.nextSyncTime(client.ObjectKeyFromObject(src), 0)
+	if err := r.updateStatus(ctx, src, &status); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.recordSuccessfulSync(client.ObjectKeyFromObject(src), time.Now())
+	return ctrl.Result{}, nil
+}
+
+// changeAcknowledgedCondition returns the ChangeUnacknowledged condition once
+// the upstream bundle is distributed.
+func changeAcknowledgedCondition() metav1.Condition {
+	return metav1.Condition{
+		Type:    ConditionChangeUnacknowledged,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Acknowledged",
+		Message: "The upstream bundle is distributed",
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/shanmugara/cabundle-operator/internal/notify"
+)
+
+var _ = Describe("Change acknowledgement", func() {
+	ctx := context.Background()
+	oldRoot := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Old Root", time.Now().Add(time.Hour))}
+	newRoot := PEMFile{Filename: "root.pem", Content: newTestCAPEM("New Root", time.Now().Add(time.Hour))}
+
+	var (
+		notifier *recordingNotifier
+		recorder *record.FakeRecorder
+		r        *CABundleReconciler
+		cfg      *BundleConfig
+		src      *corev1.ConfigMap
+	)
+	BeforeEach(func() {
+		notifier = &recordingNotifier{}
+		recorder = record.NewFakeRecorder(10)
+		src = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"}}
+		r = &CABundleReconciler{
+			Client:   fake.NewClientBuilder().WithObjects(src).Build(),
+			Recorder: recorder,
+			Scheme:   clientgoscheme.Scheme,
+			Notifier: notifier,
+		}
+		cfg = &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}, ChangePolicy: ChangeAlert}
+	})
+
+	It("holds back new versions until acknowledged", func() {
+		hash := bundleSetHash([]PEMFile{newRoot})
+		prev := &BundleStatus{RolledOutHash: bundleSetHash([]PEMFile{oldRoot})}
+		Expect(changeAcknowledged(src, cfg, hash, &BundleStatus{})).To(BeTrue(), "the first version")
+		Expect(changeAcknowledged(src, cfg, prev.RolledOutHash, prev)).To(BeTrue(), "the distributed version")
+		Expect(changeAcknowledged(src, cfg, hash, prev)).To(BeFalse())
+
+		src.Annotations = map[string]string{AcknowledgeChangeAnnotation: shortHash(hash)}
+		Expect(changeAcknowledged(src, cfg, hash, prev)).To(BeTrue())
+		src.Annotations[AcknowledgeChangeAnnotation] = shortHash(prev.RolledOutHash)
+		Expect(changeAcknowledged(src, cfg, hash, prev)).To(BeFalse(), "an earlier acknowledgement")
+
+		cfg.ChangePolicy = ChangeApply
+		Expect(changeAcknowledged(src, cfg, hash, prev)).To(BeTrue())
+	})
+
+	It("reports a held back change once, with the certificates it changes", func() {
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{oldRoot}, cfg)).To(Succeed())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Created ConfigMap")))
+		hash := bundleSetHash([]PEMFile{newRoot})
+		prev := &BundleStatus{RolledOutHash: bundleSetHash([]PEMFile{oldRoot})}
+
+		for range 2 {
+			_, err := r.holdChange(ctx, src, cfg, []string{"apps"}, []PEMFile{newRoot}, hash, prev)
+			Expect(err).NotTo(HaveOccurred())
+			prev, err = r.getStatus(ctx, src)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(prev.UnacknowledgedChange).NotTo(BeNil())
+		Expect(prev.UnacknowledgedChange.Added).To(Equal([]string{"CN=New Root,O=Corp"}))
+		Expect(prev.UnacknowledgedChange.Removed).To(Equal([]string{"CN=Old Root,O=Corp"}))
+		Expect(meta.IsStatusConditionTrue(prev.Conditions, ConditionChangeUnacknowledged)).To(BeTrue())
+		Expect(bundleHealth(prev).Status).To(Equal(HealthProgressing))
+
+		Expect(recorder.Events).To(Receive(ContainSubstring(AcknowledgeChangeAnnotation + "=" + shortHash(hash))))
+		Expect(recorder.Events).NotTo(Receive())
+		changed := notifier.events(notify.UpstreamChanged)
+		Expect(changed).To(HaveLen(1))
+		Expect(changed[0].Hash).To(Equal(hash))
+		Expect(changed[0].Added).To(Equal([]string{"CN=New Root,O=Corp"}))
+
+		// The managed ConfigMap keeps the distributed version.
+		live := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "root"}, live)).To(Succeed())
+		Expect(configMapCertificates(live)[0].Subject.CommonName).To(Equal("Old Root"))
+	})
+})
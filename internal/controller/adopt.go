@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reasons of the Events recorded when a ConfigMap of a managed name exists
+// without the operator's labels.
+const (
+	ReasonAdopted    = "Adopted"
+	ReasonReadopted  = "Readopted"
+	ReasonNotManaged = "NotManaged"
+)
+
+// checkOwnership reports whether the existing ConfigMap of a managed name is
+// to be adopted, or an error if the source may not write it: it was created
+// by someone else and the source doesn't set adopt_existing, or it is
+// managed for another source. ConfigMaps labeled by operator versions
+// predating the source labels belong to any source. Orphans are adopted
+// without adopt_existing, see isOrphan.
+func checkOwnership(cm *corev1.ConfigMap, cfg *BundleConfig) (bool, error) {
+	if cm.Labels[AppLabel] != AppLabelValue {
+		if !cfg.AdoptExisting && !isOrphan(cm) {
+			return false, fmt.Errorf("ConfigMap %s/%s exists and isn't managed by the operator, set %s to adopt it",
+				cm.Namespace, cm.Name, AdoptExistingKey)
+		}
+		return true, nil
+	}
+	name, ns := cm.Labels[SourceLabel], cm.Labels[SourceNamespaceLabel]
+	if (name != "" && name != cfg.SourceName) || (ns != "" && ns != cfg.SourceNamespace) {
+		return false, fmt.Errorf("ConfigMap %s/%s is managed for source %s/%s", cm.Namespace, cm.Name, ns, name)
+	}
+	return false, nil
+}
+
+// isOrphan reports whether a ConfigMap without the operator's labels still
+// holds the ContentHashAnnotation the operator wrote, matching its content:
+// a managed ConfigMap whose labels were stripped, e.g. by a tool reapplying
+// its own. It is adopted back rather than failing the sync, and cleanup then
+// finds it again when it is stale. ConfigMaps merely holding the same
+// content were never the operator's, and need adopt_existing.
+func isOrphan(cm *corev1.ConfigMap) bool {
+	hash, ok := cm.Annotations[ContentHashAnnotation]
+	return ok && cm.Labels[AppLabel] != AppLabelValue && contentHash(cm.Data, cm.BinaryData) == hash
+}
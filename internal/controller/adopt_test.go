@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Adopting existing ConfigMaps", func() {
+	ctx := context.Background()
+	root := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+
+	var (
+		recorder *record.FakeRecorder
+		r        *CABundleReconciler
+		cfg      *BundleConfig
+		existing *corev1.ConfigMap
+	)
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		r = &CABundleReconciler{Recorder: recorder}
+		cfg = &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+		existing = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "root", Namespace: "apps", Labels: map[string]string{"team": "apps"}},
+			Data:       map[string]string{"ca.crt": "hand-made"},
+		}
+	})
+
+	live := func() *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(existing), cm)).To(Succeed())
+		return cm
+	}
+
+	It("refuses to overwrite a ConfigMap it didn't create without opt-in", func() {
+		r.Client = fake.NewClientBuilder().WithObjects(existing).Build()
+
+		err := r.syncNamespace(ctx, "apps", []PEMFile{root}, cfg)
+		Expect(err).To(MatchError(ContainSubstring(AdoptExistingKey)))
+		Expect(live().Data).To(Equal(existing.Data))
+		Expect(recorder.Events).To(Receive(ContainSubstring(ReasonNotManaged)))
+	})
+
+	It("takes over the ConfigMap with adopt_existing", func() {
+		r.Client = fake.NewClientBuilder().WithObjects(existing).Build()
+		cfg.AdoptExisting = true
+
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{root}, cfg)).To(Succeed())
+		cm := live()
+		Expect(cm.Labels).To(HaveKeyWithValue(SourceLabel, "root-ca"))
+		Expect(cm.Labels).To(HaveKeyWithValue("team", "apps"))
+		Expect(cm.Data["ca.crt"]).To(Equal(string(root.Content)))
+		Expect(recorder.Events).To(Receive(ContainSubstring(ReasonAdopted)))
+
+		// Once adopted the ConfigMap is managed like any other.
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{root}, &BundleConfig{
+			SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM},
+		})).To(Succeed())
+	})
+
+	It("re-adopts a managed ConfigMap whose labels were stripped", func() {
+		orphan, err := r.desiredConfigMap("apps", root, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		orphan.Labels = map[string]string{"team": "apps"}
+		existing = orphan
+		r.Client = fake.NewClientBuilder().WithObjects(orphan).Build()
+
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{root}, cfg)).To(Succeed())
+		cm := live()
+		Expect(cm.Labels).To(HaveKeyWithValue(AppLabel, AppLabelValue))
+		Expect(cm.Labels).To(HaveKeyWithValue(SourceLabel, "root-ca"))
+		Expect(cm.Annotations).To(HaveKey(ContentHashAnnotation))
+		Expect(recorder.Events).To(Receive(ContainSubstring(ReasonReadopted)))
+	})
+
+	It("doesn't re-adopt a ConfigMap it never wrote that holds the same content", func() {
+		copied, err := r.desiredConfigMap("apps", root, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		copied.Labels = map[string]string{"team": "apps"}
+		copied.Annotations = nil
+		existing = copied
+		r.Client = fake.NewClientBuilder().WithObjects(copied).Build()
+
+		err = r.syncNamespace(ctx, "apps", []PEMFile{root}, cfg)
+		Expect(err).To(MatchError(ContainSubstring(AdoptExistingKey)))
+		Expect(live().Labels).NotTo(HaveKey(AppLabel))
+	})
+
+	It("never takes over a ConfigMap managed for another source", func() {
+		other := &BundleConfig{SourceName: "partner-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+		owned, err := r.desiredConfigMap("apps", root, other)
+		Expect(err).NotTo(HaveOccurred())
+		r.Client = fake.NewClientBuilder().WithObjects(owned).Build()
+		cfg.AdoptExisting = true
+
+		err = r.syncNamespace(ctx, "apps", []PEMFile{root}, cfg)
+		Expect(err).To(MatchError(ContainSubstring("managed for source cert-manager/partner-ca")))
+	})
+})
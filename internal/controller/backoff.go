@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultErrorBackoffMax caps the error backoff when no maximum is set.
+const DefaultErrorBackoffMax = 5 * time.Minute
+
+// durationOr returns d, or fallback if d isn't set.
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// errorBackoff returns the delay before retrying after the given number of
+// consecutive failures: base, doubling with every further failure up to
+// limit.
+func errorBackoff(base, limit time.Duration, failures int) time.Duration {
+	if limit <= 0 {
+		limit = DefaultErrorBackoffMax
+	}
+	delay := base
+	for i := 1; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// errorRetry returns when a source failing in the running reconcile will be
+// retried, zero if retries are left to the controller's rate limiter.
+func (r *CABundleReconciler) errorRetry(key types.NamespacedName) time.Duration {
+	tuning := r.tuning()
+	if tuning.ErrorBackoffBase <= 0 {
+		return 0
+	}
+	return errorBackoff(tuning.ErrorBackoffBase, tuning.ErrorBackoffMax, r.ConsecutiveFailures(key)+1)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Error backoff", func() {
+	It("doubles with every consecutive failure up to the maximum", func() {
+		Expect(errorBackoff(5*time.Second, time.Minute, 1)).To(Equal(5 * time.Second))
+		Expect(errorBackoff(5*time.Second, time.Minute, 2)).To(Equal(10 * time.Second))
+		Expect(errorBackoff(5*time.Second, time.Minute, 4)).To(Equal(40 * time.Second))
+		Expect(errorBackoff(5*time.Second, time.Minute, 5)).To(Equal(time.Minute))
+		Expect(errorBackoff(5*time.Second, 0, 1000)).To(Equal(DefaultErrorBackoffMax))
+	})
+
+	It("follows the tuning set while the reconciler runs", func() {
+		r := &CABundleReconciler{}
+		key := types.NamespacedName{Namespace: "cert-manager", Name: "corp-roots"}
+		Expect(r.errorRetry(key)).To(BeZero())
+
+		r.SetTuning(Tuning{ErrorBackoffBase: 5 * time.Second, ErrorBackoffMax: time.Minute})
+		Expect(r.errorRetry(key)).To(Equal(5 * time.Second))
+
+		r.SetTuning(Tuning{ErrorBackoffBase: 20 * time.Second, ErrorBackoffMax: time.Minute})
+		Expect(r.errorRetry(key)).To(Equal(20 * time.Second))
+	})
+})
@@ -0,0 +1,682 @@
+package controller
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// Keys read from the source ConfigMap data.
+const (
+	BundleURLKey          = "bundle_url"
+	BundleURLsKey         = "bundle_urls"
+	FormatsKey            = "formats"
+	TruststorePasswordKey = "truststore_password"
+	TargetNamespacesKey   = "target_namespaces"
+	NamespaceSelectorKey  = "namespace_selector"
+	AllNamespacesKey      = "all_namespaces"
+	AggregateKey          = "aggregate_configmap"
+	RestartConsumersKey   = "restart_consumers"
+	PropagateHashKey      = "propagate_hash"
+	RemoteClustersKey     = "remote_clusters"
+	ExportURLsKey         = "export_urls"
+	TargetOverridesKey    = "target_overrides"
+	CanaryNamespacesKey   = "canary_namespaces"
+	CanarySoakKey         = "canary_soak"
+	CanaryProbeURLKey     = "canary_probe_url"
+	HistoryLimitKey       = "history_limit"
+	MaintenanceWindowsKey = "maintenance_windows"
+	ExpiryWarningKey      = "expiry_warning"
+	MaxFileSizeKey        = "max_file_size"
+	AdoptExistingKey      = "adopt_existing"
+	NotifyEmailKey        = "notify_email"
+	PolicyVersionKey      = "policy_version"
+	CreateNamespacesKey   = "create_namespaces"
+	NamespaceLabelsKey    = "namespace_labels"
+	CleanupKey            = "cleanup"
+	DriftPolicyKey        = "drift_policy"
+	DriftIgnoreFieldsKey  = "drift_ignore_fields"
+	ConflictStrategyKey   = "conflict_strategy"
+	RetainRevisionsKey    = "retain_revisions"
+	PinnedRevisionKey     = "pinned_revision"
+	BundleChecksumKey     = "bundle_checksum"
+	FileChecksumsKey      = "file_checksums"
+	ChangePolicyKey       = "change_policy"
+	FileSuffixesKey       = "file_suffixes"
+	DetectContentKey      = "detect_content"
+	LinkHostsKey          = "link_hosts"
+
+	// SPIFFEBundleEndpointKey replaces bundle_url with a SPIFFE bundle
+	// endpoint, see SPIFFESource.
+	SPIFFEBundleEndpointKey  = "spiffe_bundle_endpoint"
+	SPIFFETrustDomainKey     = "spiffe_trust_domain"
+	SPIFFEEndpointProfileKey = "spiffe_endpoint_profile"
+	SPIFFEEndpointIDKey      = "spiffe_endpoint_id"
+	SPIFFEEndpointBundleKey  = "spiffe_endpoint_bundle"
+)
+
+// BundleConfig is the bundle definition parsed from the source ConfigMap.
+type BundleConfig struct {
+	// SourceName and SourceNamespace identify the source ConfigMap.
+	SourceName      string
+	SourceNamespace string
+	SourceUID       types.UID
+
+	// BundleURL is the index of bundle files synced, BundleURLs the
+	// indexes of bundle_urls instead, see bundleURLs.
+	BundleURL  string
+	BundleURLs []BundleURLEntry
+	// SPIFFE, if set, is the source of the bundle instead of the indexes at
+	// BundleURLs.
+	SPIFFE             *SPIFFESource
+	Formats            []string
+	TruststorePassword string
+	// TargetNamespaces lists the namespaces the managed ConfigMaps are
+	// written to. Empty means the reconciler's default target namespace.
+	TargetNamespaces []string
+	// NamespaceSelector selects additional target namespaces by label.
+	NamespaceSelector labels.Selector
+	// AllNamespaces distributes the bundle to every namespace that has not
+	// opted out.
+	AllNamespaces bool
+	// AggregateName, if set, is the name of an additional managed ConfigMap
+	// holding every downloaded file concatenated.
+	AggregateName string
+	// RestartConsumers triggers a rollout of workloads referencing a managed
+	// ConfigMap whenever its content changes.
+	RestartConsumers bool
+	// PropagateHash stamps the content hash of the referenced managed
+	// ConfigMaps onto the pod template of consumer workloads opted in with
+	// PropagateHashAnnotation.
+	PropagateHash bool
+	// RemoteClusters are additional clusters the managed ConfigMaps are
+	// replicated into.
+	RemoteClusters []RemoteCluster
+	// ExportURLs are object storage buckets the aggregated bundle and its
+	// checksum are uploaded to after every successful sync.
+	ExportURLs []string
+	// TargetOverrides customize the managed ConfigMaps of individual
+	// targets.
+	TargetOverrides []TargetOverride
+	// CanaryNamespaces receive a changed bundle first. The remaining targets
+	// get it once it soaked there for CanarySoak and, if set, a GET of
+	// CanaryProbeURL succeeds.
+	CanaryNamespaces []string
+	CanarySoak       time.Duration
+	CanaryProbeURL   string
+	// HistoryLimit is the number of sync results kept in the status.
+	HistoryLimit int
+	// MaintenanceWindows are change freezes during which the managed
+	// ConfigMaps are left untouched.
+	MaintenanceWindows []MaintenanceWindow
+	// ExpiryWarning is how long before the soonest certificate expiry
+	// warnings are raised.
+	ExpiryWarning time.Duration
+	// MaxFileSize is the largest file downloaded from the source, in bytes.
+	MaxFileSize int64
+	// FileSuffixes are the suffixes of the files listed on the indexes that
+	// are synced, DefaultFileSuffixes if empty.
+	FileSuffixes []string
+	// DetectContent downloads every file linked from the indexes, whatever
+	// its suffix, and syncs those holding PEM certificates.
+	DetectContent bool
+	// LinkHosts are the hosts, besides that of the index listing them,
+	// files may be downloaded from when the index links them absolutely or
+	// sets a <base href>.
+	LinkHosts []string
+	// AdoptExisting takes over ConfigMaps of the managed names that exist
+	// without the operator's labels, instead of refusing to write them.
+	AdoptExisting bool
+	// NotifyEmail lists the addresses of the team responsible for the
+	// source, emailed about expiring certificates and failing syncs.
+	NotifyEmail []string
+	// PolicyVersion is the revision of the organization's trust policy the
+	// bundle conforms to, stamped on the managed ConfigMaps.
+	PolicyVersion string
+	// CreateNamespaces creates target namespaces that don't exist yet,
+	// labeled with NamespaceLabels, instead of failing to write to them.
+	CreateNamespaces bool
+	NamespaceLabels  map[string]string
+	// CleanupMode is how stale managed ConfigMaps of the source are cleaned
+	// up, unless the reconciler's mode is more conservative.
+	CleanupMode string
+	// DriftPolicy is how managed ConfigMaps changed out of band are
+	// handled, see DriftReport and DriftRevert.
+	DriftPolicy string
+	// DriftIgnoreFields are the data.<key> and binaryData.<key> fields of
+	// the managed ConfigMaps managed by someone else: their changes aren't
+	// drift, and syncs keep them.
+	DriftIgnoreFields []string
+	// ConflictStrategy resolves files rendered into the same managed
+	// ConfigMap, see ConflictError.
+	ConflictStrategy string
+	// RetainRevisions is the number of previous revisions of every managed
+	// ConfigMap retained for rollbacks, see retainRevision.
+	RetainRevisions int
+	// PinnedRevision, if set, pins the managed ConfigMaps to this retained
+	// revision and pauses forward syncs, see syncPinned.
+	PinnedRevision int64
+	// BundleChecksum and FileChecksums, if set, pin the SHA-256 of the
+	// aggregated bundle and of every file: content that doesn't match
+	// isn't applied, see checksumViolations.
+	BundleChecksum string
+	FileChecksums  map[string]string
+	// ChangePolicy is how new versions of the upstream bundle are handled,
+	// see ChangeApply and ChangeAlert.
+	ChangePolicy string
+
+	// RetainedFiles are set by reconcile to the files that failed to
+	// download; cleanup keeps their ConfigMaps.
+	RetainedFiles []string
+
+	// Names and ExtraLabels are set by ForTarget from the override of the
+	// target being synced.
+	Names       map[string]string
+	ExtraLabels map[string]string
+
+	// HTTPClient is set by the reconciler to the client fetching the bundle
+	// and probing the canary, see httpClient.
+	HTTPClient *http.Client
+}
+
+// TargetOverride customizes the managed ConfigMaps written to a single target
+// namespace, declared as a YAML list:
+//
+//	target_overrides: |
+//	  - namespace: istio-system
+//	    names:
+//	      ca-bundle: istio-ca-root-cert
+//	    labels:
+//	      istio.io/config: "true"
+//	    formats: [pem, jks]
+type TargetOverride struct {
+	// Namespace and Cluster select the target. An empty Cluster is the local
+	// cluster, otherwise it names an entry of remote_clusters.
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster,omitempty"`
+	// Names renames managed ConfigMaps, keyed by their default name.
+	Names map[string]string `json:"names,omitempty"`
+	// Labels are added to the managed ConfigMaps.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Formats replaces the bundle's output formats.
+	Formats []string `json:"formats,omitempty"`
+}
+
+// ParseBundleConfig reads the bundle definition from the source ConfigMap.
+func ParseBundleConfig(cm *corev1.ConfigMap) (*BundleConfig, error) {
+	baseURL, ok := cm.Data[BundleURLKey]
+	urls, list := cm.Data[BundleURLsKey]
+	_, spiffe := cm.Data[SPIFFEBundleEndpointKey]
+	switch set := btoi(ok) + btoi(list) + btoi(spiffe); {
+	case set > 1:
+		return nil, fmt.Errorf("only one of %s, %s and %s may be set", BundleURLKey, BundleURLsKey, SPIFFEBundleEndpointKey)
+	case set == 0:
+		return nil, fmt.Errorf("%s key not found in ConfigMap data", BundleURLKey)
+	}
+
+	cfg := &BundleConfig{
+		SourceName:         cm.Name,
+		SourceNamespace:    cm.Namespace,
+		SourceUID:          cm.UID,
+		BundleURL:          baseURL,
+		Formats:            []string{FormatPEM},
+		TruststorePassword: DefaultTruststorePassword,
+		HistoryLimit:       DefaultHistoryLimit,
+		ExpiryWarning:      DefaultExpiryWarning,
+		MaxFileSize:        DefaultMaxFileSize,
+	}
+
+	switch {
+	case spiffe:
+		src, err := parseSPIFFESource(cm.Data)
+		if err != nil {
+			return nil, err
+		}
+		cfg.SPIFFE = src
+	case list:
+		entries, err := parseBundleURLs(urls)
+		if err != nil {
+			return nil, err
+		}
+		cfg.BundleURLs = entries
+	}
+
+	if v, ok := cm.Data[FormatsKey]; ok {
+		formats := splitList(v)
+		for _, f := range formats {
+			if !isKnownFormat(f) {
+				return nil, fmt.Errorf("unknown output format %q in %s", f, FormatsKey)
+			}
+		}
+		if len(formats) > 0 {
+			cfg.Formats = formats
+		}
+	}
+
+	if v, ok := cm.Data[TruststorePasswordKey]; ok {
+		cfg.TruststorePassword = v
+	}
+
+	cfg.TargetNamespaces = splitList(cm.Data[TargetNamespacesKey])
+
+	if v := strings.TrimSpace(cm.Data[NamespaceSelectorKey]); v != "" {
+		sel, err := labels.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", NamespaceSelectorKey, err)
+		}
+		cfg.NamespaceSelector = sel
+	}
+
+	if v := strings.TrimSpace(cm.Data[AggregateKey]); v != "" {
+		if errs := validation.IsDNS1123Subdomain(v); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s %q: %s", AggregateKey, v, strings.Join(errs, ", "))
+		}
+		if isReservedName(v) {
+			return nil, fmt.Errorf("invalid %s %q: reserved for the operator's own ConfigMaps", AggregateKey, v)
+		}
+		cfg.AggregateName = v
+	}
+
+	for _, entry := range splitList(cm.Data[RemoteClustersKey]) {
+		rc, err := parseRemoteCluster(entry)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RemoteClusters = append(cfg.RemoteClusters, rc)
+	}
+
+	switch cfg.DriftPolicy = strings.TrimSpace(cm.Data[DriftPolicyKey]); cfg.DriftPolicy {
+	case "":
+		cfg.DriftPolicy = DriftReport
+	case DriftReport, DriftRevert:
+	default:
+		return nil, fmt.Errorf("invalid %s %q, must be %s or %s", DriftPolicyKey, cfg.DriftPolicy, DriftReport, DriftRevert)
+	}
+	switch cfg.ChangePolicy = strings.TrimSpace(cm.Data[ChangePolicyKey]); cfg.ChangePolicy {
+	case "":
+		cfg.ChangePolicy = ChangeApply
+	case ChangeApply, ChangeAlert:
+	default:
+		return nil, fmt.Errorf("invalid %s %q, must be %s or %s", ChangePolicyKey, cfg.ChangePolicy, ChangeApply, ChangeAlert)
+	}
+	switch cfg.ConflictStrategy = strings.TrimSpace(cm.Data[ConflictStrategyKey]); cfg.ConflictStrategy {
+	case "":
+		cfg.ConflictStrategy = ConflictError
+	case ConflictError, ConflictFirstWins, ConflictConcatenate:
+	default:
+		return nil, fmt.Errorf("invalid %s %q, must be %s, %s or %s", ConflictStrategyKey, cfg.ConflictStrategy,
+			ConflictError, ConflictFirstWins, ConflictConcatenate)
+	}
+	for _, f := range splitList(cm.Data[DriftIgnoreFieldsKey]) {
+		if err := parseDriftIgnoreField(f); err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: %w", DriftIgnoreFieldsKey, f, err)
+		}
+		cfg.DriftIgnoreFields = append(cfg.DriftIgnoreFields, f)
+	}
+
+	for _, u := range splitList(cm.Data[ExportURLsKey]) {
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid %s entry %q", ExportURLsKey, u)
+		}
+		cfg.ExportURLs = append(cfg.ExportURLs, u)
+	}
+
+	cfg.PolicyVersion = strings.TrimSpace(cm.Data[PolicyVersionKey])
+	if errs := validation.IsValidLabelValue(cfg.PolicyVersion); cfg.PolicyVersion != "" && len(errs) > 0 {
+		return nil, fmt.Errorf("invalid %s %q: %s", PolicyVersionKey, cfg.PolicyVersion, strings.Join(errs, ", "))
+	}
+	for _, a := range splitList(cm.Data[NotifyEmailKey]) {
+		addr, err := mail.ParseAddress(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: %w", NotifyEmailKey, a, err)
+		}
+		cfg.NotifyEmail = append(cfg.NotifyEmail, addr.Address)
+	}
+
+	if v := strings.TrimSpace(cm.Data[NamespaceLabelsKey]); v != "" {
+		set, err := labels.ConvertSelectorToLabelsMap(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", NamespaceLabelsKey, err)
+		}
+		for key := range set {
+			if reservedLabel(key) {
+				return nil, fmt.Errorf("invalid %s: label %s is reserved for Kubernetes, e.g. to set the pod security level", NamespaceLabelsKey, key)
+			}
+		}
+		cfg.NamespaceLabels = set
+	}
+
+	if v := strings.TrimSpace(cm.Data[TargetOverridesKey]); v != "" {
+		if err := yaml.UnmarshalStrict([]byte(v), &cfg.TargetOverrides); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", TargetOverridesKey, err)
+		}
+		for _, o := range cfg.TargetOverrides {
+			if err := o.validate(cfg); err != nil {
+				return nil, fmt.Errorf("invalid %s for %s: %w", TargetOverridesKey, o.Namespace, err)
+			}
+		}
+	}
+
+	cfg.CanaryNamespaces = splitList(cm.Data[CanaryNamespacesKey])
+	cfg.CanarySoak = DefaultCanarySoak
+	if v := strings.TrimSpace(cm.Data[CanarySoakKey]); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid %s %q", CanarySoakKey, v)
+		}
+		cfg.CanarySoak = d
+	}
+	if v := strings.TrimSpace(cm.Data[CanaryProbeURLKey]); v != "" {
+		if _, err := url.ParseRequestURI(v); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", CanaryProbeURLKey, err)
+		}
+		cfg.CanaryProbeURL = v
+	}
+
+	if v := strings.TrimSpace(cm.Data[HistoryLimitKey]); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s %q", HistoryLimitKey, v)
+		}
+		cfg.HistoryLimit = n
+	}
+
+	if v := strings.TrimSpace(cm.Data[RetainRevisionsKey]); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > MaxRetainRevisions {
+			return nil, fmt.Errorf("invalid %s %q, must be between 0 and %d", RetainRevisionsKey, v, MaxRetainRevisions)
+		}
+		cfg.RetainRevisions = n
+	}
+
+	if v := strings.TrimSpace(cm.Data[PinnedRevisionKey]); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s %q, must be a revision", PinnedRevisionKey, v)
+		}
+		cfg.PinnedRevision = n
+	}
+
+	if v := strings.TrimSpace(cm.Data[BundleChecksumKey]); v != "" {
+		sum, err := parseChecksum(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", BundleChecksumKey, err)
+		}
+		cfg.BundleChecksum = sum
+	}
+	if v := strings.TrimSpace(cm.Data[FileChecksumsKey]); v != "" {
+		checksums, err := parseFileChecksums(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", FileChecksumsKey, err)
+		}
+		cfg.FileChecksums = checksums
+	}
+
+	if v := strings.TrimSpace(cm.Data[ExpiryWarningKey]); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid %s %q", ExpiryWarningKey, v)
+		}
+		cfg.ExpiryWarning = d
+	}
+
+	if v := strings.TrimSpace(cm.Data[MaxFileSizeKey]); v != "" {
+		q, err := resource.ParseQuantity(v)
+		if err != nil || q.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", MaxFileSizeKey, v)
+		}
+		cfg.MaxFileSize = q.Value()
+	}
+
+	for _, s := range splitList(cm.Data[FileSuffixesKey]) {
+		if len(s) < 2 || s[0] != '.' || strings.Contains(s, "/") {
+			return nil, fmt.Errorf("invalid %s entry %q, must be like .pem", FileSuffixesKey, s)
+		}
+		cfg.FileSuffixes = append(cfg.FileSuffixes, s)
+	}
+
+	if v := strings.TrimSpace(cm.Data[MaintenanceWindowsKey]); v != "" {
+		if err := yaml.UnmarshalStrict([]byte(v), &cfg.MaintenanceWindows); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", MaintenanceWindowsKey, err)
+		}
+		for i := range cfg.MaintenanceWindows {
+			if err := cfg.MaintenanceWindows[i].parse(); err != nil {
+				return nil, fmt.Errorf("invalid %s entry %d: %w", MaintenanceWindowsKey, i, err)
+			}
+		}
+	}
+
+	var err error
+	if cfg.CleanupMode, err = ParseCleanupMode(strings.TrimSpace(cm.Data[CleanupKey])); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", CleanupKey, err)
+	}
+	if cfg.AllNamespaces, err = parseBool(cm.Data, AllNamespacesKey); err != nil {
+		return nil, err
+	}
+	if cfg.RestartConsumers, err = parseBool(cm.Data, RestartConsumersKey); err != nil {
+		return nil, err
+	}
+	if cfg.PropagateHash, err = parseBool(cm.Data, PropagateHashKey); err != nil {
+		return nil, err
+	}
+	if cfg.AdoptExisting, err = parseBool(cm.Data, AdoptExistingKey); err != nil {
+		return nil, err
+	}
+	if cfg.CreateNamespaces, err = parseBool(cm.Data, CreateNamespacesKey); err != nil {
+		return nil, err
+	}
+	if cfg.DetectContent, err = parseBool(cm.Data, DetectContentKey); err != nil {
+		return nil, err
+	}
+	if v, ok := cm.Data[LinkHostsKey]; ok {
+		cfg.LinkHosts = splitList(v)
+	}
+
+	return cfg, nil
+}
+
+// reservedNamePattern matches the names of status ConfigMaps and retained
+// revisions, which names derived from files can't take.
+var reservedNamePattern = regexp.MustCompile(`\.(status|rev-[0-9]+)$`)
+
+// isReservedName reports whether a name has the form of a status ConfigMap or
+// a retained revision.
+func isReservedName(name string) bool {
+	return reservedNamePattern.MatchString(name)
+}
+
+// validate checks the override for names, labels and formats the API server
+// or the renderer would reject.
+func (o *TargetOverride) validate(cfg *BundleConfig) error {
+	if o.Namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	for _, f := range o.Formats {
+		if !isKnownFormat(f) {
+			return fmt.Errorf("unknown output format %q", f)
+		}
+	}
+	renamed := map[string]string{}
+	for _, from := range slices.Sorted(maps.Keys(o.Names)) {
+		name := o.Names[from]
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid name %q: %s", name, strings.Join(errs, ", "))
+		}
+		if isReservedName(name) {
+			return fmt.Errorf("invalid name %q: reserved for the operator's own ConfigMaps", name)
+		}
+		if other, ok := renamed[name]; ok {
+			return fmt.Errorf("%s and %s are both renamed to %q", other, from, name)
+		}
+		if name == cfg.AggregateName {
+			return fmt.Errorf("%s is renamed to %q, the name of the aggregate ConfigMap", from, name)
+		}
+		renamed[name] = from
+	}
+	managed := managedLabels(cfg)
+	for k, v := range o.Labels {
+		if _, ok := managed[k]; ok {
+			return fmt.Errorf("label %q is reserved", k)
+		}
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("invalid label %q: %s", k, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("invalid label value %q: %s", v, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// override returns the override declared for the target, if any.
+func (cfg *BundleConfig) override(cluster, namespace string) *TargetOverride {
+	for i := range cfg.TargetOverrides {
+		if o := &cfg.TargetOverrides[i]; o.Cluster == cluster && o.Namespace == namespace {
+			return o
+		}
+	}
+	return nil
+}
+
+// ForTarget returns the configuration to sync a single target with, applying
+// its override.
+func (cfg *BundleConfig) ForTarget(cluster, namespace string) *BundleConfig {
+	o := cfg.override(cluster, namespace)
+	if o == nil {
+		return cfg
+	}
+
+	out := *cfg
+	if len(o.Formats) > 0 {
+		out.Formats = o.Formats
+	}
+	out.Names = o.Names
+	out.ExtraLabels = o.Labels
+	return &out
+}
+
+// sourceURL returns the URL the bundle is fetched from, the comma separated
+// URLs of a bundle_urls source.
+func (cfg *BundleConfig) sourceURL() string {
+	return strings.Join(cfg.sourceURLs(), ",")
+}
+
+// sourceURLs returns the URLs the bundle is fetched from.
+func (cfg *BundleConfig) sourceURLs() []string {
+	if cfg.SPIFFE != nil {
+		return []string{cfg.SPIFFE.EndpointURL}
+	}
+	var urls []string
+	for _, e := range cfg.bundleURLs() {
+		urls = append(urls, e.URL)
+	}
+	return urls
+}
+
+// bundleURLs returns the indexes of bundle files synced: the entries of
+// bundle_urls, or bundle_url alone.
+func (cfg *BundleConfig) bundleURLs() []BundleURLEntry {
+	if len(cfg.BundleURLs) > 0 {
+		return cfg.BundleURLs
+	}
+	return []BundleURLEntry{{URL: cfg.BundleURL}}
+}
+
+// fileSuffixes returns the suffixes of the bundle files synced.
+func (cfg *BundleConfig) fileSuffixes() []string {
+	if len(cfg.FileSuffixes) > 0 {
+		return cfg.FileSuffixes
+	}
+	return DefaultFileSuffixes
+}
+
+// httpClient returns the client fetching the bundle, HTTPClient or
+// http.DefaultClient if it isn't set.
+func (cfg *BundleConfig) httpClient() *http.Client {
+	c := cfg.HTTPClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+	if cfg.SPIFFE != nil {
+		return cfg.SPIFFE.httpClient(c)
+	}
+	return c
+}
+
+// parseBundleConfig parses the configuration of a source, fetching the
+// bundle with the reconciler's HTTPClient.
+func (r *CABundleReconciler) parseBundleConfig(cm *corev1.ConfigMap) (*BundleConfig, error) {
+	cfg, err := ParseBundleConfig(cm)
+	if err != nil {
+		return nil, err
+	}
+	cfg.HTTPClient = r.HTTPClient
+	return cfg, nil
+}
+
+// parseBool parses an optional boolean key, defaulting to false.
+func parseBool(data map[string]string, key string) (bool, error) {
+	v, ok := data[key]
+	if !ok {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// btoi returns 1 for true and 0 for false.
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// splitList splits a comma or newline separated list, dropping empty entries.
+func splitList(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+
+	var out []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// reservedLabel reports whether the label key has a kubernetes.io or k8s.io
+// prefix, like pod-security.kubernetes.io/enforce, which sources may not set
+// on the namespaces they create.
+func reservedLabel(key string) bool {
+	prefix, _, ok := strings.Cut(key, "/")
+	if !ok {
+		return false
+	}
+	for _, domain := range []string{"kubernetes.io", "k8s.io"} {
+		if prefix == domain || strings.HasSuffix(prefix, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// HealthAnnotation and HealthMessageAnnotation are written on the source
+// ConfigMap with the Health of the bundle, so GitOps tools that only see the
+// source, e.g. an Argo CD custom health check, can classify it.
+const (
+	HealthAnnotation        = "cabundle.io/health"
+	HealthMessageAnnotation = "cabundle.io/health-message"
+)
+
+// Health statuses, named like Argo CD's.
+const (
+	HealthHealthy     = "Healthy"
+	HealthProgressing = "Progressing"
+	HealthDegraded    = "Degraded"
+	HealthSuspended   = "Suspended"
+)
+
+// Health summarizes a BundleStatus:
+//
+//   - Degraded: the source is unavailable (Stale) or its downloads are
+//     suspended (CircuitOpen), some targets failed (Degraded) or were
+//     changed out of band (Drifted), its content doesn't match the pinned
+//     checksums (PinMismatch), or a distributed certificate expired.
+//   - Progressing: nothing was synced yet, or a change is soaking on the
+//     canary namespaces, awaiting approval (PlanPending) or acknowledgement
+//     (ChangeUnacknowledged), or held back by a maintenance window.
+//   - Suspended: the source is pinned to a retained revision (Pinned).
+//   - Healthy: every target holds the current bundle.
+type Health struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// bundleHealth returns the Health of a status.
+func bundleHealth(status *BundleStatus) Health {
+	for _, t := range []string{ConditionStale, ConditionCircuitOpen, ConditionDegraded, ConditionDrifted, ConditionPinMismatch} {
+		if c := meta.FindStatusCondition(status.Conditions, t); c != nil && c.Status == "True" {
+			return Health{Status: HealthDegraded, Message: c.Message}
+		}
+	}
+	if c := meta.FindStatusCondition(status.Conditions, ConditionCertificateExpiring); c != nil && c.Reason == "Expired" {
+		return Health{Status: HealthDegraded, Message: c.Message}
+	}
+
+	if c := meta.FindStatusCondition(status.Conditions, ConditionPinned); c != nil && c.Status == "True" {
+		return Health{Status: HealthSuspended, Message: c.Message}
+	}
+
+	switch {
+	case status.LastSyncTime == nil:
+		return Health{Status: HealthProgressing, Message: "Not synced yet"}
+	case status.Canary != nil:
+		return Health{Status: HealthProgressing, Message: fmt.Sprintf("Bundle %s soaking on the canary namespaces since %s",
+			shortHash(status.Canary.Hash), status.Canary.StartedAt.UTC().Format(time.RFC3339))}
+	case status.Plan != nil:
+		return Health{Status: HealthProgressing, Message: fmt.Sprintf("Plan %s awaits approval", status.Plan.ID)}
+	case status.UnacknowledgedChange != nil:
+		return Health{Status: HealthProgressing, Message: fmt.Sprintf("Bundle %s awaits acknowledgement", shortHash(status.UnacknowledgedChange.Hash))}
+	case status.PendingChange != nil:
+		return Health{Status: HealthProgressing, Message: "Change held back by a maintenance window"}
+	}
+	return Health{Status: HealthHealthy, Message: fmt.Sprintf("Synced at %s", status.LastSyncTime.UTC().Format(time.RFC3339))}
+}
+
+// publishHealth records the Health of a status in it and on the source
+// ConfigMap, patching the source only if the health changed. A source
+// deleted meanwhile is left alone.
+func (r *CABundleReconciler) publishHealth(ctx context.Context, src *corev1.ConfigMap, status *BundleStatus) error {
+	health := bundleHealth(status)
+	status.Health = &health
+	if src.Annotations[HealthAnnotation] == health.Status && src.Annotations[HealthMessageAnnotation] == health.Message {
+		return nil
+	}
+
+	patch := client.MergeFrom(src.DeepCopy())
+	if src.Annotations == nil {
+		src.Annotations = map[string]string{}
+	}
+	src.Annotations[HealthAnnotation] = health.Status
+	src.Annotations[HealthMessageAnnotation] = health.Message
+	if err := r.Patch(ctx, src, patch); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("publishing health: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Bundle health", func() {
+	synced := func() *BundleStatus {
+		now := metav1.Now()
+		status := &BundleStatus{LastSyncTime: &now}
+		meta.SetStatusCondition(&status.Conditions, staleCondition(nil, nil))
+		meta.SetStatusCondition(&status.Conditions, degradedCondition(nil, 1))
+		return status
+	}
+
+	DescribeTable("classifies the status",
+		func(mutate func(*BundleStatus), want string) {
+			status := synced()
+			mutate(status)
+			Expect(bundleHealth(status).Status).To(Equal(want))
+		},
+		Entry("synced", func(*BundleStatus) {}, HealthHealthy),
+		Entry("never synced", func(s *BundleStatus) { s.LastSyncTime = nil }, HealthProgressing),
+		Entry("soaking on canaries", func(s *BundleStatus) { s.Canary = &CanaryStatus{Hash: "abc"} }, HealthProgressing),
+		Entry("awaiting approval", func(s *BundleStatus) { s.Plan = &Plan{ID: "p1"} }, HealthProgressing),
+		Entry("held by a maintenance window", func(s *BundleStatus) { s.PendingChange = &PendingChange{} }, HealthProgressing),
+		Entry("source unavailable", func(s *BundleStatus) {
+			meta.SetStatusCondition(&s.Conditions, staleCondition(errors.New("connection refused"), s.LastSyncTime))
+		}, HealthDegraded),
+		Entry("partially synced", func(s *BundleStatus) {
+			meta.SetStatusCondition(&s.Conditions, degradedCondition([]string{"namespace apps: forbidden"}, 1))
+		}, HealthDegraded),
+		Entry("circuit open", func(s *BundleStatus) {
+			meta.SetStatusCondition(&s.Conditions, circuitCondition(time.Now().Add(time.Minute), 5))
+		}, HealthDegraded),
+		Entry("drifted", func(s *BundleStatus) {
+			meta.SetStatusCondition(&s.Conditions, driftedCondition([]string{"apps/root (modified)"}))
+		}, HealthDegraded),
+		Entry("certificate expired", func(s *BundleStatus) {
+			meta.SetStatusCondition(&s.Conditions, metav1.Condition{Type: ConditionCertificateExpiring, Status: metav1.ConditionTrue, Reason: "Expired"})
+		}, HealthDegraded),
+		Entry("certificate expiring", func(s *BundleStatus) {
+			meta.SetStatusCondition(&s.Conditions, metav1.Condition{Type: ConditionCertificateExpiring, Status: metav1.ConditionTrue, Reason: "Expiring"})
+		}, HealthHealthy),
+	)
+
+	It("annotates the source and keeps the full sync current", func() {
+		ctx := context.Background()
+		src := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"}}
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().WithObjects(src).Build(), Scheme: clientgoscheme.Scheme}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(src), src)).To(Succeed())
+
+		status := synced()
+		status.FullSync = &FullSync{SourceVersion: src.ResourceVersion}
+		Expect(r.updateStatus(ctx, src, status)).To(Succeed())
+
+		live := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(src), live)).To(Succeed())
+		Expect(live.Annotations).To(HaveKeyWithValue(HealthAnnotation, HealthHealthy))
+		Expect(live.Annotations).To(HaveKeyWithValue(HealthMessageAnnotation, ContainSubstring("Synced at")))
+		Expect(status.FullSync.SourceVersion).To(Equal(live.ResourceVersion))
+
+		stored, err := r.getStatus(ctx, live)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Health).To(Equal(&Health{Status: HealthHealthy, Message: live.Annotations[HealthMessageAnnotation]}))
+
+		// An unchanged health isn't written again.
+		Expect(r.updateStatus(ctx, live, status)).To(Succeed())
+		again := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(src), again)).To(Succeed())
+		Expect(again.ResourceVersion).To(Equal(live.ResourceVersion))
+	})
+})
@@ -2,16 +2,27 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/html"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -22,63 +33,374 @@ const (
 	CAKey = "ca.crt"
 )
 
+// Caps on the index pages listing bundle files, so a runaway listing can't
+// exhaust the operator's memory.
+const (
+	maxIndexBytes   = 10 << 20
+	maxIndexEntries = 10000
+)
+
+// DefaultMaxFileSize is the largest bundle file downloaded by default, the
+// most a ConfigMap can hold.
+const DefaultMaxFileSize = 1 << 20
+
+// DefaultFileSuffixes are the suffixes of the bundle files listed on an index
+// unless the source sets file_suffixes.
+var DefaultFileSuffixes = []string{".pem", ".crt"}
+
+// DefaultDownloadTimeout bounds the downloads of a sync unless the
+// reconciler's Tuning sets its own DownloadTimeout.
+const DefaultDownloadTimeout = 5 * time.Minute
+
 type PEMFile struct {
 	Filename string
 	Content  []byte
 }
 
-func DownloadPEMBundles(ctx context.Context, baseURL string) ([]PEMFile, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", baseURL, nil)
+// FailedFile is a listed bundle file that could not be downloaded.
+type FailedFile struct {
+	Filename string
+	Err      error
+}
+
+// downloadBundles fetches the bundle files of the source, along with the
+// files that failed while the others were downloaded. index is the listing
+// of a bundle_url source, see listBundles.
+func downloadBundles(ctx context.Context, cfg *BundleConfig, index *bundleIndex) ([]PEMFile, []FailedFile, error) {
+	if cfg.SPIFFE != nil {
+		bundles, err := DownloadSPIFFEBundle(ctx, cfg.httpClient(), cfg.SPIFFE, cfg.MaxFileSize)
+		return bundles, nil, err
+	}
+	return index.download(ctx)
+}
+
+// bundleIndex is the listing of the bundle files served at a bundle_url, or
+// merged from the indexes of bundle_urls.
+type bundleIndex struct {
+	// BaseURL is the URL of the index, or the comma separated URLs of the
+	// indexes merged into it.
+	BaseURL string
+	Files   []string
+	// MaxFileSize is the largest file downloaded, in bytes.
+	MaxFileSize int64
+	// DetectContent lists every link that may point to a file, and syncs
+	// the files holding PEM certificates, see BundleConfig.DetectContent.
+	DetectContent bool
+	// Hash identifies the listing: the file names along with the sizes and
+	// modification times autoindex pages print next to them, and the URL
+	// every file is downloaded from.
+	Hash string
+
+	// located maps the files of a merged listing to the index they are
+	// downloaded from.
+	located map[string]listedFile
+	// client downloads the listed files.
+	client *http.Client
+}
+
+// listBundles fetches the index of a bundle_url or bundle_urls source, nil
+// for sources without one.
+func listBundles(ctx context.Context, cfg *BundleConfig) (*bundleIndex, error) {
+	if cfg.SPIFFE != nil {
+		return nil, nil
+	}
+	suffixes := cfg.fileSuffixes()
+	if cfg.DetectContent {
+		suffixes = nil
+	}
+	index, err := listBundleURLs(ctx, cfg.httpClient(), cfg.bundleURLs(), suffixes)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	if err := index.checkLinkHosts(cfg.LinkHosts); err != nil {
+		return nil, err
+	}
+	index.MaxFileSize = cfg.MaxFileSize
+	index.DetectContent = cfg.DetectContent
+	return index, nil
+}
+
+// fetchBundleIndex fetches the index at baseURL and lists the bundle files
+// linked from it, those with one of the suffixes.
+func fetchBundleIndex(ctx context.Context, c *http.Client, baseURL string, suffixes []string) (*bundleIndex, error) {
+	index, err := fetchIndex(ctx, c, baseURL, suffixes)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	// An empty listing is far more likely a broken source than every file
+	// being retired; syncing it would delete every managed ConfigMap.
+	if len(index.Files) == 0 {
+		return nil, fmt.Errorf("no bundle files listed at %s", baseURL)
+	}
+	return index, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to list bundles: %s", resp.Status)
+// isBundleFile reports whether a link points to a bundle file, one with any
+// of the suffixes. Without suffixes every candidate link is.
+func isBundleFile(href string, suffixes []string) bool {
+	if suffixes == nil {
+		return isCandidateLink(href)
 	}
+	return slices.ContainsFunc(suffixes, func(s string) bool { return strings.HasSuffix(href, s) })
+}
 
-	doc, err := html.Parse(resp.Body)
-	if err != nil {
-		return nil, err
+// isCandidateLink reports whether a link may point to a bundle file when
+// files are detected by their content. Links to other pages, like the parent
+// directory, subdirectories or the sort links of autoindex pages, and links
+// with a scheme aren't.
+func isCandidateLink(href string) bool {
+	p, _, _ := strings.Cut(href, "?")
+	return p != "" && !strings.HasPrefix(p, "#") && !strings.HasSuffix(p, "/") && !strings.Contains(p, ":")
+}
+
+// decodeHref percent-decodes the path of a link, so Corp%20Root.pem is
+// downloaded and named as Corp Root.pem. Links that aren't validly encoded,
+// or whose path decodes to a query or fragment, are kept as they are.
+func decodeHref(href string) string {
+	p, query, hasQuery := strings.Cut(href, "?")
+	decoded, err := url.PathUnescape(p)
+	if err != nil || strings.ContainsAny(decoded, "?#") {
+		return href
+	}
+	if hasQuery {
+		decoded += "?" + query
+	}
+	return decoded
+}
+
+// linkName returns the name a link is listed under, and whether the link is
+// a path relative to the index. Relative links are listed percent-decoded,
+// see decodeHref; absolute and root-relative links, e.g. to a CDN, under the
+// name of the file they point to. Links to directories, or with a scheme
+// other than http and https, aren't listed.
+func linkName(href string) (name string, relative, ok bool) {
+	u, err := url.Parse(href)
+	if err != nil || u.Scheme == "" && u.Host == "" && !strings.HasPrefix(u.Path, "/") {
+		return decodeHref(href), true, true
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" || u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		return "", false, false
+	}
+	name = path.Base(u.Path)
+	if u.RawQuery != "" {
+		name += "?" + u.RawQuery
+	}
+	return name, false, true
+}
+
+// parseIndex lists the bundle files linked from an HTML index, those with one
+// of the suffixes, or every candidate link for nil suffixes, named by
+// linkName. The page is tokenized as it streams in rather than parsed into a
+// tree, and at most maxIndexEntries files are listed.
+func parseIndex(r io.Reader, baseURL string, suffixes []string) (*bundleIndex, error) {
+	index := &bundleIndex{BaseURL: baseURL, MaxFileSize: DefaultMaxFileSize}
+	h := sha256.New()
+	// base is the <base href> of the page, links the link of every file
+	// listed, see resolveLinks.
+	var base string
+	links := map[string]indexLink{}
+
+	// The text following a link up to the next tag is where autoindex
+	// pages print the size and modification time of the file.
+	var pending string
+	var details []string
+	inLink := false
+	flush := func() {
+		if pending != "" {
+			fmt.Fprintf(h, "%s\x00%s\x00", pending, strings.Join(details, " "))
+			pending, details = "", nil
+		}
 	}
 
-	var pemFiles []string
-	var walk func(*html.Node)
-	walk = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "a" {
-			for _, a := range n.Attr {
-				if a.Key == "href" && (strings.HasSuffix(a.Val, ".pem") || strings.HasSuffix(a.Val, ".crt")) {
-					pemFiles = append(pemFiles, a.Val)
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			flush()
+			index.resolveLinks(base, links)
+			for _, file := range index.Files {
+				u, err := index.fileURL(file)
+				if err != nil {
+					return nil, err
 				}
+				fmt.Fprintf(h, "%s\x00", u)
+			}
+			index.Hash = hex.EncodeToString(h.Sum(nil))
+			return index, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if !inLink {
+				flush()
+			}
+			name, hasAttr := z.TagName()
+			if string(name) == "base" {
+				for hasAttr && base == "" {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					if string(key) == "href" {
+						base = string(val)
+					}
+				}
+				continue
+			}
+			if string(name) != "a" {
+				continue
+			}
+			inLink = true
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) != "href" {
+					continue
+				}
+				file, relative, ok := linkName(string(val))
+				if !ok || !isBundleFile(file, suffixes) {
+					continue
+				}
+				// Autoindex pages may link a file twice, e.g. from its
+				// icon, but two links named alike, e.g. to different
+				// hosts, would write the same managed ConfigMap.
+				if prev, ok := links[file]; ok {
+					if prev.Href != string(val) {
+						return nil, fmt.Errorf("index at %s links bundle file %s from both %s and %s", baseURL, file, prev.Href, val)
+					}
+					continue
+				}
+				if len(index.Files) == maxIndexEntries {
+					return nil, fmt.Errorf("index at %s lists more than %d bundle files", baseURL, maxIndexEntries)
+				}
+				flush()
+				pending = file
+				index.Files = append(index.Files, pending)
+				links[file] = indexLink{Href: string(val), Relative: relative}
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "a" {
+				inLink = false
+			} else if !inLink {
+				flush()
+			}
+		case html.TextToken:
+			if pending != "" && !inLink {
+				details = append(details, strings.Fields(string(z.Text()))...)
 			}
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			walk(c)
 		}
 	}
-	walk(doc)
+}
 
-	var results []PEMFile
+// indexLink is the link a file is listed from on an index page.
+type indexLink struct {
+	Href     string
+	Relative bool
+}
 
-	for _, name := range pemFiles {
-		// u := base.ResolveReference(&url.URL{Path: name})
-		url, _ := url.JoinPath(baseURL, name)
-		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+// resolveLinks locates the listed files whose links aren't relative to the
+// index, and every file if the page sets a <base href>, at the URL the link
+// resolves to. The index URL is resolved against as a directory, like
+// bundle_url is joined to relative links.
+func (index *bundleIndex) resolveLinks(base string, links map[string]indexLink) {
+	dir, err := url.Parse(index.BaseURL)
+	if err != nil {
+		return
+	}
+	if !strings.HasSuffix(dir.Path, "/") {
+		dir = dir.JoinPath("/")
+	}
+	if base != "" {
+		b, err := url.Parse(base)
+		if err != nil {
+			return
+		}
+		dir = dir.ResolveReference(b)
+	}
+	for file, link := range links {
+		if link.Relative && base == "" {
+			continue
+		}
+		ref, err := url.Parse(link.Href)
+		if err != nil {
+			continue
+		}
+		if index.located == nil {
+			index.located = map[string]listedFile{}
+		}
+		index.located[file] = listedFile{URL: dir.ResolveReference(ref).String()}
+	}
+}
 
-		r, err := http.DefaultClient.Do(req)
+// checkLinkHosts fails if a listed file is downloaded from another host than
+// the index listing it, unless the host is among allowed, see
+// BundleConfig.LinkHosts. Files listed by an HTTPS index must be downloaded
+// over HTTPS too, so the bundle is verified as TLS, see verification.
+func (index *bundleIndex) checkLinkHosts(allowed []string) error {
+	for _, name := range index.Files {
+		indexURL := index.BaseURL
+		if f, ok := index.located[name]; ok && f.BaseURL != "" {
+			indexURL = f.BaseURL
+		}
+		from, err := url.Parse(indexURL)
+		if err != nil {
+			return err
+		}
+		raw, err := index.fileURL(name)
+		if err != nil {
+			return err
+		}
+		u, err := url.Parse(raw)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		if from.Scheme == "https" && u.Scheme != "https" {
+			return fmt.Errorf("bundle file %s listed at %s links to %s over %s", name, indexURL, u.Host, u.Scheme)
+		}
+		if u.Host != from.Host && !slices.Contains(allowed, u.Host) && !slices.Contains(allowed, u.Hostname()) {
+			return fmt.Errorf("bundle file %s listed at %s links to host %s, which isn't listed in %s",
+				name, indexURL, u.Host, LinkHostsKey)
+		}
+	}
+	return nil
+}
+
+// DownloadPEMBundles downloads every bundle file listed on the index at
+// baseURL. A file failing to download doesn't abort the others, it is
+// returned as a FailedFile; only the listing itself failing, or every file
+// failing, is an error.
+func DownloadPEMBundles(ctx context.Context, baseURL string) ([]PEMFile, []FailedFile, error) {
+	index, err := fetchBundleIndex(ctx, http.DefaultClient, baseURL, DefaultFileSuffixes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return index.download(ctx)
+}
+
+// download downloads the listed files, see DownloadPEMBundles.
+func (index *bundleIndex) download(ctx context.Context) ([]PEMFile, []FailedFile, error) {
+	var results []PEMFile
+	var failed []FailedFile
+	c := index.client
+	if c == nil {
+		c = http.DefaultClient
+	}
 
-		data, err := io.ReadAll(r.Body)
-		r.Body.Close()
+	for _, name := range index.Files {
+		fileCtx := logf.IntoContext(ctx, logf.FromContext(ctx).WithValues("file", name))
+		var data []byte
+		u, err := index.fileURL(name)
 		if err != nil {
-			return nil, err
+			err = fmt.Errorf("failed to download %s: %w", name, err)
+		} else {
+			data, err = downloadFile(fileCtx, c, u, name, index.MaxFileSize)
+		}
+		if err != nil {
+			logf.FromContext(fileCtx).Error(err, "unable to download bundle file")
+			failed = append(failed, FailedFile{Filename: name, Err: err})
+			continue
+		}
+		if _, err := ParseCertificates(data); err != nil && index.DetectContent {
+			logf.FromContext(fileCtx).V(1).Info("Skipping linked file without certificates", "reason", err.Error())
+			continue
 		}
 
 		results = append(results, PEMFile{
@@ -86,69 +408,335 @@ func DownloadPEMBundles(ctx context.Context, baseURL string) ([]PEMFile, error)
 			Content:  data,
 		})
 	}
+	if len(results) == 0 && len(failed) == 0 {
+		return nil, nil, fmt.Errorf("none of the %d files linked from %s hold certificates", len(index.Files), index.BaseURL)
+	}
+	if len(results) == 0 {
+		return nil, nil, fmt.Errorf("every bundle file listed at %s failed to download: %w", index.BaseURL, failed[0].Err)
+	}
 
-	return results, nil
+	return results, failed, nil
 }
 
-func (r *CABundleReconciler) checkConfigMap(ctx context.Context, bundle PEMFile) bool {
-	// Placeholder for checking if the ConfigMap exists and contains the bundle
-	cm := &corev1.ConfigMap{}
+// failedFileNames returns the names of the failed files.
+func failedFileNames(failed []FailedFile) []string {
+	names := make([]string, 0, len(failed))
+	for _, f := range failed {
+		names = append(names, f.Filename)
+	}
+	return names
+}
+
+// fetchIndex downloads the HTML index at baseURL and lists the bundle files
+// with one of the suffixes linked from it, failing on pages larger than
+// maxIndexBytes.
+func fetchIndex(ctx context.Context, c *http.Client, baseURL string, suffixes []string) (_ *bundleIndex, err error) {
+	ctx, span := tracer.Start(ctx, "FetchIndex", trace.WithAttributes(attribute.String("url", baseURL)))
+	defer func() { endSpan(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list bundles: %s", resp.Status)
+	}
+
+	body := &io.LimitedReader{R: resp.Body, N: maxIndexBytes + 1}
+	index, err := parseIndex(body, baseURL, suffixes)
+	if body.N == 0 {
+		return nil, fmt.Errorf("index at %s is larger than %d bytes", baseURL, maxIndexBytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	index.client = c
+	span.SetAttributes(attribute.Int("files", len(index.Files)))
+	return index, nil
+}
+
+// downloadFile downloads a single file linked from the index.
+func downloadFile(ctx context.Context, c *http.Client, url, name string, limit int64) (_ []byte, err error) {
+	ctx, span := tracer.Start(ctx, "DownloadFile", trace.WithAttributes(attribute.String("file", name)))
+	defer func() { endSpan(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	cachedETag, cached, isCached := downloads.get(url)
+	if isCached {
+		req.Header.Set("If-None-Match", cachedETag)
+	}
+
+	r, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode == http.StatusNotModified && isCached {
+		if int64(len(cached)) > limit {
+			return nil, fmt.Errorf("failed to download %s: cached content is larger than the limit of %d bytes", name, limit)
+		}
+		span.SetAttributes(attribute.Bool("cached", true))
+		logf.FromContext(ctx).V(2).Info("File not modified, using cached content", "url", url, "etag", cachedETag)
+		return cached, nil
+	}
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", name, r.Status)
+	}
+
+	data, err := readLimited(r, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	downloads.put(url, r.Header.Get("ETag"), data)
+	span.SetAttributes(attribute.Int("bytes", len(data)))
+	logf.FromContext(ctx).V(2).Info("Downloaded file", "url", url, "bytes", len(data))
+	return data, nil
+}
+
+// fileURL returns the URL a linked file is downloaded from: the decoded link,
+// see decodeHref, escaped again and joined to the index URL, keeping the
+// query string of links like download?id=root.
+func fileURL(baseURL, href string) (string, error) {
+	p, query, hasQuery := strings.Cut(href, "?")
+	segments := strings.Split(p, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	u, err := url.JoinPath(baseURL, strings.Join(segments, "/"))
+	if err != nil {
+		return "", err
+	}
+	if hasQuery {
+		u += "?" + query
+	}
+	return u, nil
+}
+
+// hasAll reports whether have holds every entry of want.
+func hasAll(have, want map[string]string) bool {
+	for k, v := range want {
+		if cur, ok := have[k]; !ok || cur != v {
+			return false
+		}
+	}
+	return true
+}
+
+// readLimited reads the body of a response, failing as soon as it exceeds
+// limit bytes rather than buffering it all.
+func readLimited(resp *http.Response, limit int64) ([]byte, error) {
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("%d bytes, larger than the limit of %d bytes", resp.ContentLength, limit)
+	}
+	body := &io.LimitedReader{R: resp.Body, N: limit + 1}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("larger than the limit of %d bytes", limit)
+	}
+	return data, nil
+}
 
-	cmName := r.reName(bundle.Filename)
+// aggregateBundle concatenates every downloaded file into a single bundle
+// named after the aggregate ConfigMap.
+func aggregateBundle(name string, bundles []PEMFile) PEMFile {
+	sorted := slices.Clone(bundles)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Filename < sorted[j].Filename })
+
+	var content []byte
+	for _, b := range sorted {
+		content = append(content, b.Content...)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			content = append(content, '\n')
+		}
+	}
 
-	err := r.Get(ctx, client.ObjectKey{Name: cmName, Namespace: r.TargetNamespace}, cm)
+	return PEMFile{
+		Filename: name + ".pem",
+		Content:  content,
+	}
+}
+
+// desiredConfigMap renders the bundle into the managed ConfigMap for the
+// namespace.
+func (r *CABundleReconciler) desiredConfigMap(namespace string, bundle PEMFile, cfg *BundleConfig) (*corev1.ConfigMap, error) {
+	data, binaryData, err := renderFormats(bundle.Content, cfg.Formats, cfg.TruststorePassword)
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("rendering %s: %w", bundle.Filename, err)
 	}
 
-	if existingBundle, exists := cm.Data[bundle.Filename]; exists && existingBundle == string(bundle.Content) {
-		return true
+	labels := managedLabels(cfg)
+	for k, v := range cfg.ExtraLabels {
+		labels[k] = v
 	}
-	return false
+	annotations := provenanceAnnotations(cfg)
+	annotations[ContentHashAnnotation] = contentHash(data, binaryData)
+
+	return &corev1.ConfigMap{
+		ObjectMeta: ctrl.ObjectMeta{
+			Name:        r.configMapName(bundle.Filename, cfg),
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Data:       data,
+		BinaryData: binaryData,
+	}, nil
+}
 
+// createOrUpdateConfigMap writes the desired managed ConfigMap and reports
+// whether the content of an existing ConfigMap changed.
+func (r *CABundleReconciler) createOrUpdateConfigMap(ctx context.Context, desired *corev1.ConfigMap, cfg *BundleConfig) (changed bool, err error) {
+	ctx, span := tracer.Start(ctx, "WriteConfigMap", trace.WithAttributes(
+		attribute.String("namespace", desired.Namespace),
+		attribute.String("name", desired.Name),
+	))
+	defer func() { endSpan(span, err) }()
+	ctx = logf.IntoContext(ctx, logf.FromContext(ctx).WithValues("configmap", desired.Name))
+
+	// Another writer got in between the read and the write: retry on the
+	// latest version rather than failing the reconcile.
+	attempt := 0
+	err = retry.OnError(retry.DefaultRetry, isWriteConflict, func() error {
+		if attempt++; attempt > 1 {
+			logf.FromContext(ctx).V(1).Info("Retrying ConfigMap write after a conflict", "attempt", attempt)
+		}
+		var err error
+		changed, err = r.writeConfigMap(ctx, desired.DeepCopy(), cfg)
+		return err
+	})
+	return changed, err
+}
+
+// isWriteConflict reports whether a write lost a race with another writer.
+func isWriteConflict(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
 }
 
-func (r *CABundleReconciler) createOrUpdateConfigMap(ctx context.Context, bundle PEMFile) error {
+// writeConfigMap creates or updates a managed ConfigMap from its latest
+// version, see createOrUpdateConfigMap.
+func (r *CABundleReconciler) writeConfigMap(ctx context.Context, desired *corev1.ConfigMap, cfg *BundleConfig) (bool, error) {
 	logger := logf.FromContext(ctx)
 	cm := &corev1.ConfigMap{}
 
-	cmName := r.reName(bundle.Filename)
-	err := r.Get(ctx, client.ObjectKey{Name: cmName, Namespace: r.TargetNamespace}, cm)
-
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), cm)
 	if apierrors.IsNotFound(err) {
+		if r.skipWrite(ctx, cfg, ActionCreate, desired.Namespace, desired.Name) {
+			return false, nil
+		}
 		// Create new ConfigMap if it doesn't exist
-		logger.Info("Creating ConfigMap", "name", cmName, "namespace", r.TargetNamespace)
-		cm = &corev1.ConfigMap{
-			ObjectMeta: ctrl.ObjectMeta{
-				Name:      cmName,
-				Namespace: r.TargetNamespace,
-				Labels: map[string]string{
-					"app": "cabundle-operator",
-				},
-			},
-			Data: map[string]string{
-				CAKey: string(bundle.Content),
-			},
-		}
-		return r.Create(ctx, cm)
+		logger.Info("Creating ConfigMap")
+		desired.Annotations[RevisionAnnotation] = "1"
+		if err := r.Create(ctx, desired); err != nil {
+			return false, err
+		}
+		r.eventf(cfg, corev1.EventTypeNormal, ReasonCreated, "Created ConfigMap %s", r.describeConfigMap(desired.Namespace, desired.Name))
+		return false, nil
 	} else if err != nil {
-		return err
+		return false, err
+	}
+	adopt, err := checkOwnership(cm, cfg)
+	if err != nil {
+		r.eventf(cfg, corev1.EventTypeWarning, ReasonNotManaged, "Not writing ConfigMap %s: %v", r.describeConfigMap(cm.Namespace, cm.Name), err)
+		return false, err
+	}
+
+	data, binaryData := ownedContent(cm, cfg)
+	changed := !equality.Semantic.DeepEqual(data, desired.Data) ||
+		!equality.Semantic.DeepEqual(binaryData, desired.BinaryData)
+	revision, revErr := strconv.ParseInt(cm.Annotations[RevisionAnnotation], 10, 64)
+	if changed || revErr != nil {
+		desired.Annotations[RevisionAnnotation] = strconv.FormatInt(max(revision, 0)+1, 10)
+	}
+	if !changed && hasAll(cm.Labels, desired.Labels) && hasAll(cm.Annotations, desired.Annotations) {
+		logger.V(1).Info("ConfigMap up to date")
+		return false, nil
+	}
+	var diff trustDiff
+	if changed {
+		diff = diffCertificates(configMapCertificates(cm), configMapCertificates(desired))
+	}
+	if r.skipWrite(ctx, cfg, ActionUpdate, cm.Namespace, cm.Name, "trust", diff.String()) {
+		return changed, nil
+	}
+	orphan := adopt && isOrphan(cm)
+	if orphan {
+		logger.Info("Re-adopting orphaned ConfigMap")
+	} else if adopt {
+		logger.Info("Adopting ConfigMap")
 	}
 
-	// Update existing ConfigMap
-	if cm.Data == nil {
-		cm.Data = make(map[string]string)
+	if changed {
+		if err := r.retainRevision(ctx, cm, cfg); err != nil {
+			return false, err
+		}
 	}
-	cm.Data[CAKey] = string(bundle.Content)
-	return r.Update(ctx, cm)
+
+	// Update existing ConfigMap, dropping keys of formats no longer requested
+	// but keeping those managed by someone else
+	if cm.Labels == nil {
+		cm.Labels = map[string]string{}
+	}
+	for k, v := range desired.Labels {
+		cm.Labels[k] = v
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	for k, v := range desired.Annotations {
+		cm.Annotations[k] = v
+	}
+	keepIgnoredContent(cm, desired, cfg)
+	cm.Data = desired.Data
+	cm.BinaryData = desired.BinaryData
+	if err := r.Update(ctx, cm); err != nil {
+		return false, err
+	}
+	if orphan {
+		r.eventf(cfg, corev1.EventTypeNormal, ReasonReadopted, "Re-adopted orphaned ConfigMap %s", r.describeConfigMap(cm.Namespace, cm.Name))
+	} else if adopt {
+		r.eventf(cfg, corev1.EventTypeNormal, ReasonAdopted, "Adopted ConfigMap %s", r.describeConfigMap(cm.Namespace, cm.Name))
+	} else if changed {
+		r.eventf(cfg, corev1.EventTypeNormal, ReasonUpdated, "Updated ConfigMap %s", r.describeConfigMap(cm.Namespace, cm.Name))
+	}
+	if !diff.empty() {
+		logger.Info("Trust changed",
+			"added", diff.Added, "removed", diff.Removed, "expiryChanged", diff.ExpiryChanged)
+		r.eventf(cfg, corev1.EventTypeNormal, ReasonTrustChanged, "ConfigMap %s: %s", r.describeConfigMap(cm.Namespace, cm.Name), diff)
+		noteTrustChanged(ctx, r.describeConfigMap(cm.Namespace, cm.Name), diff)
+	}
+	return changed, nil
+}
+
+// cleanupReader returns the reader cleanup decisions are made from, see
+// UncachedReader.
+func (r *CABundleReconciler) cleanupReader() client.Reader {
+	if r.UncachedReader != nil {
+		return r.UncachedReader
+	}
+	return r.Client
 }
 
-func (r *CABundleReconciler) GetBundleConfigMaps(ctx context.Context) ([]string, error) {
+// GetBundleConfigMaps returns the names of the ConfigMaps the bundle's source
+// manages in the namespace, leaving those of other sources alone.
+func (r *CABundleReconciler) GetBundleConfigMaps(ctx context.Context, namespace string, cfg *BundleConfig) ([]string, error) {
 	logger := logf.FromContext(ctx)
 	cmList := &corev1.ConfigMapList{}
-	err := r.List(ctx, cmList, client.InNamespace(r.TargetNamespace), client.MatchingLabels{"app": "cabundle-operator"})
+	err := r.cleanupReader().List(ctx, cmList, client.InNamespace(namespace), client.MatchingLabels(managedLabels(cfg)))
 	if err != nil {
-		logger.Error(err, "unable to list ConfigMaps")
+		logger.Error(err, "unable to list ConfigMaps", "namespace", namespace)
 		return nil, err
 	}
 
@@ -160,24 +748,32 @@ func (r *CABundleReconciler) GetBundleConfigMaps(ctx context.Context) ([]string,
 	return bundleCMNames, nil
 }
 
-func (r *CABundleReconciler) DeleteBundleConfigMap(ctx context.Context, name string) error {
+func (r *CABundleReconciler) DeleteBundleConfigMap(ctx context.Context, namespace, name string) error {
 	logger := logf.FromContext(ctx)
 	cm := &corev1.ConfigMap{}
-	err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: r.TargetNamespace}, cm)
+	err := r.cleanupReader().Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, cm)
 	if err != nil {
-		logger.Error(err, "unable to fetch ConfigMap for deletion", "name", name)
+		logger.Error(err, "unable to fetch ConfigMap for deletion", "name", name, "namespace", namespace)
 		return client.IgnoreNotFound(err)
 	}
 
-	logger.Info("Deleting stale ConfigMap", "name", name)
-	return r.Delete(ctx, cm)
+	logger.Info("Deleting stale ConfigMap", "name", name, "namespace", namespace)
+	if err := r.Delete(ctx, cm); err != nil {
+		return err
+	}
+	// Its retained revisions go along.
+	return r.pruneRevisions(ctx, namespace, name, 0, 0)
 }
 
-func (r *CABundleReconciler) CleanUpConfigMaps(ctx context.Context, bundles []PEMFile) error {
+func (r *CABundleReconciler) CleanUpConfigMaps(ctx context.Context, namespace string, bundles []PEMFile, cfg *BundleConfig) error {
 	logger := logf.FromContext(ctx)
-	logger.Info("Starting cleanup of stale ConfigMaps")
+	if r.cleanupMode(cfg) == CleanupDisabled {
+		logger.V(1).Info("Cleanup disabled, skipping stale ConfigMaps", "namespace", namespace)
+		return nil
+	}
+	logger.Info("Starting cleanup of stale ConfigMaps", "namespace", namespace)
 
-	bundleCMNames, err := r.GetBundleConfigMaps(ctx)
+	bundleCMNames, err := r.GetBundleConfigMaps(ctx, namespace, cfg)
 	if err != nil {
 		return err
 	}
@@ -187,7 +783,14 @@ func (r *CABundleReconciler) CleanUpConfigMaps(ctx context.Context, bundles []PE
 		existingBundles[b] = false
 	}
 	for _, b := range bundles {
-		cmName := r.reName(b.Filename)
+		cmName := r.configMapName(b.Filename, cfg)
+		if _, exists := existingBundles[cmName]; exists {
+			existingBundles[cmName] = true
+		}
+	}
+	// Files that failed to download keep their last synced ConfigMap.
+	for _, name := range cfg.RetainedFiles {
+		cmName := r.configMapName(name, cfg)
 		if _, exists := existingBundles[cmName]; exists {
 			existingBundles[cmName] = true
 		}
@@ -195,22 +798,49 @@ func (r *CABundleReconciler) CleanUpConfigMaps(ctx context.Context, bundles []PE
 
 	for cmName, found := range existingBundles {
 		if !found {
-			logger.Info("Found stale ConfigMap to delete", "name", cmName)
-			err := r.DeleteBundleConfigMap(ctx, cmName)
+			logger.Info("Found stale ConfigMap to delete", "name", cmName, "namespace", namespace, "reason", CleanupReasonStale)
+			if r.skipCleanup(ctx, cfg, namespace, cmName, CleanupReasonStale) {
+				recordCleanup(ctx, cfg, CleanupReasonStale, false)
+				continue
+			}
+			err := r.DeleteBundleConfigMap(ctx, namespace, cmName)
+			recordCleanup(ctx, cfg, CleanupReasonStale, err == nil)
 			if err != nil {
 				return err
 			}
+			r.eventf(cfg, corev1.EventTypeNormal, ReasonDeleted, "Deleted stale ConfigMap %s: its file is no longer served", r.describeConfigMap(namespace, cmName))
+			noteDeleted(ctx, r.describeConfigMap(namespace, cmName))
 		}
 	}
 
-	logger.Info("Cleanup of stale ConfigMaps completed")
+	logger.Info("Cleanup of stale ConfigMaps completed", "namespace", namespace)
 
 	return nil
 }
 
-func (r *CABundleReconciler) reName(name string) string {
-	nameTrimmed := strings.TrimSuffix(name, ".pem")
-	nameTrimmed = strings.TrimSuffix(nameTrimmed, ".crt")
+// configMapName returns the name of the managed ConfigMap for a bundle file,
+// applying the target's renames.
+func (r *CABundleReconciler) configMapName(filename string, cfg *BundleConfig) string {
+	name := r.reName(filename, cfg.fileSuffixes())
+	if renamed, ok := cfg.Names[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+// reName derives the managed ConfigMap name from a bundle file name without
+// its suffix. The default suffixes are always trimmed, as the aggregate and
+// SPIFFE bundles are named .pem whatever the source lists.
+func (r *CABundleReconciler) reName(name string, suffixes []string) string {
+	nameTrimmed := name
+	for _, s := range suffixes {
+		if !slices.Contains(DefaultFileSuffixes, s) {
+			nameTrimmed = strings.TrimSuffix(nameTrimmed, s)
+		}
+	}
+	for _, s := range DefaultFileSuffixes {
+		nameTrimmed = strings.TrimSuffix(nameTrimmed, s)
+	}
 	re := regexp.MustCompile(`[^a-zA-Z0-9]`)
 	return strings.ToLower(re.ReplaceAllString(nameTrimmed, "-"))
 }
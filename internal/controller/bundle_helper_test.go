@@ -0,0 +1,173 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("Managed ConfigMap writes", func() {
+	ctx := context.Background()
+
+	It("only writes ConfigMaps that differ from the rendered object", func() {
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().Build()}
+		cfg := &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+		bundle := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+		desired := func() *corev1.ConfigMap {
+			cm, err := r.desiredConfigMap("apps", bundle, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			return cm
+		}
+		live := func() *corev1.ConfigMap {
+			cm := &corev1.ConfigMap{}
+			Expect(r.Get(ctx, client.ObjectKeyFromObject(desired()), cm)).To(Succeed())
+			return cm
+		}
+
+		_, err := r.createOrUpdateConfigMap(ctx, desired(), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		version := live().ResourceVersion
+
+		updated, err := r.createOrUpdateConfigMap(ctx, desired(), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated).To(BeFalse())
+		Expect(live().ResourceVersion).To(Equal(version))
+
+		// A label missing from the live object is restored without the
+		// content counting as changed.
+		cfg.ExtraLabels = map[string]string{"istio.io/config": "true"}
+		updated, err = r.createOrUpdateConfigMap(ctx, desired(), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated).To(BeFalse())
+		Expect(live().ResourceVersion).NotTo(Equal(version))
+		Expect(live().Labels).To(HaveKeyWithValue("istio.io/config", "true"))
+	})
+
+	It("counts the content changes of every ConfigMap in its revision", func() {
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().Build()}
+		cfg := &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+		write := func(content []byte) string {
+			desired, err := r.desiredConfigMap("apps", PEMFile{Filename: "root.pem", Content: content}, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = r.createOrUpdateConfigMap(ctx, desired, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			cm := &corev1.ConfigMap{}
+			Expect(r.Get(ctx, client.ObjectKeyFromObject(desired), cm)).To(Succeed())
+			return cm.Annotations[RevisionAnnotation]
+		}
+
+		first := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		Expect(write(first)).To(Equal("1"))
+		Expect(write(first)).To(Equal("1"))
+		Expect(write(newTestCAPEM("Corp Root G2", time.Now().Add(time.Hour)))).To(Equal("2"))
+
+		// ConfigMaps written before the revision was introduced start over.
+		cm := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "root"}, cm)).To(Succeed())
+		delete(cm.Annotations, RevisionAnnotation)
+		Expect(r.Update(ctx, cm)).To(Succeed())
+		Expect(write(first)).To(Equal("1"))
+	})
+
+	It("retries updates that conflict with another writer", func() {
+		conflicts := 0
+		c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if conflicts == 0 {
+					conflicts++
+					// Another controller writes the object first.
+					other := &corev1.ConfigMap{}
+					Expect(c.Get(ctx, client.ObjectKeyFromObject(obj), other)).To(Succeed())
+					other.Annotations = map[string]string{"example.com/owner": "other"}
+					Expect(c.Update(ctx, other)).To(Succeed())
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		}).Build()
+		r := &CABundleReconciler{Client: c}
+		cfg := &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+		desired := func(notAfter time.Time) *corev1.ConfigMap {
+			bundle := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", notAfter)}
+			cm, err := r.desiredConfigMap("apps", bundle, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			return cm
+		}
+
+		_, err := r.createOrUpdateConfigMap(ctx, desired(time.Now().Add(time.Hour)), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		want := desired(time.Now().Add(2 * time.Hour))
+		updated, err := r.createOrUpdateConfigMap(ctx, want, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated).To(BeTrue())
+		Expect(conflicts).To(Equal(1))
+
+		live := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(want), live)).To(Succeed())
+		Expect(live.Data).To(Equal(want.Data))
+		Expect(live.Annotations).To(HaveKeyWithValue("example.com/owner", "other"))
+	})
+
+	It("decides cleanup from the uncached reader when set", func() {
+		api := fake.NewClientBuilder().Build()
+		// The cache hasn't seen the ConfigMap created moments ago.
+		cached := interceptor.NewClient(api, interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				return nil
+			},
+		})
+		r := &CABundleReconciler{Client: cached, UncachedReader: api}
+		cfg := &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+		bundle := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+		for _, name := range []string{"root.pem", "legacy.pem"} {
+			cm, err := r.desiredConfigMap("apps", PEMFile{Filename: name, Content: bundle.Content}, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(api.Create(ctx, cm)).To(Succeed())
+		}
+
+		Expect(r.CleanUpConfigMaps(ctx, "apps", []PEMFile{bundle}, cfg)).To(Succeed())
+		list := &corev1.ConfigMapList{}
+		Expect(api.List(ctx, list)).To(Succeed())
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].Name).To(Equal(r.configMapName("root.pem", cfg)))
+	})
+
+	It("logs the target namespace and ConfigMap of every write", func() {
+		var lines []string
+		logger := funcr.New(func(prefix, args string) { lines = append(lines, args) }, funcr.Options{})
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().Build()}
+		cfg := &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+		bundle := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+
+		Expect(r.syncNamespace(logf.IntoContext(ctx, logger), "apps", []PEMFile{bundle}, cfg)).To(Succeed())
+		Expect(lines).To(ContainElement(SatisfyAll(
+			ContainSubstring(`"msg"="Creating ConfigMap"`),
+			ContainSubstring(`"targetNamespace"="apps"`),
+			ContainSubstring(`"configmap"="root"`),
+		)))
+	})
+})
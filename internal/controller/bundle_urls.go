@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// BundleURLEntry is an index of bundle files listed in bundle_urls, declared
+// as a YAML or JSON list of URLs or of objects:
+//
+//	bundle_urls: |
+//	  - https://pki.example.com/roots/
+//	  - url: https://pki.example.com/partners/
+//	    name: partner
+//	    include: ["*.pem"]
+//	    exclude: ["*-test.pem"]
+type BundleURLEntry struct {
+	URL string `json:"url"`
+	// Name, if set, prefixes the names of the files listed at URL, keeping
+	// apart the managed ConfigMaps of indexes listing equally named files.
+	Name string `json:"name,omitempty"`
+	// Include and Exclude are path.Match patterns on the file names. A file
+	// is synced if it matches an Include pattern, or none is set, and no
+	// Exclude pattern.
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// UnmarshalJSON accepts a plain URL as well as an object.
+func (e *BundleURLEntry) UnmarshalJSON(data []byte) error {
+	if s := ""; json.Unmarshal(data, &s) == nil {
+		*e = BundleURLEntry{URL: s}
+		return nil
+	}
+	type entry BundleURLEntry
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*entry)(e))
+}
+
+// plain reports whether the entry lists its files unfiltered and unprefixed,
+// like bundle_url.
+func (e *BundleURLEntry) plain() bool {
+	return e.Name == "" && len(e.Include) == 0 && len(e.Exclude) == 0
+}
+
+// matches reports whether the entry's filters select the file.
+func (e *BundleURLEntry) matches(href string) bool {
+	name := path.Base(href)
+	included := len(e.Include) == 0
+	for _, p := range e.Include {
+		if ok, _ := path.Match(p, name); ok {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, p := range e.Exclude {
+		if ok, _ := path.Match(p, name); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks the entry's URL, name and patterns.
+func (e *BundleURLEntry) validate() error {
+	if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q: must be an http or https URL", e.URL)
+	}
+	if errs := validation.IsDNS1123Label(e.Name); e.Name != "" && len(errs) > 0 {
+		return fmt.Errorf("invalid name %q: %s", e.Name, strings.Join(errs, ", "))
+	}
+	for _, p := range append(append([]string{}, e.Include...), e.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// parseBundleURLs parses the bundle_urls key.
+func parseBundleURLs(v string) ([]BundleURLEntry, error) {
+	var entries []BundleURLEntry
+	if err := yaml.UnmarshalStrict([]byte(v), &entries); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", BundleURLsKey, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s lists no URLs", BundleURLsKey)
+	}
+	names := map[string]bool{}
+	for i := range entries {
+		if err := entries[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s entry %d: %w", BundleURLsKey, i, err)
+		}
+		key := entries[i].URL + "\x00" + entries[i].Name
+		if names[key] {
+			return nil, fmt.Errorf("invalid %s entry %d: %s listed twice with the same name", BundleURLsKey, i, entries[i].URL)
+		}
+		names[key] = true
+	}
+	return entries, nil
+}
+
+// listBundleURLs fetches the index of every entry of bundle_urls and merges
+// their filtered listings into one. A file name listed by two entries is an
+// error, both would write the same managed ConfigMap. Only files with one of
+// the suffixes are listed.
+func listBundleURLs(ctx context.Context, c *http.Client, entries []BundleURLEntry, suffixes []string) (*bundleIndex, error) {
+	if len(entries) == 1 && entries[0].plain() {
+		return fetchBundleIndex(ctx, c, entries[0].URL, suffixes)
+	}
+
+	urls := make([]string, 0, len(entries))
+	for _, e := range entries {
+		urls = append(urls, e.URL)
+	}
+	merged := &bundleIndex{
+		BaseURL:     strings.Join(urls, ","),
+		MaxFileSize: DefaultMaxFileSize,
+		located:     map[string]listedFile{},
+		client:      c,
+	}
+	h := sha256.New()
+	for _, e := range entries {
+		index, err := fetchIndex(ctx, c, e.URL, suffixes)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", e.URL, e.Name, index.Hash)
+
+		listed := 0
+		for _, href := range index.Files {
+			if !e.matches(href) {
+				continue
+			}
+			name := href
+			if e.Name != "" {
+				name = e.Name + "-" + path.Base(href)
+			}
+			if prev, ok := merged.located[name]; ok {
+				return nil, fmt.Errorf("bundle file %s is listed at both %s and %s", name, prev.BaseURL, e.URL)
+			}
+			merged.located[name] = listedFile{BaseURL: e.URL, Href: href, URL: index.located[href].URL}
+			merged.Files = append(merged.Files, name)
+			fmt.Fprintf(h, "%s\x00", name)
+			listed++
+		}
+		// Like for bundle_url, an empty listing is more likely a broken
+		// source, or filters gone wrong, than every file being retired.
+		if listed == 0 {
+			return nil, fmt.Errorf("no bundle files listed at %s match its filters", e.URL)
+		}
+	}
+	merged.Hash = hex.EncodeToString(h.Sum(nil))
+	return merged, nil
+}
+
+// listedFile locates a file of a merged listing, see listBundleURLs.
+type listedFile struct {
+	BaseURL string
+	Href    string
+	// URL, if set, is where the file is downloaded from instead, see
+	// resolveLinks.
+	URL string
+}
+
+// fileURL returns the URL a listed file is downloaded from.
+func (index *bundleIndex) fileURL(name string) (string, error) {
+	f, ok := index.located[name]
+	switch {
+	case f.URL != "":
+		return f.URL, nil
+	case !ok:
+		f = listedFile{BaseURL: index.BaseURL, Href: name}
+	}
+	return fileURL(f.BaseURL, f.Href)
+}
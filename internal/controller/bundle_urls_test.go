@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("bundle_urls", func() {
+	ctx := context.Background()
+
+	parse := func(urls string) (*BundleConfig, error) {
+		return ParseBundleConfig(&corev1.ConfigMap{Data: map[string]string{BundleURLsKey: urls}})
+	}
+
+	// serve lists the files on the index and serves every one of them.
+	serve := func(files map[string][]byte) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/" {
+				for name := range files {
+					_, _ = w.Write([]byte(`<a href="` + name + `">` + name + `</a>`))
+				}
+				return
+			}
+			content, ok := files[req.URL.Path[1:]]
+			if !ok {
+				http.NotFound(w, req)
+				return
+			}
+			_, _ = w.Write(content)
+		}))
+	}
+
+	It("accepts plain URLs and entries with a name and filters", func() {
+		cfg, err := parse(`
+- https://pki.example.com/roots/
+- url: https://pki.example.com/partners/
+  name: partner
+  include: ["*.pem"]
+  exclude: ["*-test.pem"]
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.BundleURLs).To(Equal([]BundleURLEntry{
+			{URL: "https://pki.example.com/roots/"},
+			{URL: "https://pki.example.com/partners/", Name: "partner", Include: []string{"*.pem"}, Exclude: []string{"*-test.pem"}},
+		}))
+		Expect(cfg.sourceURL()).To(Equal("https://pki.example.com/roots/,https://pki.example.com/partners/"))
+		Expect(cfg.verification()).To(Equal(VerificationTLS))
+
+		cfg, err = parse(`["https://pki.example.com/roots/", "http://pki.example.com/legacy/"]`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.BundleURLs).To(HaveLen(2))
+		Expect(cfg.verification()).To(Equal(VerificationNone))
+	})
+
+	DescribeTable("rejects invalid lists",
+		func(urls, message string) {
+			_, err := parse(urls)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("empty", "[]", "lists no URLs"),
+		Entry("not a URL", "- pki.example.com", "must be an http or https URL"),
+		Entry("unknown field", "- url: https://pki.example.com/\n  prefix: corp", "unknown field"),
+		Entry("invalid name", "- url: https://pki.example.com/\n  name: Corp_Roots", "invalid name"),
+		Entry("invalid pattern", "- url: https://pki.example.com/\n  include: ['[']", "invalid pattern"),
+		Entry("duplicate", "- https://pki.example.com/\n- https://pki.example.com/", "listed twice"),
+	)
+
+	It("can't be combined with bundle_url", func() {
+		_, err := ParseBundleConfig(&corev1.ConfigMap{Data: map[string]string{
+			BundleURLKey:  "https://pki.example.com/",
+			BundleURLsKey: "- https://pki.example.com/partners/",
+		}})
+		Expect(err).To(MatchError(ContainSubstring("only one of")))
+	})
+
+	It("merges the filtered listings of every index", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		partner := newTestCAPEM("Partner Root", time.Now().Add(time.Hour))
+		roots := serve(map[string][]byte{"root.pem": root})
+		defer roots.Close()
+		partners := serve(map[string][]byte{"root.pem": partner, "root-test.pem": partner, "root.crt": partner})
+		defer partners.Close()
+
+		cfg, err := parse(`
+- ` + roots.URL + `
+- url: ` + partners.URL + `
+  name: partner
+  include: ["*.pem"]
+  exclude: ["*-test.pem"]
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		index, err := listBundles(ctx, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Files).To(ConsistOf("root.pem", "partner-root.pem"))
+
+		bundles, failed, err := downloadBundles(ctx, cfg, index)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failed).To(BeEmpty())
+		Expect(bundles).To(ConsistOf(
+			PEMFile{Filename: "root.pem", Content: root},
+			PEMFile{Filename: "partner-root.pem", Content: partner},
+		))
+	})
+
+	It("fails on a file name listed by two indexes", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		a := serve(map[string][]byte{"root.pem": root})
+		defer a.Close()
+		b := serve(map[string][]byte{"root.pem": root})
+		defer b.Close()
+
+		cfg, err := parse(strings.Join([]string{"- " + a.URL, "- " + b.URL}, "\n"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = listBundles(ctx, cfg)
+		Expect(err).To(MatchError(ContainSubstring("is listed at both")))
+	})
+
+	It("fails when the filters of an index match no file", func() {
+		srv := serve(map[string][]byte{"root.crt": newTestCAPEM("Corp Root", time.Now().Add(time.Hour))})
+		defer srv.Close()
+
+		cfg, err := parse("- url: " + srv.URL + "\n  include: ['*.pem']")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = listBundles(ctx, cfg)
+		Expect(err).To(MatchError(ContainSubstring("match its filters")))
+	})
+})
@@ -18,29 +18,144 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// DefaultFieldManager is the field manager recorded in managedFields for
+// the operator's writes unless configured otherwise.
+const DefaultFieldManager = "cabundle-operator"
+
+// MaxFieldManagerLength is the longest field manager the API server accepts.
+const MaxFieldManagerLength = 128
+
 // CABundleReconciler reconciles a ConfigMap object
 type CABundleReconciler struct {
 	client.Client
 	Scheme          *runtime.Scheme
 	TargetNamespace string
-	EventCh         chan event.GenericEvent
+	// ConfigMapName is the name of the operator's source ConfigMap in
+	// TargetNamespace.
+	ConfigMapName string
+	// EventCh feeds sync requests to the controller, see SyncQueue.
+	EventCh SyncQueue
+	// Recorder records Events on the source ConfigMaps.
+	Recorder record.EventRecorder
+	// VerboseLogger returns a logger enabled up to the V-level, used for
+	// sources annotated with cabundle.io/log-verbosity.
+	VerboseLogger func(v int) logr.Logger
+
+	// cluster names the remote cluster a copy of the reconciler syncs, see
+	// syncRemoteCluster.
+	cluster string
+	// freshness holds the last successful sync of every source, see
+	// SyncFreshnessCheck.
+	freshness syncFreshness
+	// started is when SetupWithManager ran, see CleanupGracePeriod.
+	started time.Time
+	// debug is the view served by DebugHandler.
+	debug debugState
+	// verbose caches the loggers of sources with raised verbosity.
+	verbose verboseLoggers
+
+	// tuningValue holds the Tuning set with SetTuning.
+	tuningValue atomic.Pointer[Tuning]
+	// breakers holds the circuit breaker of each source.
+	breakers circuitBreakers
+	// drift holds the managed ConfigMaps seen drifting, see detectDrift.
+	drift driftTracker
+	// remotes caches the clients of remote clusters, see remoteClient.
+	remotes remoteClients
+
+	// MaxConcurrentReconciles is the number of sources synced in parallel.
+	// Sources sharing a URL download in turn, and writes to a namespace are
+	// serialized, see keyedLocks.
+	MaxConcurrentReconciles int
+	urlLocks                keyedLocks
+	namespaceLocks          keyedLocks
+	// RateLimiter paces the controller's requests, see NewRateLimiter. Nil
+	// keeps controller-runtime's default.
+	RateLimiter workqueue.TypedRateLimiter[reconcile.Request]
+	// SecretReader reads the kubeconfig Secrets of remote clusters. It is
+	// expected to bypass the informer cache, e.g. the manager's API reader,
+	// so that Secrets are neither cached nor need list and watch
+	// permissions. Nil reads them through Client.
+	SecretReader client.Reader
+	// UncachedReader, if set, reads the managed ConfigMaps when deciding
+	// which are stale, bypassing the informer cache so a ConfigMap written
+	// moments ago is never mistaken for stale.
+	UncachedReader client.Reader
+	// DryRun logs the managed ConfigMaps that would be created, updated and
+	// deleted instead of writing them, and records no Events. Client is
+	// expected to be a dry-run client so that every other write is only
+	// validated by the API server.
+	DryRun bool
+	// OnlyNamespace, if set, confines the reconciler to a single namespace,
+	// e.g. when developing against a shared cluster: sources elsewhere are
+	// ignored, and every bundle is distributed to this namespace only.
+	OnlyNamespace string
+	// SourceNamespaces are the namespaces besides TargetNamespace that
+	// ConfigMaps labeled as bundle sources are accepted in, every namespace
+	// if they include AllSourceNamespaces. A source decides which namespaces
+	// trust its bundle and which managed ConfigMaps are deleted, so only
+	// the operator's own namespace is trusted by default.
+	SourceNamespaces []string
+	// WatchNamespaces, if set, are the only namespaces the manager's cache
+	// holds: sources outside them are ignored, and bundles are only
+	// distributed to them.
+	WatchNamespaces []string
+	// FieldManager, if set, is the field manager of the writes to remote
+	// clusters. Client is expected to record it for local writes, see
+	// client.WithFieldOwner.
+	FieldManager string
+	// CleanupMode is how stale and untargeted managed ConfigMaps are
+	// cleaned up, see CleanupDelete, and CleanupGracePeriod how long after
+	// startup deletions are only reported.
+	CleanupMode        string
+	CleanupGracePeriod time.Duration
+	// AllowNamespaceCreation lets sources with create_namespaces create
+	// their missing target namespaces. Off by default, since any source
+	// could create namespaces with the operator's permissions.
+	AllowNamespaceCreation bool
+	// Notifier, if set, is sent notifications when the trust distributed for
+	// a source changed, its syncs keep failing, or cleanup deleted managed
+	// ConfigMaps.
+	Notifier Notifier
+	// HTTPClient, if set, downloads the bundles and probes the sources and
+	// canaries, see BundleConfig.HTTPClient. Nil uses http.DefaultClient.
+	HTTPClient *http.Client
 }
 
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=configmaps/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;watch;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -52,61 +167,592 @@ type CABundleReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.22.4/pkg/reconcile
 func (r *CABundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracer.Start(ctx, "Reconcile", trace.WithAttributes(
+		attribute.String("source.namespace", req.Namespace),
+		attribute.String("source.name", req.Name),
+	))
+	r.debug.reconcileStarted(req)
+	result, err := r.reconcile(ctx, req)
+	failures := r.debug.reconcileFinished(req, err)
+	endSpan(span, err)
+	if err != nil {
+		r.notifyFailing(ctx, req, failures, err)
+	}
+	tuning := r.tuning()
+	if err != nil && tuning.ErrorBackoffBase > 0 {
+		// Failures are retried on the source's own backoff rather than the
+		// controller's rate limiter, unless the reconcile asked for later.
+		retry := max(result.RequeueAfter, errorBackoff(tuning.ErrorBackoffBase, tuning.ErrorBackoffMax, failures))
+		reconcileErrors.WithLabelValues(req.String()).Inc()
+		logf.FromContext(ctx).Error(err, "Reconcile failed", "consecutiveFailures", failures, "retryAfter", retry.String())
+		return ctrl.Result{RequeueAfter: retry}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// reconcile syncs the bundle of a single source. The phases are run in
+// order, each of them may end the reconcile early: loading the source,
+// checking for drift and pins, fetching the bundle, holding back changes not
+// cleared for rollout, syncing the targets and recording the outcome.
+func (r *CABundleReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Every log line of the reconcile names the bundle, and the source URL
+	// once it is known, so logs can be queried per bundle.
+	Logger := logf.FromContext(ctx).WithValues("bundle", req.String())
+	ctx = logf.IntoContext(ctx, Logger)
+	Logger.Info("Reconciling CA bundles")
+
+	ctx, cm, cfg, err := r.loadSource(ctx, req)
+	if cm == nil {
+		return ctrl.Result{}, err
+	}
+	Logger = logf.FromContext(ctx)
+
+	prevStatus, err := r.getStatus(ctx, cm)
+	if err != nil {
+		Logger.Error(err, "unable to read bundle status, starting fresh")
+		prevStatus = &BundleStatus{}
+	}
+
+	namespaces, err := r.resolveTargetNamespaces(ctx, cfg)
+	if err != nil {
+		Logger.Error(err, "unable to resolve target namespaces")
+		return ctrl.Result{}, err
+	}
+	Logger.V(1).Info("Resolved target namespaces", "namespaces", namespaces)
+
+	drifted, err := r.detectDrift(ctx, cfg, namespaces, prevStatus)
+	if err != nil {
+		Logger.Error(err, "unable to check managed ConfigMaps for drift")
+		return ctrl.Result{}, err
+	}
+	if len(drifted) > 0 && cfg.DriftPolicy != DriftRevert {
+		return r.reportDrift(ctx, cm, cfg, prevStatus, drifted)
+	} else if len(drifted) > 0 {
+		// The Drifted condition makes this sync a full one, restoring the
+		// ConfigMaps.
+		meta.SetStatusCondition(&prevStatus.Conditions, driftedCondition(r.recordDrift(ctx, cfg, drifted)))
+	}
+	if cfg.PinnedRevision > 0 {
+		return r.syncPinned(ctx, cm, cfg, namespaces, prevStatus)
+	}
+
+	fetched, result, err := r.fetchBundles(ctx, req, cm, cfg, namespaces, prevStatus)
+	if fetched == nil {
+		return result, err
+	}
+	if fetched.unchanged {
+		return r.syncUnchanged(ctx, cm, cfg, fetched.index, prevStatus)
+	}
+	if violations := checksumViolations(cfg, fetched.downloaded, fetched.failedFiles); len(violations) > 0 {
+		return r.refuseContent(ctx, cm, cfg, prevStatus, violations)
+	}
+
+	Logger.V(1).Info("Fetched bundle index", "files", len(fetched.downloaded))
+	r.debug.indexFetched(req, fetched.downloaded)
+	sourceReachable.WithLabelValues(req.String()).Set(1)
+	r.recordCertExpiry(cfg, fetched.downloaded)
+
+	run := &syncRun{cm: cm, cfg: cfg, namespaces: namespaces, fetched: fetched, prev: prevStatus}
+	r.prepareBundles(ctx, run)
+	if held, result, err := r.holdUncleared(ctx, run); held {
+		return result, err
+	}
+	run.canaryOnly, run.requeueAfter = planCanary(ctx, cfg, run.hash, namespaces, prevStatus, run.status)
+
+	ctx, cleanups := withCleanupTally(ctx)
+	defer cleanups.publish(cfg)
+	ctx, changes := withChangeTally(ctx)
+	defer r.notifyChanges(ctx, cfg, changes)
+
+	r.syncTargets(ctx, run)
+	r.exportBundle(ctx, run)
+	return r.finishSync(ctx, req, run)
+}
+
+// syncRun carries the state of a sync between its phases.
+type syncRun struct {
+	cm         *corev1.ConfigMap
+	cfg        *BundleConfig
+	namespaces []string
+	fetched    *fetchedBundles
+	prev       *BundleStatus
+	status     *BundleStatus
+
+	// bundles are the files written to the targets: the downloaded ones
+	// and the aggregate. hash identifies the downloaded version.
+	bundles []PEMFile
+	hash    string
+
+	canaryOnly   bool
+	requeueAfter time.Duration
+
+	synced, failed, clustersSynced int
+	errs                           []error
+	// failures describes every failed target for the Degraded condition.
+	failures []string
+}
+
+// fail records a failed target of the sync.
+func (run *syncRun) fail(target string, err error) {
+	run.errs = append(run.errs, err)
+	run.failures = append(run.failures, fmt.Sprintf("%s: %v", target, err))
+}
+
+// loadSource reads the source ConfigMap of the request and parses its bundle
+// configuration, returning the context logging with the source URL. A nil
+// ConfigMap ends the reconcile with the returned error: the request is not a
+// source, is gone or is invalid.
+func (r *CABundleReconciler) loadSource(ctx context.Context, req ctrl.Request) (context.Context, *corev1.ConfigMap, *BundleConfig, error) {
 	Logger := logf.FromContext(ctx)
-	Logger.Info("Reconciling CA bundles", "namespace", req.Namespace, "name", req.Name)
 
-	// Read teh ConfigMap to get the URL list
-	var cm corev1.ConfigMap
-	err := r.Get(ctx, req.NamespacedName, &cm)
+	if r.OnlyNamespace != "" && req.Namespace != r.OnlyNamespace {
+		Logger.V(1).Info("Ignoring source outside the only namespace", "onlyNamespace", r.OnlyNamespace)
+		return ctx, nil, nil, nil
+	}
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, req.NamespacedName, cm)
+	if apierrors.IsNotFound(err) {
+		r.forgetSource(req.NamespacedName)
+	}
 	if err != nil {
 		Logger.Error(err, "unable to fetch ConfigMap")
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		return ctx, nil, nil, client.IgnoreNotFound(err)
+	}
+	// Only designated sources are synced, whatever enqueued the request, as
+	// a sync also deletes the ConfigMaps it considers stale.
+	if !r.isSource(cm) {
+		Logger.Info("Ignoring ConfigMap that is not a bundle source")
+		r.forgetSource(req.NamespacedName)
+		return ctx, nil, nil, nil
 	}
+	r.freshness.track(req.NamespacedName, time.Now())
 
-	baseUrl, ok := cm.Data["bundle_url"]
-	if !ok {
-		Logger.Error(err, "bundle_url key not found in ConfigMap data")
-		return ctrl.Result{}, nil
+	if verbose, ok := r.sourceLogger(cm); ok {
+		Logger = verbose.WithValues("bundle", req.String(), "reconcileID", controller.ReconcileIDFromContext(ctx))
+		ctx = logf.IntoContext(ctx, Logger)
+		Logger.V(1).Info("Raised log verbosity for source", "verbosity", cm.Annotations[LogVerbosityAnnotation])
+	}
+
+	cfg, err := r.parseBundleConfig(cm)
+	if err != nil {
+		Logger.Error(err, "invalid bundle configuration")
+		return ctx, nil, nil, nil
 	}
+	return logf.IntoContext(ctx, Logger.WithValues("sourceURL", cfg.sourceURL())), cm, cfg, nil
+}
+
+// fetchedBundles is the outcome of fetching the bundle of a source.
+type fetchedBundles struct {
+	index *bundleIndex
+	// unchanged is set when the index didn't change since the last full
+	// sync and the files weren't downloaded.
+	unchanged   bool
+	downloaded  []PEMFile
+	failedFiles []FailedFile
+}
+
+// fetchBundles lists and downloads the bundle files of the source, guarded
+// by its circuit breaker. When the fetch fails, or is skipped while the
+// circuit is open, the failure is recorded in the status and nil is returned
+// with the result to end the reconcile with.
+func (r *CABundleReconciler) fetchBundles(ctx context.Context, req ctrl.Request, cm *corev1.ConfigMap, cfg *BundleConfig, namespaces []string, prevStatus *BundleStatus) (*fetchedBundles, ctrl.Result, error) {
+	Logger := logf.FromContext(ctx)
+	tuning := r.tuning()
 
-	httpCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	// Downloads aren't tied to the reconcile context, only to its span.
+	httpCtx, cancel := context.WithTimeout(trace.ContextWithSpan(logf.IntoContext(context.Background(), Logger), trace.SpanFromContext(ctx)),
+		durationOr(tuning.DownloadTimeout, DefaultDownloadTimeout))
 	defer cancel()
 
-	bundles, err := DownloadPEMBundles(httpCtx, baseUrl)
-	if err != nil {
-		return ctrl.Result{}, err
+	if tuning.circuitEnabled() {
+		if wait, ok := r.breakers.allow(req.String(), tuning.CircuitBreakerCooldown, time.Now()); !ok {
+			Logger.V(1).Info("Circuit open, skipping download", "retryAfter", wait)
+			meta.SetStatusCondition(&prevStatus.Conditions, circuitCondition(r.breakers.openUntil(req.String(), tuning.CircuitBreakerCooldown)))
+			prevStatus.NextSyncTime = r.nextSyncTime(req.NamespacedName, wait)
+			if err := r.updateStatus(ctx, cm, prevStatus); err != nil {
+				return nil, ctrl.Result{}, err
+			}
+			return nil, ctrl.Result{RequeueAfter: wait}, fmt.Errorf("circuit open for %s, retrying in %s", cfg.sourceURL(), wait.Round(time.Second))
+		}
 	}
 
-	for _, b := range bundles {
-		// Check if ConfigMap already exists for this bundle and mathches content
-		exists := r.checkConfigMap(ctx, b)
-		if exists {
+	fetched := &fetchedBundles{}
+	unlockURL := r.lockSourceURL(cfg.sourceURL())
+	index, err := listBundles(httpCtx, cfg)
+	fetched.index = index
+	fetched.unchanged = err == nil && r.indexUnchanged(cm, cfg, index, namespaces, prevStatus)
+	if err == nil && !fetched.unchanged {
+		fetched.downloaded, fetched.failedFiles, err = downloadBundles(httpCtx, cfg, index)
+	}
+	unlockURL()
+	opened := tuning.circuitEnabled() && r.breakers.record(req.String(), tuning.CircuitBreakerThreshold, err, time.Now())
+	if err == nil {
+		return fetched, ctrl.Result{}, nil
+	}
+
+	r.eventf(cfg, corev1.EventTypeWarning, ReasonDownloadFailed, "Downloading bundles from %s failed: %v", cfg.sourceURL(), err)
+	prevStatus.recordSync(SyncRecord{Time: metav1.Now(), Outcome: SyncFailed, Error: err.Error()}, cfg.HistoryLimit)
+	meta.SetStatusCondition(&prevStatus.Conditions, sourceReachableCondition(err))
+	meta.SetStatusCondition(&prevStatus.Conditions, staleCondition(err, prevStatus.LastSyncTime))
+	sourceReachable.WithLabelValues(req.String()).Set(0)
+	requeueAfter := r.errorRetry(req.NamespacedName)
+	if opened {
+		// The failure is retried once the circuit half-opens.
+		until, failures := r.breakers.openUntil(req.String(), tuning.CircuitBreakerCooldown)
+		Logger.Info("Circuit opened, suspending downloads", "failures", failures, "until", until)
+		r.eventf(cfg, corev1.EventTypeWarning, ReasonCircuitOpened, "Suspending downloads from %s until %s after %d consecutive failures",
+			cfg.sourceURL(), until.UTC().Format(time.RFC3339), failures)
+		if !meta.IsStatusConditionTrue(prevStatus.Conditions, ConditionCircuitOpen) {
+			r.notifyUnservable(ctx, cfg, fmt.Sprintf("Downloads from %s suspended after %d consecutive failures, the bundle can't be refreshed",
+				cfg.sourceURL(), failures), false)
+		}
+		meta.SetStatusCondition(&prevStatus.Conditions, circuitCondition(until, failures))
+		requeueAfter = tuning.CircuitBreakerCooldown
+	}
+	prevStatus.NextSyncTime = r.nextSyncTime(req.NamespacedName, requeueAfter)
+	if err := r.updateStatus(ctx, cm, prevStatus); err != nil {
+		Logger.Error(err, "unable to update bundle status")
+	}
+	return nil, ctrl.Result{RequeueAfter: requeueAfter}, err
+}
+
+// prepareBundles settles the files written to the targets and validates
+// them into the new status: the files that failed to download keep their
+// last synced ConfigMaps, otherwise the aggregate is added.
+func (r *CABundleReconciler) prepareBundles(ctx context.Context, run *syncRun) {
+	cfg, downloaded, failedFiles := run.cfg, run.fetched.downloaded, run.fetched.failedFiles
+
+	run.bundles = downloaded
+	if len(failedFiles) > 0 {
+		// The failed files keep their last synced ConfigMaps, and so does
+		// the aggregate rather than losing their certificates.
+		cfg.RetainedFiles = failedFileNames(failedFiles)
+		if cfg.AggregateName != "" {
+			cfg.RetainedFiles = append(cfg.RetainedFiles, cfg.AggregateName)
+		}
+		r.eventf(cfg, corev1.EventTypeWarning, ReasonFileDownloadFailed, "Downloading %s from %s failed, keeping their last synced ConfigMaps",
+			strings.Join(failedFileNames(failedFiles), ", "), cfg.sourceURL())
+		for _, f := range failedFiles {
+			run.fail("file "+f.Filename, f.Err)
+		}
+	} else if cfg.AggregateName != "" {
+		run.bundles = append(slices.Clip(run.bundles), aggregateBundle(cfg.AggregateName, downloaded))
+	}
+
+	_, validateSpan := tracer.Start(ctx, "Validate", trace.WithAttributes(attribute.Int("files", len(run.bundles))))
+	run.status = &BundleStatus{Files: r.fileStatuses(cfg, run.bundles, run.prev)}
+	run.status.Files = append(run.status.Files, r.failedFileStatuses(cfg, failedFiles, run.prev)...)
+	if _, conflicts, _ := r.resolveConflicts(run.bundles, cfg); len(conflicts) > 0 {
+		run.status.Conflicts = conflicts
+		for _, c := range conflicts {
+			r.eventf(cfg, corev1.EventTypeWarning, ReasonKeyConflict, "Conflicting %s", c)
+		}
+	}
+	validateSpan.End()
+	run.hash = bundleSetHash(downloaded)
+}
+
+// holdUncleared holds back a new version not cleared for rollout: during a
+// maintenance window, until acknowledged, or until its plan is approved. It
+// reports whether the version was held and the result to end the reconcile
+// with.
+func (r *CABundleReconciler) holdUncleared(ctx context.Context, run *syncRun) (bool, ctrl.Result, error) {
+	cm, cfg := run.cm, run.cfg
+	if until, frozen := cfg.frozenUntil(time.Now()); frozen {
+		result, err := r.deferChange(ctx, cm, cfg, run.hash, run.prev, until)
+		return true, result, err
+	}
+	if !changeAcknowledged(cm, cfg, run.hash, run.prev) {
+		result, err := r.holdChange(ctx, cm, cfg, run.namespaces, run.bundles, run.hash, run.prev)
+		return true, result, err
+	}
+	if requiresApproval(cm) {
+		changes, err := r.diffTargets(ctx, cfg, run.namespaces, run.bundles)
+		if err != nil {
+			logf.FromContext(ctx).Error(err, "unable to plan changes")
+			return true, ctrl.Result{}, err
+		}
+		if plan := newPlan(run.hash, changes); plan != nil && cm.Annotations[ApprovePlanAnnotation] != plan.ID {
+			result, err := r.holdPlan(ctx, cm, cfg, plan, run.prev)
+			return true, result, err
+		}
+	}
+	return false, ctrl.Result{}, nil
+}
+
+// syncTargets writes the bundles to every target namespace and remote
+// cluster, only the canary namespaces while a canary soaks, and cleans up
+// the namespaces no longer targeted.
+func (r *CABundleReconciler) syncTargets(ctx context.Context, run *syncRun) {
+	Logger := logf.FromContext(ctx)
+	cfg, prevStatus, status := run.cfg, run.prev, run.status
+
+	for _, ns := range run.namespaces {
+		if run.canaryOnly && !slices.Contains(cfg.CanaryNamespaces, ns) {
+			if prev := prevStatus.namespaceStatus(ns); prev != nil {
+				status.Namespaces = append(status.Namespaces, *prev)
+			}
 			continue
 		}
-		// if the ConfigMap does not exist or content differs, create or update it
-		err := r.createOrUpdateConfigMap(ctx, b)
+
+		nsStatus := NamespaceStatus{Namespace: ns, ConfigMaps: len(run.bundles)}
+		unlock := r.lockNamespace("", ns)
+		err := r.syncNamespace(ctx, ns, run.bundles, cfg.ForTarget("", ns))
+		unlock()
 		if err != nil {
-			return ctrl.Result{}, err
+			Logger.Error(err, "unable to sync namespace", "namespace", ns)
+			nsStatus.Error = err.Error()
+			if prev := prevStatus.namespaceStatus(ns); prev != nil {
+				nsStatus.LastSyncTime = prev.LastSyncTime
+			}
+			run.fail("namespace "+ns, err)
+			run.failed++
+		} else {
+			now := metav1.Now()
+			run.synced++
+			nsStatus.Synced = true
+			nsStatus.LastSyncTime = &now
 		}
+		status.Namespaces = append(status.Namespaces, nsStatus)
+	}
+
+	if err := r.cleanUpUntargetedNamespaces(ctx, cfg, run.namespaces); err != nil {
+		Logger.Error(err, "unable to clean up untargeted namespaces")
+		run.fail("cleanup", err)
+	}
+
+	for _, rc := range cfg.RemoteClusters {
+		if run.canaryOnly {
+			if prev := prevStatus.clusterStatus(rc.Name); prev != nil {
+				status.Clusters = append(status.Clusters, *prev)
+			}
+			continue
+		}
+
+		clusterStatus := ClusterStatus{Name: rc.Name}
+		clusterSynced, err := r.syncRemoteCluster(ctx, cfg, rc, run.bundles)
+		clusterStatus.Namespaces = clusterSynced
+		if err != nil {
+			Logger.Error(err, "unable to sync remote cluster", "cluster", rc.Name)
+			clusterStatus.Error = err.Error()
+			if prev := prevStatus.clusterStatus(rc.Name); prev != nil {
+				clusterStatus.LastSyncTime = prev.LastSyncTime
+			}
+			run.fail("cluster "+rc.Name, err)
+		} else {
+			now := metav1.Now()
+			run.clustersSynced++
+			clusterStatus.Synced = true
+			clusterStatus.LastSyncTime = &now
+		}
+		status.Clusters = append(status.Clusters, clusterStatus)
+	}
+}
+
+// exportBundle uploads the bundle to the export buckets once every target
+// synced the rolled out version.
+func (r *CABundleReconciler) exportBundle(ctx context.Context, run *syncRun) {
+	cfg, status := run.cfg, run.status
+	status.LastExportTime, status.Exports = run.prev.LastExportTime, run.prev.Exports
+	if len(run.errs) > 0 || run.canaryOnly || len(cfg.ExportURLs) == 0 || r.DryRun {
+		return
+	}
+	exports, err := exportBundle(ctx, cfg, run.fetched.downloaded, run.prev.Exports)
+	status.Exports = exports
+	if last := lastExportTime(exports); last != nil {
+		status.LastExportTime = last
 	}
-	// Finally Clean up stale ConfigMaps
-	err = r.CleanUpConfigMaps(ctx, bundles)
 	if err != nil {
-		return ctrl.Result{}, err
+		run.fail("export", err)
+	}
+}
+
+// finishSync records the outcome of the sync in the status and history,
+// notifies about it and returns the result of the reconcile. Partial syncs
+// are retried without the error backoff of a failed reconcile.
+func (r *CABundleReconciler) finishSync(ctx context.Context, req ctrl.Request, run *syncRun) (ctrl.Result, error) {
+	Logger := logf.FromContext(ctx)
+	cfg, status := run.cfg, run.status
+
+	now := metav1.Now()
+	status.LastSyncTime = &now
+	status.History = run.prev.History
+	status.Conditions = run.prev.Conditions
+	requeueAfter := sooner(run.requeueAfter, r.setSyncConditions(ctx, run))
+
+	record := SyncRecord{
+		Time:             now,
+		Outcome:          SyncSucceeded,
+		Hash:             run.hash,
+		Files:            len(run.fetched.downloaded),
+		Namespaces:       run.synced,
+		FailedNamespaces: run.failed,
+	}
+	partial := len(run.errs) > 0 && run.synced+run.clustersSynced > 0
+	if len(run.errs) > 0 {
+		record.Outcome = SyncFailed
+		if partial {
+			record.Outcome = SyncDegraded
+		}
+		record.Error = kerrors.NewAggregate(run.errs).Error()
+	}
+	status.recordSync(record, cfg.HistoryLimit)
+	if index := run.fetched.index; len(run.errs) == 0 && !run.canaryOnly && index != nil {
+		status.FullSync = &FullSync{IndexHash: index.Hash, SourceVersion: run.cm.ResourceVersion, Time: now}
 	}
+	if partial {
+		requeueAfter = durationOr(r.tuning().DegradedRetry, DefaultDegradedRetry)
+	}
+	status.NextSyncTime = r.nextSyncTime(req.NamespacedName, requeueAfter)
+	if err := r.updateStatus(ctx, run.cm, status); err != nil {
+		Logger.Error(err, "unable to update bundle status")
+		return ctrl.Result{}, kerrors.NewAggregate(append(run.errs, err))
+	}
+
+	if record.Outcome != SyncFailed {
+		r.notifySynced(ctx, cfg, record)
+	}
+
+	// What succeeded stays distributed; the failed targets are retried
+	// without the error backoff of a failed reconcile.
+	if partial {
+		Logger.Info("Bundle partially synced", "failures", run.failures)
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+	if len(run.errs) > 0 {
+		return ctrl.Result{}, kerrors.NewAggregate(run.errs)
+	}
+	r.recordSuccessfulSync(req.NamespacedName, now.Time)
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// setSyncConditions updates the conditions of the new status after a sync:
+// the source was reachable, the targets that failed, the expiry of the
+// certificates and the conditions of holds that no longer apply. It returns
+// when the expiry is due to be checked again.
+func (r *CABundleReconciler) setSyncConditions(ctx context.Context, run *syncRun) time.Duration {
+	cfg, status := run.cfg, run.status
+	meta.SetStatusCondition(&status.Conditions, sourceReachableCondition(nil))
+	meta.SetStatusCondition(&status.Conditions, staleCondition(nil, nil))
+	meta.SetStatusCondition(&status.Conditions, degradedCondition(run.failures, run.synced+run.clustersSynced))
+	recheck := r.checkExpiry(ctx, cfg, run.fetched.downloaded, status)
+	if len(cfg.MaintenanceWindows) > 0 {
+		meta.SetStatusCondition(&status.Conditions, changeFrozenCondition())
+	} else {
+		meta.RemoveStatusCondition(&status.Conditions, ConditionChangeFrozen)
+	}
+	if meta.FindStatusCondition(status.Conditions, ConditionPinned) != nil {
+		meta.SetStatusCondition(&status.Conditions, pinnedCondition(0, nil))
+	}
+	if meta.FindStatusCondition(status.Conditions, ConditionPinMismatch) != nil {
+		meta.SetStatusCondition(&status.Conditions, pinMismatchCondition(nil))
+	}
+	if meta.FindStatusCondition(status.Conditions, ConditionDrifted) != nil && run.failed == 0 && !run.canaryOnly {
+		meta.SetStatusCondition(&status.Conditions, driftedCondition(nil))
+	}
+	if cfg.ChangePolicy == ChangeAlert {
+		meta.SetStatusCondition(&status.Conditions, changeAcknowledgedCondition())
+	} else {
+		meta.RemoveStatusCondition(&status.Conditions, ConditionChangeUnacknowledged)
+	}
+	if requiresApproval(run.cm) {
+		meta.SetStatusCondition(&status.Conditions, planAppliedCondition())
+	} else {
+		meta.RemoveStatusCondition(&status.Conditions, ConditionPlanPending)
+	}
+	if meta.IsStatusConditionTrue(status.Conditions, ConditionCircuitOpen) {
+		r.notifyUnservable(ctx, cfg, fmt.Sprintf("Downloads from %s succeeded again", cfg.sourceURL()), true)
+	}
+	if r.tuning().circuitEnabled() {
+		meta.SetStatusCondition(&status.Conditions, circuitCondition(time.Time{}, 0))
+	} else {
+		meta.RemoveStatusCondition(&status.Conditions, ConditionCircuitOpen)
+	}
+	return recheck
+}
 
-	return ctrl.Result{}, nil
+// syncNamespace writes the managed ConfigMaps for every bundle file into the
+// namespace and removes stale ones. cfg is the configuration for the target,
+// see ForTarget.
+func (r *CABundleReconciler) syncNamespace(ctx context.Context, namespace string, bundles []PEMFile, cfg *BundleConfig) error {
+	ctx = logf.IntoContext(ctx, logf.FromContext(ctx).WithValues("targetNamespace", namespace))
+	if cfg.CreateNamespaces {
+		if err := r.ensureNamespace(ctx, namespace, cfg); err != nil {
+			return err
+		}
+	}
+	bundles, _, err := r.resolveConflicts(bundles, cfg)
+	if err != nil {
+		return err
+	}
+	var changed []string
+	hashes := map[string]string{}
+	for _, b := range bundles {
+		desired, err := r.desiredConfigMap(namespace, b, cfg)
+		if err != nil {
+			return err
+		}
+		hashes[desired.Name] = desired.Annotations[ContentHashAnnotation]
+
+		// Only written if missing or different from the rendered object
+		updated, err := r.createOrUpdateConfigMap(ctx, desired, cfg)
+		if err != nil {
+			return err
+		}
+		if updated {
+			changed = append(changed, desired.Name)
+		}
+	}
+
+	if cfg.RestartConsumers && len(changed) > 0 {
+		if err := r.restartConsumers(ctx, namespace, changed); err != nil {
+			return err
+		}
+	}
+	if cfg.PropagateHash {
+		if err := r.propagateHash(ctx, namespace, hashes); err != nil {
+			return err
+		}
+	}
+
+	// Finally Clean up stale ConfigMaps
+	return r.CleanUpConfigMaps(ctx, namespace, bundles, cfg)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CABundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.started = time.Now()
 	src := source.TypedChannel(
 		r.EventCh,
 		&handler.EnqueueRequestForObject{},
+		source.WithPredicates[client.Object, reconcile.Request](predicate.NewPredicateFuncs(r.isSource)),
 	)
 
 	return ctrl.NewControllerManagedBy(mgr).
 		WatchesRawSource(src).
+		Watches(
+			&corev1.ConfigMap{},
+			&handler.EnqueueRequestForObject{},
+			builder.WithPredicates(predicate.Or(r.syncNowRequested(), r.sourceChanged())),
+		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.mapDriftToSource),
+			builder.WithPredicates(r.managedConfigMapDrifted()),
+		).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.mapNamespaceToSources),
+			builder.WithPredicates(namespaceTargetingChanged),
+		).
 		Named("cabundle-operator").
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+			RateLimiter:             r.RateLimiter,
+		}).
 		Complete(r)
 }
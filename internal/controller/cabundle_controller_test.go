@@ -17,16 +17,160 @@ limitations under the License.
 package controller
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
+// newTestBundleServer serves the files as a bundle directory, listing them
+// in its index page.
+func newTestBundleServer(files map[string][]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/" {
+			names := make([]string, 0, len(files))
+			for name := range files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var links strings.Builder
+			for _, name := range names {
+				fmt.Fprintf(&links, `<a href="%s">%s</a>`, name, name)
+			}
+			_, _ = fmt.Fprintf(w, "<html><body>%s</body></html>", links.String())
+			return
+		}
+		content, ok := files[strings.TrimPrefix(req.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+}
+
 var _ = Describe("ConfigMap Controller", func() {
+	ctx := context.Background()
+
+	namespaces := func(names ...string) []client.Object {
+		objs := make([]client.Object, 0, len(names))
+		for _, name := range names {
+			objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		}
+		return objs
+	}
+
 	Context("When reconciling a resource", func() {
+		var srv *httptest.Server
+
+		BeforeEach(func() {
+			srv = newTestBundleServer(map[string][]byte{"root.pem": newTestCAPEM("Corp Root", time.Now().Add(time.Hour))})
+			DeferCleanup(srv.Close)
+		})
+
+		It("fans the bundle out to every target namespace with per-namespace status", func() {
+			src := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+				Data:       map[string]string{BundleURLKey: srv.URL, TargetNamespacesKey: "apps, web,istio-system"},
+			}
+			r := &CABundleReconciler{
+				Client:          fake.NewClientBuilder().WithObjects(namespaces("apps", "web", "istio-system")...).WithObjects(src).Build(),
+				Scheme:          clientgoscheme.Scheme,
+				TargetNamespace: "cert-manager",
+				ConfigMapName:   "corp-roots",
+			}
+
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)})
+			Expect(err).NotTo(HaveOccurred())
+			for _, ns := range []string{"apps", "web", "istio-system"} {
+				Expect(r.Get(ctx, client.ObjectKey{Namespace: ns, Name: "root"}, &corev1.ConfigMap{})).To(Succeed(), ns)
+			}
+			Expect(r.Get(ctx, client.ObjectKey{Namespace: "cert-manager", Name: "root"}, &corev1.ConfigMap{})).NotTo(Succeed(),
+				"the reconciler's target namespace is replaced by the listed ones")
+
+			status, err := r.getStatus(ctx, src)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Namespaces).To(HaveLen(3))
+			for _, ns := range status.Namespaces {
+				Expect(ns.Synced).To(BeTrue(), ns.Namespace)
+				Expect(ns.LastSyncTime).NotTo(BeNil(), ns.Namespace)
+				Expect(ns.ConfigMaps).To(Equal(1), ns.Namespace)
+			}
+			Expect(status.History[0].Namespaces).To(Equal(3))
+		})
+
+		It("keeps syncing the other namespaces when one fails", func() {
+			src := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+				Data:       map[string]string{BundleURLKey: srv.URL, TargetNamespacesKey: "apps,locked"},
+			}
+			c := fake.NewClientBuilder().WithObjects(namespaces("apps", "locked")...).WithObjects(src).WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					if obj.GetNamespace() == "locked" {
+						return errors.New("forbidden")
+					}
+					return c.Create(ctx, obj, opts...)
+				},
+			}).Build()
+			r := &CABundleReconciler{Client: c, Scheme: clientgoscheme.Scheme, TargetNamespace: "cert-manager", ConfigMapName: "corp-roots"}
+
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)})
+			Expect(err).NotTo(HaveOccurred(), "a partial sync is retried without the error backoff")
+			Expect(result.RequeueAfter).To(Equal(DefaultDegradedRetry))
+			Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "root"}, &corev1.ConfigMap{})).To(Succeed())
+
+			status, err := r.getStatus(ctx, src)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.namespaceStatus("apps").Synced).To(BeTrue())
+			locked := status.namespaceStatus("locked")
+			Expect(locked.Synced).To(BeFalse())
+			Expect(locked.Error).To(ContainSubstring("forbidden"))
+			Expect(status.History[0].Outcome).To(Equal(SyncDegraded))
+			Expect(status.History[0].FailedNamespaces).To(Equal(1))
+			Expect(meta.IsStatusConditionTrue(status.Conditions, ConditionDegraded)).To(BeTrue())
+		})
 
-		It("should successfully reconcile the resource", func() {
+		It("records the configured field manager on its writes", func() {
+			src := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+				Data:       map[string]string{BundleURLKey: srv.URL, TargetNamespacesKey: "apps"},
+			}
+			c := fake.NewClientBuilder().WithObjects(namespaces("apps")...).WithObjects(src).WithReturnManagedFields().Build()
+			r := &CABundleReconciler{
+				Client:          client.WithFieldOwner(c, "cabundle-operator-blue"),
+				Scheme:          clientgoscheme.Scheme,
+				TargetNamespace: "cert-manager",
+				ConfigMapName:   "corp-roots",
+				FieldManager:    "cabundle-operator-blue",
+			}
 
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)})
+			Expect(err).NotTo(HaveOccurred())
+			for _, key := range []client.ObjectKey{{Namespace: "apps", Name: "root"}, {Namespace: "cert-manager", Name: "corp-roots.status"}} {
+				cm := &corev1.ConfigMap{}
+				Expect(c.Get(ctx, key, cm)).To(Succeed())
+				var managers []string
+				for _, f := range cm.ManagedFields {
+					managers = append(managers, f.Manager)
+				}
+				Expect(managers).To(ConsistOf("cabundle-operator-blue"), key.String())
+			}
 		})
 	})
 })
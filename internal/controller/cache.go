@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+// cacheIdle is how long a cached file outlives its last use, so files no
+// longer served or referenced are eventually dropped.
+const cacheIdle = 24 * time.Hour
+
+// contentCache holds downloaded files keyed by URL along with their ETag.
+// Revalidating with If-None-Match lets sources sharing a URL, and every
+// periodic sync, download a file's content only when it changed.
+type contentCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedContent
+}
+
+type cachedContent struct {
+	etag     string
+	data     []byte
+	lastUsed time.Time
+}
+
+// downloads is the cache shared by every bundle download.
+var downloads contentCache
+
+// get returns the cached content of url and its ETag.
+func (c *contentCache) get(url string) (string, []byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[url]
+	if !ok {
+		return "", nil, false
+	}
+	e.lastUsed = time.Now()
+	return e.etag, e.data, true
+}
+
+// etags returns the ETag of every cached URL.
+func (c *contentCache) etags() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	etags := make(map[string]string, len(c.entries))
+	for u, e := range c.entries {
+		etags[u] = e.etag
+	}
+	return etags
+}
+
+// put caches the content of url, dropping entries idle for too long.
+func (c *contentCache) put(url, etag string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.entries == nil {
+		c.entries = map[string]*cachedContent{}
+	}
+	for u, e := range c.entries {
+		if now.Sub(e.lastUsed) > cacheIdle {
+			delete(c.entries, u)
+		}
+	}
+	if etag == "" {
+		delete(c.entries, url)
+		return
+	}
+	c.entries[url] = &cachedContent{etag: etag, data: data, lastUsed: now}
+}
@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+)
+
+// InjectCAFromAnnotation on an injectable object names the managed bundle
+// ConfigMap, as <namespace>/<name>, whose ca.crt is injected as its caBundle.
+// ConfigMaps without the operator's managed-by label are refused, so the
+// annotation can't make the API server trust arbitrary ConfigMap content.
+const InjectCAFromAnnotation = "cabundle.io/inject-ca-from"
+
+// CAInjectorReconciler keeps the caBundle fields of annotated objects of one
+// kind in sync with a managed bundle ConfigMap.
+type CAInjectorReconciler struct {
+	client.Client
+	// Kind names the injected kind in logs and the controller name.
+	Kind string
+	// NewObject and NewList return empty instances of the injected kind.
+	NewObject func() client.Object
+	NewList   func() client.ObjectList
+	// Inject sets the caBundle fields of the object and reports whether
+	// anything changed.
+	Inject func(obj client.Object, caBundle []byte) bool
+}
+
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations;mutatingwebhookconfigurations,verbs=get;list;watch;patch
+
+// NewValidatingWebhookInjector returns an injector for
+// ValidatingWebhookConfigurations.
+func NewValidatingWebhookInjector(c client.Client) *CAInjectorReconciler {
+	return &CAInjectorReconciler{
+		Client:    c,
+		Kind:      "ValidatingWebhookConfiguration",
+		NewObject: func() client.Object { return &admissionregistrationv1.ValidatingWebhookConfiguration{} },
+		NewList:   func() client.ObjectList { return &admissionregistrationv1.ValidatingWebhookConfigurationList{} },
+		Inject: func(obj client.Object, caBundle []byte) bool {
+			changed := false
+			whc := obj.(*admissionregistrationv1.ValidatingWebhookConfiguration)
+			for i := range whc.Webhooks {
+				changed = setCABundle(&whc.Webhooks[i].ClientConfig.CABundle, caBundle) || changed
+			}
+			return changed
+		},
+	}
+}
+
+// NewMutatingWebhookInjector returns an injector for
+// MutatingWebhookConfigurations.
+func NewMutatingWebhookInjector(c client.Client) *CAInjectorReconciler {
+	return &CAInjectorReconciler{
+		Client:    c,
+		Kind:      "MutatingWebhookConfiguration",
+		NewObject: func() client.Object { return &admissionregistrationv1.MutatingWebhookConfiguration{} },
+		NewList:   func() client.ObjectList { return &admissionregistrationv1.MutatingWebhookConfigurationList{} },
+		Inject: func(obj client.Object, caBundle []byte) bool {
+			changed := false
+			whc := obj.(*admissionregistrationv1.MutatingWebhookConfiguration)
+			for i := range whc.Webhooks {
+				changed = setCABundle(&whc.Webhooks[i].ClientConfig.CABundle, caBundle) || changed
+			}
+			return changed
+		},
+	}
+}
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;patch
+
+// NewCRDConversionInjector returns an injector for the conversion webhook of
+// CustomResourceDefinitions. CRDs without a webhook conversion are left
+// untouched.
+func NewCRDConversionInjector(c client.Client) *CAInjectorReconciler {
+	return &CAInjectorReconciler{
+		Client:    c,
+		Kind:      "CustomResourceDefinition",
+		NewObject: func() client.Object { return &apiextensionsv1.CustomResourceDefinition{} },
+		NewList:   func() client.ObjectList { return &apiextensionsv1.CustomResourceDefinitionList{} },
+		Inject: func(obj client.Object, caBundle []byte) bool {
+			crd := obj.(*apiextensionsv1.CustomResourceDefinition)
+			conv := crd.Spec.Conversion
+			if conv == nil || conv.Strategy != apiextensionsv1.WebhookConverter ||
+				conv.Webhook == nil || conv.Webhook.ClientConfig == nil {
+				return false
+			}
+			return setCABundle(&conv.Webhook.ClientConfig.CABundle, caBundle)
+		},
+	}
+}
+
+// +kubebuilder:rbac:groups=apiregistration.k8s.io,resources=apiservices,verbs=get;list;watch;patch
+
+// NewAPIServiceInjector returns an injector for APIServices. Local
+// APIServices, which have no service, are left untouched.
+func NewAPIServiceInjector(c client.Client) *CAInjectorReconciler {
+	return &CAInjectorReconciler{
+		Client:    c,
+		Kind:      "APIService",
+		NewObject: func() client.Object { return &apiregistrationv1.APIService{} },
+		NewList:   func() client.ObjectList { return &apiregistrationv1.APIServiceList{} },
+		Inject: func(obj client.Object, caBundle []byte) bool {
+			apiSvc := obj.(*apiregistrationv1.APIService)
+			if apiSvc.Spec.Service == nil {
+				return false
+			}
+			return setCABundle(&apiSvc.Spec.CABundle, caBundle)
+		},
+	}
+}
+
+// setCABundle sets the field and reports whether it changed.
+func setCABundle(field *[]byte, caBundle []byte) bool {
+	if string(*field) == string(caBundle) {
+		return false
+	}
+	*field = caBundle
+	return true
+}
+
+// parseInjectCAFrom splits the annotation value into the ConfigMap key.
+func parseInjectCAFrom(v string) (client.ObjectKey, error) {
+	ns, name, ok := strings.Cut(v, "/")
+	if !ok || ns == "" || name == "" {
+		return client.ObjectKey{}, fmt.Errorf("invalid %s %q, expected <namespace>/<name>", InjectCAFromAnnotation, v)
+	}
+	return client.ObjectKey{Namespace: ns, Name: name}, nil
+}
+
+// Reconcile injects the referenced bundle into the object.
+func (r *CAInjectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx).WithValues("kind", r.Kind)
+
+	obj := r.NewObject()
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	v, ok := obj.GetAnnotations()[InjectCAFromAnnotation]
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+	key, err := parseInjectCAFrom(v)
+	if err != nil {
+		logger.Error(err, "unable to inject CA bundle")
+		return ctrl.Result{}, nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, key, cm); err != nil {
+		logger.Error(err, "unable to fetch bundle ConfigMap", "configmap", key)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if cm.Labels[AppLabel] != AppLabelValue {
+		logger.Info("Refusing to inject a ConfigMap not managed by the operator", "configmap", key)
+		return ctrl.Result{}, nil
+	}
+	caBundle, ok := cm.Data[CAKey]
+	if !ok || caBundle == "" {
+		logger.Info("Bundle ConfigMap has no PEM content to inject", "configmap", key)
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.MergeFromWithOptions(obj.DeepCopyObject().(client.Object), client.MergeFromWithOptimisticLock{})
+	if !r.Inject(obj, []byte(caBundle)) {
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("Injecting CA bundle", "name", obj.GetName(), "configmap", key)
+	return ctrl.Result{}, r.Patch(ctx, obj, patch)
+}
+
+// mapConfigMapToInjectables enqueues every object of the kind referencing
+// the ConfigMap.
+func (r *CAInjectorReconciler) mapConfigMapToInjectables(ctx context.Context, cm client.Object) []reconcile.Request {
+	logger := logf.FromContext(ctx)
+
+	list := r.NewList()
+	if err := r.List(ctx, list); err != nil {
+		logger.Error(err, "unable to list injectable objects", "kind", r.Kind)
+		return nil
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		logger.Error(err, "unable to extract injectable objects", "kind", r.Kind)
+		return nil
+	}
+
+	ref := client.ObjectKeyFromObject(cm).String()
+	var requests []reconcile.Request
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok || obj.GetAnnotations()[InjectCAFromAnnotation] != ref {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(obj)})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CAInjectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	hasAnnotation := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		_, ok := o.GetAnnotations()[InjectCAFromAnnotation]
+		return ok
+	})
+	isManaged := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetLabels()[AppLabel] == AppLabelValue
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(r.NewObject(), builder.WithPredicates(hasAnnotation)).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.mapConfigMapToInjectables),
+			builder.WithPredicates(isManaged),
+		).
+		Named("cainjector-" + strings.ToLower(r.Kind)).
+		Complete(r)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("CA injection", func() {
+	const pem = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+	bundle := func(name string, labels map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cert-manager", Labels: labels},
+			Data:       map[string]string{CAKey: pem},
+		}
+	}
+	managed := map[string]string{AppLabel: AppLabelValue}
+
+	webhook := func(name, from string) *admissionregistrationv1.ValidatingWebhookConfiguration {
+		return &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{InjectCAFromAnnotation: from}},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				{Name: "a.example.com"},
+				{Name: "b.example.com"},
+			},
+		}
+	}
+
+	inject := func(r *CAInjectorReconciler, obj client.Object) {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: obj.GetName()}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(context.Background(), client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+	}
+
+	It("injects a managed bundle into every webhook of the configuration", func() {
+		c := fake.NewClientBuilder().WithObjects(bundle("ca-bundle", managed), webhook("policy", "cert-manager/ca-bundle")).Build()
+		r := NewValidatingWebhookInjector(c)
+
+		whc := webhook("policy", "")
+		inject(r, whc)
+		for _, w := range whc.Webhooks {
+			Expect(string(w.ClientConfig.CABundle)).To(Equal(pem))
+		}
+	})
+
+	It("refuses ConfigMaps not managed by the operator", func() {
+		c := fake.NewClientBuilder().WithObjects(bundle("attacker", nil), webhook("policy", "cert-manager/attacker")).Build()
+		r := NewValidatingWebhookInjector(c)
+
+		whc := webhook("policy", "")
+		inject(r, whc)
+		for _, w := range whc.Webhooks {
+			Expect(w.ClientConfig.CABundle).To(BeEmpty())
+		}
+	})
+
+	It("ignores invalid references", func() {
+		c := fake.NewClientBuilder().WithObjects(webhook("policy", "ca-bundle")).Build()
+		r := NewValidatingWebhookInjector(c)
+
+		whc := webhook("policy", "")
+		inject(r, whc)
+		Expect(whc.Webhooks[0].ClientConfig.CABundle).To(BeEmpty())
+	})
+
+	It("enqueues the configurations referencing a bundle", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			webhook("policy", "cert-manager/ca-bundle"),
+			webhook("other", "cert-manager/other-bundle"),
+		).Build()
+		r := NewValidatingWebhookInjector(c)
+
+		requests := r.mapConfigMapToInjectables(context.Background(), bundle("ca-bundle", managed))
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Name).To(Equal("policy"))
+	})
+
+	Context("for CRDs and APIServices", func() {
+		var c client.Client
+
+		BeforeEach(func() {
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+			Expect(apiextensionsv1.AddToScheme(s)).To(Succeed())
+			Expect(apiregistrationv1.AddToScheme(s)).To(Succeed())
+
+			annotations := map[string]string{InjectCAFromAnnotation: "cert-manager/ca-bundle"}
+			c = fake.NewClientBuilder().WithScheme(s).WithObjects(
+				bundle("ca-bundle", managed),
+				&apiextensionsv1.CustomResourceDefinition{
+					ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.com", Annotations: annotations},
+					Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+						Conversion: &apiextensionsv1.CustomResourceConversion{
+							Strategy: apiextensionsv1.WebhookConverter,
+							Webhook: &apiextensionsv1.WebhookConversion{
+								ClientConfig:             &apiextensionsv1.WebhookClientConfig{},
+								ConversionReviewVersions: []string{"v1"},
+							},
+						},
+					},
+				},
+				&apiextensionsv1.CustomResourceDefinition{
+					ObjectMeta: metav1.ObjectMeta{Name: "gadgets.example.com", Annotations: annotations},
+					Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+						Conversion: &apiextensionsv1.CustomResourceConversion{Strategy: apiextensionsv1.NoneConverter},
+					},
+				},
+				&apiregistrationv1.APIService{
+					ObjectMeta: metav1.ObjectMeta{Name: "v1beta1.metrics.example.com", Annotations: annotations},
+					Spec: apiregistrationv1.APIServiceSpec{
+						Service: &apiregistrationv1.ServiceReference{Namespace: "metrics", Name: "metrics-server"},
+					},
+				},
+				&apiregistrationv1.APIService{
+					ObjectMeta: metav1.ObjectMeta{Name: "v1.local.example.com", Annotations: annotations},
+				},
+			).Build()
+		})
+
+		It("injects the bundle into CRD conversion webhooks only", func() {
+			r := NewCRDConversionInjector(c)
+
+			crd := &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.com"}}
+			inject(r, crd)
+			Expect(string(crd.Spec.Conversion.Webhook.ClientConfig.CABundle)).To(Equal(pem))
+
+			crd = &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "gadgets.example.com"}}
+			inject(r, crd)
+			Expect(crd.Spec.Conversion.Webhook).To(BeNil())
+		})
+
+		It("injects the bundle into APIServices backed by a service only", func() {
+			r := NewAPIServiceInjector(c)
+
+			apiSvc := &apiregistrationv1.APIService{ObjectMeta: metav1.ObjectMeta{Name: "v1beta1.metrics.example.com"}}
+			inject(r, apiSvc)
+			Expect(string(apiSvc.Spec.CABundle)).To(Equal(pem))
+
+			apiSvc = &apiregistrationv1.APIService{ObjectMeta: metav1.ObjectMeta{Name: "v1.local.example.com"}}
+			inject(r, apiSvc)
+			Expect(apiSvc.Spec.CABundle).To(BeEmpty())
+		})
+	})
+})
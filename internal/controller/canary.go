@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DefaultCanarySoak is how long a changed bundle stays on the canary
+	// namespaces before it is rolled out everywhere.
+	DefaultCanarySoak = 15 * time.Minute
+	// canaryProbeRetry is how long a rollout is held after a failed
+	// verification probe.
+	canaryProbeRetry = time.Minute
+	// canaryProbeTimeout bounds a single verification probe.
+	canaryProbeTimeout = 30 * time.Second
+)
+
+// CanaryStatus tracks a bundle change being soaked on the canary namespaces.
+type CanaryStatus struct {
+	Hash      string      `json:"hash"`
+	StartedAt metav1.Time `json:"startedAt"`
+	Error     string      `json:"error,omitempty"`
+	// Untargeted lists the configured canary namespaces that are not among
+	// the sync targets and so never receive the canary.
+	Untargeted []string `json:"untargeted,omitempty"`
+}
+
+// bundleSetHash returns a hash over the names and content of the downloaded
+// files, identifying a version of the bundle.
+func bundleSetHash(bundles []PEMFile) string {
+	sorted := slices.Clone(bundles)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Filename < sorted[j].Filename })
+
+	h := sha256.New()
+	for _, b := range sorted {
+		h.Write([]byte(b.Filename))
+		h.Write([]byte{0})
+		h.Write(b.Content)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// planCanary decides whether the bundle version may be rolled out to every
+// target or only to the canary namespaces, recording the rollout state in
+// status. The first version distributed is rolled out directly since there is
+// nothing to protect yet. A new version is only promoted once every canary
+// namespace among the targets has synced it. When the rollout is held, the
+// returned duration is when to check again.
+func planCanary(ctx context.Context, cfg *BundleConfig, hash string, namespaces []string, prev, status *BundleStatus) (bool, time.Duration) {
+	logger := logf.FromContext(ctx)
+
+	if len(cfg.CanaryNamespaces) == 0 || prev.RolledOutHash == "" || prev.RolledOutHash == hash {
+		status.RolledOutHash = hash
+		return false, 0
+	}
+	status.RolledOutHash = prev.RolledOutHash
+
+	now := metav1.Now()
+	canary := &CanaryStatus{Hash: hash, StartedAt: now}
+	if prev.Canary != nil && prev.Canary.Hash == hash {
+		canary.StartedAt = prev.Canary.StartedAt
+	} else {
+		logger.Info("Starting canary rollout", "hash", hash, "namespaces", cfg.CanaryNamespaces)
+	}
+	status.Canary = canary
+
+	var canaries []string
+	for _, ns := range cfg.CanaryNamespaces {
+		if slices.Contains(namespaces, ns) {
+			canaries = append(canaries, ns)
+		} else {
+			canary.Untargeted = append(canary.Untargeted, ns)
+		}
+	}
+	if len(canary.Untargeted) > 0 {
+		logger.Error(nil, "canary namespaces are not sync targets", "namespaces", canary.Untargeted)
+	}
+	if len(canaries) == 0 {
+		canary.Error = "none of the canary namespaces is a sync target"
+		return true, canaryProbeRetry
+	}
+
+	if remaining := canary.StartedAt.Add(cfg.CanarySoak).Sub(now.Time); remaining > 0 {
+		return true, remaining
+	}
+
+	if pending := unsyncedCanaries(canaries, canary.StartedAt, prev); len(pending) > 0 {
+		logger.Info("Canary namespaces have not synced, holding rollout", "hash", hash, "namespaces", pending)
+		canary.Error = fmt.Sprintf("canary namespaces %s have not synced", strings.Join(pending, ", "))
+		return true, canaryProbeRetry
+	}
+
+	if cfg.CanaryProbeURL != "" {
+		if err := probeCanary(ctx, cfg.httpClient(), cfg.CanaryProbeURL); err != nil {
+			logger.Error(err, "canary verification failed, holding rollout", "hash", hash)
+			canary.Error = err.Error()
+			return true, canaryProbeRetry
+		}
+	}
+
+	logger.Info("Canary soak completed, rolling out", "hash", hash)
+	status.Canary = nil
+	status.RolledOutHash = hash
+	return false, 0
+}
+
+// unsyncedCanaries returns the canary namespaces that have not synced
+// successfully since the canary started.
+func unsyncedCanaries(canaries []string, startedAt metav1.Time, prev *BundleStatus) []string {
+	var pending []string
+	for _, ns := range canaries {
+		nsStatus := prev.namespaceStatus(ns)
+		if nsStatus == nil || !nsStatus.Synced || nsStatus.LastSyncTime == nil || nsStatus.LastSyncTime.Before(&startedAt) {
+			pending = append(pending, ns)
+		}
+	}
+	return pending
+}
+
+// probeCanary verifies the canary through an HTTP GET expecting a 2xx
+// response.
+func probeCanary(ctx context.Context, c *http.Client, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, canaryProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("canary probe %s returned %s", url, resp.Status)
+	}
+	return nil
+}
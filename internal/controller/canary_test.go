@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Canary rollout", func() {
+	ctx := context.Background()
+	cfg := &BundleConfig{CanaryNamespaces: []string{"canary"}, CanarySoak: time.Hour}
+	targets := []string{"canary", "apps"}
+	soaked := func() *BundleStatus {
+		synced := metav1.NewTime(time.Now().Add(-time.Hour))
+		return &BundleStatus{
+			RolledOutHash: "v1",
+			Canary:        &CanaryStatus{Hash: "v2", StartedAt: metav1.NewTime(time.Now().Add(-2 * time.Hour))},
+			Namespaces:    []NamespaceStatus{{Namespace: "canary", Synced: true, LastSyncTime: &synced}},
+		}
+	}
+
+	It("rolls out the first version directly", func() {
+		status := &BundleStatus{}
+		canaryOnly, _ := planCanary(ctx, cfg, "v1", targets, &BundleStatus{}, status)
+		Expect(canaryOnly).To(BeFalse())
+		Expect(status.RolledOutHash).To(Equal("v1"))
+	})
+
+	It("holds a changed version on the canary namespaces while soaking", func() {
+		status := &BundleStatus{}
+		canaryOnly, requeue := planCanary(ctx, cfg, "v2", targets, &BundleStatus{RolledOutHash: "v1"}, status)
+		Expect(canaryOnly).To(BeTrue())
+		Expect(requeue).To(BeNumerically("~", time.Hour, time.Minute))
+		Expect(status.RolledOutHash).To(Equal("v1"))
+		Expect(status.Canary.Hash).To(Equal("v2"))
+	})
+
+	It("rolls out once the soak completed and the probe passes", func() {
+		healthy := true
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if !healthy {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer srv.Close()
+
+		probed := *cfg
+		probed.CanaryProbeURL = srv.URL
+		prev := soaked()
+
+		healthy = false
+		status := &BundleStatus{}
+		canaryOnly, requeue := planCanary(ctx, &probed, "v2", targets, prev, status)
+		Expect(canaryOnly).To(BeTrue())
+		Expect(requeue).To(Equal(canaryProbeRetry))
+		Expect(status.Canary.Error).NotTo(BeEmpty())
+
+		healthy = true
+		status = &BundleStatus{}
+		canaryOnly, _ = planCanary(ctx, &probed, "v2", targets, prev, status)
+		Expect(canaryOnly).To(BeFalse())
+		Expect(status.RolledOutHash).To(Equal("v2"))
+		Expect(status.Canary).To(BeNil())
+	})
+
+	It("holds the rollout until the canary namespaces synced the version", func() {
+		prev := soaked()
+		prev.Namespaces[0].Synced = false
+		prev.Namespaces[0].Error = "forbidden"
+
+		status := &BundleStatus{}
+		canaryOnly, requeue := planCanary(ctx, cfg, "v2", targets, prev, status)
+		Expect(canaryOnly).To(BeTrue())
+		Expect(requeue).To(Equal(canaryProbeRetry))
+		Expect(status.Canary.Error).To(ContainSubstring("canary"))
+
+		prev = soaked()
+		before := metav1.NewTime(prev.Canary.StartedAt.Add(-time.Minute))
+		prev.Namespaces[0].LastSyncTime = &before
+		canaryOnly, _ = planCanary(ctx, cfg, "v2", targets, prev, &BundleStatus{})
+		Expect(canaryOnly).To(BeTrue())
+
+		status = &BundleStatus{}
+		canaryOnly, _ = planCanary(ctx, cfg, "v2", targets, soaked(), status)
+		Expect(canaryOnly).To(BeFalse())
+		Expect(status.RolledOutHash).To(Equal("v2"))
+	})
+
+	It("reports canary namespaces outside the target set", func() {
+		partial := *cfg
+		partial.CanaryNamespaces = []string{"canary", "elsewhere"}
+		status := &BundleStatus{}
+		canaryOnly, _ := planCanary(ctx, &partial, "v2", targets, &BundleStatus{RolledOutHash: "v1"}, status)
+		Expect(canaryOnly).To(BeTrue())
+		Expect(status.Canary.Untargeted).To(Equal([]string{"elsewhere"}))
+		Expect(status.Canary.Error).To(BeEmpty())
+
+		untargeted := *cfg
+		untargeted.CanaryNamespaces = []string{"elsewhere"}
+		status = &BundleStatus{}
+		canaryOnly, requeue := planCanary(ctx, &untargeted, "v2", targets, soaked(), status)
+		Expect(canaryOnly).To(BeTrue())
+		Expect(requeue).To(Equal(canaryProbeRetry))
+		Expect(status.Canary.Untargeted).To(Equal([]string{"elsewhere"}))
+		Expect(status.Canary.Error).NotTo(BeEmpty())
+	})
+})
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"crypto/md5"  //nolint:gosec // required by the NSS trust object
+	"crypto/sha1" //nolint:gosec // required by the NSS trust object
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// certdataHeader starts the certdata.txt rendered for FormatNSS.
+const certdataHeader = `# This file is generated by cabundle-operator in the Mozilla NSS
+# certdata.txt format. Every certificate is trusted as a CA for server
+# authentication and email protection.
+#
+BEGINDATA
+`
+
+// encodeCertdata encodes the certificates in the Mozilla NSS certdata.txt
+// format, as a certificate object and a trust object per certificate, so
+// tools such as mk-ca-bundle or extract-nss-root-certs can consume the
+// bundle. Labels are made unique, NSS consumers key on them.
+func encodeCertdata(certs []*x509.Certificate) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(certdataHeader)
+
+	labels := map[string]int{}
+	for _, c := range certs {
+		label := c.Subject.CommonName
+		if label == "" {
+			label = c.Subject.String()
+		}
+		if labels[label]++; labels[label] > 1 {
+			label = fmt.Sprintf("%s %d", label, labels[label])
+		}
+		label = strings.ReplaceAll(label, `"`, `'`)
+
+		serial, err := asn1.Marshal(c.SerialNumber)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Subject, err)
+		}
+		sha1Sum := sha1.Sum(c.Raw) //nolint:gosec
+		md5Sum := md5.Sum(c.Raw)   //nolint:gosec
+		sha256Sum := sha256.Sum256(c.Raw)
+
+		comment := func() {
+			fmt.Fprintf(&b, "# Issuer: %s\n", c.Issuer)
+			fmt.Fprintf(&b, "# Serial Number: %x\n", c.SerialNumber)
+			fmt.Fprintf(&b, "# Subject: %s\n", c.Subject)
+			fmt.Fprintf(&b, "# Not Valid Before: %s\n", c.NotBefore.UTC().Format(time.ANSIC))
+			fmt.Fprintf(&b, "# Not Valid After : %s\n", c.NotAfter.UTC().Format(time.ANSIC))
+			fmt.Fprintf(&b, "# Fingerprint (SHA-256): %s\n", fingerprint(sha256Sum[:]))
+			fmt.Fprintf(&b, "# Fingerprint (SHA1): %s\n", fingerprint(sha1Sum[:]))
+		}
+		object := func(class string) {
+			fmt.Fprintf(&b, "CKA_CLASS CK_OBJECT_CLASS %s\n", class)
+			b.WriteString("CKA_TOKEN CK_BBOOL CK_TRUE\n")
+			b.WriteString("CKA_PRIVATE CK_BBOOL CK_FALSE\n")
+			b.WriteString("CKA_MODIFIABLE CK_BBOOL CK_FALSE\n")
+			fmt.Fprintf(&b, "CKA_LABEL UTF8 \"%s\"\n", label)
+		}
+
+		fmt.Fprintf(&b, "\n#\n# Certificate %q\n#\n", label)
+		comment()
+		object("CKO_CERTIFICATE")
+		b.WriteString("CKA_CERTIFICATE_TYPE CK_CERTIFICATE_TYPE CKC_X_509\n")
+		writeOctal(&b, "CKA_SUBJECT", c.RawSubject)
+		b.WriteString("CKA_ID UTF8 \"0\"\n")
+		writeOctal(&b, "CKA_ISSUER", c.RawIssuer)
+		writeOctal(&b, "CKA_SERIAL_NUMBER", serial)
+		writeOctal(&b, "CKA_VALUE", c.Raw)
+		b.WriteString("CKA_NSS_MOZILLA_CA_POLICY CK_BBOOL CK_TRUE\n")
+		b.WriteString("CKA_NSS_SERVER_DISTRUST_AFTER CK_BBOOL CK_FALSE\n")
+		b.WriteString("CKA_NSS_EMAIL_DISTRUST_AFTER CK_BBOOL CK_FALSE\n")
+
+		fmt.Fprintf(&b, "\n# Trust for %q\n", label)
+		comment()
+		object("CKO_NSS_TRUST")
+		writeOctal(&b, "CKA_CERT_SHA1_HASH", sha1Sum[:])
+		writeOctal(&b, "CKA_CERT_MD5_HASH", md5Sum[:])
+		writeOctal(&b, "CKA_ISSUER", c.RawIssuer)
+		writeOctal(&b, "CKA_SERIAL_NUMBER", serial)
+		b.WriteString("CKA_TRUST_SERVER_AUTH CK_TRUST CKT_NSS_TRUSTED_DELEGATOR\n")
+		b.WriteString("CKA_TRUST_EMAIL_PROTECTION CK_TRUST CKT_NSS_TRUSTED_DELEGATOR\n")
+		b.WriteString("CKA_TRUST_CODE_SIGNING CK_TRUST CKT_NSS_MUST_VERIFY_TRUST\n")
+		b.WriteString("CKA_TRUST_STEP_UP_APPROVED CK_BBOOL CK_FALSE\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// writeOctal writes a MULTILINE_OCTAL attribute, 16 octal escaped bytes per
+// line.
+func writeOctal(b *strings.Builder, attr string, value []byte) {
+	fmt.Fprintf(b, "%s MULTILINE_OCTAL\n", attr)
+	for i, v := range value {
+		fmt.Fprintf(b, "\\%03o", v)
+		if i%16 == 15 || i == len(value)-1 {
+			b.WriteByte('\n')
+		}
+	}
+	b.WriteString("END\n")
+}
+
+// fingerprint formats a digest as colon separated uppercase hex.
+func fingerprint(sum []byte) string {
+	parts := make([]string, len(sum))
+	for i, v := range sum {
+		parts[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ConditionPinMismatch reports that the downloaded content didn't match the
+// checksums pinned by bundle_checksum or file_checksums, and wasn't applied.
+const ConditionPinMismatch = "PinMismatch"
+
+// ReasonPinMismatch is recorded when downloaded content is refused for not
+// matching the pinned checksums.
+const ReasonPinMismatch = "PinMismatch"
+
+// parseChecksum validates a hex encoded SHA-256 checksum, returning it in
+// lower case.
+func parseChecksum(v string) (string, error) {
+	sum, err := hex.DecodeString(v)
+	if err != nil || len(sum) != sha256.Size {
+		return "", fmt.Errorf("must be a hex encoded SHA-256 checksum")
+	}
+	return strings.ToLower(v), nil
+}
+
+// parseFileChecksums parses file_checksums: a line per file in the format
+// sha256sum prints, "<checksum>  <file>".
+func parseFileChecksums(v string) (map[string]string, error) {
+	checksums := map[string]string{}
+	for _, line := range strings.Split(v, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %q must be <checksum> <file>", strings.TrimSpace(line))
+		}
+		file := strings.TrimPrefix(fields[1], "*")
+		sum, err := parseChecksum(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("file %s: %w", file, err)
+		}
+		if _, ok := checksums[file]; ok {
+			return nil, fmt.Errorf("file %s listed twice", file)
+		}
+		checksums[file] = sum
+	}
+	return checksums, nil
+}
+
+// checksumViolations checks the downloaded files against the checksums
+// pinned by the source. BundleChecksum pins the aggregated bundle, as
+// exported; FileChecksums pins every file, so files without a checksum are
+// refused too. Files that failed to download are left to the failure
+// handling, but with BundleChecksum the aggregate can't match without them.
+func checksumViolations(cfg *BundleConfig, downloaded []PEMFile, failed []FailedFile) []Violation {
+	var violations []Violation
+	if cfg.BundleChecksum != "" {
+		sum := sha256.Sum256(aggregateBundle(cfg.SourceName, downloaded).Content)
+		if got := hex.EncodeToString(sum[:]); got != cfg.BundleChecksum {
+			violations = append(violations, Violation{Filename: BundleChecksumKey,
+				Message: fmt.Sprintf("bundle has checksum %s, pinned %s", shortHash(got), shortHash(cfg.BundleChecksum))})
+		}
+	}
+	if cfg.FileChecksums == nil {
+		return violations
+	}
+
+	seen := map[string]bool{}
+	for _, b := range downloaded {
+		seen[b.Filename] = true
+		want, ok := cfg.FileChecksums[b.Filename]
+		if !ok {
+			violations = append(violations, Violation{Filename: b.Filename, Message: "no checksum pinned"})
+			continue
+		}
+		sum := sha256.Sum256(b.Content)
+		if got := hex.EncodeToString(sum[:]); got != want {
+			violations = append(violations, Violation{Filename: b.Filename,
+				Message: fmt.Sprintf("checksum %s, pinned %s", shortHash(got), shortHash(want))})
+		}
+	}
+	for _, f := range failed {
+		seen[f.Filename] = true
+	}
+	for _, file := range slices.Sorted(maps.Keys(cfg.FileChecksums)) {
+		if !seen[file] {
+			violations = append(violations, Violation{Filename: file, Message: "pinned but not listed by the source"})
+		}
+	}
+	return violations
+}
+
+// refuseContent completes the sync of a source whose downloaded content
+// doesn't match its pinned checksums: the managed ConfigMaps are left
+// untouched and the mismatch is reported until the content or the checksums
+// are corrected.
+func (r *CABundleReconciler) refuseContent(ctx context.Context, src *corev1.ConfigMap, cfg *BundleConfig, prev *BundleStatus, violations []Violation) (ctrl.Result, error) {
+	mismatches := make([]string, 0, len(violations))
+	for _, v := range violations {
+		mismatches = append(mismatches, v.String())
+	}
+	logf.FromContext(ctx).Info("Downloaded content doesn't match the pinned checksums, not applying it", "mismatches", mismatches)
+	r.eventf(cfg, corev1.EventTypeWarning, ReasonPinMismatch, "Refusing content from %s not matching the pinned checksums: %s",
+		cfg.sourceURL(), strings.Join(mismatches, "; "))
+
+	status := *prev
+	status.Conditions = slices.Clone(prev.Conditions)
+	meta.SetStatusCondition(&status.Conditions, pinMismatchCondition(mismatches))
+	meta.SetStatusCondition(&status.Conditions, sourceReachableCondition(nil))
+	status.recordSync(SyncRecord{Time: metav1.Now(), Outcome: SyncFailed, Error: "content doesn't match the pinned checksums"}, cfg.HistoryLimit)
+	status.NextSyncTime = r.nextSyncTime(client.ObjectKeyFromObject(src), 0)
+	if err := r.updateStatus(ctx, src, &status); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// pinMismatchCondition returns the PinMismatch condition for the content
+// refused, none once it matched.
+func pinMismatchCondition(mismatches []string) metav1.Condition {
+	if len(mismatches) == 0 {
+		return metav1.Condition{
+			Type:    ConditionPinMismatch,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ChecksumsMatch",
+			Message: "Downloaded content matches the pinned checksums",
+		}
+	}
+	return metav1.Condition{
+		Type:    ConditionPinMismatch,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ChecksumMismatch",
+		Message: fmt.Sprintf("Content not applied, it doesn't match the pinned checksums: %s", strings.Join(mismatches, "; ")),
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+var _ = Describe("Checksum pinning", func() {
+	root := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+	issuing := PEMFile{Filename: "issuing.pem", Content: newTestCAPEM("Corp Issuing", time.Now().Add(time.Hour))}
+	checksum := func(content []byte) string {
+		sum := sha256.Sum256(content)
+		return hex.EncodeToString(sum[:])
+	}
+
+	It("parses checksums in the sha256sum format", func() {
+		cfg, err := ParseBundleConfig(&corev1.ConfigMap{Data: map[string]string{
+			BundleURLKey:      "https://example.com/bundles/",
+			BundleChecksumKey: "  " + checksum([]byte("bundle")),
+			FileChecksumsKey:  checksum(root.Content) + "  root.pem\n" + checksum(issuing.Content) + " *issuing.pem\n",
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.BundleChecksum).To(Equal(checksum([]byte("bundle"))))
+		Expect(cfg.FileChecksums).To(Equal(map[string]string{
+			"root.pem":    checksum(root.Content),
+			"issuing.pem": checksum(issuing.Content),
+		}))
+
+		for _, v := range []string{"abc  root.pem", checksum(root.Content), checksum(root.Content) + "  root.pem\n" + checksum(root.Content) + "  root.pem"} {
+			_, err := ParseBundleConfig(&corev1.ConfigMap{Data: map[string]string{
+				BundleURLKey:     "https://example.com/bundles/",
+				FileChecksumsKey: v,
+			}})
+			Expect(err).To(HaveOccurred(), v)
+		}
+	})
+
+	It("finds files that don't match, aren't pinned or aren't listed", func() {
+		cfg := &BundleConfig{SourceName: "root-ca", FileChecksums: map[string]string{
+			"root.pem":    checksum(root.Content),
+			"retired.pem": checksum([]byte("retired")),
+		}}
+		Expect(checksumViolations(cfg, []PEMFile{root}, []FailedFile{{Filename: "retired.pem"}})).To(BeEmpty())
+
+		tampered := PEMFile{Filename: "root.pem", Content: issuing.Content}
+		Expect(checksumViolations(cfg, []PEMFile{tampered, issuing}, nil)).To(Equal([]Violation{
+			{Filename: "root.pem", Message: "checksum " + shortHash(checksum(issuing.Content)) + ", pinned " + shortHash(checksum(root.Content))},
+			{Filename: "issuing.pem", Message: "no checksum pinned"},
+			{Filename: "retired.pem", Message: "pinned but not listed by the source"},
+		}))
+
+		cfg = &BundleConfig{SourceName: "root-ca", BundleChecksum: checksum(aggregateBundle("", []PEMFile{root, issuing}).Content)}
+		Expect(checksumViolations(cfg, []PEMFile{issuing, root}, nil)).To(BeEmpty())
+		Expect(checksumViolations(cfg, []PEMFile{root}, []FailedFile{{Filename: "issuing.pem"}})).To(HaveLen(1))
+	})
+
+	It("refuses content that doesn't match and reports PinMismatch", func() {
+		ctx := context.Background()
+		recorder := record.NewFakeRecorder(10)
+		src := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"}}
+		r := &CABundleReconciler{
+			Client:   fake.NewClientBuilder().WithObjects(src).Build(),
+			Recorder: recorder,
+			Scheme:   clientgoscheme.Scheme,
+		}
+		cfg := &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", BundleURL: "https://example.com/bundles/", HistoryLimit: DefaultHistoryLimit}
+
+		_, err := r.refuseContent(ctx, src, cfg, &BundleStatus{}, []Violation{{Filename: "root.pem", Message: "no checksum pinned"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring("root.pem: no checksum pinned")))
+		status, err := r.getStatus(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(meta.IsStatusConditionTrue(status.Conditions, ConditionPinMismatch)).To(BeTrue())
+		Expect(status.History).To(ConsistOf(HaveField("Outcome", SyncFailed)))
+		Expect(bundleHealth(status).Status).To(Equal(HealthDegraded))
+	})
+})
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// ConditionCircuitOpen reports that downloads from the source are suspended
+// after repeated failures.
+const ConditionCircuitOpen = "CircuitOpen"
+
+// ReasonCircuitOpened is recorded when the circuit breaker of a source opens.
+const ReasonCircuitOpened = "CircuitOpened"
+
+// Circuit breaker states, also the values of the state metric.
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitState exposes the circuit breaker state of each source.
+var circuitState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "cabundle_circuit_breaker_state",
+	Help: "Circuit breaker state of a source: 0 closed, 1 open, 2 half-open.",
+}, []string{"source"})
+
+func init() {
+	metrics.Registry.MustRegister(circuitState)
+}
+
+// circuitBreakers stops downloads from a source after consecutive failures.
+// Once the cooldown has passed the circuit half-opens: a single download is
+// let through, closing the circuit if it succeeds and reopening it if not.
+type circuitBreakers struct {
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state    int
+	failures int
+	openedAt time.Time
+}
+
+// get returns the circuit of a source, creating it. Callers hold mu.
+func (b *circuitBreakers) get(key string) *circuit {
+	if b.circuits == nil {
+		b.circuits = map[string]*circuit{}
+	}
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// allow reports whether the source may be downloaded from at now, and if not
+// how long until the circuit half-opens.
+func (b *circuitBreakers) allow(key string, cooldown time.Duration, now time.Time) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.get(key)
+	if c.state != circuitOpen {
+		return 0, true
+	}
+	if wait := c.openedAt.Add(cooldown).Sub(now); wait > 0 {
+		return wait, false
+	}
+	c.state = circuitHalfOpen
+	circuitState.WithLabelValues(key).Set(circuitHalfOpen)
+	return 0, true
+}
+
+// record accounts for a download from the source and reports whether it
+// opened the circuit.
+func (b *circuitBreakers) record(key string, threshold int, err error, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.get(key)
+	if err == nil {
+		c.state, c.failures = circuitClosed, 0
+		circuitState.WithLabelValues(key).Set(circuitClosed)
+		return false
+	}
+	c.failures++
+	if c.state == circuitHalfOpen || (c.state == circuitClosed && c.failures >= threshold) {
+		c.state, c.openedAt = circuitOpen, now
+		circuitState.WithLabelValues(key).Set(circuitOpen)
+		return true
+	}
+	return false
+}
+
+// openUntil returns when the open circuit of a source half-opens, along with
+// the failures that opened it.
+func (b *circuitBreakers) openUntil(key string, cooldown time.Duration) (time.Time, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.get(key)
+	return c.openedAt.Add(cooldown), c.failures
+}
+
+// circuitEnabled reports whether circuits are broken with the tuning.
+func (t Tuning) circuitEnabled() bool {
+	return t.CircuitBreakerThreshold > 0 && t.CircuitBreakerCooldown > 0
+}
+
+// circuitCondition returns the CircuitOpen condition, open until the given
+// time if not zero.
+func circuitCondition(until time.Time, failures int) metav1.Condition {
+	if until.IsZero() {
+		return metav1.Condition{
+			Type:    ConditionCircuitOpen,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Closed",
+			Message: "Downloads from the source are allowed",
+		}
+	}
+	return metav1.Condition{
+		Type:    ConditionCircuitOpen,
+		Status:  metav1.ConditionTrue,
+		Reason:  "RepeatedFailures",
+		Message: fmt.Sprintf("Downloads suspended after %d consecutive failures, retrying at %s", failures, until.UTC().Format(time.RFC3339)),
+	}
+}
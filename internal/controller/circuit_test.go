@@ -0,0 +1,58 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Circuit breaker", func() {
+	const key = "cert-manager/root-ca"
+	cooldown := 10 * time.Minute
+	failed := errors.New("connection refused")
+
+	It("opens after the threshold, half-opens after the cooldown and closes on success", func() {
+		var b circuitBreakers
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		Expect(b.record(key, 3, failed, now)).To(BeFalse())
+		Expect(b.record(key, 3, failed, now)).To(BeFalse())
+		Expect(b.record(key, 3, failed, now)).To(BeTrue())
+
+		wait, ok := b.allow(key, cooldown, now.Add(time.Minute))
+		Expect(ok).To(BeFalse())
+		Expect(wait).To(Equal(9 * time.Minute))
+
+		// A failed trial reopens the circuit for another cooldown.
+		_, ok = b.allow(key, cooldown, now.Add(cooldown))
+		Expect(ok).To(BeTrue())
+		Expect(b.record(key, 3, failed, now.Add(cooldown))).To(BeTrue())
+		_, ok = b.allow(key, cooldown, now.Add(cooldown+time.Minute))
+		Expect(ok).To(BeFalse())
+
+		_, ok = b.allow(key, cooldown, now.Add(2*cooldown))
+		Expect(ok).To(BeTrue())
+		Expect(b.record(key, 3, nil, now.Add(2*cooldown))).To(BeFalse())
+		Expect(b.record(key, 3, failed, now.Add(2*cooldown))).To(BeFalse())
+		_, ok = b.allow(key, cooldown, now.Add(2*cooldown))
+		Expect(ok).To(BeTrue())
+	})
+})
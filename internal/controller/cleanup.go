@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Cleanup modes, set for every source by the reconciler's CleanupMode and for
+// a single one by its cleanup key. The more conservative of the two applies.
+const (
+	// CleanupDelete deletes stale and untargeted managed ConfigMaps.
+	CleanupDelete = "delete"
+	// CleanupReport only logs the ConfigMaps cleanup would delete and counts
+	// them in the cleanup candidates metric.
+	CleanupReport = "report"
+	// CleanupDisabled doesn't look for ConfigMaps to delete at all.
+	CleanupDisabled = "disabled"
+)
+
+// cleanupModes are ordered from the least to the most conservative.
+var cleanupModes = []string{CleanupDelete, CleanupReport, CleanupDisabled}
+
+// ParseCleanupMode validates a cleanup mode, empty meaning CleanupDelete.
+func ParseCleanupMode(v string) (string, error) {
+	if v == "" {
+		return CleanupDelete, nil
+	}
+	if !slices.Contains(cleanupModes, v) {
+		return "", fmt.Errorf("unknown cleanup mode %q, must be one of %v", v, cleanupModes)
+	}
+	return v, nil
+}
+
+// cleanupMode returns how cleanup treats the managed ConfigMaps of the
+// source. During the CleanupGracePeriod after startup deletions are only
+// reported, so adopting the operator in a cluster with existing ConfigMaps
+// can't delete any on the first syncs.
+func (r *CABundleReconciler) cleanupMode(cfg *BundleConfig) string {
+	mode := CleanupDelete
+	for _, m := range []string{r.CleanupMode, cfg.CleanupMode} {
+		if slices.Index(cleanupModes, m) > slices.Index(cleanupModes, mode) {
+			mode = m
+		}
+	}
+	if mode == CleanupDelete && r.inCleanupGracePeriod() {
+		return CleanupReport
+	}
+	return mode
+}
+
+// inCleanupGracePeriod reports whether the reconciler started less than
+// CleanupGracePeriod ago.
+func (r *CABundleReconciler) inCleanupGracePeriod() bool {
+	return r.CleanupGracePeriod > 0 && !r.started.IsZero() && time.Since(r.started) < r.CleanupGracePeriod
+}
+
+// skipCleanup reports whether cleanup keeps a ConfigMap it would delete,
+// logging it, in a dry run or when cleanup only reports.
+func (r *CABundleReconciler) skipCleanup(ctx context.Context, cfg *BundleConfig, namespace, name, reason string) bool {
+	if r.skipWrite(ctx, cfg, ActionDelete, namespace, name, "reason", reason) {
+		return true
+	}
+	if r.cleanupMode(cfg) != CleanupReport {
+		return false
+	}
+	kv := []any{"name", name, "namespace", namespace, "reason", reason, "gracePeriod", r.inCleanupGracePeriod()}
+	if r.cluster != "" {
+		kv = append(kv, "cluster", r.cluster)
+	}
+	logf.FromContext(ctx).Info("Cleanup only reports, keeping ConfigMap it would delete", kv...)
+	return true
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Cleanup modes", func() {
+	ctx := context.Background()
+
+	// cleanUp runs cleanup in apps with root.pem listed and a stale
+	// legacy.pem, returning the ConfigMaps left.
+	cleanUp := func(r *CABundleReconciler, cfg *BundleConfig) []string {
+		c := fake.NewClientBuilder().Build()
+		r.Client = c
+		bundle := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+		for _, name := range []string{"root.pem", "legacy.pem"} {
+			cm, err := r.desiredConfigMap("apps", PEMFile{Filename: name, Content: bundle.Content}, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Create(ctx, cm)).To(Succeed())
+		}
+
+		Expect(r.CleanUpConfigMaps(ctx, "apps", []PEMFile{bundle}, cfg)).To(Succeed())
+		list := &corev1.ConfigMapList{}
+		Expect(c.List(ctx, list)).To(Succeed())
+		var names []string
+		for _, cm := range list.Items {
+			names = append(names, cm.Name)
+		}
+		return names
+	}
+	newConfig := func(mode string) *BundleConfig {
+		return &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM},
+			CleanupMode: mode}
+	}
+
+	It("deletes stale ConfigMaps by default", func() {
+		Expect(cleanUp(&CABundleReconciler{}, newConfig(""))).To(HaveLen(1))
+	})
+
+	It("keeps stale ConfigMaps when it only reports or is disabled", func() {
+		for _, mode := range []string{CleanupReport, CleanupDisabled} {
+			Expect(cleanUp(&CABundleReconciler{CleanupMode: mode}, newConfig(""))).To(HaveLen(2), mode)
+			Expect(cleanUp(&CABundleReconciler{}, newConfig(mode))).To(HaveLen(2), mode)
+		}
+	})
+
+	It("doesn't let a source loosen the operator's mode", func() {
+		r := &CABundleReconciler{CleanupMode: CleanupReport}
+		Expect(r.cleanupMode(newConfig(CleanupDelete))).To(Equal(CleanupReport))
+		Expect(r.cleanupMode(newConfig(CleanupDisabled))).To(Equal(CleanupDisabled))
+	})
+
+	It("only reports during the grace period after startup", func() {
+		r := &CABundleReconciler{CleanupGracePeriod: time.Hour, started: time.Now()}
+		Expect(cleanUp(r, newConfig(""))).To(HaveLen(2))
+
+		r.started = time.Now().Add(-2 * time.Hour)
+		Expect(cleanUp(r, newConfig(""))).To(HaveLen(1))
+	})
+
+	It("rejects unknown modes", func() {
+		_, err := ParseCleanupMode("purge")
+		Expect(err).To(HaveOccurred())
+		mode, err := ParseCleanupMode("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mode).To(Equal(CleanupDelete))
+	})
+})
+
+var _ = Describe("Stale cleanup with several sources", func() {
+	It("leaves the ConfigMaps of other sources sharing a target namespace alone", func() {
+		ctx := context.Background()
+		corp := newTestBundleServer(map[string][]byte{"a.pem": newTestCAPEM("Corp Root", time.Now().AddDate(1, 0, 0))})
+		defer corp.Close()
+		partner := newTestBundleServer(map[string][]byte{"b.pem": newTestCAPEM("Partner Root", time.Now().AddDate(1, 0, 0))})
+		defer partner.Close()
+		source := func(name, url string) *corev1.ConfigMap {
+			return &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cert-manager", Labels: map[string]string{BundleSourceLabel: "true"}},
+				Data:       map[string]string{BundleURLKey: url, TargetNamespacesKey: "apps"},
+			}
+		}
+		sources := []*corev1.ConfigMap{source("corp-roots", corp.URL), source("partner", partner.URL)}
+		r := &CABundleReconciler{
+			Client: fake.NewClientBuilder().WithObjects(sources[0], sources[1],
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}).Build(),
+			Scheme:          clientgoscheme.Scheme,
+			TargetNamespace: "cert-manager",
+		}
+
+		for range 2 {
+			for _, src := range sources {
+				_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)})
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+		a := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "a"}, a)).To(Succeed())
+		Expect(a.Labels).To(HaveKeyWithValue(SourceLabel, "corp-roots"))
+		b := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "b"}, b)).To(Succeed())
+		Expect(b.Labels).To(HaveKeyWithValue(SourceLabel, "partner"))
+	})
+})
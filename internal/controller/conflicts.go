@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conflict strategies, set by the conflict_strategy key of a source, for
+// files rendered into the same managed ConfigMap: files whose names only
+// differ in the suffix or in case, listed by different
+// bundle_urls entries, named like the aggregate ConfigMap, or renamed by a
+// target override to the name of another file.
+const (
+	// ConflictError fails the sync of the targets the files conflict in.
+	ConflictError = "error"
+	// ConflictFirstWins writes the file listed first.
+	ConflictFirstWins = "first-wins"
+	// ConflictConcatenate writes the certificates of every file.
+	ConflictConcatenate = "concatenate"
+)
+
+// ReasonKeyConflict is recorded when files are rendered into the same
+// managed ConfigMap.
+const ReasonKeyConflict = "KeyConflict"
+
+// BundleConflict is a managed ConfigMap several files are rendered into, and
+// how the conflict was resolved.
+type BundleConflict struct {
+	ConfigMap  string   `json:"configMap"`
+	Files      []string `json:"files"`
+	Resolution string   `json:"resolution"`
+}
+
+func (c BundleConflict) String() string {
+	return fmt.Sprintf("files %s map to ConfigMap %s: %s", strings.Join(c.Files, ", "), c.ConfigMap, c.Resolution)
+}
+
+// resolveConflicts resolves the files rendered into the same managed
+// ConfigMap of a target by the source's ConflictStrategy, returning the
+// bundles to write and the conflicts found. With ConflictError the conflicts
+// are returned along with an error.
+func (r *CABundleReconciler) resolveConflicts(bundles []PEMFile, cfg *BundleConfig) ([]PEMFile, []BundleConflict, error) {
+	groups := map[string][]PEMFile{}
+	var names []string
+	for _, b := range bundles {
+		name := r.configMapName(b.Filename, cfg)
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], b)
+	}
+	if len(names) == len(bundles) {
+		return bundles, nil, nil
+	}
+
+	resolved := make([]PEMFile, 0, len(names))
+	var conflicts []BundleConflict
+	for _, name := range names {
+		group := groups[name]
+		if len(group) == 1 {
+			resolved = append(resolved, group[0])
+			continue
+		}
+		c := BundleConflict{ConfigMap: name}
+		for _, b := range group {
+			c.Files = append(c.Files, b.Filename)
+		}
+		switch cfg.ConflictStrategy {
+		case ConflictFirstWins:
+			c.Resolution = "kept " + group[0].Filename
+			resolved = append(resolved, group[0])
+		case ConflictConcatenate:
+			c.Resolution = "concatenated"
+			resolved = append(resolved, PEMFile{Filename: group[0].Filename, Content: aggregateBundle(name, group).Content})
+		default:
+			c.Resolution = "not written"
+		}
+		conflicts = append(conflicts, c)
+	}
+	if cfg.ConflictStrategy == ConflictFirstWins || cfg.ConflictStrategy == ConflictConcatenate {
+		return resolved, conflicts, nil
+	}
+	return nil, conflicts, fmt.Errorf("%s, set %s to resolve", conflicts[0], ConflictStrategyKey)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Conflicting files", func() {
+	ctx := context.Background()
+	pem := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Root CA", time.Now().Add(time.Hour))}
+	crt := PEMFile{Filename: "Root.crt", Content: newTestCAPEM("Other Root CA", time.Now().Add(time.Hour))}
+	issuing := PEMFile{Filename: "issuing.pem", Content: newTestCAPEM("Issuing CA", time.Now().Add(time.Hour))}
+
+	var (
+		r   *CABundleReconciler
+		cfg *BundleConfig
+	)
+	BeforeEach(func() {
+		r = &CABundleReconciler{Client: fake.NewClientBuilder().Build()}
+		cfg = &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+	})
+
+	live := func(name string) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: name}, cm)).To(Succeed())
+		return cm
+	}
+
+	It("fails the sync by default", func() {
+		cfg.ConflictStrategy = ConflictError
+		_, conflicts, err := r.resolveConflicts([]PEMFile{pem, issuing, crt}, cfg)
+		Expect(err).To(MatchError(ContainSubstring(ConflictStrategyKey)))
+		Expect(conflicts).To(Equal([]BundleConflict{{ConfigMap: "root", Files: []string{"root.pem", "Root.crt"}, Resolution: "not written"}}))
+
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{pem, issuing, crt}, cfg)).To(MatchError(ContainSubstring("map to ConfigMap root")))
+	})
+
+	It("writes the file listed first with first-wins", func() {
+		cfg.ConflictStrategy = ConflictFirstWins
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{pem, issuing, crt}, cfg)).To(Succeed())
+		Expect(live("root").Data["ca.crt"]).To(Equal(string(pem.Content)))
+		Expect(live("issuing").Data["ca.crt"]).To(Equal(string(issuing.Content)))
+	})
+
+	It("writes the certificates of every file with concatenate", func() {
+		cfg.ConflictStrategy = ConflictConcatenate
+		bundles, conflicts, err := r.resolveConflicts([]PEMFile{pem, issuing, crt}, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(HaveLen(2))
+		Expect(conflicts[0].Resolution).To(Equal("concatenated"))
+
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{pem, issuing, crt}, cfg)).To(Succeed())
+		certs, err := ParseCertificates([]byte(live("root").Data["ca.crt"]))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// RestartedAtAnnotation is set on the pod template of consumer workloads
+	// to roll them out after a bundle change.
+	RestartedAtAnnotation = "cabundle.io/restartedAt"
+	// BundleHashAnnotation is set on the pod template of consumer workloads
+	// to the combined content hash of the managed ConfigMaps they reference.
+	BundleHashAnnotation = "cabundle.io/bundle-hash"
+	// PropagateHashAnnotation set to "true" on a Deployment, StatefulSet or
+	// DaemonSet opts it into BundleHashAnnotation. Workloads managed by
+	// GitOps tools would otherwise see their pod template drift.
+	PropagateHashAnnotation = "cabundle.io/propagate-hash"
+	// ContentHashAnnotation is set on every managed ConfigMap to the SHA-256
+	// of its rendered content.
+	ContentHashAnnotation = "cabundle.io/content-hash"
+	// RevisionAnnotation is set on every managed ConfigMap to a counter
+	// incremented whenever its content changes, starting at 1.
+	RevisionAnnotation = "cabundle.io/revision"
+)
+
+// consumer is a workload whose pod template references managed ConfigMaps.
+type consumer struct {
+	kind     string
+	obj      client.Object
+	template *corev1.PodTemplateSpec
+}
+
+// listConsumers returns the Deployments, StatefulSets and DaemonSets in the
+// namespace whose pod template references any of the named ConfigMaps.
+func (r *CABundleReconciler) listConsumers(ctx context.Context, namespace string, names []string) ([]consumer, error) {
+	refs := map[string]bool{}
+	for _, n := range names {
+		refs[n] = true
+	}
+
+	var consumers []consumer
+	add := func(kind string, obj client.Object, tmpl *corev1.PodTemplateSpec) {
+		if podSpecReferences(&tmpl.Spec, refs) {
+			consumers = append(consumers, consumer{kind: kind, obj: obj, template: tmpl})
+		}
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		add("Deployment", &deployments.Items[i], &deployments.Items[i].Spec.Template)
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range statefulSets.Items {
+		add("StatefulSet", &statefulSets.Items[i], &statefulSets.Items[i].Spec.Template)
+	}
+
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := r.List(ctx, daemonSets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range daemonSets.Items {
+		add("DaemonSet", &daemonSets.Items[i], &daemonSets.Items[i].Spec.Template)
+	}
+
+	return consumers, nil
+}
+
+// podSpecReferences reports whether the pod spec mounts or reads any of the
+// ConfigMaps.
+func podSpecReferences(spec *corev1.PodSpec, refs map[string]bool) bool {
+	for name := range referencedConfigMaps(spec) {
+		if refs[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// referencedConfigMaps returns the names of all ConfigMaps the pod spec
+// mounts or reads.
+func referencedConfigMaps(spec *corev1.PodSpec) map[string]bool {
+	names := map[string]bool{}
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			names[v.ConfigMap.Name] = true
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.ConfigMap != nil {
+					names[src.ConfigMap.Name] = true
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, e := range c.EnvFrom {
+			if e.ConfigMapRef != nil {
+				names[e.ConfigMapRef.Name] = true
+			}
+		}
+		for _, e := range c.Env {
+			if e.ValueFrom != nil && e.ValueFrom.ConfigMapKeyRef != nil {
+				names[e.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+		}
+	}
+	return names
+}
+
+// contentHash returns the SHA-256 of the ConfigMap content over sorted keys.
+func contentHash(data map[string]string, binaryData map[string][]byte) string {
+	h := sha256.New()
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		fmt.Fprintf(h, "%s\x00%s\x00", k, data[k])
+	}
+	for _, k := range slices.Sorted(maps.Keys(binaryData)) {
+		fmt.Fprintf(h, "%s\x00", k)
+		h.Write(binaryData[k])
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// propagateHash stamps every consumer workload in the namespace opted in
+// with PropagateHashAnnotation with the combined hash of the managed
+// ConfigMaps it references, so the pod template only changes when the
+// referenced content does.
+func (r *CABundleReconciler) propagateHash(ctx context.Context, namespace string, hashes map[string]string) error {
+	logger := logf.FromContext(ctx)
+
+	consumers, err := r.listConsumers(ctx, namespace, slices.Collect(maps.Keys(hashes)))
+	if err != nil {
+		return err
+	}
+
+	for _, c := range consumers {
+		if c.obj.GetAnnotations()[PropagateHashAnnotation] != "true" {
+			continue
+		}
+		h := sha256.New()
+		refs := referencedConfigMaps(&c.template.Spec)
+		for _, name := range slices.Sorted(maps.Keys(refs)) {
+			if v, ok := hashes[name]; ok {
+				fmt.Fprintf(h, "%s=%s\n", name, v)
+			}
+		}
+		sum := hex.EncodeToString(h.Sum(nil))
+		if c.template.Annotations[BundleHashAnnotation] == sum {
+			continue
+		}
+
+		patch := client.MergeFrom(c.obj.DeepCopyObject().(client.Object))
+		if c.template.Annotations == nil {
+			c.template.Annotations = map[string]string{}
+		}
+		c.template.Annotations[BundleHashAnnotation] = sum
+
+		logger.Info("Updating bundle hash on consumer", "kind", c.kind, "name", c.obj.GetName(), "namespace", namespace)
+		if err := r.Patch(ctx, c.obj, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restartConsumers rolls out every workload in the namespace referencing
+// one of the changed ConfigMaps by stamping its pod template.
+func (r *CABundleReconciler) restartConsumers(ctx context.Context, namespace string, changed []string) error {
+	logger := logf.FromContext(ctx)
+
+	consumers, err := r.listConsumers(ctx, namespace, changed)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, c := range consumers {
+		patch := client.MergeFrom(c.obj.DeepCopyObject().(client.Object))
+		if c.template.Annotations == nil {
+			c.template.Annotations = map[string]string{}
+		}
+		c.template.Annotations[RestartedAtAnnotation] = now
+
+		logger.Info("Restarting bundle consumer", "kind", c.kind,
+			"name", c.obj.GetName(), "namespace", namespace)
+		if err := r.Patch(ctx, c.obj, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Consumer workloads", func() {
+	ctx := context.Background()
+
+	deployment := func(name string, annotations map[string]string, configMap string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apps", Annotations: annotations},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{Name: "ca", VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: configMap}},
+				}}},
+			}}},
+		}
+	}
+	bundleHash := func(c client.Client, name string) string {
+		d := &appsv1.Deployment{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "apps", Name: name}, d)).To(Succeed())
+		return d.Spec.Template.Annotations[BundleHashAnnotation]
+	}
+
+	It("propagates the bundle hash to workloads opted in only", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			deployment("web", map[string]string{PropagateHashAnnotation: "true"}, "root"),
+			deployment("argo-managed", nil, "root"),
+			deployment("unrelated", map[string]string{PropagateHashAnnotation: "true"}, "settings"),
+		).Build()
+		r := &CABundleReconciler{Client: c}
+
+		Expect(r.propagateHash(ctx, "apps", map[string]string{"root": "v1"})).To(Succeed())
+		first := bundleHash(c, "web")
+		Expect(first).NotTo(BeEmpty())
+		Expect(bundleHash(c, "argo-managed")).To(BeEmpty())
+		Expect(bundleHash(c, "unrelated")).To(BeEmpty())
+
+		Expect(r.propagateHash(ctx, "apps", map[string]string{"root": "v1", "other": "v1"})).To(Succeed())
+		Expect(bundleHash(c, "web")).To(Equal(first), "unreferenced ConfigMaps don't change the hash")
+		Expect(r.propagateHash(ctx, "apps", map[string]string{"root": "v2"})).To(Succeed())
+		Expect(bundleHash(c, "web")).NotTo(Equal(first))
+	})
+})
@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// DebugPath is where DebugHandler is served on the metrics server, behind
+// its authentication and authorization.
+const DebugPath = "/debug/cabundle"
+
+// debugState is the reconciler's in-memory view of each source, exposed by
+// DebugHandler.
+type debugState struct {
+	mu      sync.Mutex
+	sources map[string]*sourceDebug
+}
+
+// sourceDebug is the in-memory view of a single source.
+type sourceDebug struct {
+	Reconciling        bool         `json:"reconciling"`
+	LastReconcileStart *metav1.Time `json:"lastReconcileStart,omitempty"`
+	LastReconcileEnd   *metav1.Time `json:"lastReconcileEnd,omitempty"`
+	LastError          string       `json:"lastError,omitempty"`
+	// ConsecutiveFailures counts the failed reconciles since the last one
+	// that succeeded.
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+	// LastIndex lists the files found in the last fetched index.
+	LastIndex     []string     `json:"lastIndex,omitempty"`
+	LastIndexTime *metav1.Time `json:"lastIndexTime,omitempty"`
+	// NextSync is when the periodic runner plans to sync the source next.
+	NextSync *metav1.Time `json:"nextSync,omitempty"`
+}
+
+// source returns the entry of a source, creating it. Callers hold mu.
+func (d *debugState) source(key string) *sourceDebug {
+	if d.sources == nil {
+		d.sources = map[string]*sourceDebug{}
+	}
+	s, ok := d.sources[key]
+	if !ok {
+		s = &sourceDebug{}
+		d.sources[key] = s
+	}
+	return s
+}
+
+func (d *debugState) reconcileStarted(req ctrl.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := metav1.Now()
+	s := d.source(req.String())
+	s.Reconciling = true
+	s.LastReconcileStart = &now
+}
+
+// reconcileFinished records the end of a reconcile and returns the
+// consecutive failures of the source.
+func (d *debugState) reconcileFinished(req ctrl.Request, err error) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := metav1.Now()
+	s := d.source(req.String())
+	failures := s.ConsecutiveFailures
+	s.Reconciling = false
+	s.LastReconcileEnd = &now
+	s.LastError = ""
+	s.ConsecutiveFailures = 0
+	if err != nil {
+		s.LastError = err.Error()
+		s.ConsecutiveFailures = failures + 1
+	}
+	return s.ConsecutiveFailures
+}
+
+// InFlight reports whether a reconcile of the source is running.
+func (r *CABundleReconciler) InFlight(key types.NamespacedName) bool {
+	r.debug.mu.Lock()
+	defer r.debug.mu.Unlock()
+	s, ok := r.debug.sources[key.String()]
+	return ok && s.Reconciling
+}
+
+// ConsecutiveFailures returns the number of failed reconciles of the source
+// since the last one that succeeded.
+func (r *CABundleReconciler) ConsecutiveFailures(key types.NamespacedName) int {
+	r.debug.mu.Lock()
+	defer r.debug.mu.Unlock()
+	if s, ok := r.debug.sources[key.String()]; ok {
+		return s.ConsecutiveFailures
+	}
+	return 0
+}
+
+// SetNextSync records when the source is planned to be synced next, for its
+// status and the next sync metric. A zero time forgets the source.
+func (r *CABundleReconciler) SetNextSync(key types.NamespacedName, next time.Time) {
+	r.debug.mu.Lock()
+	defer r.debug.mu.Unlock()
+	if next.IsZero() {
+		if s, ok := r.debug.sources[key.String()]; ok {
+			s.NextSync = nil
+		}
+		nextSync.DeleteLabelValues(key.String())
+		return
+	}
+	t := metav1.NewTime(next)
+	r.debug.source(key.String()).NextSync = &t
+	nextSync.WithLabelValues(key.String()).Set(float64(next.Unix()))
+}
+
+// nextSyncTime returns when the source will be synced next: the periodic
+// runner's plan or, if sooner, after requeueAfter.
+func (r *CABundleReconciler) nextSyncTime(key types.NamespacedName, requeueAfter time.Duration) *metav1.Time {
+	r.debug.mu.Lock()
+	defer r.debug.mu.Unlock()
+	var next *metav1.Time
+	if s, ok := r.debug.sources[key.String()]; ok && s.NextSync != nil {
+		next = s.NextSync.DeepCopy()
+	}
+	if requeueAfter > 0 {
+		requeue := metav1.NewTime(time.Now().Add(requeueAfter))
+		if next == nil || requeue.Before(next) {
+			next = &requeue
+		}
+	}
+	return next
+}
+
+func (d *debugState) indexFetched(req ctrl.Request, bundles []PEMFile) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := metav1.Now()
+	s := d.source(req.String())
+	s.LastIndex = make([]string, 0, len(bundles))
+	for _, b := range bundles {
+		s.LastIndex = append(s.LastIndex, b.Filename)
+	}
+	s.LastIndexTime = &now
+}
+
+// debugSource is a source as reported by DebugHandler.
+type debugSource struct {
+	Namespace        string   `json:"namespace"`
+	Name             string   `json:"name"`
+	BundleURL        string   `json:"bundleURL,omitempty"`
+	Formats          []string `json:"formats,omitempty"`
+	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
+	ConfigError      string   `json:"configError,omitempty"`
+	*sourceDebug
+}
+
+// debugReport is the document served by DebugHandler.
+type debugReport struct {
+	Sources []debugSource `json:"sources"`
+	// PendingEnqueues is the number of periodic enqueues not yet picked up
+	// by the controller.
+	PendingEnqueues int `json:"pendingEnqueues"`
+	// CachedETags are the ETags of the cached bundle files by URL.
+	CachedETags map[string]string `json:"cachedETags"`
+}
+
+// DebugHandler serves the operator's current view of its sources as JSON:
+// their configuration, the last fetched index and any reconcile in progress.
+// Secrets such as the truststore password are never included, and URLs are
+// reported without their credentials and query.
+func (r *CABundleReconciler) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		sources, err := r.ListSources(req.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		report := debugReport{Sources: []debugSource{}, PendingEnqueues: len(r.EventCh), CachedETags: map[string]string{}}
+		for u, etag := range downloads.etags() {
+			report.CachedETags[redactURL(u)] = etag
+		}
+		r.debug.mu.Lock()
+		for i := range sources {
+			src := &sources[i]
+			ds := debugSource{Namespace: src.Namespace, Name: src.Name, sourceDebug: &sourceDebug{}}
+			if s, ok := r.debug.sources[src.Namespace+"/"+src.Name]; ok {
+				copied := *s
+				ds.sourceDebug = &copied
+			}
+			if cfg, err := ParseBundleConfig(src); err != nil {
+				ds.ConfigError = err.Error()
+			} else {
+				urls := cfg.sourceURLs()
+				for i, u := range urls {
+					urls[i] = redactURL(u)
+				}
+				ds.BundleURL = strings.Join(urls, ",")
+				ds.Formats = cfg.Formats
+				ds.TargetNamespaces = cfg.TargetNamespaces
+			}
+			report.Sources = append(report.Sources, ds)
+		}
+		r.debug.mu.Unlock()
+		sort.Slice(report.Sources, func(i, j int) bool {
+			return report.Sources[i].Namespace+"/"+report.Sources[i].Name < report.Sources[j].Namespace+"/"+report.Sources[j].Name
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	})
+}
+
+// redactURL strips the userinfo and query of a URL, which may carry
+// credentials.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	return u.String()
+}
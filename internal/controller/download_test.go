@@ -0,0 +1,416 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Bundle download", func() {
+	ctx := context.Background()
+
+	// serve lists the files on the index and serves their content, files
+	// without content answer 404.
+	serve := func(files map[string][]byte, listed ...string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/" {
+				_, _ = w.Write([]byte("<html><body>"))
+				for _, name := range listed {
+					_, _ = w.Write([]byte(`<a href="` + name + `">` + name + `</a>`))
+				}
+				_, _ = w.Write([]byte("</body></html>"))
+				return
+			}
+			content, ok := files[req.URL.Path[1:]]
+			if !ok {
+				http.NotFound(w, req)
+				return
+			}
+			_, _ = w.Write(content)
+		}))
+	}
+
+	It("downloads every listed file", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		srv := serve(map[string][]byte{"root.pem": root}, "root.pem", "index.html")
+		defer srv.Close()
+
+		bundles, failed, err := DownloadPEMBundles(ctx, srv.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failed).To(BeEmpty())
+		Expect(bundles).To(Equal([]PEMFile{{Filename: "root.pem", Content: root}}))
+	})
+
+	It("fails on an empty listing instead of retiring every file", func() {
+		srv := serve(nil)
+		defer srv.Close()
+
+		_, _, err := DownloadPEMBundles(ctx, srv.URL)
+		Expect(err).To(MatchError(ContainSubstring("no bundle files listed")))
+	})
+
+	It("fails on error responses instead of distributing them", func() {
+		srv := serve(nil, "gone.pem")
+		defer srv.Close()
+
+		_, _, err := DownloadPEMBundles(ctx, srv.URL)
+		Expect(err).To(MatchError(ContainSubstring("404")))
+	})
+
+	It("keeps downloading past a failed file and reports it", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		srv := serve(map[string][]byte{"root.pem": root}, "gone.pem", "root.pem")
+		defer srv.Close()
+
+		bundles, failed, err := DownloadPEMBundles(ctx, srv.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(Equal([]PEMFile{{Filename: "root.pem", Content: root}}))
+		Expect(failed).To(HaveLen(1))
+		Expect(failed[0].Filename).To(Equal("gone.pem"))
+		Expect(failed[0].Err).To(MatchError(ContainSubstring("404")))
+
+		r := &CABundleReconciler{}
+		prev := &BundleStatus{Files: []FileStatus{{Filename: "gone.pem", ConfigMap: "gone", Hash: "abc", Certificates: 2}}}
+		files := r.failedFileStatuses(&BundleConfig{}, failed, prev)
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Hash).To(Equal("abc"))
+		Expect(files[0].Certificates).To(Equal(2))
+		Expect(files[0].Error).To(ContainSubstring("404"))
+	})
+
+	It("revalidates cached files by ETag instead of downloading them again", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		var downloaded int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/" {
+				_, _ = w.Write([]byte(`<a href="root.pem">root.pem</a>`))
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			downloaded++
+			_, _ = w.Write(root)
+		}))
+		defer srv.Close()
+
+		for range 3 {
+			bundles, _, err := DownloadPEMBundles(ctx, srv.URL)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bundles).To(Equal([]PEMFile{{Filename: "root.pem", Content: root}}))
+		}
+		Expect(downloaded).To(Equal(1))
+	})
+
+	It("skips sources whose index is unchanged since the last full sync", func() {
+		listing := func(size string) *httptest.Server {
+			return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				_, _ = w.Write([]byte(`<pre><a href="root.pem">root.pem</a>   01-Jun-2025 12:00   ` + size + "\n</pre>"))
+			}))
+		}
+		srv := listing("1234")
+		defer srv.Close()
+		index, err := fetchBundleIndex(ctx, http.DefaultClient, srv.URL, DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Files).To(Equal([]string{"root.pem"}))
+
+		resized := listing("1240")
+		defer resized.Close()
+		changed, err := fetchBundleIndex(ctx, http.DefaultClient, resized.URL, DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed.Hash).NotTo(Equal(index.Hash))
+
+		r := &CABundleReconciler{}
+		src := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager", ResourceVersion: "7"}}
+		cfg := &BundleConfig{ExpiryWarning: DefaultExpiryWarning}
+		prev := &BundleStatus{
+			FullSync:   &FullSync{IndexHash: index.Hash, SourceVersion: "7", Time: metav1.Now()},
+			Namespaces: []NamespaceStatus{{Namespace: "b", Synced: true}, {Namespace: "a", Synced: true}},
+		}
+		Expect(r.indexUnchanged(src, cfg, index, []string{"a", "b"}, prev)).To(BeTrue())
+		Expect(r.indexUnchanged(src, cfg, changed, []string{"a", "b"}, prev)).To(BeFalse())
+		Expect(r.indexUnchanged(src, cfg, index, []string{"a", "b", "c"}, prev)).To(BeFalse())
+
+		src.ResourceVersion = "8"
+		Expect(r.indexUnchanged(src, cfg, index, []string{"a", "b"}, prev)).To(BeFalse())
+	})
+
+	It("rejects files larger than the limit", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		srv := serve(map[string][]byte{"root.pem": root, "huge.pem": bytes.Repeat([]byte("A"), 4096)}, "huge.pem", "root.pem")
+		defer srv.Close()
+
+		index, err := fetchBundleIndex(ctx, http.DefaultClient, srv.URL, DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		index.MaxFileSize = 2048
+		bundles, failed, err := index.download(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(HaveLen(1))
+		Expect(failed).To(HaveLen(1))
+		Expect(failed[0].Err).To(MatchError(ContainSubstring("larger than the limit")))
+	})
+
+	It("lists the files with the source's suffixes", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		srv := serve(map[string][]byte{"root.cer": root, "corp.ca-bundle": root}, "root.cer", "corp.ca-bundle", "root.pem", "notes.md")
+		defer srv.Close()
+
+		cfg, err := ParseBundleConfig(&corev1.ConfigMap{Data: map[string]string{
+			BundleURLKey:    srv.URL,
+			FileSuffixesKey: ".cer, .ca-bundle",
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		index, err := listBundles(ctx, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Files).To(Equal([]string{"root.cer", "corp.ca-bundle"}))
+
+		r := &CABundleReconciler{}
+		Expect(r.configMapName("corp.ca-bundle", cfg)).To(Equal("corp"))
+		Expect(r.configMapName("root-ca.pem", cfg)).To(Equal("root-ca"), "the default suffixes are trimmed too")
+		Expect(r.configMapName("root.crt.pem", &BundleConfig{})).To(Equal("root"))
+
+		_, err = ParseBundleConfig(&corev1.ConfigMap{Data: map[string]string{
+			BundleURLKey:    srv.URL,
+			FileSuffixesKey: "pem",
+		}})
+		Expect(err).To(MatchError(ContainSubstring(FileSuffixesKey)))
+	})
+
+	It("downloads with the reconciler's HTTP client", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		srv := serve(map[string][]byte{"root.pem": root}, "root.pem")
+		defer srv.Close()
+
+		var requests []string
+		client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req.URL.Path)
+			return http.DefaultTransport.RoundTrip(req)
+		})}
+		r := &CABundleReconciler{HTTPClient: client}
+		cfg, err := r.parseBundleConfig(&corev1.ConfigMap{Data: map[string]string{BundleURLKey: srv.URL + "/"}})
+		Expect(err).NotTo(HaveOccurred())
+		index, err := listBundles(ctx, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		bundles, _, err := downloadBundles(ctx, cfg, index)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(HaveLen(1))
+		Expect(requests).To(Equal([]string{"/", "/root.pem"}))
+
+		Expect(probeCanary(ctx, cfg.httpClient(), srv.URL+"/root.pem")).To(Succeed())
+		Expect(requests).To(HaveLen(3))
+	})
+
+	It("detects bundle files by their content", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		issuing := newTestCAPEM("Corp Issuing", time.Now().Add(time.Hour))
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch req.URL.RequestURI() {
+			case "/":
+				_, _ = w.Write([]byte(`<a href="../">Parent</a><a href="?C=N;O=D">Name</a><a href="old/">old/</a>` +
+					`<a href="root">root</a><a href="download?id=issuing">issuing</a><a href="README">README</a>` +
+					`<a href="mailto:pki@example.com">PKI</a>`))
+			case "/root":
+				_, _ = w.Write(root)
+			case "/download?id=issuing":
+				_, _ = w.Write(issuing)
+			case "/README":
+				_, _ = w.Write([]byte("Corporate trust anchors"))
+			default:
+				http.NotFound(w, req)
+			}
+		}))
+		defer srv.Close()
+
+		cfg, err := ParseBundleConfig(&corev1.ConfigMap{Data: map[string]string{
+			BundleURLKey:     srv.URL,
+			DetectContentKey: "true",
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		index, err := listBundles(ctx, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Files).To(Equal([]string{"root", "download?id=issuing", "README"}))
+		bundles, failed, err := downloadBundles(ctx, cfg, index)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failed).To(BeEmpty())
+		Expect(bundles).To(Equal([]PEMFile{
+			{Filename: "root", Content: root},
+			{Filename: "download?id=issuing", Content: issuing},
+		}))
+		Expect((&CABundleReconciler{}).configMapName("download?id=issuing", cfg)).To(Equal("download-id-issuing"))
+
+		index.Files = []string{"README"}
+		_, _, err = downloadBundles(ctx, cfg, index)
+		Expect(err).To(MatchError(ContainSubstring("hold certificates")))
+	})
+
+	It("decodes percent-encoded links", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		srv := serve(map[string][]byte{"Corp Root CA.pem": root, "100%.pem": root}, "Corp%20Root%20CA.pem", "100%.pem")
+		defer srv.Close()
+
+		index, err := fetchBundleIndex(ctx, http.DefaultClient, srv.URL, DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Files).To(Equal([]string{"Corp Root CA.pem", "100%.pem"}))
+		bundles, failed, err := index.download(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failed).To(BeEmpty())
+		Expect(bundles).To(HaveLen(2))
+		Expect((&CABundleReconciler{}).configMapName(bundles[0].Filename, &BundleConfig{})).To(Equal("corp-root-ca"))
+	})
+
+	It("resolves absolute links and the <base href> of the index", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		cdn := serve(map[string][]byte{"certs/root.pem": root, "certs/issuing.pem": root, "certs/Partner CA.pem": root})
+		defer cdn.Close()
+
+		var page string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/bundles/" || req.URL.Path == "/bundles" {
+				_, _ = w.Write([]byte(page))
+				return
+			}
+			_, _ = w.Write(root)
+		}))
+		defer srv.Close()
+
+		page = `<a href="` + cdn.URL + `/certs/root.pem">root</a><a href="/bundles/local.pem">local</a>` +
+			`<a href="relative.pem">relative</a><a href="` + cdn.URL + `/certs/">certs</a><a href="ftp://pki.example.com/x.pem">x</a>`
+		index, err := fetchBundleIndex(ctx, http.DefaultClient, srv.URL+"/bundles", DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Files).To(Equal([]string{"root.pem", "local.pem", "relative.pem"}))
+		Expect(index.fileURL("root.pem")).To(Equal(cdn.URL + "/certs/root.pem"))
+		Expect(index.fileURL("local.pem")).To(Equal(srv.URL + "/bundles/local.pem"))
+		Expect(index.fileURL("relative.pem")).To(Equal(srv.URL + "/bundles/relative.pem"))
+		bundles, failed, err := index.download(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failed).To(BeEmpty())
+		Expect(bundles).To(HaveLen(3))
+
+		page = `<html><head><base href="` + cdn.URL + `/certs/"></head><body>` +
+			`<a href="issuing.pem">issuing</a><a href="Partner%20CA.pem">partner</a></body></html>`
+		index, err = fetchBundleIndex(ctx, http.DefaultClient, srv.URL+"/bundles/", DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Files).To(Equal([]string{"issuing.pem", "Partner CA.pem"}))
+		bundles, failed, err = index.download(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failed).To(BeEmpty())
+		Expect(bundles).To(HaveLen(2))
+	})
+
+	It("hashes the URL every file is downloaded from", func() {
+		parse := func(page string) *bundleIndex {
+			index, err := parseIndex(strings.NewReader(page), "https://pki.example.com/bundles/", DefaultFileSuffixes)
+			Expect(err).NotTo(HaveOccurred())
+			return index
+		}
+		relative := parse(`<a href="root.pem">root.pem</a>`)
+		Expect(parse(`<a href="root.pem">root.pem</a>`).Hash).To(Equal(relative.Hash))
+		Expect(parse(`<base href="https://cdn.example.com/"><a href="root.pem">root.pem</a>`).Hash).NotTo(Equal(relative.Hash))
+		Expect(parse(`<a href="https://cdn.example.com/root.pem">root.pem</a>`).Hash).NotTo(Equal(relative.Hash))
+	})
+
+	It("rejects files linked twice under the same name", func() {
+		index, err := parseIndex(strings.NewReader(`<a href="root.pem"><img></a> <a href="root.pem">root.pem</a>`),
+			"https://pki.example.com/", DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.Files).To(Equal([]string{"root.pem"}))
+
+		_, err = parseIndex(strings.NewReader(
+			`<a href="https://a.example.com/root.pem">a</a><a href="https://b.example.com/root.pem">b</a>`),
+			"https://pki.example.com/", DefaultFileSuffixes)
+		Expect(err).To(MatchError(ContainSubstring("links bundle file root.pem from both")))
+	})
+
+	It("restricts links to the host of the index unless allowed", func() {
+		index, err := parseIndex(strings.NewReader(
+			`<a href="root.pem">root</a><a href="https://cdn.example.com/issuing.pem">issuing</a>`),
+			"https://pki.example.com/", DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.checkLinkHosts(nil)).To(MatchError(ContainSubstring("links to host cdn.example.com")))
+		Expect(index.checkLinkHosts([]string{"cdn.example.com"})).To(Succeed())
+
+		index, err = parseIndex(strings.NewReader(`<base href="//evil.example.com/"><a href="root.pem">root</a>`),
+			"https://pki.example.com/", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.checkLinkHosts(nil)).To(MatchError(ContainSubstring("links to host evil.example.com")))
+	})
+
+	It("rejects plain HTTP links from an HTTPS index", func() {
+		index, err := parseIndex(strings.NewReader(
+			`<a href="root.pem">root</a><a href="http://pki.example.com/issuing.pem">issuing</a>`),
+			"https://pki.example.com/", DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.checkLinkHosts([]string{"pki.example.com"})).To(MatchError(ContainSubstring("over http")))
+
+		index, err = parseIndex(strings.NewReader(`<base href="http://pki.example.com/"><a href="root.pem">root</a>`),
+			"https://pki.example.com/", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.checkLinkHosts(nil)).To(MatchError(ContainSubstring("over http")))
+
+		By("allowing either scheme from an HTTP index")
+		index, err = parseIndex(strings.NewReader(`<a href="https://pki.example.com/root.pem">root</a>`),
+			"http://pki.example.com/", DefaultFileSuffixes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(index.checkLinkHosts(nil)).To(Succeed())
+	})
+
+	It("caps the size and entries of the index", func() {
+		var links strings.Builder
+		for i := range maxIndexEntries + 1 {
+			fmt.Fprintf(&links, `<a href="root-%d.pem">root-%d.pem</a>`, i, i)
+		}
+		_, err := parseIndex(strings.NewReader(links.String()), "https://pki.example.com/", DefaultFileSuffixes)
+		Expect(err).To(MatchError(ContainSubstring("more than")))
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte(`<a href="root.pem">root.pem</a>`))
+			_, _ = w.Write(bytes.Repeat([]byte(" "), maxIndexBytes))
+		}))
+		defer srv.Close()
+		_, err = fetchBundleIndex(ctx, http.DefaultClient, srv.URL, DefaultFileSuffixes)
+		Expect(err).To(MatchError(ContainSubstring("larger than")))
+	})
+
+	It("reports serving the last synced bundle while the source is down", func() {
+		synced := metav1.NewTime(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+		cond := staleCondition(context.DeadlineExceeded, &synced)
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Message).To(ContainSubstring("2025-06-01T12:00:00Z"))
+		Expect(staleCondition(nil, nil).Status).To(Equal(metav1.ConditionFalse))
+	})
+})
+
+// roundTripFunc is an http.RoundTripper calling itself.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
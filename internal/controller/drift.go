@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ConditionDrifted reports that managed ConfigMaps were modified or deleted
+// out of band. They are restored by the next full sync, right away with
+// DriftRevert.
+const ConditionDrifted = "Drifted"
+
+// ReasonDrifted is recorded for every managed ConfigMap found modified or
+// deleted out of band.
+const ReasonDrifted = "Drifted"
+
+// Drift policies, set by the drift_policy key of a source.
+const (
+	// DriftReport reports drifted ConfigMaps, leaving them to the next
+	// full sync.
+	DriftReport = "report"
+	// DriftRevert restores drifted ConfigMaps right away.
+	DriftRevert = "revert"
+)
+
+// parseDriftIgnoreField validates an entry of drift_ignore_fields: data.<key>
+// or binaryData.<key>, the key being a path.Match pattern.
+func parseDriftIgnoreField(field string) error {
+	section, pattern, _ := strings.Cut(field, ".")
+	if section != "data" && section != "binaryData" || pattern == "" {
+		return fmt.Errorf("must be data.<key> or binaryData.<key>")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// ignoresDrift reports whether a data or binaryData key of the managed
+// ConfigMaps is managed by someone else, see DriftIgnoreFields.
+func (cfg *BundleConfig) ignoresDrift(section, key string) bool {
+	for _, f := range cfg.DriftIgnoreFields {
+		s, pattern, _ := strings.Cut(f, ".")
+		if ok, _ := path.Match(pattern, key); ok && s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// ownedContent returns the content of a managed ConfigMap the operator
+// owns: every key but those the source ignores the drift of. A nil cfg
+// ignores none.
+func ownedContent(cm *corev1.ConfigMap, cfg *BundleConfig) (map[string]string, map[string][]byte) {
+	if cfg == nil || len(cfg.DriftIgnoreFields) == 0 {
+		return cm.Data, cm.BinaryData
+	}
+	data := maps.Clone(cm.Data)
+	maps.DeleteFunc(data, func(k, _ string) bool { return cfg.ignoresDrift("data", k) })
+	binaryData := maps.Clone(cm.BinaryData)
+	maps.DeleteFunc(binaryData, func(k string, _ []byte) bool { return cfg.ignoresDrift("binaryData", k) })
+	return data, binaryData
+}
+
+// keepIgnoredContent copies the keys of the live ConfigMap the source
+// ignores the drift of into the ConfigMap about to be written.
+func keepIgnoredContent(live, desired *corev1.ConfigMap, cfg *BundleConfig) {
+	for k, v := range live.Data {
+		if _, ok := desired.Data[k]; !ok && cfg.ignoresDrift("data", k) {
+			if desired.Data == nil {
+				desired.Data = map[string]string{}
+			}
+			desired.Data[k] = v
+		}
+	}
+	for k, v := range live.BinaryData {
+		if _, ok := desired.BinaryData[k]; !ok && cfg.ignoresDrift("binaryData", k) {
+			if desired.BinaryData == nil {
+				desired.BinaryData = map[string][]byte{}
+			}
+			desired.BinaryData[k] = v
+		}
+	}
+}
+
+// managedConfigMapDrifted passes updates of managed ConfigMaps whose content
+// no longer matches their ContentHashAnnotation, and deletions of managed
+// ConfigMaps. The operator's own writes keep the annotation in step with the
+// content, so they don't pass, nor do edits of the keys the source ignores
+// the drift of as of its last reconcile.
+func (r *CABundleReconciler) managedConfigMapDrifted() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return managedSource(e.Object) != nil
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			cm, ok := e.ObjectNew.(*corev1.ConfigMap)
+			if !ok {
+				return false
+			}
+			src := managedSource(e.ObjectOld)
+			return src != nil && !contentMatchesHash(cm, r.drift.ignoring(*src))
+		},
+	}
+}
+
+// managedSource returns the source a managed ConfigMap is labeled with, nil
+// for other ConfigMaps and those labeled by operator versions predating the
+// source labels.
+func managedSource(obj client.Object) *types.NamespacedName {
+	l := obj.GetLabels()
+	if l[AppLabel] != AppLabelValue || l[SourceLabel] == "" || l[SourceNamespaceLabel] == "" {
+		return nil
+	}
+	return &types.NamespacedName{Namespace: l[SourceNamespaceLabel], Name: l[SourceLabel]}
+}
+
+// contentMatchesHash reports whether a managed ConfigMap still holds the
+// labels and content it was written with. ConfigMaps written before the
+// ContentHashAnnotation was introduced are assumed to. Keys cfg ignores the
+// drift of are left out.
+func contentMatchesHash(cm *corev1.ConfigMap, cfg *BundleConfig) bool {
+	want, ok := cm.Annotations[ContentHashAnnotation]
+	if !ok {
+		return true
+	}
+	return cm.Labels[AppLabel] == AppLabelValue && contentHash(ownedContent(cm, cfg)) == want
+}
+
+// mapDriftToSource notes the drifted ConfigMap for its source and enqueues
+// the source, see detectDrift.
+func (r *CABundleReconciler) mapDriftToSource(_ context.Context, obj client.Object) []reconcile.Request {
+	src := managedSource(obj)
+	if src == nil {
+		return nil
+	}
+	r.drift.note(*src, client.ObjectKeyFromObject(obj))
+	return []reconcile.Request{{NamespacedName: *src}}
+}
+
+// driftTracker holds the managed ConfigMaps of each source seen modified or
+// deleted since its last reconcile, and the fields each source ignores the
+// drift of.
+type driftTracker struct {
+	mu      sync.Mutex
+	pending map[types.NamespacedName]map[types.NamespacedName]bool
+	ignored map[types.NamespacedName][]string
+}
+
+// ignore records the fields the source ignores the drift of.
+func (t *driftTracker) ignore(src types.NamespacedName, fields []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(fields) == 0 {
+		delete(t.ignored, src)
+		return
+	}
+	if t.ignored == nil {
+		t.ignored = map[types.NamespacedName][]string{}
+	}
+	t.ignored[src] = fields
+}
+
+// ignoring returns the configuration to check the content of the source's
+// ConfigMaps with, nil if it ignores no fields or hasn't been reconciled
+// yet, see contentMatchesHash.
+func (t *driftTracker) ignoring(src types.NamespacedName) *BundleConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if fields, ok := t.ignored[src]; ok {
+		return &BundleConfig{DriftIgnoreFields: fields}
+	}
+	return nil
+}
+
+// note records a managed ConfigMap of a source as possibly drifted.
+func (t *driftTracker) note(src, cm types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending == nil {
+		t.pending = map[types.NamespacedName]map[types.NamespacedName]bool{}
+	}
+	if t.pending[src] == nil {
+		t.pending[src] = map[types.NamespacedName]bool{}
+	}
+	t.pending[src][cm] = true
+}
+
+// take returns and forgets the ConfigMaps noted for a source.
+func (t *driftTracker) take(src types.NamespacedName) []types.NamespacedName {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cms := make([]types.NamespacedName, 0, len(t.pending[src]))
+	for cm := range t.pending[src] {
+		cms = append(cms, cm)
+	}
+	delete(t.pending, src)
+	slices.SortFunc(cms, func(a, b types.NamespacedName) int { return strings.Compare(a.String(), b.String()) })
+	return cms
+}
+
+// driftedConfigMap is a managed ConfigMap modified or deleted out of band.
+type driftedConfigMap struct {
+	Key     types.NamespacedName
+	Deleted bool
+}
+
+// change describes what happened to the ConfigMap.
+func (d driftedConfigMap) change() string {
+	if d.Deleted {
+		return "deleted"
+	}
+	return "modified"
+}
+
+func (d driftedConfigMap) String() string {
+	return d.Key.String() + " (" + d.change() + ")"
+}
+
+// detectDrift confirms the drift noted for the source: the ConfigMaps are
+// read again, so those restored meanwhile, deleted by cleanup, or in
+// namespaces no longer targeted aren't reported.
+func (r *CABundleReconciler) detectDrift(ctx context.Context, cfg *BundleConfig, namespaces []string, prev *BundleStatus) ([]driftedConfigMap, error) {
+	src := types.NamespacedName{Namespace: cfg.SourceNamespace, Name: cfg.SourceName}
+	r.drift.ignore(src, cfg.DriftIgnoreFields)
+	var drifted []driftedConfigMap
+	for _, key := range r.drift.take(src) {
+		cm := &corev1.ConfigMap{}
+		err := r.Get(ctx, key, cm)
+		switch {
+		case apierrors.IsNotFound(err):
+			// The ConfigMaps of the namespace may be renamed by its override.
+			tcfg := cfg.ForTarget("", key.Namespace)
+			synced := slices.ContainsFunc(prev.Files, func(f FileStatus) bool {
+				return r.configMapName(f.Filename, tcfg) == key.Name
+			})
+			if synced && slices.Contains(namespaces, key.Namespace) {
+				drifted = append(drifted, driftedConfigMap{Key: key, Deleted: true})
+			}
+		case err != nil:
+			return nil, err
+		case !contentMatchesHash(cm, cfg.ForTarget("", key.Namespace)):
+			drifted = append(drifted, driftedConfigMap{Key: key})
+		}
+	}
+	return drifted, nil
+}
+
+// recordDrift records an Event for every drifted ConfigMap and returns
+// their descriptions for the Drifted condition.
+func (r *CABundleReconciler) recordDrift(ctx context.Context, cfg *BundleConfig, drifted []driftedConfigMap) []string {
+	names := make([]string, 0, len(drifted))
+	for _, d := range drifted {
+		names = append(names, d.String())
+		r.eventf(cfg, corev1.EventTypeWarning, ReasonDrifted, "ConfigMap %s was %s out of band",
+			r.describeConfigMap(d.Key.Namespace, d.Key.Name), d.change())
+	}
+	logf.FromContext(ctx).Info("Managed ConfigMaps drifted", "configMaps", names, "policy", cfg.DriftPolicy)
+	return names
+}
+
+// reportDrift records the drifted ConfigMaps and raises the Drifted
+// condition, leaving the ConfigMaps to the next full sync.
+func (r *CABundleReconciler) reportDrift(ctx context.Context, src *corev1.ConfigMap, cfg *BundleConfig, prev *BundleStatus, drifted []driftedConfigMap) (ctrl.Result, error) {
+	status := *prev
+	status.Conditions = slices.Clone(prev.Conditions)
+	meta.SetStatusCondition(&status.Conditions, driftedCondition(r.recordDrift(ctx, cfg, drifted)))
+	if err := r.updateStatus(ctx, src, &status); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.recordSuccessfulSync(client.ObjectKeyFromObject(src), time.Now())
+	return ctrl.Result{}, nil
+}
+
+// driftedCondition returns the Drifted condition for the drifted ConfigMaps,
+// none once a full sync restored them.
+func driftedCondition(drifted []string) metav1.Condition {
+	if len(drifted) == 0 {
+		return metav1.Condition{
+			Type:    ConditionDrifted,
+			Status:  metav1.ConditionFalse,
+			Reason:  "InSync",
+			Message: "Managed ConfigMaps hold the synced content",
+		}
+	}
+	return metav1.Condition{
+		Type:    ConditionDrifted,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ModifiedOutOfBand",
+		Message: fmt.Sprintf("Changed out of band, restored on the next full sync: %s", strings.Join(drifted, ", ")),
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+var _ = Describe("Drift detection", func() {
+	ctx := context.Background()
+	root := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+
+	var (
+		recorder *record.FakeRecorder
+		r        *CABundleReconciler
+		cfg      *BundleConfig
+		src      *corev1.ConfigMap
+	)
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		r = &CABundleReconciler{Recorder: recorder, Scheme: clientgoscheme.Scheme}
+		cfg = &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+		src = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"}}
+	})
+
+	managed := func(namespace string) *corev1.ConfigMap {
+		cm, err := r.desiredConfigMap(namespace, root, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		return cm
+	}
+
+	It("passes out of band changes of managed ConfigMaps only", func() {
+		cm := managed("apps")
+		Expect(r.managedConfigMapDrifted().Update(event.UpdateEvent{ObjectOld: cm, ObjectNew: cm.DeepCopy()})).To(BeFalse())
+
+		edited := cm.DeepCopy()
+		edited.Data["ca.crt"] = "tampered"
+		Expect(r.managedConfigMapDrifted().Update(event.UpdateEvent{ObjectOld: cm, ObjectNew: edited})).To(BeTrue())
+
+		stripped := cm.DeepCopy()
+		delete(stripped.Labels, AppLabel)
+		Expect(r.managedConfigMapDrifted().Update(event.UpdateEvent{ObjectOld: cm, ObjectNew: stripped})).To(BeTrue())
+
+		Expect(r.managedConfigMapDrifted().Delete(event.DeleteEvent{Object: cm})).To(BeTrue())
+		Expect(r.managedConfigMapDrifted().Delete(event.DeleteEvent{Object: src})).To(BeFalse())
+	})
+
+	It("reports ConfigMaps modified or deleted out of band", func() {
+		edited := managed("apps")
+		edited.Data["ca.crt"] = "tampered"
+		restored := managed("web")
+		r.Client = fake.NewClientBuilder().WithObjects(src, edited, restored).Build()
+		prev := &BundleStatus{Files: r.fileStatuses(cfg, []PEMFile{root}, &BundleStatus{})}
+
+		// Every ConfigMap is mapped to its source and noted.
+		for _, ns := range []string{"apps", "web", "db", "legacy"} {
+			cm := managed(ns)
+			Expect(r.mapDriftToSource(ctx, cm)).To(ConsistOf(HaveField("NamespacedName", client.ObjectKeyFromObject(src))))
+		}
+		drifted, err := r.detectDrift(ctx, cfg, []string{"apps", "web", "db"}, prev)
+		Expect(err).NotTo(HaveOccurred())
+		// legacy is no longer targeted, web holds its synced content.
+		Expect(drifted).To(Equal([]driftedConfigMap{
+			{Key: client.ObjectKey{Namespace: "apps", Name: edited.Name}},
+			{Key: client.ObjectKey{Namespace: "db", Name: edited.Name}, Deleted: true},
+		}))
+
+		_, err = r.reportDrift(ctx, src, cfg, prev, drifted)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring("apps/" + edited.Name + " was modified out of band")))
+		Expect(recorder.Events).To(Receive(ContainSubstring("db/" + edited.Name + " was deleted out of band")))
+		status, err := r.getStatus(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(meta.IsStatusConditionTrue(status.Conditions, ConditionDrifted)).To(BeTrue())
+
+		// Once checked, the drift is no longer pending.
+		drifted, err = r.detectDrift(ctx, cfg, []string{"apps", "web", "db"}, prev)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(drifted).To(BeEmpty())
+	})
+
+	It("reports the deletion of ConfigMaps renamed by a target override", func() {
+		cfg.TargetOverrides = []TargetOverride{{Namespace: "istio-system", Names: map[string]string{"root": "istio-ca-root-cert"}}}
+		r.Client = fake.NewClientBuilder().WithObjects(src).Build()
+		prev := &BundleStatus{Files: r.fileStatuses(cfg, []PEMFile{root}, &BundleStatus{})}
+
+		renamed := managed("istio-system")
+		renamed.Name = "istio-ca-root-cert"
+		r.mapDriftToSource(ctx, renamed)
+		drifted, err := r.detectDrift(ctx, cfg, []string{"istio-system"}, prev)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(drifted).To(Equal([]driftedConfigMap{
+			{Key: client.ObjectKey{Namespace: "istio-system", Name: "istio-ca-root-cert"}, Deleted: true},
+		}))
+	})
+
+	It("leaves fields managed by someone else alone", func() {
+		cfg.DriftIgnoreFields = []string{"data.extra-*"}
+		cm := managed("apps")
+		cm.Data["extra-ca.crt"] = "added by the mesh"
+		Expect(contentMatchesHash(cm, nil)).To(BeFalse())
+		Expect(contentMatchesHash(cm, cfg)).To(BeTrue())
+
+		cm.Data["ca.crt"] = "tampered"
+		r.Client = fake.NewClientBuilder().WithObjects(cm).Build()
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{root}, cfg)).To(Succeed())
+		live := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(cm), live)).To(Succeed())
+		Expect(live.Data).To(HaveKeyWithValue("ca.crt", string(root.Content)))
+		Expect(live.Data).To(HaveKeyWithValue("extra-ca.crt", "added by the mesh"))
+		Expect(contentMatchesHash(live, cfg)).To(BeTrue())
+	})
+
+	It("doesn't enqueue the source for edits of ignored fields", func() {
+		cfg.DriftIgnoreFields = []string{"data.extra-*"}
+		cm := managed("apps")
+		r.Client = fake.NewClientBuilder().WithObjects(src, cm).Build()
+		_, err := r.detectDrift(ctx, cfg, []string{"apps"}, &BundleStatus{})
+		Expect(err).NotTo(HaveOccurred())
+
+		edited := cm.DeepCopy()
+		edited.Data["extra-ca.crt"] = "added by the mesh"
+		Expect(r.managedConfigMapDrifted().Update(event.UpdateEvent{ObjectOld: cm, ObjectNew: edited})).To(BeFalse())
+
+		tampered := edited.DeepCopy()
+		tampered.Data["ca.crt"] = "tampered"
+		Expect(r.managedConfigMapDrifted().Update(event.UpdateEvent{ObjectOld: edited, ObjectNew: tampered})).To(BeTrue())
+	})
+
+	It("validates the drift policy and ignored fields", func() {
+		parse := func(data map[string]string) error {
+			data[BundleURLKey] = "https://pki.example.com/"
+			_, err := ParseBundleConfig(&corev1.ConfigMap{Data: data})
+			return err
+		}
+		Expect(parse(map[string]string{DriftPolicyKey: DriftRevert, DriftIgnoreFieldsKey: "data.extra-*, binaryData.mesh.jks"})).To(Succeed())
+		Expect(parse(map[string]string{DriftPolicyKey: "ignore"})).To(MatchError(ContainSubstring(DriftPolicyKey)))
+		Expect(parse(map[string]string{DriftIgnoreFieldsKey: "metadata.labels.team"})).To(MatchError(ContainSubstring(DriftIgnoreFieldsKey)))
+		Expect(parse(map[string]string{DriftIgnoreFieldsKey: "data.[extra"})).To(MatchError(ContainSubstring("invalid pattern")))
+	})
+
+	It("doesn't skip the next sync of a drifted source", func() {
+		status := &BundleStatus{FullSync: &FullSync{IndexHash: "abc", Time: metav1.Now()}}
+		index := &bundleIndex{Hash: "abc"}
+		Expect(r.indexUnchanged(src, cfg, index, nil, status)).To(BeTrue())
+
+		meta.SetStatusCondition(&status.Conditions, driftedCondition([]string{"apps/root (modified)"}))
+		Expect(r.indexUnchanged(src, cfg, index, nil, status)).To(BeFalse())
+	})
+})
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// dryRunChanges counts the managed ConfigMap writes skipped by a dry run, by
+// the action they would have taken.
+var dryRunChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cabundle_dry_run_changes_total",
+	Help: "Managed ConfigMap writes skipped by a dry run.",
+}, []string{"source", "action"})
+
+func init() {
+	metrics.Registry.MustRegister(dryRunChanges)
+}
+
+// skipWrite reports whether the reconciler only pretends to write, logging
+// the change to a managed ConfigMap it skips, see DryRun.
+func (r *CABundleReconciler) skipWrite(ctx context.Context, cfg *BundleConfig, action, namespace, name string, keysAndValues ...any) bool {
+	if !r.DryRun {
+		return false
+	}
+	kv := []any{"action", action, "name", name, "namespace", namespace}
+	if r.cluster != "" {
+		kv = append(kv, "cluster", r.cluster)
+	}
+	logf.FromContext(ctx).Info("Dry run, skipping ConfigMap write", append(kv, keysAndValues...)...)
+	dryRunChanges.WithLabelValues(cfg.SourceNamespace+"/"+cfg.SourceName, action).Inc()
+	return true
+}
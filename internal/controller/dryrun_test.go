@@ -0,0 +1,62 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Dry run", func() {
+	ctx := context.Background()
+
+	It("leaves managed ConfigMaps and Events alone", func() {
+		recorder := record.NewFakeRecorder(10)
+		r := &CABundleReconciler{Recorder: recorder}
+		cfg := &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}}
+		old := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+		current, err := r.desiredConfigMap("apps", old, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		legacy, err := r.desiredConfigMap("apps", PEMFile{Filename: "legacy.pem", Content: old.Content}, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		r.Client = fake.NewClientBuilder().WithObjects(current, legacy).Build()
+		r.DryRun = true
+
+		renewed := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(2*time.Hour))}
+		added := PEMFile{Filename: "extra.pem", Content: newTestCAPEM("Corp Extra", time.Now().Add(time.Hour))}
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{renewed, added}, cfg)).To(Succeed())
+
+		list := &corev1.ConfigMapList{}
+		Expect(r.List(ctx, list)).To(Succeed())
+		Expect(list.Items).To(HaveLen(2))
+		live := map[string]corev1.ConfigMap{}
+		for _, cm := range list.Items {
+			live[cm.Name] = cm
+		}
+		Expect(live).To(HaveKey(legacy.Name))
+		Expect(live).To(HaveKey(current.Name))
+		Expect(live[current.Name].Data).To(Equal(current.Data))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+})
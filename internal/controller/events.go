@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the Events recorded on the source ConfigMap.
+const (
+	ReasonCreated        = "Created"
+	ReasonUpdated        = "Updated"
+	ReasonDeleted        = "Deleted"
+	ReasonDownloadFailed = "DownloadFailed"
+	// ReasonFileDownloadFailed is recorded when some of the bundle files
+	// failed to download while the others were synced.
+	ReasonFileDownloadFailed = "FileDownloadFailed"
+	// ReasonNamespaceCreated is recorded when a missing target namespace
+	// was created, see BundleConfig.CreateNamespaces.
+	ReasonNamespaceCreated = "NamespaceCreated"
+	// ReasonNotRequestable is recorded when namespaces can't request the
+	// bundle, see RequestLabelPrefix.
+	ReasonNotRequestable = "NotRequestable"
+)
+
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// sourceRef returns a reference to the source ConfigMap to record Events on.
+func (cfg *BundleConfig) sourceRef() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cfg.SourceName,
+			Namespace: cfg.SourceNamespace,
+			UID:       cfg.SourceUID,
+		},
+	}
+}
+
+// eventf records an Event on the bundle's source ConfigMap, if the reconciler
+// has a recorder.
+func (r *CABundleReconciler) eventf(cfg *BundleConfig, eventtype, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder == nil || r.DryRun {
+		return
+	}
+	r.Recorder.Eventf(cfg.sourceRef(), eventtype, reason, messageFmt, args...)
+}
+
+// describeConfigMap names a managed ConfigMap in Event messages, including
+// the remote cluster it lives in.
+func (r *CABundleReconciler) describeConfigMap(namespace, name string) string {
+	if r.cluster != "" {
+		return namespace + "/" + name + " in cluster " + r.cluster
+	}
+	return namespace + "/" + name
+}
+
+// describeNamespace names a target namespace in Event messages, including
+// the remote cluster it lives in.
+func (r *CABundleReconciler) describeNamespace(namespace string) string {
+	if r.cluster != "" {
+		return namespace + " in cluster " + r.cluster
+	}
+	return namespace
+}
+
+// dedupKey identifies a stream of identical Events.
+type dedupKey struct {
+	uid       types.UID
+	namespace string
+	name      string
+	reason    string
+	message   string
+}
+
+// dedupEntry tracks the last emitted Event of a stream.
+type dedupEntry struct {
+	object     runtime.Object
+	eventtype  string
+	emitted    time.Time
+	suppressed int
+}
+
+// DedupingRecorder collapses repeated Events so a flapping source doesn't
+// flood the namespace. Within window, an Event repeating the reason and
+// message of the last one emitted on the same object is suppressed. Once the
+// window expires, the number suppressed is reported by the next Event of the
+// stream or, if none comes, by a summary Event emitted from Start.
+type DedupingRecorder struct {
+	record.EventRecorder
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[dedupKey]*dedupEntry
+}
+
+// NewDedupingRecorder wraps the recorder, deduplicating Events within window.
+func NewDedupingRecorder(recorder record.EventRecorder, window time.Duration) *DedupingRecorder {
+	return &DedupingRecorder{
+		EventRecorder: recorder,
+		window:        window,
+		now:           time.Now,
+		entries:       map[dedupKey]*dedupEntry{},
+	}
+}
+
+// Event implements record.EventRecorder.
+func (d *DedupingRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if message, ok := d.admit(object, eventtype, reason, message); ok {
+		d.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf implements record.EventRecorder.
+func (d *DedupingRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	d.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf implements record.EventRecorder.
+func (d *DedupingRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if message, ok := d.admit(object, eventtype, reason, fmt.Sprintf(messageFmt, args...)); ok {
+		d.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+// Start implements the
+// [sigs.k8s.io/controller-runtime/pkg/manager.Runnable] interface, flushing
+// the counts of suppressed Events every window.
+func (d *DedupingRecorder) Start(ctx context.Context) error {
+	ticker := time.NewTicker(d.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.flush()
+		case <-ctx.Done():
+			d.flush()
+			return nil
+		}
+	}
+}
+
+// admit decides whether the Event is emitted and returns its message,
+// annotated with the number of Events suppressed since the last one.
+func (d *DedupingRecorder) admit(object runtime.Object, eventtype, reason, message string) (string, bool) {
+	key := dedupKey{reason: reason, message: message}
+	if obj, err := meta.Accessor(object); err == nil {
+		key.uid, key.namespace, key.name = obj.GetUID(), obj.GetNamespace(), obj.GetName()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	entry, ok := d.entries[key]
+	if ok && now.Sub(entry.emitted) < d.window {
+		entry.suppressed++
+		return "", false
+	}
+	if !ok {
+		entry = &dedupEntry{}
+		d.entries[key] = entry
+	}
+
+	out := suppressedMessage(message, entry.suppressed)
+	entry.object, entry.eventtype, entry.emitted, entry.suppressed = object, eventtype, now, 0
+	return out, true
+}
+
+// flush reports the Events suppressed in streams whose window expired and
+// forgets the streams that stayed quiet.
+func (d *DedupingRecorder) flush() {
+	type summary struct {
+		key   dedupKey
+		entry dedupEntry
+	}
+	var summaries []summary
+
+	d.mu.Lock()
+	now := d.now()
+	for key, entry := range d.entries {
+		if now.Sub(entry.emitted) < d.window {
+			continue
+		}
+		if entry.suppressed == 0 {
+			delete(d.entries, key)
+			continue
+		}
+		summaries = append(summaries, summary{key: key, entry: *entry})
+		entry.emitted, entry.suppressed = now, 0
+	}
+	d.mu.Unlock()
+
+	for _, s := range summaries {
+		d.EventRecorder.Event(s.entry.object, s.entry.eventtype, s.key.reason, suppressedMessage(s.key.message, s.entry.suppressed))
+	}
+}
+
+// suppressedMessage annotates the message with the number of identical
+// Events suppressed.
+func suppressedMessage(message string, suppressed int) string {
+	if suppressed == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s (%d similar events suppressed)", message, suppressed)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Event deduplication", func() {
+	var (
+		fake     *record.FakeRecorder
+		recorder *DedupingRecorder
+		now      time.Time
+		src      = (&BundleConfig{SourceName: "corp-roots", SourceNamespace: "cert-manager"}).sourceRef()
+	)
+
+	BeforeEach(func() {
+		fake = record.NewFakeRecorder(10)
+		recorder = NewDedupingRecorder(fake, 5*time.Minute)
+		now = time.Now()
+		recorder.now = func() time.Time { return now }
+	})
+
+	It("collapses repeated events and reports the count with the next one", func() {
+		recorder.Eventf(src, corev1.EventTypeWarning, ReasonDownloadFailed, "timeout after %ds", 30)
+		recorder.Eventf(src, corev1.EventTypeWarning, ReasonDownloadFailed, "timeout after %ds", 30)
+		recorder.Eventf(src, corev1.EventTypeWarning, ReasonDownloadFailed, "timeout after %ds", 30)
+		recorder.Eventf(src, corev1.EventTypeWarning, ReasonDownloadFailed, "connection refused")
+		Expect(fake.Events).To(HaveLen(2))
+		Expect(<-fake.Events).To(HaveSuffix("timeout after 30s"))
+		Expect(<-fake.Events).To(HaveSuffix("connection refused"))
+
+		now = now.Add(6 * time.Minute)
+		recorder.Eventf(src, corev1.EventTypeWarning, ReasonDownloadFailed, "timeout after %ds", 30)
+		Expect(<-fake.Events).To(HaveSuffix("timeout after 30s (2 similar events suppressed)"))
+	})
+
+	It("flushes the counts of streams once their window expired", func() {
+		recorder.Eventf(src, corev1.EventTypeWarning, ReasonDownloadFailed, "connection refused")
+		recorder.Eventf(src, corev1.EventTypeWarning, ReasonDownloadFailed, "connection refused")
+		recorder.Eventf(src, corev1.EventTypeNormal, ReasonCreated, "Created ConfigMap apps/a")
+		<-fake.Events
+		<-fake.Events
+
+		recorder.flush()
+		Expect(fake.Events).To(BeEmpty())
+
+		now = now.Add(6 * time.Minute)
+		recorder.flush()
+		Expect(fake.Events).To(HaveLen(1))
+		Expect(<-fake.Events).To(Equal("Warning DownloadFailed connection refused (1 similar events suppressed)"))
+		Expect(recorder.entries).To(HaveLen(1))
+
+		now = now.Add(6 * time.Minute)
+		recorder.flush()
+		Expect(fake.Events).To(BeEmpty())
+		Expect(recorder.entries).To(BeEmpty())
+	})
+
+	It("only suppresses identical normal events", func() {
+		recorder.Eventf(src, corev1.EventTypeNormal, ReasonCreated, "Created ConfigMap %s", "apps/a")
+		recorder.Eventf(src, corev1.EventTypeNormal, ReasonCreated, "Created ConfigMap %s", "apps/b")
+		recorder.Eventf(src, corev1.EventTypeNormal, ReasonCreated, "Created ConfigMap %s", "apps/b")
+		Expect(fake.Events).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("Source events", func() {
+	It("tells the story of the managed ConfigMaps and downloads on the source", func() {
+		ctx := context.Background()
+		files := map[string][]byte{"root.pem": newTestCAPEM("Corp Root", time.Now().AddDate(1, 0, 0))}
+		srv := newTestBundleServer(files)
+		defer srv.Close()
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       map[string]string{BundleURLKey: srv.URL, TargetNamespacesKey: "apps"},
+		}
+		events := record.NewFakeRecorder(20)
+		r := &CABundleReconciler{
+			Client: fakeclient.NewClientBuilder().WithObjects(src,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}).Build(),
+			Scheme:          clientgoscheme.Scheme,
+			Recorder:        events,
+			TargetNamespace: "cert-manager",
+			ConfigMapName:   "corp-roots",
+		}
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)}
+		reconcile := func() []string {
+			_, _ = r.Reconcile(ctx, req)
+			var recorded []string
+			for len(events.Events) > 0 {
+				recorded = append(recorded, <-events.Events)
+			}
+			return recorded
+		}
+
+		Expect(reconcile()).To(ConsistOf("Normal Created Created ConfigMap apps/root"))
+
+		files["root.pem"] = newTestCAPEM("Corp Root", time.Now().AddDate(2, 0, 0))
+		files["issuing.pem"] = newTestCAPEM("Corp Issuing", time.Now().AddDate(1, 0, 0))
+		Expect(reconcile()).To(ContainElements(
+			"Normal Updated Updated ConfigMap apps/root",
+			"Normal Created Created ConfigMap apps/issuing",
+		))
+
+		delete(files, "root.pem")
+		Expect(reconcile()).To(ConsistOf("Normal Deleted Deleted stale ConfigMap apps/root: its file is no longer served"))
+
+		srv.Close()
+		Expect(reconcile()).To(ContainElement(HavePrefix("Warning DownloadFailed Downloading bundles from " + srv.URL)))
+	})
+})
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/shanmugara/cabundle-operator/internal/notify"
+)
+
+// DefaultExpiryWarning is how long before the soonest certificate expiry
+// warnings are raised by default.
+const DefaultExpiryWarning = 30 * 24 * time.Hour
+
+// ConditionCertificateExpiring reports a distributed certificate expiring
+// within the warning period, or already expired.
+const ConditionCertificateExpiring = "CertificateExpiring"
+
+// Reasons of the Events recorded for expiring certificates.
+const (
+	ReasonCertificateExpiring = "CertificateExpiring"
+	ReasonCertificateExpired  = "CertificateExpired"
+)
+
+// soonestExpiry returns the distributed certificate expiring first, nil if
+// no file holds a certificate.
+func soonestExpiry(bundles []PEMFile) (*x509.Certificate, string) {
+	var soonest *x509.Certificate
+	var file string
+	for _, b := range bundles {
+		certs, err := ParseCertificates(b.Content)
+		if err != nil {
+			continue
+		}
+		for _, cert := range certs {
+			if soonest == nil || cert.NotAfter.Before(soonest.NotAfter) {
+				soonest, file = cert, b.Filename
+			}
+		}
+	}
+	return soonest, file
+}
+
+// checkExpiry escalates the soonest certificate expiry into the
+// CertificateExpiring condition and Warning Events, and returns when to
+// reconcile again to escalate further: when the warning period starts and
+// when the certificate expires.
+func (r *CABundleReconciler) checkExpiry(ctx context.Context, cfg *BundleConfig, bundles []PEMFile, status *BundleStatus) time.Duration {
+	cert, file := soonestExpiry(bundles)
+	if cert == nil {
+		status.SoonestExpiry = nil
+		return 0
+	}
+	notAfter := metav1.NewTime(cert.NotAfter)
+	status.SoonestExpiry = &notAfter
+
+	now := time.Now()
+	warnAt := cert.NotAfter.Add(-cfg.ExpiryWarning)
+	condition := metav1.Condition{
+		Type:    ConditionCertificateExpiring,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NotExpiring",
+		Message: fmt.Sprintf("Soonest expiry is %s on %s", cert.Subject, cert.NotAfter.UTC().Format(time.RFC3339)),
+	}
+
+	var requeue time.Duration
+	switch {
+	case !now.Before(cert.NotAfter):
+		condition.Status, condition.Reason = metav1.ConditionTrue, "Expired"
+		condition.Message = fmt.Sprintf("%s in %s expired on %s", cert.Subject, file, cert.NotAfter.UTC().Format(time.RFC3339))
+		r.eventf(cfg, corev1.EventTypeWarning, ReasonCertificateExpired, "%s", condition.Message)
+	case !now.Before(warnAt):
+		condition.Status, condition.Reason = metav1.ConditionTrue, "Expiring"
+		condition.Message = fmt.Sprintf("%s in %s expires on %s", cert.Subject, file, cert.NotAfter.UTC().Format(time.RFC3339))
+		r.eventf(cfg, corev1.EventTypeWarning, ReasonCertificateExpiring, "%s", condition.Message)
+		requeue = cert.NotAfter.Sub(now)
+	default:
+		requeue = warnAt.Sub(now)
+	}
+	if condition.Status == metav1.ConditionTrue {
+		logf.FromContext(ctx).Info("Distributed certificate expiring", "subject", cert.Subject.String(),
+			"file", file, "notAfter", cert.NotAfter)
+	}
+	// Notified only as the condition escalates, not on every sync.
+	if prev := meta.FindStatusCondition(status.Conditions, ConditionCertificateExpiring); condition.Status == metav1.ConditionTrue &&
+		(prev == nil || prev.Reason != condition.Reason) {
+		r.notify(ctx, notify.Notification{
+			Event:     notify.CertificateExpiring,
+			Severity:  notify.SeverityWarning,
+			Source:    cfg.SourceNamespace + "/" + cfg.SourceName,
+			SourceURL: cfg.sourceURL(),
+			Message:   condition.Message,
+			Contacts:  cfg.NotifyEmail,
+		})
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	if requeue > 0 {
+		// Land just past the threshold.
+		requeue += time.Second
+	}
+	return requeue
+}
+
+// sooner returns the shorter of two requeue durations, zero meaning none.
+func sooner(a, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
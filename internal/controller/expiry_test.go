@@ -0,0 +1,70 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+var _ = Describe("Certificate expiry", func() {
+	r := &CABundleReconciler{}
+	cfg := &BundleConfig{ExpiryWarning: 30 * 24 * time.Hour}
+	ctx := context.Background()
+
+	check := func(notAfter time.Time) (*BundleStatus, time.Duration) {
+		status := &BundleStatus{}
+		requeue := r.checkExpiry(ctx, cfg, []PEMFile{
+			{Filename: "root.pem", Content: newTestCAPEM("Long Lived Root", time.Now().Add(10*365*24*time.Hour))},
+			{Filename: "issuing.pem", Content: newTestCAPEM("Issuing CA", notAfter)},
+		}, status)
+		return status, requeue
+	}
+
+	It("requeues for the start of the warning period", func() {
+		notAfter := time.Now().Add(40 * 24 * time.Hour)
+		status, requeue := check(notAfter)
+		Expect(status.SoonestExpiry.Time).To(BeTemporally("~", notAfter, time.Second))
+		Expect(meta.IsStatusConditionFalse(status.Conditions, ConditionCertificateExpiring)).To(BeTrue())
+		Expect(requeue).To(BeNumerically("~", 10*24*time.Hour, time.Minute))
+	})
+
+	It("warns within the warning period and requeues for the expiry", func() {
+		status, requeue := check(time.Now().Add(24 * time.Hour))
+		cond := meta.FindStatusCondition(status.Conditions, ConditionCertificateExpiring)
+		Expect(cond.Reason).To(Equal("Expiring"))
+		Expect(cond.Message).To(ContainSubstring("CN=Issuing CA"))
+		Expect(requeue).To(BeNumerically("~", 24*time.Hour, time.Minute))
+	})
+
+	It("reports expired certificates without requeueing", func() {
+		status, requeue := check(time.Now().Add(-time.Hour))
+		Expect(meta.FindStatusCondition(status.Conditions, ConditionCertificateExpiring).Reason).To(Equal("Expired"))
+		Expect(requeue).To(BeZero())
+	})
+
+	It("picks the sooner requeue", func() {
+		Expect(sooner(0, time.Minute)).To(Equal(time.Minute))
+		Expect(sooner(time.Hour, 0)).To(Equal(time.Hour))
+		Expect(sooner(time.Hour, time.Minute)).To(Equal(time.Minute))
+	})
+})
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gocloud.dev/blob"
+	// Drivers for the s3://, gs:// and azblob:// export URL schemes.
+	_ "gocloud.dev/blob/azureblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// exportObjectName returns the object key the aggregated bundle of a source
+// is exported as. The checksum is written next to it with a .sha256 suffix.
+func exportObjectName(source string) string {
+	return source + ".pem"
+}
+
+// exportBundle uploads the aggregated PEM bundle and its SHA-256 checksum to
+// every export bucket. Bucket URLs follow the gocloud.dev conventions, e.g.
+// s3://bucket?region=us-east-1&prefix=trust/, and credentials are taken from
+// the environment the operator runs in. Buckets already holding the bundle
+// according to prev are skipped, and a failing bucket doesn't keep the
+// others from being exported to. The returned statuses record the upload to
+// each bucket, the error joins those of the failed ones.
+func exportBundle(ctx context.Context, cfg *BundleConfig, bundles []PEMFile, prev []ExportStatus) ([]ExportStatus, error) {
+	logger := logf.FromContext(ctx)
+
+	key := exportObjectName(cfg.SourceName)
+	content := aggregateBundle(cfg.SourceName, bundles).Content
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	checksum := []byte(hash + "  " + key + "\n")
+
+	var errs []error
+	exports := make([]ExportStatus, 0, len(cfg.ExportURLs))
+	for _, u := range cfg.ExportURLs {
+		export := ExportStatus{URL: u}
+		if i := slices.IndexFunc(prev, func(e ExportStatus) bool { return e.URL == u }); i >= 0 {
+			export = prev[i]
+		}
+		if export.Hash == hash && export.Error == "" {
+			logger.V(1).Info("Bundle already exported, skipping", "bucket", u, "object", key)
+			exports = append(exports, export)
+			continue
+		}
+
+		if err := exportToBucket(ctx, u, key, content, checksum); err != nil {
+			logger.Error(err, "unable to export bundle", "bucket", u)
+			export.Error = err.Error()
+			errs = append(errs, err)
+		} else {
+			now := metav1.Now()
+			export.Hash, export.Error, export.LastExportTime = hash, "", &now
+			logger.Info("Exported bundle", "bucket", u, "object", key)
+		}
+		exports = append(exports, export)
+	}
+	return exports, errors.Join(errs...)
+}
+
+// lastExportTime returns the time of the latest upload to any bucket.
+func lastExportTime(exports []ExportStatus) *metav1.Time {
+	var last *metav1.Time
+	for _, e := range exports {
+		if e.LastExportTime != nil && (last == nil || last.Before(e.LastExportTime)) {
+			last = e.LastExportTime
+		}
+	}
+	return last
+}
+
+// exportToBucket writes the bundle and its checksum into a single bucket.
+func exportToBucket(ctx context.Context, bucketURL, key string, content, checksum []byte) error {
+	bucket, err := blob.OpenBucket(ctx, bucketURL)
+	if err != nil {
+		return fmt.Errorf("opening export bucket %s: %w", bucketURL, err)
+	}
+	defer bucket.Close()
+
+	if err := bucket.WriteAll(ctx, key, content, &blob.WriterOptions{ContentType: "application/x-pem-file"}); err != nil {
+		return fmt.Errorf("exporting %s to %s: %w", key, bucketURL, err)
+	}
+	if err := bucket.WriteAll(ctx, key+".sha256", checksum, &blob.WriterOptions{ContentType: "text/plain"}); err != nil {
+		return fmt.Errorf("exporting %s.sha256 to %s: %w", key, bucketURL, err)
+	}
+	return nil
+}
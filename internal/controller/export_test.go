@@ -0,0 +1,72 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	// Driver for the file:// export URLs of the tests.
+	_ "gocloud.dev/blob/fileblob"
+)
+
+var _ = Describe("Bundle export", func() {
+	ctx := context.Background()
+
+	It("exports to every bucket, skipping those holding the bundle and continuing past failures", func() {
+		dir := GinkgoT().TempDir()
+		good := "file://" + dir
+		bad := "file://" + filepath.Join(dir, "missing")
+		cfg := &BundleConfig{SourceName: "roots", ExportURLs: []string{bad, good}}
+		bundles := []PEMFile{{Filename: "root.pem", Content: []byte("root\n")}}
+		object := filepath.Join(dir, exportObjectName("roots"))
+
+		exports, err := exportBundle(ctx, cfg, bundles, nil)
+		Expect(err).To(MatchError(ContainSubstring("missing")))
+		Expect(exports).To(HaveLen(2))
+		Expect(exports[0].Error).NotTo(BeEmpty())
+		Expect(exports[0].Hash).To(BeEmpty())
+		Expect(exports[1].Error).To(BeEmpty())
+		Expect(exports[1].Hash).NotTo(BeEmpty())
+		Expect(lastExportTime(exports)).To(Equal(exports[1].LastExportTime))
+		Expect(os.ReadFile(object)).To(Equal([]byte("root\n")))
+		Expect(os.ReadFile(object + ".sha256")).To(ContainSubstring(exports[1].Hash))
+
+		Expect(os.Remove(object)).To(Succeed())
+		again, err := exportBundle(ctx, cfg, bundles, exports)
+		Expect(err).To(HaveOccurred())
+		Expect(again[1]).To(Equal(exports[1]))
+		Expect(object).NotTo(BeAnExistingFile())
+
+		Expect(os.Mkdir(filepath.Join(dir, "missing"), 0o755)).To(Succeed())
+		again, err = exportBundle(ctx, cfg, bundles, exports)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again[0].Error).To(BeEmpty())
+		Expect(again[0].Hash).To(Equal(exports[1].Hash))
+		Expect(object).NotTo(BeAnExistingFile())
+
+		changed := []PEMFile{{Filename: "root.pem", Content: []byte("rotated\n")}}
+		again, err = exportBundle(ctx, cfg, changed, again)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again[1].Hash).NotTo(Equal(exports[1].Hash))
+		Expect(os.ReadFile(object)).To(Equal([]byte("rotated\n")))
+	})
+})
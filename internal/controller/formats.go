@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec // required by the JKS and OpenSSL hash formats
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Supported output formats.
+const (
+	FormatPEM       = "pem"
+	FormatDER       = "der"
+	FormatJKS       = "jks"
+	FormatPKCS12    = "pkcs12"
+	FormatHashedDir = "hashed-dir"
+	FormatSPIFFE    = "spiffe"
+	FormatNSS       = "nss"
+)
+
+// Keys written to the managed ConfigMaps for the non-PEM formats.
+const (
+	JKSKey          = "truststore.jks"
+	PKCS12Key       = "truststore.p12"
+	SPIFFEBundleKey = "bundle.spiffe"
+	CertdataKey     = "certdata.txt"
+)
+
+// DefaultTruststorePassword is the password used for JKS and PKCS#12
+// truststores when none is configured. It matches the JDK default.
+const DefaultTruststorePassword = "changeit"
+
+func isKnownFormat(f string) bool {
+	switch f {
+	case FormatPEM, FormatDER, FormatJKS, FormatPKCS12, FormatHashedDir, FormatSPIFFE, FormatNSS:
+		return true
+	}
+	return false
+}
+
+// ParseCertificates decodes every CERTIFICATE block in the PEM content.
+func ParseCertificates(content []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := content
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, nil
+}
+
+// renderFormats renders the bundle content in every requested format and
+// returns the resulting ConfigMap data and binaryData.
+func renderFormats(content []byte, formats []string, password string) (map[string]string, map[string][]byte, error) {
+	data := map[string]string{}
+	binaryData := map[string][]byte{}
+
+	var certs []*x509.Certificate
+	for _, f := range formats {
+		if f == FormatPEM {
+			continue
+		}
+		var err error
+		if certs, err = ParseCertificates(content); err != nil {
+			return nil, nil, err
+		}
+		break
+	}
+
+	for _, f := range formats {
+		switch f {
+		case FormatPEM:
+			data[CAKey] = string(content)
+		case FormatDER:
+			for i, c := range certs {
+				binaryData[fmt.Sprintf("ca-%d.der", i)] = c.Raw
+			}
+		case FormatJKS:
+			jks, err := encodeJKS(certs, password)
+			if err != nil {
+				return nil, nil, err
+			}
+			binaryData[JKSKey] = jks
+		case FormatPKCS12:
+			p12, err := pkcs12.Modern.WithRand(truststoreRand(content, password)).EncodeTrustStore(certs, password)
+			if err != nil {
+				return nil, nil, err
+			}
+			binaryData[PKCS12Key] = p12
+		case FormatHashedDir:
+			seen := map[string]int{}
+			for _, c := range certs {
+				h, err := subjectHash(c)
+				if err != nil {
+					return nil, nil, err
+				}
+				data[fmt.Sprintf("%s.%d", h, seen[h])] = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}))
+				seen[h]++
+			}
+		case FormatSPIFFE:
+			bundle, err := encodeSPIFFEBundle(certs)
+			if err != nil {
+				return nil, nil, err
+			}
+			data[SPIFFEBundleKey] = string(bundle)
+		case FormatNSS:
+			certdata, err := encodeCertdata(certs)
+			if err != nil {
+				return nil, nil, err
+			}
+			data[CertdataKey] = string(certdata)
+		default:
+			return nil, nil, fmt.Errorf("unknown output format %q", f)
+		}
+	}
+
+	if len(binaryData) == 0 {
+		binaryData = nil
+	}
+	return data, binaryData, nil
+}
+
+// truststoreRand returns the randomness of the PKCS#12 salts and IVs,
+// derived from the content and password. Rendering the same bundle then
+// yields the same bytes, so an unchanged bundle never changes the content
+// hash and restarts its consumers.
+func truststoreRand(content []byte, password string) *mathrand.ChaCha8 {
+	return mathrand.NewChaCha8(sha256.Sum256(append(append([]byte{}, content...), password...)))
+}
+
+// encodeJKS encodes the certificates as a Java KeyStore containing only
+// trusted certificate entries. Entries are dated by the certificate's
+// NotBefore rather than the time of rendering, keeping the output
+// deterministic like truststoreRand.
+func encodeJKS(certs []*x509.Certificate, password string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := func(v any) {
+		_ = binary.Write(&buf, binary.BigEndian, v)
+	}
+	writeUTF := func(s string) {
+		w(uint16(len(s)))
+		buf.WriteString(s)
+	}
+
+	w(uint32(0xFEEDFEED))
+	w(uint32(2))
+	w(uint32(len(certs)))
+
+	for i, c := range certs {
+		w(uint32(2)) // trusted certificate entry
+		writeUTF(fmt.Sprintf("ca-%d", i))
+		w(c.NotBefore.UnixMilli())
+		writeUTF("X.509")
+		w(uint32(len(c.Raw)))
+		buf.Write(c.Raw)
+	}
+
+	h := sha1.New() //nolint:gosec
+	for _, u := range utf16.Encode([]rune(password)) {
+		h.Write([]byte{byte(u >> 8), byte(u)})
+	}
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(buf.Bytes())
+	buf.Write(h.Sum(nil))
+
+	return buf.Bytes(), nil
+}
+
+// subjectHash computes the OpenSSL subject name hash (as printed by
+// `openssl x509 -hash`) used for c_rehash style directories.
+func subjectHash(cert *x509.Certificate) (string, error) {
+	var rdns []asn1.RawValue
+	if rest, err := asn1.Unmarshal(cert.RawSubject, &rdns); err != nil {
+		return "", err
+	} else if len(rest) > 0 {
+		return "", errors.New("trailing data after subject")
+	}
+
+	var canon []byte
+	for _, rdn := range rdns {
+		var atvs []asn1.RawValue
+		if _, err := asn1.UnmarshalWithParams(rdn.FullBytes, &atvs, "set"); err != nil {
+			return "", err
+		}
+
+		var encoded [][]byte
+		for _, atv := range atvs {
+			var a struct {
+				Type  asn1.ObjectIdentifier
+				Value asn1.RawValue
+			}
+			if _, err := asn1.Unmarshal(atv.FullBytes, &a); err != nil {
+				return "", err
+			}
+			if s, ok := decodeASN1String(a.Value); ok {
+				a.Value = asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(canonicalizeString(s))}
+			}
+			b, err := asn1.Marshal(a)
+			if err != nil {
+				return "", err
+			}
+			encoded = append(encoded, b)
+		}
+		sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+
+		set, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: bytes.Join(encoded, nil)})
+		if err != nil {
+			return "", err
+		}
+		canon = append(canon, set...)
+	}
+
+	sum := sha1.Sum(canon) //nolint:gosec
+	return fmt.Sprintf("%08x", binary.LittleEndian.Uint32(sum[:4])), nil
+}
+
+// decodeASN1String decodes the ASN.1 string types OpenSSL canonicalizes.
+func decodeASN1String(v asn1.RawValue) (string, bool) {
+	if v.Class != asn1.ClassUniversal {
+		return "", false
+	}
+	switch v.Tag {
+	case asn1.TagUTF8String, asn1.TagPrintableString, asn1.TagIA5String, 26: // VisibleString
+		return string(v.Bytes), true
+	case asn1.TagT61String:
+		r := make([]rune, len(v.Bytes))
+		for i, b := range v.Bytes {
+			r[i] = rune(b)
+		}
+		return string(r), true
+	case asn1.TagBMPString:
+		if len(v.Bytes)%2 != 0 {
+			return "", false
+		}
+		u := make([]uint16, len(v.Bytes)/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(v.Bytes[2*i:])
+		}
+		return string(utf16.Decode(u)), true
+	case 28: // UniversalString
+		if len(v.Bytes)%4 != 0 {
+			return "", false
+		}
+		r := make([]rune, len(v.Bytes)/4)
+		for i := range r {
+			r[i] = rune(binary.BigEndian.Uint32(v.Bytes[4*i:]))
+		}
+		return string(r), true
+	}
+	return "", false
+}
+
+// canonicalizeString trims and collapses ASCII whitespace and lowercases
+// ASCII letters, matching OpenSSL's name canonicalization.
+func canonicalizeString(s string) string {
+	isSpace := func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\v' || r == '\f' || r == '\r'
+	}
+
+	var b strings.Builder
+	space := false
+	for _, r := range strings.TrimFunc(s, isSpace) {
+		if isSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// newTestCAPEM returns a self-signed CA certificate in PEM form.
+func newTestCAPEM(cn string, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Corp"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+var _ = Describe("Output formats", func() {
+	It("renders every requested format from a single bundle", func() {
+		content := append(newTestCAPEM("Root CA", time.Now().Add(time.Hour)),
+			newTestCAPEM("Issuing CA", time.Now().Add(time.Hour))...)
+
+		data, binaryData, err := renderFormats(content,
+			[]string{FormatPEM, FormatDER, FormatJKS, FormatPKCS12, FormatHashedDir}, DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(data).To(HaveKeyWithValue(CAKey, string(content)))
+		Expect(binaryData).To(HaveKey("ca-0.der"))
+		Expect(binaryData).To(HaveKey("ca-1.der"))
+		Expect(binaryData[JKSKey][:4]).To(Equal([]byte{0xFE, 0xED, 0xFE, 0xED}))
+
+		certs, err := pkcs12.DecodeTrustStore(binaryData[PKCS12Key], DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs).To(HaveLen(2))
+
+		Expect(data).To(HaveLen(3))
+	})
+
+	It("renders identical output for identical input", func() {
+		content := newTestCAPEM("Root CA", time.Now().Add(time.Hour))
+		formats := []string{FormatPEM, FormatJKS, FormatPKCS12}
+
+		data1, binaryData1, err := renderFormats(content, formats, DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+		data2, binaryData2, err := renderFormats(content, formats, DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(contentHash(data1, binaryData1)).To(Equal(contentHash(data2, binaryData2)))
+		// Byte for byte, not only by hash.
+		Expect(binaryData2[JKSKey]).To(Equal(binaryData1[JKSKey]))
+		Expect(binaryData2[PKCS12Key]).To(Equal(binaryData1[PKCS12Key]))
+		Expect(data2).To(Equal(data1))
+	})
+
+	It("renders different truststores for a different password or bundle", func() {
+		content := newTestCAPEM("Root CA", time.Now().Add(time.Hour))
+		_, binaryData1, err := renderFormats(content, []string{FormatPKCS12}, DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+		_, binaryData2, err := renderFormats(content, []string{FormatPKCS12}, "rotated")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(binaryData2[PKCS12Key]).NotTo(Equal(binaryData1[PKCS12Key]))
+		certs, err := pkcs12.DecodeTrustStore(binaryData2[PKCS12Key], "rotated")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs).To(HaveLen(1))
+
+		other := append(content, newTestCAPEM("Issuing CA", time.Now().Add(time.Hour))...)
+		_, binaryData3, err := renderFormats(other, []string{FormatJKS}, DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+		_, binaryData4, err := renderFormats(content, []string{FormatJKS}, DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(binaryData3[JKSKey]).NotTo(Equal(binaryData4[JKSKey]))
+	})
+
+	It("rejects non-PEM formats for content without certificates", func() {
+		_, _, err := renderFormats([]byte("not a certificate"), []string{FormatDER}, DefaultTruststorePassword)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("renders a SPIFFE bundle a SPIFFE implementation can read back", func() {
+		content := append(newTestCAPEM("Root CA", time.Now().Add(time.Hour)),
+			newTestCAPEM("Issuing CA", time.Now().Add(time.Hour))...)
+
+		data, _, err := renderFormats(content, []string{FormatSPIFFE}, DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(HaveKey(SPIFFEBundleKey))
+
+		var bundle spiffeBundle
+		Expect(json.Unmarshal([]byte(data[SPIFFEBundleKey]), &bundle)).To(Succeed())
+		Expect(bundle.Keys).To(HaveLen(2))
+		for _, key := range bundle.Keys {
+			Expect(key.Kty).To(Equal("EC"))
+			Expect(key.Crv).To(Equal("P-256"))
+			Expect(key.X).To(HaveLen(43))
+			Expect(key.Y).To(HaveLen(43))
+		}
+
+		certs, err := parseSPIFFEBundle([]byte(data[SPIFFEBundleKey]))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs).To(HaveLen(2))
+		Expect(certs[0].Subject.CommonName).To(Equal("Root CA"))
+		Expect(certs[1].Subject.CommonName).To(Equal("Issuing CA"))
+	})
+
+	It("renders an NSS certdata.txt with unique labels", func() {
+		content := append(newTestCAPEM("Root CA", time.Now().Add(time.Hour)),
+			newTestCAPEM("Root CA", time.Now().Add(time.Hour))...)
+		certs, err := ParseCertificates(content)
+		Expect(err).NotTo(HaveOccurred())
+
+		data, _, err := renderFormats(content, []string{FormatNSS}, DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+		certdata := data[CertdataKey]
+
+		Expect(certdata).To(ContainSubstring("BEGINDATA\n"))
+		Expect(strings.Count(certdata, "CKA_CLASS CK_OBJECT_CLASS CKO_CERTIFICATE\n")).To(Equal(2))
+		Expect(strings.Count(certdata, "CKA_CLASS CK_OBJECT_CLASS CKO_NSS_TRUST\n")).To(Equal(2))
+		Expect(strings.Count(certdata, "CKA_TRUST_SERVER_AUTH CK_TRUST CKT_NSS_TRUSTED_DELEGATOR\n")).To(Equal(2))
+		Expect(certdata).To(ContainSubstring(`CKA_LABEL UTF8 "Root CA"`))
+		Expect(certdata).To(ContainSubstring(`CKA_LABEL UTF8 "Root CA 2"`))
+
+		// Decode the CKA_VALUE attributes back into the certificates.
+		var values [][]byte
+		for _, block := range strings.Split(certdata, "CKA_VALUE MULTILINE_OCTAL\n")[1:] {
+			octal, _, _ := strings.Cut(block, "END\n")
+			var der []byte
+			for _, o := range strings.Split(strings.ReplaceAll(octal, "\n", ""), `\`)[1:] {
+				v, err := strconv.ParseUint(o, 8, 8)
+				Expect(err).NotTo(HaveOccurred())
+				der = append(der, byte(v))
+			}
+			values = append(values, der)
+		}
+		Expect(values).To(Equal([][]byte{certs[0].Raw, certs[1].Raw}))
+	})
+})
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// syncFreshness holds the time of the last successful sync of every source,
+// for SyncFreshnessCheck. Sources are tracked from their first reconcile, so
+// a source that never synced is as stale as one that stopped syncing.
+type syncFreshness struct {
+	mu       sync.Mutex
+	lastSync map[types.NamespacedName]time.Time
+}
+
+// track starts tracking the source, as of t, unless it already is.
+func (f *syncFreshness) track(key types.NamespacedName, t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.lastSync == nil {
+		f.lastSync = map[types.NamespacedName]time.Time{}
+	}
+	if _, ok := f.lastSync[key]; !ok {
+		f.lastSync[key] = t
+	}
+}
+
+// synced records a successful sync of the source at t.
+func (f *syncFreshness) synced(key types.NamespacedName, t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.lastSync == nil {
+		f.lastSync = map[types.NamespacedName]time.Time{}
+	}
+	f.lastSync[key] = t
+}
+
+// forget stops tracking a source that was deleted or is no longer one.
+func (f *syncFreshness) forget(key types.NamespacedName) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.lastSync, key)
+}
+
+// stale returns the sources whose last successful sync, or since, whichever
+// is later, is older than threshold.
+func (f *syncFreshness) stale(now, since time.Time, threshold time.Duration) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var stale []string
+	for key, last := range f.lastSync {
+		if since.After(last) {
+			last = since
+		}
+		if age := now.Sub(last); age > threshold {
+			stale = append(stale, fmt.Sprintf("%s (%s ago)", key, age.Round(time.Second)))
+		}
+	}
+	slices.Sort(stale)
+	return stale
+}
+
+// forgetSource drops what is kept about a source that was deleted or is no
+// longer one: its sync freshness and certificate expiry series.
+func (r *CABundleReconciler) forgetSource(key types.NamespacedName) {
+	r.freshness.forget(key)
+	forgetCertExpiry(key.String())
+}
+
+// recordSuccessfulSync notes the time of a sync of the source that completed
+// without errors, or deliberately held its bundle back, for
+// SyncFreshnessCheck.
+func (r *CABundleReconciler) recordSuccessfulSync(key types.NamespacedName, t time.Time) {
+	r.freshness.synced(key, t)
+}
+
+// SyncFreshnessCheck returns a readiness check failing once any source hasn't
+// synced successfully for longer than threshold. Replicas that haven't been
+// elected leader don't sync and always pass; an elected replica gets
+// threshold to complete the first sync of every source.
+func (r *CABundleReconciler) SyncFreshnessCheck(threshold time.Duration, elected <-chan struct{}) healthz.Checker {
+	var once sync.Once
+	var electedAt time.Time
+
+	return func(_ *http.Request) error {
+		select {
+		case <-elected:
+		default:
+			return nil
+		}
+		once.Do(func() { electedAt = time.Now() })
+
+		if stale := r.freshness.stale(time.Now(), electedAt, threshold); len(stale) > 0 {
+			return fmt.Errorf("no successful sync within %s of %s", threshold, strings.Join(stale, ", "))
+		}
+		return nil
+	}
+}
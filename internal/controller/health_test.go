@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Sync freshness", func() {
+	corpRoots := types.NamespacedName{Namespace: "cert-manager", Name: "corp-roots"}
+	partnerRoots := types.NamespacedName{Namespace: "cert-manager", Name: "partner-roots"}
+
+	It("tracks every source on its own", func() {
+		var f syncFreshness
+		now := time.Now()
+		f.track(corpRoots, now.Add(-2*time.Hour))
+		f.track(partnerRoots, now.Add(-2*time.Hour))
+		f.synced(partnerRoots, now.Add(-time.Minute))
+		f.track(partnerRoots, now.Add(-2*time.Hour))
+
+		Expect(f.stale(now, time.Time{}, time.Hour)).To(Equal([]string{"cert-manager/corp-roots (2h0m0s ago)"}),
+			"a healthy source doesn't mask a stale one")
+		Expect(f.stale(now, now.Add(-time.Minute), time.Hour)).To(BeEmpty(), "sources get the threshold after the election")
+		f.forget(corpRoots)
+		Expect(f.stale(now, time.Time{}, time.Hour)).To(BeEmpty())
+	})
+
+	It("fails readiness once a source is stale, only on the leader", func() {
+		r := &CABundleReconciler{}
+		elected := make(chan struct{})
+		check := r.SyncFreshnessCheck(time.Hour, elected)
+		r.freshness.track(corpRoots, time.Now().Add(-2*time.Hour))
+		Expect(check(nil)).To(Succeed())
+
+		close(elected)
+		Expect(check(nil)).To(Succeed(), "the first syncs get the threshold after the election")
+
+		check = r.SyncFreshnessCheck(0, elected)
+		r.recordSuccessfulSync(partnerRoots, time.Now())
+		Expect(check(nil)).To(MatchError(And(ContainSubstring("cert-manager/corp-roots"), ContainSubstring("cert-manager/partner-roots"))))
+	})
+
+	It("counts a deliberately held back change as a successful sync", func() {
+		src := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: corpRoots.Name, Namespace: corpRoots.Namespace}}
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().WithObjects(src).Build(), Scheme: clientgoscheme.Scheme,
+			Recorder: record.NewFakeRecorder(10)}
+		cfg := &BundleConfig{SourceName: src.Name, SourceNamespace: src.Namespace}
+		r.freshness.track(corpRoots, time.Now().Add(-2*time.Hour))
+
+		_, err := r.deferChange(context.Background(), src, cfg, "v2", &BundleStatus{RolledOutHash: "v1"}, time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.freshness.stale(time.Now(), time.Time{}, time.Hour)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Actions of a ConfigMapChange.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// ConfigMapChange is a pending change to a managed ConfigMap, see Diff.
+type ConfigMapChange struct {
+	Action    string
+	Namespace string
+	Name      string
+	// Trust summarizes the certificates added and removed by an update.
+	Trust   string
+	Live    *corev1.ConfigMap
+	Desired *corev1.ConfigMap
+
+	trust trustDiff
+}
+
+// DistributedCertificate is a certificate found in a managed ConfigMap, see
+// Certificates.
+type DistributedCertificate struct {
+	Namespace   string
+	ConfigMap   string
+	Subject     string
+	Issuer      string
+	NotAfter    time.Time
+	Fingerprint string
+}
+
+// Status returns the recorded status of a source, empty if it was never
+// synced.
+func (r *CABundleReconciler) Status(ctx context.Context, src *corev1.ConfigMap) (*BundleStatus, error) {
+	return r.getStatus(ctx, src)
+}
+
+// Diff downloads the bundle of a source and compares the ConfigMaps a sync
+// would write to the live ones in the local cluster, without writing
+// anything. Changes are sorted by namespace and name.
+func (r *CABundleReconciler) Diff(ctx context.Context, src *corev1.ConfigMap) ([]ConfigMapChange, error) {
+	cfg, err := r.parseBundleConfig(src)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := r.resolveTargetNamespaces(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	index, err := listBundles(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	bundles, failed, err := downloadBundles(ctx, cfg, index)
+	if err != nil {
+		return nil, err
+	}
+	// As in a sync, files that failed to download keep their ConfigMaps.
+	cfg.RetainedFiles = failedFileNames(failed)
+	if len(failed) > 0 && cfg.AggregateName != "" {
+		cfg.RetainedFiles = append(cfg.RetainedFiles, cfg.AggregateName)
+	} else if cfg.AggregateName != "" {
+		bundles = append(bundles, aggregateBundle(cfg.AggregateName, bundles))
+	}
+	return r.diffTargets(ctx, cfg, namespaces, bundles)
+}
+
+// diffTargets compares the ConfigMaps the bundle files render to in the
+// target namespaces to the live ones, see Diff.
+func (r *CABundleReconciler) diffTargets(ctx context.Context, cfg *BundleConfig, namespaces []string, bundles []PEMFile) ([]ConfigMapChange, error) {
+	var changes []ConfigMapChange
+	for _, ns := range namespaces {
+		tcfg := cfg.ForTarget("", ns)
+		keep := map[string]bool{}
+		for _, name := range cfg.RetainedFiles {
+			keep[r.configMapName(name, tcfg)] = true
+		}
+		resolved, _, err := r.resolveConflicts(bundles, tcfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range resolved {
+			desired, err := r.desiredConfigMap(ns, b, tcfg)
+			if err != nil {
+				return nil, err
+			}
+			keep[desired.Name] = true
+			change, err := r.diffConfigMap(ctx, desired, tcfg)
+			if err != nil {
+				return nil, err
+			}
+			if change != nil {
+				changes = append(changes, *change)
+			}
+		}
+		live, err := r.sourceConfigMaps(ctx, cfg, client.InNamespace(ns))
+		if err != nil {
+			return nil, err
+		}
+		for i := range live {
+			if !keep[live[i].Name] {
+				changes = append(changes, ConfigMapChange{Action: ActionDelete, Namespace: ns, Name: live[i].Name, Live: &live[i]})
+			}
+		}
+	}
+
+	// Namespaces no longer targeted lose the bundle altogether.
+	live, err := r.sourceConfigMaps(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range live {
+		if !slices.Contains(namespaces, live[i].Namespace) {
+			changes = append(changes, ConfigMapChange{Action: ActionDelete, Namespace: live[i].Namespace, Name: live[i].Name, Live: &live[i]})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Namespace != changes[j].Namespace {
+			return changes[i].Namespace < changes[j].Namespace
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+// diffConfigMap compares a rendered ConfigMap to the live one, returning nil
+// if it is up to date.
+func (r *CABundleReconciler) diffConfigMap(ctx context.Context, desired *corev1.ConfigMap, cfg *BundleConfig) (*ConfigMapChange, error) {
+	change := &ConfigMapChange{Namespace: desired.Namespace, Name: desired.Name, Desired: desired}
+	live := &corev1.ConfigMap{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), live)
+	if apierrors.IsNotFound(err) {
+		change.Action = ActionCreate
+		change.trust = diffCertificates(nil, configMapCertificates(desired))
+		change.Trust = change.trust.String()
+		return change, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, binaryData := ownedContent(live, cfg)
+	changed := !equality.Semantic.DeepEqual(data, desired.Data) ||
+		!equality.Semantic.DeepEqual(binaryData, desired.BinaryData)
+	if !changed && hasAll(live.Labels, desired.Labels) && hasAll(live.Annotations, desired.Annotations) {
+		return nil, nil
+	}
+	change.Action = ActionUpdate
+	change.Live = live
+	if changed {
+		change.trust = diffCertificates(configMapCertificates(live), configMapCertificates(desired))
+		change.Trust = change.trust.String()
+	}
+	return change, nil
+}
+
+// Certificates lists the certificates distributed by a source in the local
+// cluster, sorted by namespace, ConfigMap and expiry.
+func (r *CABundleReconciler) Certificates(ctx context.Context, src *corev1.ConfigMap) ([]DistributedCertificate, error) {
+	cfg, err := r.parseBundleConfig(src)
+	if err != nil {
+		return nil, err
+	}
+	live, err := r.sourceConfigMaps(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []DistributedCertificate
+	for i := range live {
+		for _, c := range configMapCertificates(&live[i]) {
+			sum := sha256.Sum256(c.Raw)
+			certs = append(certs, DistributedCertificate{
+				Namespace:   live[i].Namespace,
+				ConfigMap:   live[i].Name,
+				Subject:     c.Subject.String(),
+				Issuer:      c.Issuer.String(),
+				NotAfter:    c.NotAfter,
+				Fingerprint: hex.EncodeToString(sum[:]),
+			})
+		}
+	}
+	sort.SliceStable(certs, func(i, j int) bool {
+		if certs[i].Namespace != certs[j].Namespace {
+			return certs[i].Namespace < certs[j].Namespace
+		}
+		if certs[i].ConfigMap != certs[j].ConfigMap {
+			return certs[i].ConfigMap < certs[j].ConfigMap
+		}
+		return certs[i].NotAfter.Before(certs[j].NotAfter)
+	})
+	return certs, nil
+}
+
+// sourceConfigMaps lists the managed ConfigMaps of a source.
+func (r *CABundleReconciler) sourceConfigMaps(ctx context.Context, cfg *BundleConfig, opts ...client.ListOption) ([]corev1.ConfigMap, error) {
+	cmList := &corev1.ConfigMapList{}
+	opts = append(opts, client.MatchingLabels(managedLabels(cfg)))
+	if err := r.List(ctx, cmList, opts...); err != nil {
+		return nil, err
+	}
+	return cmList.Items, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Inspecting sources", func() {
+	ctx := context.Background()
+	root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+
+	var srv *httptest.Server
+	BeforeEach(func() {
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/" {
+				_, _ = w.Write([]byte(`<html><body><a href="root.pem">root.pem</a></body></html>`))
+				return
+			}
+			_, _ = w.Write(root)
+		}))
+		DeferCleanup(srv.Close)
+	})
+
+	source := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       map[string]string{BundleURLKey: srv.URL, TargetNamespacesKey: "apps"},
+		}
+	}
+
+	It("diffs the rendered ConfigMaps against the live ones without writing", func() {
+		src := source()
+		cfg, err := ParseBundleConfig(src)
+		Expect(err).NotTo(HaveOccurred())
+		r := &CABundleReconciler{TargetNamespace: "cert-manager"}
+		legacy, err := r.desiredConfigMap("apps", PEMFile{Filename: "legacy.pem", Content: root}, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		r.Client = fake.NewClientBuilder().WithObjects(src, legacy,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}).Build()
+
+		changes, err := r.Diff(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(HaveLen(2))
+		Expect(changes[0].Action).To(Equal(ActionDelete))
+		Expect(changes[0].Name).To(Equal("legacy"))
+		Expect(changes[1].Action).To(Equal(ActionCreate))
+		Expect(changes[1].Name).To(Equal("root"))
+		Expect(changes[1].Trust).To(ContainSubstring("added CN=Corp Root"))
+
+		// Once synced there is nothing left to change.
+		bundles := []PEMFile{{Filename: "root.pem", Content: root}}
+		Expect(r.syncNamespace(ctx, "apps", bundles, cfg)).To(Succeed())
+		changes, err = r.Diff(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+
+		certs, err := r.Certificates(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs).To(HaveLen(1))
+		Expect(certs[0].ConfigMap).To(Equal("root"))
+		Expect(certs[0].Subject).To(ContainSubstring("CN=Corp Root"))
+	})
+})
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InventoryEntry is a certificate distributed by the operator, along with
+// everywhere it is distributed, see Inventory.
+type InventoryEntry struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	Fingerprint string    `json:"sha256"`
+	NotAfter    time.Time `json:"notAfter"`
+	// Sources are the sources distributing the certificate, as
+	// namespace/name.
+	Sources []string `json:"sources"`
+	// Namespaces and ConfigMaps are where the certificate is distributed,
+	// the ConfigMaps as namespace/name.
+	Namespaces []string `json:"namespaces"`
+	ConfigMaps []string `json:"configMaps"`
+}
+
+// Inventory lists every certificate distributed by the sources in the local
+// cluster once, for audits and compliance reviews. Entries are sorted by
+// expiry and subject.
+func (r *CABundleReconciler) Inventory(ctx context.Context, sources []corev1.ConfigMap) ([]InventoryEntry, error) {
+	entries := map[string]*InventoryEntry{}
+	for i := range sources {
+		certs, err := r.Certificates(ctx, &sources[i])
+		if err != nil {
+			return nil, err
+		}
+		source := client.ObjectKeyFromObject(&sources[i]).String()
+		for _, c := range certs {
+			e, ok := entries[c.Fingerprint]
+			if !ok {
+				e = &InventoryEntry{Subject: c.Subject, Issuer: c.Issuer, Fingerprint: c.Fingerprint, NotAfter: c.NotAfter}
+				entries[c.Fingerprint] = e
+			}
+			e.Sources = appendUnique(e.Sources, source)
+			e.Namespaces = appendUnique(e.Namespaces, c.Namespace)
+			e.ConfigMaps = appendUnique(e.ConfigMaps, c.Namespace+"/"+c.ConfigMap)
+		}
+	}
+
+	inventory := make([]InventoryEntry, 0, len(entries))
+	for _, e := range entries {
+		sort.Strings(e.Sources)
+		sort.Strings(e.Namespaces)
+		sort.Strings(e.ConfigMaps)
+		inventory = append(inventory, *e)
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		if !inventory[i].NotAfter.Equal(inventory[j].NotAfter) {
+			return inventory[i].NotAfter.Before(inventory[j].NotAfter)
+		}
+		if inventory[i].Subject != inventory[j].Subject {
+			return inventory[i].Subject < inventory[j].Subject
+		}
+		return inventory[i].Fingerprint < inventory[j].Fingerprint
+	})
+	return inventory, nil
+}
+
+// appendUnique appends s unless the list holds it already.
+func appendUnique(list []string, s string) []string {
+	if slices.Contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Trust inventory", func() {
+	ctx := context.Background()
+
+	It("lists every distributed certificate once with everywhere it is distributed", func() {
+		root := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(48*time.Hour))}
+		partner := PEMFile{Filename: "partner.pem", Content: newTestCAPEM("Partner Root", time.Now().Add(24*time.Hour))}
+		source := func(name string) corev1.ConfigMap {
+			return corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "pki"},
+				Data:       map[string]string{BundleURLKey: "https://pki.example.com/" + name + "/"},
+			}
+		}
+		sources := []corev1.ConfigMap{source("corp-roots"), source("partner-roots")}
+
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().Build()}
+		corp, err := ParseBundleConfig(&sources[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{root}, corp)).To(Succeed())
+		Expect(r.syncNamespace(ctx, "web", []PEMFile{root}, corp)).To(Succeed())
+		partners, err := ParseBundleConfig(&sources[1])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.syncNamespace(ctx, "partners", []PEMFile{partner, {Filename: "corp.pem", Content: root.Content}}, partners)).To(Succeed())
+
+		inventory, err := r.Inventory(ctx, sources)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inventory).To(HaveLen(2))
+
+		Expect(inventory[0].Subject).To(ContainSubstring("CN=Partner Root"))
+		Expect(inventory[0].Issuer).To(Equal(inventory[0].Subject))
+		Expect(inventory[0].Sources).To(Equal([]string{"pki/partner-roots"}))
+
+		Expect(inventory[1].Subject).To(ContainSubstring("CN=Corp Root"))
+		Expect(inventory[1].Fingerprint).To(HaveLen(64))
+		Expect(inventory[1].Sources).To(Equal([]string{"pki/corp-roots", "pki/partner-roots"}))
+		Expect(inventory[1].Namespaces).To(Equal([]string{"apps", "partners", "web"}))
+		Expect(inventory[1].ConfigMaps).To(Equal([]string{"apps/root", "partners/corp", "web/root"}))
+	})
+})
@@ -0,0 +1,52 @@
+package controller
+
+import "sync"
+
+// keyedLocks serializes work per key while work on different keys runs in
+// parallel. Locks are dropped once no one holds or waits for them.
+type keyedLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks key and returns the function unlocking it.
+func (k *keyedLocks) lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = map[string]*keyedLock{}
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
+	}
+}
+
+// lockSourceURL serializes downloads from a URL shared by several sources,
+// so the later ones revalidate the content the first one cached.
+func (r *CABundleReconciler) lockSourceURL(url string) func() {
+	return r.urlLocks.lock(url)
+}
+
+// lockNamespace serializes writes to the managed ConfigMaps of a namespace,
+// the local cluster's if cluster is empty.
+func (r *CABundleReconciler) lockNamespace(cluster, namespace string) func() {
+	return r.namespaceLocks.lock(cluster + "/" + namespace)
+}
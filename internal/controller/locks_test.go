@@ -0,0 +1,51 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Keyed locks", func() {
+	It("serializes work per key and drops released locks", func() {
+		var locks keyedLocks
+		var running, peak atomic.Int32
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock := locks.lock("cert-manager")
+				defer unlock()
+				peak.Store(max(peak.Load(), running.Add(1)))
+				running.Add(-1)
+			}()
+		}
+		wg.Wait()
+		Expect(peak.Load()).To(Equal(int32(1)))
+		Expect(locks.locks).To(BeEmpty())
+
+		// Other keys don't wait.
+		unlock := locks.lock("a")
+		locks.lock("b")()
+		unlock()
+	})
+})
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// LogVerbosityAnnotation on a source ConfigMap raises the log verbosity of
+// its reconciles to the given V-level, e.g. "2", without turning on debug
+// logging for every source.
+const LogVerbosityAnnotation = "cabundle.io/log-verbosity"
+
+// verboseLoggers caches the loggers built by CABundleReconciler.VerboseLogger
+// per V-level.
+type verboseLoggers struct {
+	mu      sync.Mutex
+	loggers map[int]logr.Logger
+}
+
+// sourceLogger returns the logger for reconciles of the source if it requests
+// a raised verbosity.
+func (r *CABundleReconciler) sourceLogger(src *corev1.ConfigMap) (logr.Logger, bool) {
+	if r.VerboseLogger == nil {
+		return logr.Logger{}, false
+	}
+	v, err := strconv.Atoi(src.Annotations[LogVerbosityAnnotation])
+	if err != nil || v <= 0 {
+		return logr.Logger{}, false
+	}
+
+	r.verbose.mu.Lock()
+	defer r.verbose.mu.Unlock()
+	if r.verbose.loggers == nil {
+		r.verbose.loggers = map[int]logr.Logger{}
+	}
+	logger, ok := r.verbose.loggers[v]
+	if !ok {
+		logger = r.VerboseLogger(v)
+		r.verbose.loggers[v] = logger
+	}
+	return logger, true
+}
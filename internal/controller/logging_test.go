@@ -0,0 +1,110 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Per-source log verbosity", func() {
+	ctx := context.Background()
+
+	var (
+		mu      sync.Mutex
+		lines   []string
+		created []int
+		r       *CABundleReconciler
+		srv     *httptest.Server
+	)
+
+	source := func(name, verbosity string) *corev1.ConfigMap {
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cert-manager", Labels: map[string]string{BundleSourceLabel: "true"}},
+			Data:       map[string]string{BundleURLKey: srv.URL, TargetNamespacesKey: name},
+		}
+		if verbosity != "" {
+			src.Annotations = map[string]string{LogVerbosityAnnotation: verbosity}
+		}
+		return src
+	}
+	reconcile := func(src *corev1.ConfigMap) []string {
+		mu.Lock()
+		lines = nil
+		mu.Unlock()
+		Expect(r.Create(ctx, src)).To(Succeed())
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)})
+		Expect(err).NotTo(HaveOccurred())
+		mu.Lock()
+		defer mu.Unlock()
+		return lines
+	}
+
+	BeforeEach(func() {
+		srv = newTestBundleServer(map[string][]byte{"root.pem": newTestCAPEM("Corp Root", time.Now().AddDate(1, 0, 0))})
+		DeferCleanup(srv.Close)
+		created = nil
+		r = &CABundleReconciler{
+			Client:          fake.NewClientBuilder().Build(),
+			Scheme:          clientgoscheme.Scheme,
+			TargetNamespace: "cert-manager",
+			VerboseLogger: func(v int) logr.Logger {
+				created = append(created, v)
+				return funcr.New(func(_, args string) {
+					mu.Lock()
+					defer mu.Unlock()
+					lines = append(lines, args)
+				}, funcr.Options{Verbosity: v})
+			},
+		}
+	})
+
+	It("logs the debug lines of an annotated source only", func() {
+		verbose := reconcile(source("loud-roots", "1"))
+		Expect(verbose).To(ContainElement(And(
+			ContainSubstring(`"msg"="Resolved target namespaces"`),
+			ContainSubstring(`"bundle"="cert-manager/loud-roots"`),
+		)))
+		Expect(verbose).To(ContainElement(ContainSubstring(`"msg"="Raised log verbosity for source"`)))
+
+		Expect(reconcile(source("quiet-roots", ""))).To(BeEmpty())
+		Expect(reconcile(source("odd-roots", "loud"))).To(BeEmpty())
+		Expect(reconcile(source("muted-roots", "0"))).To(BeEmpty())
+	})
+
+	It("logs up to the requested level and reuses the logger of a level", func() {
+		downloaded := ContainElement(ContainSubstring(`"msg"="Downloaded file"`))
+		Expect(reconcile(source("loud-roots", "1"))).NotTo(downloaded)
+		Expect(reconcile(source("louder-roots", "2"))).To(downloaded)
+		reconcile(source("other-roots", "1"))
+		Expect(created).To(Equal([]int{1, 2}))
+	})
+})
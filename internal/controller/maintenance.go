@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ConditionChangeFrozen reports that a maintenance window holds back changes
+// to the managed ConfigMaps.
+const ConditionChangeFrozen = "ChangeFrozen"
+
+// ReasonChangeDeferred is recorded when a changed bundle is held back by a
+// maintenance window.
+const ReasonChangeDeferred = "ChangeDeferred"
+
+// maxWindowFires bounds the cron fires considered when extending an active
+// window whose occurrences overlap.
+const maxWindowFires = 1000
+
+// MaintenanceWindow is a change freeze during which the managed ConfigMaps
+// are left untouched, declared as a YAML list:
+//
+//	maintenance_windows: |
+//	  # Every weekend, Friday 18:00 to Monday 06:00.
+//	  - schedule: "0 18 * * 5"
+//	    duration: 60h
+//	  - start: "2025-12-20T00:00:00Z"
+//	    end: "2026-01-05T00:00:00Z"
+type MaintenanceWindow struct {
+	// Schedule is a cron expression the window opens at, staying open for
+	// Duration.
+	Schedule string `json:"schedule,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	// Start and End declare a one-off window.
+	Start *metav1.Time `json:"start,omitempty"`
+	End   *metav1.Time `json:"end,omitempty"`
+
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+// PendingChange is a bundle version detected during a maintenance window and
+// held back until it closes.
+type PendingChange struct {
+	Hash       string      `json:"hash"`
+	DetectedAt metav1.Time `json:"detectedAt"`
+	Until      metav1.Time `json:"until"`
+}
+
+// parse validates the window and prepares its schedule.
+func (w *MaintenanceWindow) parse() error {
+	switch {
+	case w.Schedule != "" && (w.Start != nil || w.End != nil):
+		return fmt.Errorf("schedule and start/end are mutually exclusive")
+	case w.Schedule != "":
+		s, err := cron.ParseStandard(w.Schedule)
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q: %w", w.Schedule, err)
+		}
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q", w.Duration)
+		}
+		w.schedule, w.duration = s, d
+	case w.Start != nil && w.End != nil:
+		if !w.End.After(w.Start.Time) {
+			return fmt.Errorf("end must be after start")
+		}
+	default:
+		return fmt.Errorf("either schedule and duration or start and end are required")
+	}
+	return nil
+}
+
+// activeUntil returns when the window closes if it is open at now.
+func (w *MaintenanceWindow) activeUntil(now time.Time) (time.Time, bool) {
+	if w.schedule == nil {
+		if !now.Before(w.Start.Time) && now.Before(w.End.Time) {
+			return w.End.Time, true
+		}
+		return time.Time{}, false
+	}
+
+	start := w.schedule.Next(now.Add(-w.duration))
+	if start.After(now) {
+		return time.Time{}, false
+	}
+	end := start.Add(w.duration)
+	for i, next := 0, w.schedule.Next(start); i < maxWindowFires && next.Before(end); i, next = i+1, w.schedule.Next(next) {
+		end = next.Add(w.duration)
+	}
+	return end, true
+}
+
+// frozenUntil returns when the last maintenance window open at now closes.
+func (cfg *BundleConfig) frozenUntil(now time.Time) (time.Time, bool) {
+	var until time.Time
+	for i := range cfg.MaintenanceWindows {
+		if end, ok := cfg.MaintenanceWindows[i].activeUntil(now); ok && end.After(until) {
+			until = end
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// deferChange records the bundle version held back by a maintenance window
+// and requeues the source for when the window closes.
+func (r *CABundleReconciler) deferChange(ctx context.Context, src *corev1.ConfigMap, cfg *BundleConfig, hash string, prev *BundleStatus, until time.Time) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	status := *prev
+	status.Conditions = slices.Clone(prev.Conditions)
+	status.PendingChange = nil
+	message := fmt.Sprintf("Maintenance window until %s, no pending change", until.UTC().Format(time.RFC3339))
+	if hash != prev.RolledOutHash {
+		pending := &PendingChange{Hash: hash, DetectedAt: metav1.Now(), Until: metav1.NewTime(until)}
+		if prev.PendingChange != nil && prev.PendingChange.Hash == hash {
+			pending.DetectedAt = prev.PendingChange.DetectedAt
+		} else {
+			logger.Info("Deferring bundle change until the maintenance window closes", "hash", hash, "until", until)
+			r.eventf(cfg, corev1.EventTypeNormal, ReasonChangeDeferred, "Bundle change %s deferred until %s", shortHash(hash), until.UTC().Format(time.RFC3339))
+		}
+		status.PendingChange = pending
+		message = fmt.Sprintf("Maintenance window until %s, change %s pending", until.UTC().Format(time.RFC3339), shortHash(hash))
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    ConditionChangeFrozen,
+		Status:  metav1.ConditionTrue,
+		Reason:  "MaintenanceWindow",
+		Message: message,
+	})
+	meta.SetStatusCondition(&status.Conditions, sourceReachableCondition(nil))
+
+	requeueAfter := time.Until(until) + time.Second
+	status.NextSyncTime = r.nextSyncTime(client.ObjectKeyFromObject(src), requeueAfter)
+	if err := r.updateStatus(ctx, src, &status); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.recordSuccessfulSync(client.ObjectKeyFromObject(src), time.Now())
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// changeFrozenCondition returns the ChangeFrozen condition outside of a
+// maintenance window.
+func changeFrozenCondition() metav1.Condition {
+	return metav1.Condition{
+		Type:    ConditionChangeFrozen,
+		Status:  metav1.ConditionFalse,
+		Reason:  "OutsideMaintenanceWindow",
+		Message: "Changes are applied",
+	}
+}
+
+// shortHash abbreviates a bundle hash for messages.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Maintenance windows", func() {
+	parse := func(v string) (*BundleConfig, error) {
+		return ParseBundleConfig(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data: map[string]string{
+				BundleURLKey:          "https://pki.example.com/certs/",
+				MaintenanceWindowsKey: v,
+			},
+		})
+	}
+	at := func(v string) time.Time {
+		t, err := time.Parse(time.RFC3339, v)
+		Expect(err).NotTo(HaveOccurred())
+		return t
+	}
+
+	It("freezes changes during recurring windows", func() {
+		cfg, err := parse(`
+- schedule: "CRON_TZ=UTC 0 18 * * 5"
+  duration: 60h
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Friday 2025-06-06 18:00 UTC to Monday 2025-06-09 06:00 UTC.
+		_, frozen := cfg.frozenUntil(at("2025-06-06T17:59:00Z"))
+		Expect(frozen).To(BeFalse())
+		until, frozen := cfg.frozenUntil(at("2025-06-08T12:00:00Z"))
+		Expect(frozen).To(BeTrue())
+		Expect(until.Sub(at("2025-06-06T18:00:00Z"))).To(Equal(60 * time.Hour))
+		_, frozen = cfg.frozenUntil(at("2025-06-09T18:00:00Z"))
+		Expect(frozen).To(BeFalse())
+	})
+
+	It("freezes changes during one-off windows", func() {
+		cfg, err := parse(`
+- start: "2025-12-20T00:00:00Z"
+  end: "2026-01-05T00:00:00Z"
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		until, frozen := cfg.frozenUntil(at("2025-12-24T00:00:00Z"))
+		Expect(frozen).To(BeTrue())
+		Expect(until).To(BeTemporally("==", at("2026-01-05T00:00:00Z")))
+		_, frozen = cfg.frozenUntil(at("2026-01-05T00:00:00Z"))
+		Expect(frozen).To(BeFalse())
+	})
+
+	It("rejects incomplete windows", func() {
+		_, err := parse(`- schedule: "0 18 * * 5"`)
+		Expect(err).To(HaveOccurred())
+		_, err = parse(`- start: "2025-12-20T00:00:00Z"`)
+		Expect(err).To(HaveOccurred())
+		_, err = parse(`- start: "2026-01-05T00:00:00Z"
+  end: "2025-12-20T00:00:00Z"`)
+		Expect(err).To(HaveOccurred())
+	})
+})
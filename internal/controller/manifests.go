@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Manifests returns the managed ConfigMaps of a source in the local cluster as
+// manifests to apply elsewhere, e.g. for backups, reviews or seeding
+// air-gapped clusters: the fields set by the API server are cleared, labels
+// and annotations kept so the operator adopts the ConfigMaps where it runs.
+// ConfigMaps are sorted by namespace and name.
+func (r *CABundleReconciler) Manifests(ctx context.Context, src *corev1.ConfigMap) ([]corev1.ConfigMap, error) {
+	cfg, err := ParseBundleConfig(src)
+	if err != nil {
+		return nil, err
+	}
+	live, err := r.sourceConfigMaps(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]corev1.ConfigMap, 0, len(live))
+	for i := range live {
+		out = append(out, corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        live[i].Name,
+				Namespace:   live[i].Namespace,
+				Labels:      live[i].Labels,
+				Annotations: live[i].Annotations,
+			},
+			Data:       live[i].Data,
+			BinaryData: live[i].BinaryData,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// WriteManifests writes the ConfigMaps as a single multi-document YAML
+// stream.
+func WriteManifests(w io.Writer, cms []corev1.ConfigMap) error {
+	for i := range cms {
+		out, err := yaml.Marshal(&cms[i])
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
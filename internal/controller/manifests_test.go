@@ -0,0 +1,70 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("Exporting manifests", func() {
+	ctx := context.Background()
+	root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+
+	It("writes the managed ConfigMaps without server fields as multi-document YAML", func() {
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       map[string]string{BundleURLKey: "https://pki.example.com/", TargetNamespacesKey: "apps,web"},
+		}
+		cfg, err := ParseBundleConfig(src)
+		Expect(err).NotTo(HaveOccurred())
+		r := &CABundleReconciler{TargetNamespace: "cert-manager"}
+		r.Client = fake.NewClientBuilder().WithObjects(src).Build()
+		bundles := []PEMFile{{Filename: "root.pem", Content: root}}
+		Expect(r.syncNamespace(ctx, "web", bundles, cfg.ForTarget("", "web"))).To(Succeed())
+		Expect(r.syncNamespace(ctx, "apps", bundles, cfg.ForTarget("", "apps"))).To(Succeed())
+
+		cms, err := r.Manifests(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cms).To(HaveLen(2))
+		Expect(cms[0].Namespace).To(Equal("apps"))
+		Expect(cms[1].Namespace).To(Equal("web"))
+		Expect(cms[0].ResourceVersion).To(BeEmpty())
+		Expect(cms[0].Labels).To(HaveKeyWithValue(SourceLabel, "corp-roots"))
+
+		var buf bytes.Buffer
+		Expect(WriteManifests(&buf, cms)).To(Succeed())
+		docs := strings.Split("\n"+buf.String(), "\n---\n")
+		Expect(docs).To(HaveLen(3))
+		var cm corev1.ConfigMap
+		Expect(yaml.UnmarshalStrict([]byte(docs[2]), &cm)).To(Succeed())
+		Expect(cm.Kind).To(Equal("ConfigMap"))
+		Expect(cm.Namespace).To(Equal("web"))
+		Expect(cm.Data).To(Equal(cms[1].Data))
+		Expect(buf.String()).NotTo(ContainSubstring("resourceVersion"))
+	})
+})
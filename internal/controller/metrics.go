@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// certExpiry exposes the NotAfter of every distributed certificate so
+	// upcoming expirations can be alerted on. A certificate distributed by
+	// several sources under the same bundle name is a single series.
+	certExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cabundle_cert_expiry_timestamp",
+		Help: "Expiry of a distributed certificate in seconds since the epoch.",
+	}, []string{"subject", "fingerprint", "bundle"})
+
+	// cleanupDeleted counts managed ConfigMaps deleted by cleanup, by why
+	// they were deleted.
+	cleanupDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cabundle_cleanup_deleted_total",
+		Help: "Managed ConfigMaps deleted by cleanup.",
+	}, []string{"source", "reason"})
+
+	// cleanupCandidates summarizes the last cleanup pass of each source: the
+	// managed ConfigMaps it found to be removed.
+	cleanupCandidates = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cabundle_cleanup_candidates",
+		Help: "Managed ConfigMaps found to be removed by the last cleanup pass.",
+	}, []string{"source", "reason"})
+
+	// nextSync exposes when each source is planned to be synced next.
+	nextSync = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cabundle_next_sync_timestamp",
+		Help: "Next planned sync of a source in seconds since the epoch.",
+	}, []string{"source"})
+
+	// reconcileErrors counts failed reconciles retried on the error backoff,
+	// which the controller's own error metric doesn't see.
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cabundle_reconcile_errors_total",
+		Help: "Failed reconciles of a source retried on the error backoff.",
+	}, []string{"source"})
+
+	// certExpiryMu serializes replacing the expiry series of a source, so
+	// concurrent syncs don't interleave deleting and recording them.
+	// certExpirySources holds the series recorded for each source, so a
+	// series is only deleted once no source distributes it.
+	certExpiryMu      sync.Mutex
+	certExpirySources = map[string]map[expirySeries]float64{}
+)
+
+// expirySeries are the labels of a certExpiry series.
+type expirySeries struct {
+	subject, fingerprint, bundle string
+}
+
+func init() {
+	metrics.Registry.MustRegister(certExpiry, cleanupDeleted, cleanupCandidates, nextSync, reconcileErrors)
+}
+
+// recordCertExpiry replaces the expiry series of the source with those of the
+// downloaded files. Files that aren't valid PEM are skipped, they are reported
+// when rendered.
+func (r *CABundleReconciler) recordCertExpiry(cfg *BundleConfig, bundles []PEMFile) {
+	recorded := map[expirySeries]float64{}
+	for _, b := range bundles {
+		certs, err := ParseCertificates(b.Content)
+		if err != nil {
+			continue
+		}
+		name := r.reName(b.Filename, cfg.fileSuffixes())
+		for _, cert := range certs {
+			sum := sha256.Sum256(cert.Raw)
+			recorded[expirySeries{cert.Subject.String(), hex.EncodeToString(sum[:]), name}] = float64(cert.NotAfter.Unix())
+		}
+	}
+	setCertExpiry(cfg.SourceNamespace+"/"+cfg.SourceName, recorded)
+}
+
+// forgetCertExpiry drops the expiry series of a source that was deleted or
+// is no longer one.
+func forgetCertExpiry(source string) {
+	setCertExpiry(source, nil)
+}
+
+// setCertExpiry replaces the expiry series of the source, deleting those no
+// other source records.
+func setCertExpiry(source string, recorded map[expirySeries]float64) {
+	certExpiryMu.Lock()
+	defer certExpiryMu.Unlock()
+
+	previous := certExpirySources[source]
+	if len(recorded) == 0 {
+		delete(certExpirySources, source)
+	} else {
+		certExpirySources[source] = recorded
+	}
+	for s := range previous {
+		if _, ok := recorded[s]; !ok && !expiryRecorded(s) {
+			certExpiry.DeleteLabelValues(s.subject, s.fingerprint, s.bundle)
+		}
+	}
+	for s, notAfter := range recorded {
+		certExpiry.WithLabelValues(s.subject, s.fingerprint, s.bundle).Set(notAfter)
+	}
+}
+
+// expiryRecorded reports whether any source records the series.
+func expiryRecorded(s expirySeries) bool {
+	for _, recorded := range certExpirySources {
+		if _, ok := recorded[s]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Reasons a managed ConfigMap is removed by cleanup.
+const (
+	// CleanupReasonStale is a ConfigMap whose file is no longer served.
+	CleanupReasonStale = "stale"
+	// CleanupReasonUntargeted is a ConfigMap in a namespace no longer
+	// targeted.
+	CleanupReasonUntargeted = "untargeted"
+)
+
+// cleanupTally counts the cleanup candidates of a reconcile by reason.
+type cleanupTally struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+type cleanupTallyKey struct{}
+
+// withCleanupTally returns a context collecting the cleanup candidates of a
+// reconcile.
+func withCleanupTally(ctx context.Context) (context.Context, *cleanupTally) {
+	t := &cleanupTally{counts: map[string]int{}}
+	return context.WithValue(ctx, cleanupTallyKey{}, t), t
+}
+
+// recordCleanup notes a ConfigMap found to be removed, and deleted unless
+// the deletion failed.
+func recordCleanup(ctx context.Context, cfg *BundleConfig, reason string, deleted bool) {
+	if t, ok := ctx.Value(cleanupTallyKey{}).(*cleanupTally); ok {
+		t.mu.Lock()
+		t.counts[reason]++
+		t.mu.Unlock()
+	}
+	if deleted {
+		cleanupDeleted.WithLabelValues(cfg.SourceNamespace+"/"+cfg.SourceName, reason).Inc()
+	}
+}
+
+// publish sets the candidates gauge of the source from the tally.
+func (t *cleanupTally) publish(cfg *BundleConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	source := cfg.SourceNamespace + "/" + cfg.SourceName
+	for _, reason := range []string{CleanupReasonStale, CleanupReasonUntargeted} {
+		cleanupCandidates.WithLabelValues(source, reason).Set(float64(t.counts[reason]))
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Certificate expiry metrics", func() {
+	// series counts the expiry series recorded by every source.
+	series := func() int {
+		return testutil.CollectAndCount(certExpiry)
+	}
+
+	It("records a certificate distributed by several sources once", func() {
+		r := &CABundleReconciler{}
+		pki := &BundleConfig{SourceNamespace: "pki", SourceName: "metrics-roots"}
+		partners := &BundleConfig{SourceNamespace: "partners", SourceName: "metrics-roots"}
+		root := []PEMFile{{Filename: "root.pem", Content: newTestCAPEM("Metrics Root", time.Now().Add(time.Hour))}}
+
+		before := series()
+		r.recordCertExpiry(pki, root)
+		r.recordCertExpiry(partners, root)
+		Expect(series()).To(Equal(before + 1))
+
+		By("keeping the series while another source distributes it")
+		r.recordCertExpiry(pki, nil)
+		Expect(series()).To(Equal(before + 1))
+		r.recordCertExpiry(partners, nil)
+		Expect(series()).To(Equal(before))
+	})
+
+	It("drops the series of a source that went away", func() {
+		r := &CABundleReconciler{}
+		cfg := &BundleConfig{SourceNamespace: "pki", SourceName: "deleted-roots"}
+		before := series()
+		r.recordCertExpiry(cfg, []PEMFile{{Filename: "root.pem", Content: newTestCAPEM("Deleted Root", time.Now().Add(time.Hour))}})
+		Expect(series()).To(Equal(before + 1))
+
+		r.forgetSource(types.NamespacedName{Namespace: "pki", Name: "deleted-roots"})
+		Expect(series()).To(Equal(before))
+	})
+})
+
+var _ = Describe("Cleanup metrics", func() {
+	ctx := context.Background()
+
+	// run syncs the files of the source to its targets, then again after
+	// retiring the issuing file and the web namespace.
+	run := func(name string, data map[string]string) {
+		files := map[string][]byte{
+			"root.pem":    newTestCAPEM("Cleanup Root", time.Now().AddDate(1, 0, 0)),
+			"issuing.pem": newTestCAPEM("Cleanup Issuing", time.Now().AddDate(1, 0, 0)),
+		}
+		srv := newTestBundleServer(files)
+		defer srv.Close()
+		data[BundleURLKey] = srv.URL
+		data[TargetNamespacesKey] = "apps,web"
+		src := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cert-manager"}, Data: data}
+		r := &CABundleReconciler{
+			Client:          fake.NewClientBuilder().WithObjects(src).Build(),
+			Scheme:          clientgoscheme.Scheme,
+			TargetNamespace: "cert-manager",
+			ConfigMapName:   name,
+		}
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		delete(files, "issuing.pem")
+		Expect(r.Get(ctx, req.NamespacedName, src)).To(Succeed())
+		src.Data[TargetNamespacesKey] = "apps"
+		Expect(r.Update(ctx, src)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("counts the deleted ConfigMaps and summarizes the pass by reason", func() {
+		run("cleanup-metrics-roots", map[string]string{})
+
+		source := "cert-manager/cleanup-metrics-roots"
+		Expect(testutil.ToFloat64(cleanupDeleted.WithLabelValues(source, CleanupReasonStale))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(cleanupDeleted.WithLabelValues(source, CleanupReasonUntargeted))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(cleanupCandidates.WithLabelValues(source, CleanupReasonStale))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(cleanupCandidates.WithLabelValues(source, CleanupReasonUntargeted))).To(Equal(2.0))
+	})
+
+	It("summarizes what a report-only pass would remove without counting deletions", func() {
+		run("report-metrics-roots", map[string]string{CleanupKey: CleanupReport})
+
+		source := "cert-manager/report-metrics-roots"
+		Expect(testutil.ToFloat64(cleanupCandidates.WithLabelValues(source, CleanupReasonStale))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(cleanupCandidates.WithLabelValues(source, CleanupReasonUntargeted))).To(Equal(2.0))
+		Expect(cleanupDeleted.DeletePartialMatch(prometheus.Labels{"source": source})).To(BeZero())
+	})
+})
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// NamespaceReconciler populates newly created namespaces with the managed
+// ConfigMaps of every bundle targeting them. It copies the content already
+// distributed to other namespaces so new namespaces don't wait for the next
+// download.
+type NamespaceReconciler struct {
+	*CABundleReconciler
+
+	// since is when SetupWithManager ran, see namespaceCreated.
+	since time.Time
+}
+
+// Reconcile copies the managed ConfigMaps of matching bundles into the
+// namespace.
+func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: req.Name}, ns); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if ns.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	sources, err := r.ListSources(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for _, src := range sources {
+		cfg, err := ParseBundleConfig(&src)
+		if err != nil || !r.namespaceTargeted(ns, cfg) {
+			continue
+		}
+
+		logger.Info("Populating new namespace", "namespace", ns.Name, "source", src.Name)
+		if err := r.copyManagedConfigMaps(ctx, &src, cfg, ns.Name); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// copyManagedConfigMaps creates the bundle's managed ConfigMaps in the
+// namespace from a namespace whose last sync succeeded.
+func (r *NamespaceReconciler) copyManagedConfigMaps(ctx context.Context, src *corev1.ConfigMap, cfg *BundleConfig, namespace string) error {
+	// Overridden targets render differently, leave them to the next sync.
+	if cfg.override("", namespace) != nil {
+		return nil
+	}
+
+	status, err := r.getStatus(ctx, src)
+	if err != nil {
+		return err
+	}
+	// Copy from a single namespace so a partially synced one isn't mixed in.
+	var from string
+	for _, nsStatus := range status.Namespaces {
+		// Canary namespaces may hold a version not rolled out yet.
+		if nsStatus.Synced && nsStatus.Namespace != namespace && cfg.override("", nsStatus.Namespace) == nil &&
+			!slices.Contains(cfg.CanaryNamespaces, nsStatus.Namespace) {
+			from = nsStatus.Namespace
+			break
+		}
+	}
+	if from == "" {
+		return nil
+	}
+
+	cmList := &corev1.ConfigMapList{}
+	if err := r.List(ctx, cmList, client.InNamespace(from), client.MatchingLabels(managedLabels(cfg))); err != nil {
+		return err
+	}
+
+	// A sync of the namespace running meanwhile writes the latest content.
+	unlock := r.lockNamespace("", namespace)
+	defer unlock()
+
+	for _, cm := range cmList.Items {
+		// The annotations carry the provenance and content hash that drift
+		// detection and consumers rely on.
+		out := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        cm.Name,
+				Namespace:   namespace,
+				Labels:      cm.Labels,
+				Annotations: cm.Annotations,
+			},
+			Data:       cm.Data,
+			BinaryData: cm.BinaryData,
+		}
+		if r.skipWrite(ctx, cfg, ActionCreate, out.Namespace, out.Name) {
+			continue
+		}
+		if err := r.Create(ctx, out); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// namespaceCreated passes only creations of Namespaces created since the
+// controller was set up. The informer's initial list reports every existing
+// Namespace as created, on every start and leader change; those are left to
+// the regular syncs.
+func (r *NamespaceReconciler) namespaceCreated() predicate.Funcs {
+	// Creation timestamps are truncated to the second.
+	since := r.since.Truncate(time.Second)
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return !e.Object.GetCreationTimestamp().Time.Before(since)
+		},
+		UpdateFunc:  func(event.UpdateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.since = time.Now()
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}, builder.WithPredicates(r.namespaceCreated())).
+		Named("cabundle-namespace").
+		Complete(r)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("Namespace population", func() {
+	It("copies the managed ConfigMaps of a synced namespace with their annotations", func() {
+		ctx := context.Background()
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data: map[string]string{
+				BundleURLKey:        "https://pki.example.com/certs/",
+				TargetNamespacesKey: "apps,new",
+			},
+		}
+		cfg, err := ParseBundleConfig(src)
+		Expect(err).NotTo(HaveOccurred())
+
+		r := &CABundleReconciler{TargetNamespace: "cert-manager", ConfigMapName: "corp-roots", Scheme: clientgoscheme.Scheme}
+		synced, err := r.desiredConfigMap("apps", PEMFile{Filename: "corp-root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		r.Client = fake.NewClientBuilder().WithObjects(src, synced,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "new"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+		).Build()
+		nr := &NamespaceReconciler{CABundleReconciler: r}
+		Expect(r.updateStatus(ctx, src, &BundleStatus{Namespaces: []NamespaceStatus{{Namespace: "apps", Synced: true}}})).To(Succeed())
+
+		_, err = nr.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: "new"}})
+		Expect(err).NotTo(HaveOccurred())
+		copied := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "new", Name: synced.Name}, copied)).To(Succeed())
+		Expect(copied.Labels).To(Equal(synced.Labels))
+		Expect(copied.Annotations).To(Equal(synced.Annotations))
+		Expect(copied.Annotations).To(HaveKey(ContentHashAnnotation))
+		Expect(copied.Data).To(Equal(synced.Data))
+
+		_, err = nr.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: "other"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "other", Name: synced.Name}, &corev1.ConfigMap{})).NotTo(Succeed())
+	})
+
+	It("copies from a namespace whose last sync succeeded", func() {
+		ctx := context.Background()
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       map[string]string{BundleURLKey: "https://pki.example.com/certs/", TargetNamespacesKey: "apps,db,new"},
+		}
+		cfg, err := ParseBundleConfig(src)
+		Expect(err).NotTo(HaveOccurred())
+
+		r := &CABundleReconciler{TargetNamespace: "cert-manager", ConfigMapName: "corp-roots", Scheme: clientgoscheme.Scheme}
+		root := PEMFile{Filename: "corp-root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+		stale, err := r.desiredConfigMap("apps", PEMFile{Filename: root.Filename, Content: newTestCAPEM("Old Root", time.Now().Add(time.Hour))}, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		synced, err := r.desiredConfigMap("db", root, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		r.Client = fake.NewClientBuilder().WithObjects(src, stale, synced,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "new"}},
+		).Build()
+		nr := &NamespaceReconciler{CABundleReconciler: r}
+
+		By("waiting for a successful sync")
+		_, err = nr.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: "new"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "new", Name: synced.Name}, &corev1.ConfigMap{})).NotTo(Succeed())
+
+		Expect(r.updateStatus(ctx, src, &BundleStatus{Namespaces: []NamespaceStatus{
+			{Namespace: "apps", Error: "forbidden"},
+			{Namespace: "db", Synced: true},
+		}})).To(Succeed())
+		_, err = nr.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: "new"}})
+		Expect(err).NotTo(HaveOccurred())
+		copied := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "new", Name: synced.Name}, copied)).To(Succeed())
+		Expect(copied.Data).To(Equal(synced.Data))
+	})
+
+	It("only passes namespaces created since the controller was set up", func() {
+		nr := &NamespaceReconciler{since: time.Now()}
+		created := func(at time.Time) bool {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps", CreationTimestamp: metav1.NewTime(at)}}
+			return nr.namespaceCreated().Create(event.CreateEvent{Object: ns})
+		}
+		Expect(created(time.Now().Add(time.Minute))).To(BeTrue())
+		Expect(created(nr.since.Truncate(time.Second))).To(BeTrue())
+		// Listed by the informer on start.
+		Expect(created(time.Now().Add(-time.Hour))).To(BeFalse())
+	})
+})
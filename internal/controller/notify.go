@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"slices"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/shanmugara/cabundle-operator/internal/notify"
+)
+
+// Notifier delivers notifications to the configured sinks, see
+// notify.Notifier.
+type Notifier interface {
+	Notify(ctx context.Context, n notify.Notification)
+}
+
+// DefaultNotifyFailureThreshold is the number of consecutive failed syncs of
+// a source after which a notification is sent.
+const DefaultNotifyFailureThreshold = 3
+
+// changeTally collects the managed ConfigMaps whose trust changed, and those
+// deleted by cleanup, during a reconcile.
+type changeTally struct {
+	mu      sync.Mutex
+	changed []string
+	added   []string
+	removed []string
+	deleted []string
+}
+
+type changeTallyKey struct{}
+
+// withChangeTally returns a context collecting the changes of a reconcile.
+func withChangeTally(ctx context.Context) (context.Context, *changeTally) {
+	t := &changeTally{}
+	return context.WithValue(ctx, changeTallyKey{}, t), t
+}
+
+// noteTrustChanged records the trust of a managed ConfigMap changed by diff.
+func noteTrustChanged(ctx context.Context, configMap string, diff trustDiff) {
+	if t, ok := ctx.Value(changeTallyKey{}).(*changeTally); ok {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.changed = append(t.changed, configMap)
+		for _, s := range diff.Added {
+			t.added = appendUnique(t.added, s)
+		}
+		for _, s := range diff.Removed {
+			t.removed = appendUnique(t.removed, s)
+		}
+	}
+}
+
+// noteDeleted records a managed ConfigMap deleted by cleanup.
+func noteDeleted(ctx context.Context, configMap string) {
+	if t, ok := ctx.Value(changeTallyKey{}).(*changeTally); ok {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.deleted = append(t.deleted, configMap)
+	}
+}
+
+// notifyChanges sends a notification for the changes collected by t, if
+// any.
+func (r *CABundleReconciler) notifyChanges(ctx context.Context, cfg *BundleConfig, t *changeTally) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.changed) > 0 {
+		slices.Sort(t.changed)
+		r.notify(ctx, notify.Notification{
+			Event:      notify.BundleChanged,
+			Severity:   notify.SeverityInfo,
+			Source:     cfg.SourceNamespace + "/" + cfg.SourceName,
+			SourceURL:  cfg.sourceURL(),
+			Message:    fmt.Sprintf("Trust changed in %d ConfigMaps", len(t.changed)),
+			ConfigMaps: t.changed,
+			Added:      t.added,
+			Removed:    t.removed,
+			Contacts:   cfg.NotifyEmail,
+		})
+	}
+	if len(t.deleted) > 0 {
+		slices.Sort(t.deleted)
+		r.notify(ctx, notify.Notification{
+			Event:      notify.ConfigMapsDeleted,
+			Severity:   notify.SeverityInfo,
+			Source:     cfg.SourceNamespace + "/" + cfg.SourceName,
+			SourceURL:  cfg.sourceURL(),
+			Message:    fmt.Sprintf("Cleanup deleted %d ConfigMaps", len(t.deleted)),
+			ConfigMaps: t.deleted,
+			Contacts:   cfg.NotifyEmail,
+		})
+	}
+}
+
+// notifySynced reports a sync that distributed the bundle, if only in part.
+func (r *CABundleReconciler) notifySynced(ctx context.Context, cfg *BundleConfig, record SyncRecord) {
+	r.notify(ctx, notify.Notification{
+		Event:     notify.BundleSynced,
+		Severity:  notify.SeverityInfo,
+		Source:    cfg.SourceNamespace + "/" + cfg.SourceName,
+		SourceURL: cfg.sourceURL(),
+		Message:   fmt.Sprintf("Synced %d files to %d namespaces", record.Files, record.Namespaces),
+		Outcome:   record.Outcome,
+		Hash:      record.Hash,
+		Error:     record.Error,
+	})
+}
+
+// notifyFailing reports a failed sync, and sends a notification once the
+// syncs of a source have failed failures times in a row, not again until it
+// recovered.
+func (r *CABundleReconciler) notifyFailing(ctx context.Context, req ctrl.Request, failures int, err error) {
+	r.notify(ctx, notify.Notification{
+		Event:               notify.SyncFailed,
+		Severity:            notify.SeverityWarning,
+		Source:              req.String(),
+		Message:             "Sync failed",
+		Outcome:             SyncFailed,
+		ConsecutiveFailures: failures,
+		Error:               err.Error(),
+	})
+
+	threshold := r.tuning().NotifyFailureThreshold
+	if threshold <= 0 {
+		threshold = DefaultNotifyFailureThreshold
+	}
+	if failures != threshold {
+		return
+	}
+	r.notify(ctx, notify.Notification{
+		Event:               notify.SyncFailing,
+		Severity:            notify.SeverityWarning,
+		Source:              req.String(),
+		Message:             fmt.Sprintf("Sync failed %d times in a row", failures),
+		ConsecutiveFailures: failures,
+		Error:               err.Error(),
+		Contacts:            r.sourceContacts(ctx, req),
+	})
+}
+
+// sourceContacts returns the notify_email addresses of a source, read anew
+// since its configuration may be what fails.
+func (r *CABundleReconciler) sourceContacts(ctx context.Context, req ctrl.Request) []string {
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, req.NamespacedName, cm); err != nil {
+		return nil
+	}
+	var contacts []string
+	for _, a := range splitList(cm.Data[NotifyEmailKey]) {
+		if addr, err := mail.ParseAddress(a); err == nil {
+			contacts = append(contacts, addr.Address)
+		}
+	}
+	return contacts
+}
+
+// notifyUnservable pages about a source whose downloads are suspended by the
+// circuit breaker, or resolves the page once resolved is set.
+func (r *CABundleReconciler) notifyUnservable(ctx context.Context, cfg *BundleConfig, message string, resolved bool) {
+	r.notify(ctx, notify.Notification{
+		Event:     notify.BundleUnservable,
+		Severity:  notify.SeverityCritical,
+		Resolved:  resolved,
+		Source:    cfg.SourceNamespace + "/" + cfg.SourceName,
+		SourceURL: cfg.sourceURL(),
+		Message:   message,
+		Contacts:  cfg.NotifyEmail,
+	})
+}
+
+// notify sends n unless the reconciler has no notifier or is in dry-run mode.
+func (r *CABundleReconciler) notify(ctx context.Context, n notify.Notification) {
+	if r.Notifier == nil || r.DryRun {
+		return
+	}
+	n.Time = time.Now().UTC()
+	r.Notifier.Notify(ctx, n)
+}
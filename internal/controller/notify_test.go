@@ -0,0 +1,174 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/shanmugara/cabundle-operator/internal/notify"
+)
+
+// recordingNotifier collects the notifications sent.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, sent notify.Notification) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, sent)
+}
+
+// events returns the notifications sent of the event.
+func (n *recordingNotifier) events(event notify.Event) []notify.Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var sent []notify.Notification
+	for _, s := range n.sent {
+		if s.Event == event {
+			sent = append(sent, s)
+		}
+	}
+	return sent
+}
+
+var _ = Describe("Notifications", func() {
+	ctx := context.Background()
+	source := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "cert-manager", Name: "root-ca"}}
+	var (
+		notifier *recordingNotifier
+		r        *CABundleReconciler
+		cfg      *BundleConfig
+	)
+	BeforeEach(func() {
+		notifier = &recordingNotifier{}
+		r = &CABundleReconciler{Client: fake.NewClientBuilder().Build(), Notifier: notifier}
+		cfg = &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", BundleURL: "https://pki.example.com/", Formats: []string{FormatPEM}}
+	})
+
+	// syncAndNotify syncs the bundles into the namespace and sends the notifications
+	// of the changes, like a reconcile.
+	syncAndNotify := func(namespace string, bundles ...PEMFile) {
+		ctx, changes := withChangeTally(ctx)
+		Expect(r.syncNamespace(ctx, namespace, bundles, cfg)).To(Succeed())
+		r.notifyChanges(ctx, cfg, changes)
+	}
+
+	It("reports a change of trust with the certificates added and removed", func() {
+		syncAndNotify("apps", PEMFile{Filename: "root.pem", Content: newTestCAPEM("Old Root", time.Now().Add(time.Hour))})
+		Expect(notifier.sent).To(BeEmpty())
+
+		syncAndNotify("apps", PEMFile{Filename: "root.pem", Content: newTestCAPEM("New Root", time.Now().Add(time.Hour))})
+		Expect(notifier.sent).To(HaveLen(1))
+		n := notifier.sent[0]
+		Expect(n.Event).To(Equal(notify.BundleChanged))
+		Expect(n.Source).To(Equal("cert-manager/root-ca"))
+		Expect(n.SourceURL).To(Equal("https://pki.example.com/"))
+		Expect(n.ConfigMaps).To(Equal([]string{"apps/root"}))
+		Expect(n.Added).To(Equal([]string{"CN=New Root,O=Corp"}))
+		Expect(n.Removed).To(Equal([]string{"CN=Old Root,O=Corp"}))
+	})
+
+	It("reports ConfigMaps deleted by cleanup", func() {
+		root := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+		syncAndNotify("apps", root, PEMFile{Filename: "old.pem", Content: newTestCAPEM("Old Root", time.Now().Add(time.Hour))})
+		Expect(notifier.sent).To(BeEmpty())
+
+		syncAndNotify("apps", root)
+		Expect(notifier.sent).To(HaveLen(1))
+		Expect(notifier.sent[0].Event).To(Equal(notify.ConfigMapsDeleted))
+		Expect(notifier.sent[0].ConfigMaps).To(Equal([]string{"apps/old"}))
+	})
+
+	It("reports failing syncs once when the threshold is reached", func() {
+		err := errors.New("connection refused")
+		for failures := 1; failures <= 5; failures++ {
+			r.notifyFailing(ctx, source, failures, err)
+		}
+		failing := notifier.events(notify.SyncFailing)
+		Expect(failing).To(HaveLen(1))
+		Expect(failing[0].ConsecutiveFailures).To(Equal(DefaultNotifyFailureThreshold))
+		Expect(failing[0].Error).To(Equal("connection refused"))
+		// Every failure is a lifecycle event.
+		Expect(notifier.events(notify.SyncFailed)).To(HaveLen(5))
+	})
+
+	It("emails failing syncs to the contacts of the source", func() {
+		r.Client = fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"},
+			Data:       map[string]string{NotifyEmailKey: "PKI Team <pki@example.com>, sre@example.com"},
+		}).Build()
+		r.notifyFailing(ctx, source, DefaultNotifyFailureThreshold, errors.New("connection refused"))
+		failing := notifier.events(notify.SyncFailing)
+		Expect(failing).To(HaveLen(1))
+		Expect(failing[0].Contacts).To(Equal([]string{"pki@example.com", "sre@example.com"}))
+	})
+
+	It("reports an expiring certificate as the condition escalates", func() {
+		cfg.ExpiryWarning = DefaultExpiryWarning
+		cfg.NotifyEmail = []string{"pki@example.com"}
+		expiring := []PEMFile{{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(24*time.Hour))}}
+		status := &BundleStatus{}
+		r.checkExpiry(ctx, cfg, expiring, status)
+		r.checkExpiry(ctx, cfg, expiring, status)
+		Expect(notifier.sent).To(HaveLen(1))
+		Expect(notifier.sent[0].Event).To(Equal(notify.CertificateExpiring))
+		Expect(notifier.sent[0].Message).To(ContainSubstring("CN=Corp Root,O=Corp in root.pem expires on"))
+		Expect(notifier.sent[0].Contacts).To(Equal([]string{"pki@example.com"}))
+
+		expired := []PEMFile{{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(-time.Minute))}}
+		r.checkExpiry(ctx, cfg, expired, status)
+		Expect(notifier.sent).To(HaveLen(2))
+		Expect(notifier.sent[1].Message).To(ContainSubstring("expired on"))
+	})
+
+	It("resolves the unservable page once the source syncs again", func() {
+		src := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"}}
+		r.Client = fake.NewClientBuilder().WithObjects(src).Build()
+		r.Scheme = clientgoscheme.Scheme
+		prev := &BundleStatus{FullSync: &FullSync{Time: metav1.Now()}}
+		meta.SetStatusCondition(&prev.Conditions, circuitCondition(time.Now().Add(time.Minute), 5))
+
+		_, err := r.syncUnchanged(ctx, src, cfg, &bundleIndex{}, prev)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notifier.sent).To(HaveLen(1))
+		Expect(notifier.sent[0].Event).To(Equal(notify.BundleUnservable))
+		Expect(notifier.sent[0].Severity).To(Equal(notify.SeverityCritical))
+		Expect(notifier.sent[0].Resolved).To(BeTrue())
+	})
+
+	It("sends nothing in dry-run mode", func() {
+		r.DryRun = true
+		r.notifyFailing(ctx, source, DefaultNotifyFailureThreshold, errors.New("connection refused"))
+		Expect(notifier.sent).To(BeEmpty())
+	})
+})
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ConditionPinned reports that the managed ConfigMaps are pinned to a
+// retained revision and forward syncs are paused, see
+// BundleConfig.PinnedRevision.
+const ConditionPinned = "Pinned"
+
+// ReasonRolledBack is recorded when a managed ConfigMap is restored to the
+// pinned revision.
+const ReasonRolledBack = "RolledBack"
+
+// syncPinned completes the sync of a source pinned to a retained revision:
+// nothing is downloaded, and every managed ConfigMap of the targets is
+// restored to the content of the pinned revision, if retained. Cleanup is
+// paused too, as the pinned revision may name files no longer listed.
+func (r *CABundleReconciler) syncPinned(ctx context.Context, src *corev1.ConfigMap, cfg *BundleConfig, namespaces []string, prev *BundleStatus) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+	logger.V(1).Info("Source pinned, skipping downloads", "revision", cfg.PinnedRevision)
+
+	var missing []string
+	var errs []error
+	for _, ns := range namespaces {
+		unlock := r.lockNamespace("", ns)
+		m, err := r.restorePinnedRevision(ctx, ns, cfg.ForTarget("", ns))
+		unlock()
+		missing = append(missing, m...)
+		if err != nil {
+			logger.Error(err, "unable to restore pinned revision", "namespace", ns)
+			errs = append(errs, err)
+		}
+	}
+
+	status := *prev
+	status.Conditions = slices.Clone(prev.Conditions)
+	meta.SetStatusCondition(&status.Conditions, pinnedCondition(cfg.PinnedRevision, missing))
+	if len(errs) == 0 && meta.FindStatusCondition(status.Conditions, ConditionDrifted) != nil {
+		meta.SetStatusCondition(&status.Conditions, driftedCondition(nil))
+	}
+	status.NextSyncTime = r.nextSyncTime(client.ObjectKeyFromObject(src), 0)
+	if err := r.updateStatus(ctx, src, &status); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return ctrl.Result{}, errs[0]
+	}
+	r.recordSuccessfulSync(client.ObjectKeyFromObject(src), time.Now())
+	return ctrl.Result{}, nil
+}
+
+// restorePinnedRevision restores the managed ConfigMaps of the source in the
+// namespace to the pinned revision, returning those that don't retain it.
+func (r *CABundleReconciler) restorePinnedRevision(ctx context.Context, namespace string, cfg *BundleConfig) ([]string, error) {
+	managed, err := r.sourceConfigMaps(ctx, cfg, client.InNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for i := range managed {
+		live := &managed[i]
+		// Found by label, so revisions retained under the former
+		// <name>-rev-<n> names are restored too.
+		revisions, err := r.listRevisions(ctx, namespace, live.Name)
+		if err != nil {
+			return missing, err
+		}
+		at := slices.IndexFunc(revisions, func(cm corev1.ConfigMap) bool {
+			return revisionOf(&cm) == cfg.PinnedRevision
+		})
+		if at < 0 {
+			// The live ConfigMap may be at the pinned revision itself.
+			if revisionOf(live) != cfg.PinnedRevision {
+				missing = append(missing, namespace+"/"+live.Name)
+			}
+			continue
+		}
+		retained := &revisions[at]
+
+		want := retained.Annotations[ContentHashAnnotation]
+		if contentMatchesHash(live, cfg) && live.Annotations[ContentHashAnnotation] == want {
+			continue
+		}
+		desired := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        live.Name,
+				Namespace:   namespace,
+				Labels:      maps.Clone(live.Labels),
+				Annotations: map[string]string{ContentHashAnnotation: want},
+			},
+			Data:       retained.Data,
+			BinaryData: retained.BinaryData,
+		}
+		changed, err := r.createOrUpdateConfigMap(ctx, desired, cfg)
+		if err != nil {
+			return missing, err
+		}
+		if changed {
+			r.eventf(cfg, corev1.EventTypeNormal, ReasonRolledBack, "Rolled back ConfigMap %s to revision %d",
+				r.describeConfigMap(namespace, live.Name), cfg.PinnedRevision)
+		}
+	}
+	return missing, nil
+}
+
+// pinnedCondition returns the Pinned condition of a source pinned to the
+// revision, listing the managed ConfigMaps that don't retain it.
+func pinnedCondition(revision int64, missing []string) metav1.Condition {
+	if revision == 0 {
+		return metav1.Condition{
+			Type:    ConditionPinned,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Unpinned",
+			Message: "Forward syncs are running",
+		}
+	}
+	c := metav1.Condition{
+		Type:    ConditionPinned,
+		Status:  metav1.ConditionTrue,
+		Reason:  "RevisionPinned",
+		Message: fmt.Sprintf("Pinned to revision %d, forward syncs are paused", revision),
+	}
+	if len(missing) > 0 {
+		c.Reason = "RevisionNotRetained"
+		c.Message += fmt.Sprintf("; revision %d isn't retained for %s", revision, strings.Join(missing, ", "))
+	}
+	return c
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Pinned revisions", func() {
+	ctx := context.Background()
+
+	var (
+		recorder *record.FakeRecorder
+		r        *CABundleReconciler
+		cfg      *BundleConfig
+		src      *corev1.ConfigMap
+	)
+	BeforeEach(func() {
+		src = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"}}
+		recorder = record.NewFakeRecorder(20)
+		r = &CABundleReconciler{Client: fake.NewClientBuilder().WithObjects(src).Build(), Scheme: clientgoscheme.Scheme, Recorder: recorder}
+		cfg = &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}, RetainRevisions: 1}
+	})
+
+	// publish syncs a new root certificate into apps.
+	publish := func(cn string) []byte {
+		content := newTestCAPEM(cn, time.Now().Add(time.Hour))
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{{Filename: "root.pem", Content: content}}, cfg)).To(Succeed())
+		return content
+	}
+	live := func() *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "root"}, cm)).To(Succeed())
+		return cm
+	}
+
+	It("restores the pinned revision and keeps it retained", func() {
+		good := publish("Root CA 1")
+		publish("Root CA 2")
+		publish("Root CA 3")
+		cfg.RetainRevisions = 2
+		Expect(live().Annotations).To(HaveKeyWithValue(RevisionAnnotation, "3"))
+
+		cfg.PinnedRevision = 2
+		_, err := r.syncPinned(ctx, src, cfg, []string{"apps"}, &BundleStatus{})
+		Expect(err).NotTo(HaveOccurred())
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		Expect(events).To(ContainElement(ContainSubstring("Rolled back ConfigMap apps/root to revision 2")))
+		Expect(live().Annotations).To(HaveKeyWithValue(RevisionAnnotation, "4"))
+		revisions, err := r.listRevisions(ctx, "apps", "root")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revisions).To(HaveLen(2))
+
+		// Pinned again to the first revision, which was pruned before.
+		cfg.PinnedRevision = 1
+		_, err = r.syncPinned(ctx, src, cfg, []string{"apps"}, &BundleStatus{})
+		Expect(err).NotTo(HaveOccurred())
+		status, err := r.getStatus(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		pinned := meta.FindStatusCondition(status.Conditions, ConditionPinned)
+		Expect(pinned.Reason).To(Equal("RevisionNotRetained"))
+		Expect(pinned.Message).To(ContainSubstring("apps/root"))
+		Expect(status.Health.Status).To(Equal(HealthSuspended))
+		Expect(live().Data["ca.crt"]).NotTo(Equal(string(good)))
+	})
+
+	It("leaves a ConfigMap at the pinned revision alone", func() {
+		publish("Root CA 1")
+		second := publish("Root CA 2")
+		cfg.PinnedRevision = 1
+		for i := 0; i < 2; i++ {
+			_, err := r.syncPinned(ctx, src, cfg, []string{"apps"}, &BundleStatus{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(live().Annotations).To(HaveKeyWithValue(RevisionAnnotation, "3"))
+		Expect(live().Data["ca.crt"]).NotTo(Equal(string(second)))
+
+		// The revision pinned isn't pruned by the rollback retaining
+		// revision 2.
+		revisions, err := r.listRevisions(ctx, "apps", "root")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revisions).To(HaveLen(2))
+		Expect(fmt.Sprint(revisionOf(&revisions[1]))).To(Equal("1"))
+	})
+
+	It("restores revisions retained under their former names", func() {
+		good := publish("Root CA 1")
+		publish("Root CA 2")
+		retained := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "root.rev-1"}, retained)).To(Succeed())
+		Expect(r.Delete(ctx, retained)).To(Succeed())
+		retained.Name = "root-rev-1"
+		retained.ResourceVersion = ""
+		Expect(r.Create(ctx, retained)).To(Succeed())
+
+		cfg.PinnedRevision = 1
+		_, err := r.syncPinned(ctx, src, cfg, []string{"apps"}, &BundleStatus{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(live().Data["ca.crt"]).To(Equal(string(good)))
+	})
+})
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// PlanAnnotation set to "true" on a source holds every change to its managed
+// ConfigMaps back until approved: the pending changes are recorded in the
+// status as a plan, applied once ApprovePlanAnnotation names the plan.
+const PlanAnnotation = "cabundle.io/plan"
+
+// ApprovePlanAnnotation on a source approves the plan with the given ID,
+// e.g. `kubectl annotate cm corp-roots cabundle.io/approve-plan=<id>
+// --overwrite`.
+const ApprovePlanAnnotation = "cabundle.io/approve-plan"
+
+// ConditionPlanPending reports that changes to the managed ConfigMaps await
+// approval.
+const ConditionPlanPending = "PlanPending"
+
+// ReasonPlanCreated is recorded when a new plan awaits approval.
+const ReasonPlanCreated = "PlanCreated"
+
+// Plan is a set of changes to the managed ConfigMaps held back for approval.
+// Changes to remote clusters are applied along with the approved plan.
+type Plan struct {
+	// ID identifies the exact changes, approved through
+	// ApprovePlanAnnotation.
+	ID string `json:"id"`
+	// Hash identifies the bundle version planned.
+	Hash      string          `json:"hash"`
+	CreatedAt metav1.Time     `json:"createdAt"`
+	Changes   []PlannedChange `json:"changes"`
+}
+
+// PlannedChange is a pending create, update or delete of a managed
+// ConfigMap, along with the certificates it adds and removes.
+type PlannedChange struct {
+	Action        string   `json:"action"`
+	Namespace     string   `json:"namespace"`
+	Name          string   `json:"name"`
+	Added         []string `json:"added,omitempty"`
+	Removed       []string `json:"removed,omitempty"`
+	ExpiryChanged []string `json:"expiryChanged,omitempty"`
+}
+
+// requiresApproval reports whether changes of the source wait for an
+// approved plan.
+func requiresApproval(src *corev1.ConfigMap) bool {
+	return src.Annotations[PlanAnnotation] == "true"
+}
+
+// newPlan returns the plan of the changes, nil if there are none. The ID
+// covers the content of every change, so a plan approved for one version is
+// never applied to another.
+func newPlan(hash string, changes []ConfigMapChange) *Plan {
+	if len(changes) == 0 {
+		return nil
+	}
+	plan := &Plan{Hash: hash, CreatedAt: metav1.Now()}
+	h := sha256.New()
+	fmt.Fprintln(h, hash)
+	for _, c := range changes {
+		var content string
+		if c.Desired != nil {
+			content = c.Desired.Annotations[ContentHashAnnotation]
+		}
+		fmt.Fprintln(h, c.Action, c.Namespace, c.Name, content)
+		plan.Changes = append(plan.Changes, PlannedChange{
+			Action:        c.Action,
+			Namespace:     c.Namespace,
+			Name:          c.Name,
+			Added:         c.trust.Added,
+			Removed:       c.trust.Removed,
+			ExpiryChanged: c.trust.ExpiryChanged,
+		})
+	}
+	plan.ID = hex.EncodeToString(h.Sum(nil))[:12]
+	return plan
+}
+
+// holdPlan records the plan awaiting approval. The source is planned again
+// on its next sync, or when the approval changes.
+func (r *CABundleReconciler) holdPlan(ctx context.Context, src *corev1.ConfigMap, cfg *BundleConfig, plan *Plan, prev *BundleStatus) (ctrl.Result, error) {
+	status := *prev
+	status.Conditions = slices.Clone(prev.Conditions)
+	if prev.Plan != nil && prev.Plan.ID == plan.ID {
+		plan.CreatedAt = prev.Plan.CreatedAt
+	} else {
+		logf.FromContext(ctx).Info("Holding changes for approval", "plan", plan.ID, "changes", len(plan.Changes))
+		r.eventf(cfg, corev1.EventTypeNormal, ReasonPlanCreated, "Plan %s with %d changes awaits approval, annotate the source with %s=%s to apply it",
+			plan.ID, len(plan.Changes), ApprovePlanAnnotation, plan.ID)
+	}
+	status.Plan = plan
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    ConditionPlanPending,
+		Status:  metav1.ConditionTrue,
+		Reason:  "AwaitingApproval",
+		Message: fmt.Sprintf("Plan %s with %d changes awaits approval", plan.ID, len(plan.Changes)),
+	})
+	meta.SetStatusCondition(&status.Conditions, sourceReachableCondition(nil))
+
+	status.NextSyncTime = r.nextSyncTime(client.ObjectKeyFromObject(src), 0)
+	if err := r.updateStatus(ctx, src, &status); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.recordSuccessfulSync(client.ObjectKeyFromObject(src), time.Now())
+	return ctrl.Result{}, nil
+}
+
+// planAppliedCondition returns the PlanPending condition once every change
+// is applied.
+func planAppliedCondition() metav1.Condition {
+	return metav1.Condition{
+		Type:    ConditionPlanPending,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Applied",
+		Message: "No changes await approval",
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Plan mode", func() {
+	ctx := context.Background()
+
+	It("holds changes until their plan is approved", func() {
+		root := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/" {
+				_, _ = w.Write([]byte(`<html><body><a href="root.pem">root.pem</a></body></html>`))
+				return
+			}
+			_, _ = w.Write(root)
+		}))
+		defer srv.Close()
+
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "corp-roots", Namespace: "cert-manager",
+				Annotations: map[string]string{PlanAnnotation: "true"},
+			},
+			Data: map[string]string{BundleURLKey: srv.URL, TargetNamespacesKey: "apps"},
+		}
+		r := &CABundleReconciler{
+			Client: fake.NewClientBuilder().WithObjects(src,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}).Build(),
+			Scheme:          clientgoscheme.Scheme,
+			TargetNamespace: "cert-manager",
+			ConfigMapName:   "corp-roots",
+		}
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)}
+		managed := &corev1.ConfigMap{}
+		key := client.ObjectKey{Namespace: "apps", Name: "root"}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, key, managed)).NotTo(Succeed())
+		status, err := r.getStatus(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Plan).NotTo(BeNil())
+		Expect(status.Plan.Changes).To(HaveLen(1))
+		Expect(status.Plan.Changes[0].Action).To(Equal(ActionCreate))
+		Expect(status.Plan.Changes[0].Added).To(ConsistOf(ContainSubstring("CN=Corp Root")))
+		Expect(meta.IsStatusConditionTrue(status.Conditions, ConditionPlanPending)).To(BeTrue())
+
+		// Approving another plan changes nothing.
+		Expect(r.Get(ctx, req.NamespacedName, src)).To(Succeed())
+		src.Annotations[ApprovePlanAnnotation] = "stale"
+		Expect(r.Update(ctx, src)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, key, managed)).NotTo(Succeed())
+
+		src.Annotations[ApprovePlanAnnotation] = status.Plan.ID
+		Expect(r.Update(ctx, src)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, key, managed)).To(Succeed())
+		status, err = r.getStatus(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Plan).To(BeNil())
+		Expect(meta.IsStatusConditionFalse(status.Conditions, ConditionPlanPending)).To(BeTrue())
+	})
+})
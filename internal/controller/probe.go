@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/yaml"
+)
+
+// ConditionSourceReachable reports whether the bundle URL of a source
+// answered the last probe or download.
+const ConditionSourceReachable = "SourceReachable"
+
+// DefaultProbeTimeout bounds a single reachability probe unless the prober
+// sets its own Timeout.
+const DefaultProbeTimeout = 10 * time.Second
+
+var sourceReachable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "cabundle_source_reachable",
+	Help: "Whether the bundle URL of a source answered the last probe (1) or not (0).",
+}, []string{"source"})
+
+func init() {
+	metrics.Registry.MustRegister(sourceReachable)
+}
+
+// SourceProber periodically checks that the bundle URL of every source is
+// reachable, independent of full syncs, so a dead distribution point shows up
+// before the next sync fails.
+type SourceProber struct {
+	Reconciler *CABundleReconciler
+	Interval   time.Duration
+	// Timeout bounds a single probe, DefaultProbeTimeout if zero.
+	Timeout time.Duration
+}
+
+// Start implements the
+// [sigs.k8s.io/controller-runtime/pkg/manager.Runnable] interface.
+func (p *SourceProber) Start(ctx context.Context) error {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.probeAll(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// NeedLeaderElection implements the
+// [sigs.k8s.io/controller-runtime/pkg/manager.LeaderElectionRunnable]
+// interface, since probes update the status.
+func (p *SourceProber) NeedLeaderElection() bool {
+	return true
+}
+
+// probeAll probes every source and records the result.
+func (p *SourceProber) probeAll(ctx context.Context) {
+	logger := logf.FromContext(ctx)
+
+	sources, err := p.Reconciler.ListSources(ctx)
+	if err != nil {
+		logger.Error(err, "unable to list bundle sources")
+		return
+	}
+	for i := range sources {
+		src := &sources[i]
+		cfg, err := p.Reconciler.parseBundleConfig(src)
+		if err != nil {
+			continue
+		}
+		var probeErr error
+		for _, u := range cfg.sourceURLs() {
+			if probeErr = probeSource(ctx, cfg.httpClient(), u, durationOr(p.Timeout, DefaultProbeTimeout)); probeErr != nil {
+				logger.Info("Bundle source unreachable", "source", src.Name, "url", u, "error", probeErr.Error())
+				break
+			}
+		}
+		if err := p.Reconciler.setSourceReachable(ctx, src, probeErr); err != nil {
+			logger.Error(err, "unable to update bundle status", "source", src.Name)
+		}
+	}
+}
+
+// probeSource sends a HEAD request to the bundle URL, falling back to GET for
+// servers that don't implement HEAD.
+func probeSource(ctx context.Context, c *http.Client, url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	status, err := probeRequest(ctx, c, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = probeRequest(ctx, c, http.MethodGet, url)
+	}
+	if err != nil {
+		return err
+	}
+	if status >= http.StatusBadRequest {
+		return fmt.Errorf("bundle URL returned %d %s", status, http.StatusText(status))
+	}
+	return nil
+}
+
+func probeRequest(ctx context.Context, c *http.Client, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// sourceReachableCondition returns the SourceReachable condition for the
+// outcome of a probe or download.
+func sourceReachableCondition(err error) metav1.Condition {
+	if err != nil {
+		return metav1.Condition{
+			Type:    ConditionSourceReachable,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Unreachable",
+			Message: err.Error(),
+		}
+	}
+	return metav1.Condition{
+		Type:    ConditionSourceReachable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Reachable",
+		Message: "Bundle URL is reachable",
+	}
+}
+
+// setSourceReachable records the reachability of a source in its metric and
+// status, writing the status only when the condition changed. Unlike
+// updateStatus, the status is only written if no sync wrote it since it was
+// read, so a probe never reverts what a sync recorded; a conflicting write is
+// left to the next probe. Sources not synced yet are left to their first
+// sync.
+func (r *CABundleReconciler) setSourceReachable(ctx context.Context, src *corev1.ConfigMap, probeErr error) error {
+	value := 1.0
+	if probeErr != nil {
+		value = 0
+	}
+	sourceReachable.WithLabelValues(client.ObjectKeyFromObject(src).String()).Set(value)
+
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, client.ObjectKey{Name: statusConfigMapName(src.Name), Namespace: src.Namespace}, cm)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	status := &BundleStatus{}
+	if err := yaml.Unmarshal([]byte(cm.Data[StatusKey]), status); err != nil {
+		return err
+	}
+	if !meta.SetStatusCondition(&status.Conditions, sourceReachableCondition(probeErr)) {
+		return nil
+	}
+
+	health := bundleHealth(status)
+	status.Health = &health
+	if err := r.writeProbedStatus(ctx, cm, status); apierrors.IsConflict(err) {
+		logf.FromContext(ctx).V(1).Info("Status changed while probing, leaving it to the next probe", "source", src.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// As in updateStatus, the full sync follows the health annotations.
+	version := src.ResourceVersion
+	if err := r.publishHealth(ctx, src, status); err != nil {
+		return err
+	}
+	if status.FullSync != nil && status.FullSync.SourceVersion == version && src.ResourceVersion != version {
+		status.FullSync.SourceVersion = src.ResourceVersion
+		// A sync writing the status meanwhile recorded the version itself.
+		if err := r.writeProbedStatus(ctx, cm, status); !apierrors.IsConflict(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProbedStatus writes the status into the status ConfigMap, failing
+// with a conflict if it changed since it was read.
+func (r *CABundleReconciler) writeProbedStatus(ctx context.Context, cm *corev1.ConfigMap, status *BundleStatus) error {
+	out, err := yaml.Marshal(status)
+	if err != nil {
+		return err
+	}
+	cm.Data = map[string]string{StatusKey: string(out)}
+	return r.Update(ctx, cm)
+}
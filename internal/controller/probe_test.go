@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Source probe", func() {
+	ctx := context.Background()
+
+	It("falls back to GET for servers without HEAD", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}))
+		defer srv.Close()
+
+		Expect(probeSource(ctx, http.DefaultClient, srv.URL, DefaultProbeTimeout)).To(Succeed())
+	})
+
+	It("reports error responses and unreachable servers", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		Expect(probeSource(ctx, http.DefaultClient, srv.URL, DefaultProbeTimeout)).To(MatchError(ContainSubstring("502")))
+
+		srv.Close()
+		Expect(probeSource(ctx, http.DefaultClient, srv.URL, DefaultProbeTimeout)).NotTo(Succeed())
+	})
+
+	It("records reachability without reverting a concurrent sync", func() {
+		src := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"}}
+		var sync func()
+		c := fake.NewClientBuilder().WithObjects(src).WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if concurrent := sync; concurrent != nil {
+					sync = nil
+					concurrent()
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		}).Build()
+		r := &CABundleReconciler{Client: c, Scheme: clientgoscheme.Scheme}
+
+		Expect(r.setSourceReachable(ctx, src, errors.New("connection refused"))).To(Succeed())
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "cert-manager", Name: statusConfigMapName("corp-roots")}, &corev1.ConfigMap{})).NotTo(Succeed(),
+			"a source not synced yet is left to its first sync")
+
+		Expect(r.updateStatus(ctx, src, &BundleStatus{RolledOutHash: "v1"})).To(Succeed())
+		Expect(r.setSourceReachable(ctx, src, errors.New("connection refused"))).To(Succeed())
+		status, err := r.getStatus(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(meta.IsStatusConditionFalse(status.Conditions, ConditionSourceReachable)).To(BeTrue())
+		Expect(status.RolledOutHash).To(Equal("v1"))
+
+		// A sync writing the status while probing wins.
+		sync = func() {
+			defer GinkgoRecover()
+			Expect(r.updateStatus(ctx, src, &BundleStatus{RolledOutHash: "v2"})).To(Succeed())
+		}
+		Expect(r.setSourceReachable(ctx, src, nil)).To(Succeed())
+		status, err = r.getStatus(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.RolledOutHash).To(Equal("v2"))
+		Expect(meta.FindStatusCondition(status.Conditions, ConditionSourceReachable)).To(BeNil())
+	})
+})
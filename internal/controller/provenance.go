@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"net/url"
+)
+
+// Provenance annotations written on every managed ConfigMap, so admission
+// policies (Kyverno, Gatekeeper) can require that mounted trust bundles are
+// operator-managed and were fetched over an authenticated channel.
+const (
+	// SourceURLAnnotation is the URL the bundle was fetched from.
+	SourceURLAnnotation = "cabundle.io/source-url"
+	// VerificationAnnotation is how the origin of the bundle was verified,
+	// one of the Verification values.
+	VerificationAnnotation = "cabundle.io/verification"
+	// PolicyVersionAnnotation is the policy_version of the source, if set.
+	PolicyVersionAnnotation = "cabundle.io/policy-version"
+)
+
+// Values of VerificationAnnotation.
+const (
+	// VerificationNone is a bundle fetched over plain HTTP.
+	VerificationNone = "none"
+	// VerificationTLS is a bundle fetched over HTTPS, the server
+	// authenticated with the system roots.
+	VerificationTLS = "tls"
+	// VerificationSPIFFEWeb and VerificationSPIFFE are SPIFFE bundle
+	// endpoints authenticated with the Web PKI or by their SPIFFE ID.
+	VerificationSPIFFEWeb = "spiffe-" + SPIFFEProfileWeb
+	VerificationSPIFFE    = "spiffe-" + SPIFFEProfileSPIFFE
+)
+
+// verification returns how the origin of the bundle is verified.
+func (cfg *BundleConfig) verification() string {
+	if cfg.SPIFFE != nil {
+		if cfg.SPIFFE.Profile == SPIFFEProfileSPIFFE {
+			return VerificationSPIFFE
+		}
+		return VerificationSPIFFEWeb
+	}
+	for _, e := range cfg.bundleURLs() {
+		if u, err := url.Parse(e.URL); err != nil || u.Scheme != "https" {
+			return VerificationNone
+		}
+	}
+	return VerificationTLS
+}
+
+// provenanceAnnotations returns the provenance annotations of the managed
+// ConfigMaps of a source.
+func provenanceAnnotations(cfg *BundleConfig) map[string]string {
+	annotations := map[string]string{
+		SourceURLAnnotation:    cfg.sourceURL(),
+		VerificationAnnotation: cfg.verification(),
+	}
+	if cfg.PolicyVersion != "" {
+		annotations[PolicyVersionAnnotation] = cfg.PolicyVersion
+	}
+	return annotations
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Provenance annotations", func() {
+	root := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+
+	parse := func(data map[string]string) (*BundleConfig, error) {
+		return ParseBundleConfig(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"},
+			Data:       data,
+		})
+	}
+
+	DescribeTable("records how the source was verified",
+		func(data map[string]string, want string) {
+			cfg, err := parse(data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.verification()).To(Equal(want))
+		},
+		Entry("plain HTTP", map[string]string{BundleURLKey: "http://pki.example.com/"}, VerificationNone),
+		Entry("HTTPS", map[string]string{BundleURLKey: "https://pki.example.com/"}, VerificationTLS),
+		Entry("SPIFFE bundle endpoint", map[string]string{
+			SPIFFEBundleEndpointKey: "https://spire.example.com/bundle", SPIFFETrustDomainKey: "example.com",
+		}, VerificationSPIFFEWeb),
+	)
+
+	It("stamps the managed ConfigMaps", func() {
+		cfg, err := parse(map[string]string{BundleURLKey: "https://pki.example.com/", PolicyVersionKey: "2026.1"})
+		Expect(err).NotTo(HaveOccurred())
+		cm, err := (&CABundleReconciler{}).desiredConfigMap("apps", root, cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cm.Annotations).To(HaveKeyWithValue(SourceURLAnnotation, "https://pki.example.com/"))
+		Expect(cm.Annotations).To(HaveKeyWithValue(VerificationAnnotation, VerificationTLS))
+		Expect(cm.Annotations).To(HaveKeyWithValue(PolicyVersionAnnotation, "2026.1"))
+		Expect(cm.Annotations).To(HaveKey(ContentHashAnnotation))
+	})
+
+	It("rejects a policy version that can't be matched on", func() {
+		_, err := parse(map[string]string{BundleURLKey: "https://pki.example.com/", PolicyVersionKey: "v1 draft"})
+		Expect(err).To(MatchError(ContainSubstring(PolicyVersionKey)))
+	})
+})
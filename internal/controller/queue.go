@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// SyncQueue carries sync requests from every producer, such as the periodic
+// runner and TriggerHandler, to the controller. It is owned by whoever
+// creates it and never closed: producers stop by cancelling the context
+// they enqueue with, and the controller stops reading when the manager
+// stops, so no producer can send on a closed channel during shutdown.
+type SyncQueue chan event.GenericEvent
+
+// NewSyncQueue returns an empty SyncQueue.
+func NewSyncQueue() SyncQueue {
+	return make(SyncQueue)
+}
+
+// Enqueue requests a sync of the source, blocking until the controller
+// picked it up or ctx is done.
+func (q SyncQueue) Enqueue(ctx context.Context, obj client.Object) error {
+	select {
+	case q <- event.GenericEvent{Object: obj}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
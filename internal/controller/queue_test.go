@@ -0,0 +1,72 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("Sync queue", func() {
+	source := func(name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "cert-manager", Name: name}}
+	}
+
+	It("delivers the requests of several producers", func() {
+		q := NewSyncQueue()
+		var producers sync.WaitGroup
+		for _, name := range []string{"periodic", "trigger", "webhook"} {
+			producers.Add(1)
+			go func() {
+				defer producers.Done()
+				defer GinkgoRecover()
+				Expect(q.Enqueue(context.Background(), source(name))).To(Succeed())
+			}()
+		}
+
+		var names []string
+		for range 3 {
+			var ev event.GenericEvent
+			Eventually(q).Should(Receive(&ev))
+			names = append(names, ev.Object.GetName())
+		}
+		producers.Wait()
+		Expect(names).To(ConsistOf("periodic", "trigger", "webhook"))
+	})
+
+	It("stops a producer by its context and stays open for the others", func() {
+		q := NewSyncQueue()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(q.Enqueue(ctx, source("stopped"))).To(MatchError(context.Canceled))
+
+		go func() {
+			defer GinkgoRecover()
+			Expect(q.Enqueue(context.Background(), source("running"))).To(Succeed())
+		}()
+		var ev event.GenericEvent
+		Eventually(q).Should(Receive(&ev))
+		Expect(ev.Object.GetName()).To(Equal("running"))
+	})
+})
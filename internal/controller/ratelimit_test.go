@@ -0,0 +1,56 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Rate limiter", func() {
+	request := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "cert-manager", Name: name}}
+	}
+
+	It("backs off the failures of a source exponentially up to the maximum delay", func() {
+		rl := NewRateLimiter(10*time.Millisecond, 50*time.Millisecond, 1000, 1000)
+		roots := request("corp-roots")
+
+		Expect(rl.When(roots)).To(Equal(10 * time.Millisecond))
+		Expect(rl.When(roots)).To(Equal(20 * time.Millisecond))
+		Expect(rl.When(roots)).To(Equal(40 * time.Millisecond))
+		Expect(rl.When(roots)).To(Equal(50 * time.Millisecond))
+		Expect(rl.NumRequeues(roots)).To(Equal(4))
+		Expect(rl.When(request("partner-roots"))).To(Equal(10*time.Millisecond), "sources back off independently")
+
+		rl.Forget(roots)
+		Expect(rl.When(roots)).To(Equal(10 * time.Millisecond))
+	})
+
+	It("limits the overall rate to the QPS beyond the burst", func() {
+		rl := NewRateLimiter(time.Millisecond, time.Millisecond, 1, 2)
+
+		Expect(rl.When(request("a"))).To(Equal(time.Millisecond))
+		Expect(rl.When(request("b"))).To(Equal(time.Millisecond))
+		Expect(rl.When(request("c"))).To(BeNumerically("~", time.Second, 100*time.Millisecond))
+	})
+})
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Remote cluster kubeconfig Secret conventions.
+const (
+	// KubeconfigSecretKey is the Secret key holding a plain kubeconfig.
+	KubeconfigSecretKey = "kubeconfig"
+	// clusterAPIPrefix marks a remote cluster entry as a cluster-api Cluster.
+	clusterAPIPrefix = "cluster/"
+	// clusterAPISecretKey is the key of the kubeconfig Secret cluster-api
+	// generates for every Cluster as <cluster>-kubeconfig.
+	clusterAPISecretKey = "value"
+)
+
+// RemoteCluster is a cluster the bundle is replicated into, reached through a
+// kubeconfig Secret in the source namespace.
+type RemoteCluster struct {
+	// Name identifies the cluster in logs and status.
+	Name string
+	// SecretName and SecretKey locate the kubeconfig.
+	SecretName string
+	SecretKey  string
+}
+
+// parseRemoteCluster parses a remote_clusters entry. A plain name refers to a
+// Secret holding a kubeconfig under the "kubeconfig" key, while
+// "cluster/<name>" refers to a cluster-api Cluster in the source namespace.
+func parseRemoteCluster(entry string) (RemoteCluster, error) {
+	if name, ok := strings.CutPrefix(entry, clusterAPIPrefix); ok {
+		if name == "" {
+			return RemoteCluster{}, fmt.Errorf("invalid %s entry %q", RemoteClustersKey, entry)
+		}
+		return RemoteCluster{Name: entry, SecretName: name + "-kubeconfig", SecretKey: clusterAPISecretKey}, nil
+	}
+	if strings.Contains(entry, "/") {
+		return RemoteCluster{}, fmt.Errorf("invalid %s entry %q", RemoteClustersKey, entry)
+	}
+	return RemoteCluster{Name: entry, SecretName: entry, SecretKey: KubeconfigSecretKey}, nil
+}
+
+// +kubebuilder:rbac:groups=core,namespace=system,resources=secrets,verbs=get
+
+// remoteClients caches the client of every remote cluster by its kubeconfig
+// Secret, so clients are only rebuilt once the Secret changed.
+type remoteClients struct {
+	mu      sync.Mutex
+	entries map[string]remoteClientEntry
+}
+
+type remoteClientEntry struct {
+	resourceVersion string
+	client          client.Client
+}
+
+// get returns the client cached for the Secret key at the resourceVersion.
+func (c *remoteClients) get(key, resourceVersion string) (client.Client, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || e.resourceVersion != resourceVersion {
+		return nil, false
+	}
+	return e.client, true
+}
+
+// put caches the client built from the Secret key at the resourceVersion.
+func (c *remoteClients) put(key, resourceVersion string, cl client.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]remoteClientEntry{}
+	}
+	c.entries[key] = remoteClientEntry{resourceVersion: resourceVersion, client: cl}
+}
+
+// remoteClient returns a client for the remote cluster from its kubeconfig
+// Secret. The Secret is read through SecretReader, so the manager caches no
+// Secrets, and the client is reused until the Secret changes.
+func (r *CABundleReconciler) remoteClient(ctx context.Context, cfg *BundleConfig, rc RemoteCluster) (client.Client, error) {
+	reader := r.SecretReader
+	if reader == nil {
+		reader = r.Client
+	}
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: rc.SecretName, Namespace: cfg.SourceNamespace}, secret); err != nil {
+		return nil, fmt.Errorf("fetching kubeconfig Secret %s: %w", rc.SecretName, err)
+	}
+	key := cfg.SourceNamespace + "/" + rc.SecretName + "/" + rc.SecretKey
+	if c, ok := r.remotes.get(key, secret.ResourceVersion); ok {
+		return c, nil
+	}
+	kubeconfig, ok := secret.Data[rc.SecretKey]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig Secret %s has no %s key", rc.SecretName, rc.SecretKey)
+	}
+
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig from Secret %s: %w", rc.SecretName, err)
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: r.Scheme})
+	if err != nil {
+		return nil, err
+	}
+	if r.FieldManager != "" {
+		c = client.WithFieldOwner(c, r.FieldManager)
+	}
+	if r.DryRun {
+		c = client.NewDryRunClient(c)
+	}
+	r.remotes.put(key, secret.ResourceVersion, c)
+	return c, nil
+}
+
+// forCluster returns a copy of the reconciler writing to a remote cluster
+// through the client. Every exported setting is carried over, so settings
+// added later apply to remote clusters too, along with the current Tuning;
+// the reconciler's state, such as locks and circuit breakers, is not.
+// Settings about the local cluster's cache don't apply remotely.
+func (r *CABundleReconciler) forCluster(c client.Client, cluster string) *CABundleReconciler {
+	remote := &CABundleReconciler{}
+	from, to := reflect.ValueOf(r).Elem(), reflect.ValueOf(remote).Elem()
+	for i := range from.NumField() {
+		if from.Type().Field(i).IsExported() {
+			to.Field(i).Set(from.Field(i))
+		}
+	}
+	remote.Client = c
+	remote.UncachedReader = nil
+	remote.WatchNamespaces = nil
+	remote.started = r.started
+	remote.cluster = cluster
+	remote.SetTuning(r.tuning())
+	return remote
+}
+
+// syncRemoteCluster replicates the managed ConfigMaps into a remote cluster,
+// resolving the bundle's namespace targeting against that cluster.
+func (r *CABundleReconciler) syncRemoteCluster(ctx context.Context, cfg *BundleConfig, rc RemoteCluster, bundles []PEMFile) (int, error) {
+	logger := logf.FromContext(ctx).WithValues("cluster", rc.Name)
+	ctx = logf.IntoContext(ctx, logger)
+
+	c, err := r.remoteClient(ctx, cfg, rc)
+	if err != nil {
+		return 0, err
+	}
+	remote := r.forCluster(c, rc.Name)
+
+	namespaces, err := remote.resolveTargetNamespaces(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	synced := 0
+	var firstErr error
+	for _, ns := range namespaces {
+		unlock := r.lockNamespace(rc.Name, ns)
+		err := remote.syncNamespace(ctx, ns, bundles, cfg.ForTarget(rc.Name, ns))
+		unlock()
+		if err != nil {
+			logger.Error(err, "unable to sync namespace", "namespace", ns)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		synced++
+	}
+	if firstErr != nil {
+		return synced, firstErr
+	}
+
+	return synced, remote.cleanUpUntargetedNamespaces(ctx, cfg, namespaces)
+}
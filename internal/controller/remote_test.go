@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Remote clusters", func() {
+	parse := func(v string) (*BundleConfig, error) {
+		return ParseBundleConfig(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data: map[string]string{
+				BundleURLKey:      "https://pki.example.com/certs/",
+				RemoteClustersKey: v,
+			},
+		})
+	}
+
+	It("resolves kubeconfig Secrets and cluster-api Clusters", func() {
+		cfg, err := parse("edge-west-kubeconfig, cluster/edge-east")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.RemoteClusters).To(Equal([]RemoteCluster{
+			{Name: "edge-west-kubeconfig", SecretName: "edge-west-kubeconfig", SecretKey: KubeconfigSecretKey},
+			{Name: "cluster/edge-east", SecretName: "edge-east-kubeconfig", SecretKey: "value"},
+		}))
+	})
+
+	It("rejects malformed entries", func() {
+		_, err := parse("cluster/")
+		Expect(err).To(HaveOccurred())
+		_, err = parse("other-ns/secret")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reuses the client of a remote cluster until its Secret changes", func() {
+		kubeconfig := func(server string) []byte {
+			return []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: edge
+  cluster:
+    server: ` + server + `
+contexts:
+- name: edge
+  context:
+    cluster: edge
+current-context: edge
+`)
+		}
+		ctx := context.Background()
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-west-kubeconfig", Namespace: "cert-manager"},
+			Data:       map[string][]byte{KubeconfigSecretKey: kubeconfig("https://edge-west.example.com:6443")},
+		}
+		reader := fake.NewClientBuilder().WithObjects(secret).Build()
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().Build(), SecretReader: reader, Scheme: clientgoscheme.Scheme}
+		cfg, err := parse("edge-west-kubeconfig")
+		Expect(err).NotTo(HaveOccurred())
+
+		first, err := r.remoteClient(ctx, cfg, cfg.RemoteClusters[0])
+		Expect(err).NotTo(HaveOccurred())
+		again, err := r.remoteClient(ctx, cfg, cfg.RemoteClusters[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(BeIdenticalTo(first))
+
+		secret.Data[KubeconfigSecretKey] = kubeconfig("https://edge-west-2.example.com:6443")
+		Expect(reader.Update(ctx, secret)).To(Succeed())
+		rotated, err := r.remoteClient(ctx, cfg, cfg.RemoteClusters[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotated).NotTo(BeIdenticalTo(first))
+
+		_, err = (&CABundleReconciler{Client: reader, SecretReader: fake.NewClientBuilder().Build()}).remoteClient(ctx, cfg, cfg.RemoteClusters[0])
+		Expect(err).To(HaveOccurred(), "the Secret is only read through the SecretReader")
+	})
+
+	It("carries the reconciler settings over to remote clusters", func() {
+		notifier := &recordingNotifier{}
+		r := &CABundleReconciler{TargetNamespace: "cert-manager", CleanupMode: CleanupReport, Notifier: notifier,
+			AllowNamespaceCreation: true, WatchNamespaces: []string{"cert-manager"}, UncachedReader: fake.NewClientBuilder().Build()}
+		r.SetTuning(Tuning{FullSyncInterval: 42})
+		remote := fake.NewClientBuilder().Build()
+
+		copied := r.forCluster(remote, "edge-west")
+		Expect(copied.Client).To(BeIdenticalTo(remote))
+		Expect(copied.cluster).To(Equal("edge-west"))
+		Expect(copied.Notifier).To(BeIdenticalTo(notifier))
+		Expect(copied.TargetNamespace).To(Equal("cert-manager"))
+		Expect(copied.CleanupMode).To(Equal(CleanupReport))
+		Expect(copied.AllowNamespaceCreation).To(BeTrue())
+		Expect(copied.tuning().FullSyncInterval).To(BeEquivalentTo(42))
+		Expect(copied.WatchNamespaces).To(BeNil(), "the local cache doesn't confine remote clusters")
+		Expect(copied.UncachedReader).To(BeNil())
+	})
+})
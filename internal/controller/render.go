@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Render downloads and validates the bundle of a source and renders its
+// managed ConfigMaps for the namespaces, without a cluster, e.g. for GitOps
+// pipelines committing the rendered trust. Unlike a sync, a file that fails
+// to download or holds an invalid certificate fails the render. The bundle
+// files are returned along with the ConfigMaps, the aggregate included.
+func (r *CABundleReconciler) Render(ctx context.Context, src *corev1.ConfigMap, namespaces []string) ([]corev1.ConfigMap, []PEMFile, error) {
+	cfg, err := r.parseBundleConfig(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(namespaces) == 0 {
+		if cfg.NamespaceSelector != nil || cfg.AllNamespaces {
+			return nil, nil, fmt.Errorf("%s and %s can only be resolved against a cluster, name the namespaces instead",
+				NamespaceSelectorKey, AllNamespacesKey)
+		}
+		namespaces = cfg.TargetNamespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{r.TargetNamespace}
+		}
+	}
+
+	index, err := listBundles(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	bundles, failed, err := downloadBundles(ctx, cfg, index)
+	if err != nil {
+		return nil, nil, err
+	}
+	var errs []error
+	for _, f := range failed {
+		errs = append(errs, fmt.Errorf("%s: %w", f.Filename, f.Err))
+	}
+	for _, b := range bundles {
+		if _, err := ParseCertificates(b.Content); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Filename, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+	if cfg.AggregateName != "" {
+		bundles = append(bundles, aggregateBundle(cfg.AggregateName, bundles))
+	}
+
+	var out []corev1.ConfigMap
+	for _, ns := range namespaces {
+		tcfg := cfg.ForTarget("", ns)
+		for _, b := range bundles {
+			cm, err := r.desiredConfigMap(ns, b, tcfg)
+			if err != nil {
+				return nil, nil, err
+			}
+			out = append(out, *cm)
+		}
+	}
+	return out, bundles, nil
+}
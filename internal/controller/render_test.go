@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Rendering without a cluster", func() {
+	ctx := context.Background()
+
+	serve := func(files map[string][]byte) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/" {
+				_, _ = w.Write([]byte("<html><body>"))
+				for name := range files {
+					_, _ = w.Write([]byte(`<a href="` + name + `">` + name + `</a>`))
+				}
+				_, _ = w.Write([]byte("</body></html>"))
+				return
+			}
+			_, _ = w.Write(files[req.URL.Path[1:]])
+		}))
+	}
+	source := func(url string, data map[string]string) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       map[string]string{BundleURLKey: url},
+		}
+		for k, v := range data {
+			cm.Data[k] = v
+		}
+		return cm
+	}
+
+	It("renders the ConfigMaps of every target namespace", func() {
+		srv := serve(map[string][]byte{"root.pem": newTestCAPEM("Corp Root", time.Now().Add(time.Hour))})
+		defer srv.Close()
+		r := &CABundleReconciler{TargetNamespace: "cert-manager"}
+
+		cms, bundles, err := r.Render(ctx, source(srv.URL, map[string]string{
+			TargetNamespacesKey: "apps,web",
+			AggregateKey:        "ca-bundle",
+		}), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(HaveLen(2))
+		Expect(cms).To(HaveLen(4))
+		Expect(cms[0].Namespace).To(Equal("apps"))
+		Expect(cms[0].Name).To(Equal("root"))
+		Expect(cms[0].Labels).To(HaveKeyWithValue(SourceLabel, "corp-roots"))
+		Expect(cms[3].Namespace).To(Equal("web"))
+		Expect(cms[3].Name).To(Equal("ca-bundle"))
+	})
+
+	It("fails on files without valid certificates", func() {
+		srv := serve(map[string][]byte{"broken.pem": []byte("not a certificate")})
+		defer srv.Close()
+		r := &CABundleReconciler{TargetNamespace: "cert-manager"}
+
+		_, _, err := r.Render(ctx, source(srv.URL, nil), nil)
+		Expect(err).To(MatchError(ContainSubstring("broken.pem")))
+	})
+
+	It("needs the namespaces of selector targeting", func() {
+		r := &CABundleReconciler{TargetNamespace: "cert-manager"}
+		_, _, err := r.Render(ctx, source("http://127.0.0.1:1", map[string]string{AllNamespacesKey: "true"}), nil)
+		Expect(err).To(MatchError(ContainSubstring("name the namespaces")))
+	})
+})
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// RevisionOfLabel is set on the ConfigMaps retaining a previous revision of
+// a managed ConfigMap to the name of the managed ConfigMap, see
+// BundleConfig.RetainRevisions. They don't carry the AppLabel, so cleanup
+// and drift detection leave them alone.
+const RevisionOfLabel = "cabundle.io/revision-of"
+
+// MaxRetainRevisions bounds retain_revisions.
+const MaxRetainRevisions = 100
+
+// revisionConfigMapName returns the name of the ConfigMap retaining a
+// revision of a managed ConfigMap. The dot keeps it apart from the managed
+// ConfigMaps named after files.
+func revisionConfigMapName(name string, revision int64) string {
+	return fmt.Sprintf("%s.rev-%d", name, revision)
+}
+
+// revisionOf returns the revision a managed or retained ConfigMap holds, 0 if
+// it has none.
+func revisionOf(cm *corev1.ConfigMap) int64 {
+	revision, _ := strconv.ParseInt(cm.Annotations[RevisionAnnotation], 10, 64)
+	return max(revision, 0)
+}
+
+// retainRevision copies the content of a managed ConfigMap about to change
+// into a ConfigMap of its own, so it can be rolled back to, and prunes the
+// copies beyond the source's RetainRevisions. ConfigMaps written before the
+// revision was introduced aren't retained.
+func (r *CABundleReconciler) retainRevision(ctx context.Context, live *corev1.ConfigMap, cfg *BundleConfig) error {
+	if revision := revisionOf(live); cfg.RetainRevisions > 0 && revision > 0 {
+		retained := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      revisionConfigMapName(live.Name, revision),
+				Namespace: live.Namespace,
+				Labels: map[string]string{
+					RevisionOfLabel:      live.Name,
+					SourceLabel:          cfg.SourceName,
+					SourceNamespaceLabel: cfg.SourceNamespace,
+				},
+				Annotations: map[string]string{
+					RevisionAnnotation:    live.Annotations[RevisionAnnotation],
+					ContentHashAnnotation: live.Annotations[ContentHashAnnotation],
+				},
+			},
+			Data:       live.Data,
+			BinaryData: live.BinaryData,
+		}
+		logf.FromContext(ctx).V(1).Info("Retaining ConfigMap revision", "revision", revision, "retained", retained.Name)
+		if err := r.Create(ctx, retained); client.IgnoreAlreadyExists(err) != nil {
+			return fmt.Errorf("retaining revision %d of ConfigMap %s: %w", revision, r.describeConfigMap(live.Namespace, live.Name), err)
+		}
+	}
+	// Also prunes the revisions retained before retain_revisions was
+	// lowered.
+	return r.pruneRevisions(ctx, live.Namespace, live.Name, cfg.RetainRevisions, cfg.PinnedRevision)
+}
+
+// listRevisions returns the retained revisions of a managed ConfigMap,
+// newest first.
+func (r *CABundleReconciler) listRevisions(ctx context.Context, namespace, name string) ([]corev1.ConfigMap, error) {
+	list := &corev1.ConfigMapList{}
+	if err := r.cleanupReader().List(ctx, list, client.InNamespace(namespace), client.MatchingLabels{RevisionOfLabel: name}); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(list.Items, func(a, b corev1.ConfigMap) int {
+		return cmp.Compare(revisionOf(&b), revisionOf(&a))
+	})
+	return list.Items, nil
+}
+
+// pruneRevisions deletes the retained revisions of a managed ConfigMap
+// beyond the newest keep, except the pinned one.
+func (r *CABundleReconciler) pruneRevisions(ctx context.Context, namespace, name string, keep int, pinned int64) error {
+	revisions, err := r.listRevisions(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	revisions = slices.DeleteFunc(revisions, func(cm corev1.ConfigMap) bool {
+		return pinned > 0 && revisionOf(&cm) == pinned
+	})
+	for i := keep; i < len(revisions); i++ {
+		logf.FromContext(ctx).V(1).Info("Pruning ConfigMap revision", "retained", revisions[i].Name)
+		if err := r.Delete(ctx, &revisions[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
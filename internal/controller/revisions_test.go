@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Retained revisions", func() {
+	ctx := context.Background()
+
+	var (
+		r   *CABundleReconciler
+		cfg *BundleConfig
+	)
+	BeforeEach(func() {
+		r = &CABundleReconciler{Client: fake.NewClientBuilder().Build()}
+		cfg = &BundleConfig{SourceName: "root-ca", SourceNamespace: "cert-manager", Formats: []string{FormatPEM}, RetainRevisions: 2}
+	})
+
+	// publish syncs a new root certificate into apps.
+	publish := func(cn string) []byte {
+		content := newTestCAPEM(cn, time.Now().Add(time.Hour))
+		Expect(r.syncNamespace(ctx, "apps", []PEMFile{{Filename: "root.pem", Content: content}}, cfg)).To(Succeed())
+		return content
+	}
+	retained := func() []string {
+		revisions, err := r.listRevisions(ctx, "apps", "root")
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, cm := range revisions {
+			names = append(names, cm.Name)
+		}
+		return names
+	}
+
+	It("keeps the previous revisions of a changed ConfigMap", func() {
+		var published [][]byte
+		for i := 1; i <= 4; i++ {
+			published = append(published, publish(fmt.Sprintf("Root CA %d", i)))
+		}
+		Expect(retained()).To(Equal([]string{"root.rev-3", "root.rev-2"}))
+
+		cm := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "root.rev-3"}, cm)).To(Succeed())
+		Expect(cm.Data["ca.crt"]).To(Equal(string(published[2])))
+		Expect(cm.Annotations).To(HaveKeyWithValue(RevisionAnnotation, "3"))
+		Expect(cm.Labels).NotTo(HaveKey(AppLabel))
+
+		// Cleanup leaves the retained revisions alone.
+		Expect(r.CleanUpConfigMaps(ctx, "apps", []PEMFile{{Filename: "root.pem"}}, cfg)).To(Succeed())
+		Expect(retained()).To(HaveLen(2))
+	})
+
+	It("prunes the revisions no longer retained", func() {
+		publish("Root CA 1")
+		publish("Root CA 2")
+		publish("Root CA 3")
+		cfg.RetainRevisions = 1
+		publish("Root CA 4")
+		Expect(retained()).To(Equal([]string{"root.rev-3"}))
+
+		// A stale ConfigMap is deleted along with its revisions.
+		Expect(r.CleanUpConfigMaps(ctx, "apps", nil, cfg)).To(Succeed())
+		Expect(retained()).To(BeEmpty())
+	})
+
+	It("keeps revisions apart from ConfigMaps named after files", func() {
+		sync := func(cn string) {
+			content := newTestCAPEM(cn, time.Now().Add(time.Hour))
+			Expect(r.syncNamespace(ctx, "apps", []PEMFile{
+				{Filename: "root.pem", Content: content},
+				{Filename: "root-rev-1.pem", Content: content},
+			}, cfg)).To(Succeed())
+		}
+		sync("Root CA 1")
+		sync("Root CA 2")
+		Expect(retained()).To(Equal([]string{"root.rev-1"}))
+
+		managed := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "root-rev-1"}, managed)).To(Succeed())
+		Expect(managed.Labels).To(HaveKeyWithValue(AppLabel, AppLabelValue))
+	})
+})
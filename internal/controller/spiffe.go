@@ -0,0 +1,315 @@
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// SPIFFE bundle endpoint profiles, see the SPIFFE Trust Domain and Bundle
+// specification.
+const (
+	// SPIFFEProfileWeb authenticates the endpoint with the Web PKI.
+	SPIFFEProfileWeb = "https_web"
+	// SPIFFEProfileSPIFFE authenticates the endpoint by its SPIFFE ID.
+	SPIFFEProfileSPIFFE = "https_spiffe"
+)
+
+// SPIFFESource is a SPIFFE bundle endpoint serving the trust bundle of a
+// trust domain.
+type SPIFFESource struct {
+	EndpointURL string
+	TrustDomain string
+	Profile     string
+	// EndpointID is the SPIFFE ID the endpoint must present with the
+	// https_spiffe profile, verified against EndpointRoots until the
+	// endpoint served its own trust domain's bundle.
+	EndpointID    string
+	EndpointRoots *x509.CertPool
+	// endpointBundle is the PEM form of EndpointRoots.
+	endpointBundle string
+}
+
+// spiffeEndpoint is the client of an https_spiffe bundle endpoint, kept
+// across syncs so connections are reused and the endpoint's roots follow
+// the bundles it serves.
+type spiffeEndpoint struct {
+	id string
+	// configured is the PEM bundle the roots were initialised with.
+	configured string
+	client     *http.Client
+
+	mu    sync.RWMutex
+	roots *x509.CertPool
+}
+
+// spiffeEndpoints holds the client of every https_spiffe endpoint by URL and
+// SPIFFE ID.
+var spiffeEndpoints = struct {
+	sync.Mutex
+	m map[string]*spiffeEndpoint
+}{m: map[string]*spiffeEndpoint{}}
+
+// parseSPIFFESource reads the SPIFFE bundle endpoint keys of the source
+// ConfigMap.
+func parseSPIFFESource(data map[string]string) (*SPIFFESource, error) {
+	s := &SPIFFESource{
+		EndpointURL: strings.TrimSpace(data[SPIFFEBundleEndpointKey]),
+		TrustDomain: strings.TrimSpace(data[SPIFFETrustDomainKey]),
+		Profile:     SPIFFEProfileWeb,
+	}
+	if u, err := url.Parse(s.EndpointURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s %q: must be an https URL", SPIFFEBundleEndpointKey, s.EndpointURL)
+	}
+	if s.TrustDomain == "" {
+		return nil, fmt.Errorf("%s is required with %s", SPIFFETrustDomainKey, SPIFFEBundleEndpointKey)
+	}
+	if _, err := url.Parse("spiffe://" + s.TrustDomain); err != nil || strings.ContainsAny(s.TrustDomain, "/:@") {
+		return nil, fmt.Errorf("invalid %s %q", SPIFFETrustDomainKey, s.TrustDomain)
+	}
+
+	if v := strings.TrimSpace(data[SPIFFEEndpointProfileKey]); v != "" {
+		s.Profile = v
+	}
+	switch s.Profile {
+	case SPIFFEProfileWeb:
+	case SPIFFEProfileSPIFFE:
+		s.EndpointID = strings.TrimSpace(data[SPIFFEEndpointIDKey])
+		if u, err := url.Parse(s.EndpointID); err != nil || u.Scheme != "spiffe" || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s %q: must be a SPIFFE ID", SPIFFEEndpointIDKey, s.EndpointID)
+		}
+		s.endpointBundle = data[SPIFFEEndpointBundleKey]
+		s.EndpointRoots = x509.NewCertPool()
+		if !s.EndpointRoots.AppendCertsFromPEM([]byte(s.endpointBundle)) {
+			return nil, fmt.Errorf("%s must hold the PEM roots of the endpoint's trust domain", SPIFFEEndpointBundleKey)
+		}
+	default:
+		return nil, fmt.Errorf("unknown %s %q", SPIFFEEndpointProfileKey, s.Profile)
+	}
+	return s, nil
+}
+
+// httpClient returns the client authenticating the endpoint according to
+// its profile, web for the Web PKI.
+func (s *SPIFFESource) httpClient(web *http.Client) *http.Client {
+	if s.Profile != SPIFFEProfileSPIFFE {
+		return web
+	}
+	return s.endpoint().client
+}
+
+// endpoint returns the client of the https_spiffe endpoint, creating it on
+// first use and whenever the configured roots change.
+func (s *SPIFFESource) endpoint() *spiffeEndpoint {
+	spiffeEndpoints.Lock()
+	defer spiffeEndpoints.Unlock()
+
+	key := s.EndpointURL + " " + s.EndpointID
+	if e := spiffeEndpoints.m[key]; e != nil && e.configured == s.endpointBundle {
+		return e
+	}
+	e := &spiffeEndpoint{id: s.EndpointID, configured: s.endpointBundle, roots: s.EndpointRoots}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		// The endpoint presents an X509-SVID without DNS names; it is
+		// verified by its SPIFFE ID below instead of the hostname.
+		InsecureSkipVerify: true,
+		VerifyConnection:   e.verify,
+	}
+	e.client = &http.Client{Transport: transport}
+	spiffeEndpoints.m[key] = e
+	return e
+}
+
+// updateRoots replaces the endpoint's roots with the bundle it served when
+// the endpoint belongs to the trust domain of the bundle, as the SPIFFE
+// federation specification requires for https_spiffe endpoints, so it stays
+// trusted across root rotations.
+func (s *SPIFFESource) updateRoots(certs []*x509.Certificate) {
+	id, err := url.Parse(s.EndpointID)
+	if err != nil || id.Host != s.TrustDomain {
+		return
+	}
+	roots := x509.NewCertPool()
+	for _, c := range certs {
+		roots.AddCert(c)
+	}
+	e := s.endpoint()
+	e.mu.Lock()
+	e.roots = roots
+	e.mu.Unlock()
+}
+
+// verify checks the endpoint's X509-SVID chains to its current roots and
+// carries the expected SPIFFE ID.
+func (e *spiffeEndpoint) verify(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("bundle endpoint presented no certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, c := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+	e.mu.RLock()
+	roots := e.roots
+	e.mu.RUnlock()
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return fmt.Errorf("verifying bundle endpoint: %w", err)
+	}
+	for _, u := range leaf.URIs {
+		if u.String() == e.id {
+			return nil
+		}
+	}
+	return fmt.Errorf("bundle endpoint is not %s", e.id)
+}
+
+// spiffeBundle is the JWK Set document served by a bundle endpoint.
+type spiffeBundle struct {
+	Keys []spiffeKey `json:"keys"`
+}
+
+// spiffeKey is a JWK of a SPIFFE bundle. Only the members of X.509
+// authorities are modelled.
+type spiffeKey struct {
+	Use string `json:"use"`
+	Kty string `json:"kty,omitempty"`
+	// RSA public keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP public keys.
+	Crv string   `json:"crv,omitempty"`
+	X   string   `json:"x,omitempty"`
+	Y   string   `json:"y,omitempty"`
+	X5C []string `json:"x5c"`
+}
+
+// DownloadSPIFFEBundle fetches the trust bundle of the source and converts
+// its X.509 authorities into a single PEM file named after the trust
+// domain. JWT authorities are skipped, they have no PEM form. Bundles larger
+// than limit bytes are rejected.
+func DownloadSPIFFEBundle(ctx context.Context, c *http.Client, s *SPIFFESource, limit int64) (_ []PEMFile, err error) {
+	ctx, span := tracer.Start(ctx, "FetchSPIFFEBundle", trace.WithAttributes(
+		attribute.String("url", s.EndpointURL),
+		attribute.String("trust_domain", s.TrustDomain),
+	))
+	defer func() { endSpan(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.EndpointURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient(c).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch SPIFFE bundle: %s", resp.Status)
+	}
+	data, err := readLimited(resp, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SPIFFE bundle: %w", err)
+	}
+
+	certs, err := parseSPIFFEBundle(data)
+	if err != nil {
+		return nil, fmt.Errorf("SPIFFE bundle of %s: %w", s.TrustDomain, err)
+	}
+	logf.FromContext(ctx).V(2).Info("Downloaded SPIFFE bundle", "url", s.EndpointURL, "authorities", len(certs))
+	if s.Profile == SPIFFEProfileSPIFFE {
+		s.updateRoots(certs)
+	}
+
+	var content []byte
+	for _, c := range certs {
+		content = append(content, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	return []PEMFile{{Filename: s.TrustDomain + ".pem", Content: content}}, nil
+}
+
+// parseSPIFFEBundle returns the X.509 authorities of a SPIFFE bundle.
+func parseSPIFFEBundle(data []byte) ([]*x509.Certificate, error) {
+	var bundle spiffeBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("parsing JWK Set: %w", err)
+	}
+
+	var certs []*x509.Certificate
+	for i, key := range bundle.Keys {
+		if key.Use != "x509-svid" {
+			continue
+		}
+		if len(key.X5C) != 1 {
+			return nil, fmt.Errorf("key %d: x509-svid authority must have exactly one x5c entry", i)
+		}
+		der, err := base64.StdEncoding.DecodeString(key.X5C[0])
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no X.509 authorities")
+	}
+	return certs, nil
+}
+
+// encodeSPIFFEBundle encodes the certificates as the X.509 authorities of a
+// SPIFFE bundle, which SPIRE and other SPIFFE implementations accept for
+// federation. Each authority carries its public key parameters, as the JWK
+// Set layout requires, and the certificate in x5c.
+func encodeSPIFFEBundle(certs []*x509.Certificate) ([]byte, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	bundle := spiffeBundle{Keys: make([]spiffeKey, 0, len(certs))}
+	for _, c := range certs {
+		key := spiffeKey{Use: "x509-svid", X5C: []string{base64.StdEncoding.EncodeToString(c.Raw)}}
+		switch pub := c.PublicKey.(type) {
+		case *rsa.PublicKey:
+			key.Kty = "RSA"
+			key.N = b64(pub.N.Bytes())
+			key.E = b64(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			// JWK requires the coordinates padded to the field size.
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			key.Kty = "EC"
+			key.Crv = pub.Curve.Params().Name
+			key.X = b64(pub.X.FillBytes(make([]byte, size)))
+			key.Y = b64(pub.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			key.Kty = "OKP"
+			key.Crv = "Ed25519"
+			key.X = b64(pub)
+		default:
+			return nil, fmt.Errorf("%s: unsupported public key type %T", c.Subject, c.PublicKey)
+		}
+		bundle.Keys = append(bundle.Keys, key)
+	}
+	return json.MarshalIndent(bundle, "", "  ")
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newTestSVID returns a certificate for the SPIFFE ID issued by a new CA,
+// and the CA.
+func newTestSVID(id, caName string) (tls.Certificate, *x509.Certificate) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: caName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	Expect(err).NotTo(HaveOccurred())
+	ca, err := x509.ParseCertificate(caDER)
+	Expect(err).NotTo(HaveOccurred())
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	u, err := url.Parse(id)
+	Expect(err).NotTo(HaveOccurred())
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{u},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	Expect(err).NotTo(HaveOccurred())
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, ca
+}
+
+var _ = Describe("SPIFFE bundle endpoint", func() {
+	parse := func(data map[string]string) (*BundleConfig, error) {
+		return ParseBundleConfig(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "spire", Namespace: "cert-manager"},
+			Data:       data,
+		})
+	}
+
+	It("converts the X.509 authorities of the JWK Set", func() {
+		block, _ := pem.Decode(newTestCAPEM("SPIRE Root", time.Now().Add(time.Hour)))
+		doc := fmt.Sprintf(`{"spiffe_sequence": 3, "keys": [
+			{"use": "x509-svid", "kty": "EC", "crv": "P-256", "x": "a", "y": "b", "x5c": [%q]},
+			{"use": "jwt-svid", "kty": "EC", "kid": "k1", "crv": "P-256", "x": "a", "y": "b"}
+		]}`, base64.StdEncoding.EncodeToString(block.Bytes))
+
+		certs, err := parseSPIFFEBundle([]byte(doc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs).To(HaveLen(1))
+		Expect(certs[0].Subject.CommonName).To(Equal("SPIRE Root"))
+
+		_, err = parseSPIFFEBundle([]byte(`{"keys": [{"use": "jwt-svid"}]}`))
+		Expect(err).To(MatchError(ContainSubstring("no X.509 authorities")))
+	})
+
+	It("requires a trust domain and, for https_spiffe, the endpoint's identity", func() {
+		cfg, err := parse(map[string]string{
+			SPIFFEBundleEndpointKey: "https://spire.example.com/bundle",
+			SPIFFETrustDomainKey:    "example.com",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.SPIFFE.Profile).To(Equal(SPIFFEProfileWeb))
+		Expect(cfg.sourceURL()).To(Equal("https://spire.example.com/bundle"))
+
+		_, err = parse(map[string]string{SPIFFEBundleEndpointKey: "https://spire.example.com/bundle"})
+		Expect(err).To(HaveOccurred())
+
+		_, err = parse(map[string]string{
+			SPIFFEBundleEndpointKey:  "https://spire.example.com/bundle",
+			SPIFFETrustDomainKey:     "example.com",
+			SPIFFEEndpointProfileKey: SPIFFEProfileSPIFFE,
+			SPIFFEEndpointIDKey:      "spiffe://example.com/spire/server",
+		})
+		Expect(err).To(MatchError(ContainSubstring(SPIFFEEndpointBundleKey)))
+
+		cfg, err = parse(map[string]string{
+			SPIFFEBundleEndpointKey:  "https://spire.example.com/bundle",
+			SPIFFETrustDomainKey:     "example.com",
+			SPIFFEEndpointProfileKey: SPIFFEProfileSPIFFE,
+			SPIFFEEndpointIDKey:      "spiffe://example.com/spire/server",
+			SPIFFEEndpointBundleKey:  string(newTestCAPEM("SPIRE Root", time.Now().Add(time.Hour))),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.SPIFFE.EndpointID).To(Equal("spiffe://example.com/spire/server"))
+	})
+
+	It("rejects a source with both bundle_url and a bundle endpoint", func() {
+		_, err := parse(map[string]string{
+			BundleURLKey:            "https://pki.example.com/certs/",
+			SPIFFEBundleEndpointKey: "https://spire.example.com/bundle",
+			SPIFFETrustDomainKey:    "example.com",
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reuses the endpoint client and follows the roots it serves", func() {
+		const id = "spiffe://example.com/spire/server"
+		oldSVID, oldCA := newTestSVID(id, "SPIRE Root 1")
+		newSVID, newCA := newTestSVID(id, "SPIRE Root 2")
+
+		var rotated atomic.Bool
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			bundle, err := encodeSPIFFEBundle([]*x509.Certificate{oldCA, newCA})
+			Expect(err).NotTo(HaveOccurred())
+			_, _ = w.Write(bundle)
+		}))
+		srv.TLS = &tls.Config{GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			if rotated.Load() {
+				return &tls.Config{Certificates: []tls.Certificate{newSVID}}, nil
+			}
+			return &tls.Config{Certificates: []tls.Certificate{oldSVID}}, nil
+		}}
+		srv.StartTLS()
+		defer srv.Close()
+
+		data := map[string]string{
+			SPIFFEBundleEndpointKey:  srv.URL,
+			SPIFFETrustDomainKey:     "example.com",
+			SPIFFEEndpointProfileKey: SPIFFEProfileSPIFFE,
+			SPIFFEEndpointIDKey:      id,
+			SPIFFEEndpointBundleKey:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: oldCA.Raw})),
+		}
+		cfg, err := parse(data)
+		Expect(err).NotTo(HaveOccurred())
+		bundles, err := DownloadSPIFFEBundle(context.Background(), http.DefaultClient, cfg.SPIFFE, 1<<20)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundles).To(HaveLen(1))
+
+		rotated.Store(true)
+		again, err := parse(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again.httpClient()).To(BeIdenticalTo(cfg.httpClient()))
+		again.httpClient().CloseIdleConnections()
+		_, err = DownloadSPIFFEBundle(context.Background(), http.DefaultClient, again.SPIFFE, 1<<20)
+		Expect(err).NotTo(HaveOccurred())
+
+		other := map[string]string{}
+		for k, v := range data {
+			other[k] = v
+		}
+		other[SPIFFEEndpointIDKey] = "spiffe://example.com/other"
+		cfg, err = parse(other)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = DownloadSPIFFEBundle(context.Background(), http.DefaultClient, cfg.SPIFFE, 1<<20)
+		Expect(err).To(MatchError(ContainSubstring("bundle endpoint")))
+	})
+})
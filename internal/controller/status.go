@@ -0,0 +1,365 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// StatusKey is the data key holding the serialized BundleStatus in the
+	// status ConfigMap.
+	StatusKey = "status.yaml"
+	// StatusForLabel marks a status ConfigMap with the name of its source.
+	StatusForLabel = "cabundle.io/status-for"
+	// DefaultHistoryLimit is the number of sync results kept by default.
+	DefaultHistoryLimit = 10
+)
+
+// Outcomes of a sync recorded in the history.
+const (
+	SyncSucceeded = "Succeeded"
+	SyncFailed    = "Failed"
+	SyncDegraded  = "Degraded"
+)
+
+// ConditionDegraded reports that some targets of the last sync failed.
+const ConditionDegraded = "Degraded"
+
+// ConditionStale reports that the managed ConfigMaps hold the content of the
+// last successful sync because the source is unavailable.
+const ConditionStale = "Stale"
+
+// DefaultDegradedRetry is when a partially synced bundle is retried unless
+// the reconciler's Tuning sets its own DegradedRetry.
+const DefaultDegradedRetry = time.Minute
+
+// BundleStatus is the observed state of a bundle source. It is kept in a
+// companion ConfigMap next to the source since ConfigMaps have no status
+// subresource.
+type BundleStatus struct {
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// NextSyncTime is when the source is planned to be synced next.
+	NextSyncTime *metav1.Time      `json:"nextSyncTime,omitempty"`
+	Namespaces   []NamespaceStatus `json:"namespaces,omitempty"`
+	Clusters     []ClusterStatus   `json:"clusters,omitempty"`
+	Files        []FileStatus      `json:"files,omitempty"`
+	// Conflicts are the managed ConfigMaps several files are rendered
+	// into.
+	Conflicts []BundleConflict `json:"conflicts,omitempty"`
+	// LastExportTime is when the bundle was last uploaded to the export
+	// buckets, Exports the last upload to each of them.
+	LastExportTime *metav1.Time   `json:"lastExportTime,omitempty"`
+	Exports        []ExportStatus `json:"exports,omitempty"`
+	// RolledOutHash identifies the bundle version distributed to every
+	// target, Canary the version being soaked on the canary namespaces.
+	RolledOutHash string        `json:"rolledOutHash,omitempty"`
+	Canary        *CanaryStatus `json:"canary,omitempty"`
+	// SoonestExpiry is the NotAfter of the distributed certificate expiring
+	// first.
+	SoonestExpiry *metav1.Time `json:"soonestExpiry,omitempty"`
+	// PendingChange is the bundle version held back by a maintenance
+	// window.
+	PendingChange *PendingChange `json:"pendingChange,omitempty"`
+	// UnacknowledgedChange is the bundle version awaiting acknowledgement,
+	// see ChangeAlert.
+	UnacknowledgedChange *UnacknowledgedChange `json:"unacknowledgedChange,omitempty"`
+	// Plan holds the changes awaiting approval, see PlanAnnotation.
+	Plan *Plan `json:"plan,omitempty"`
+	// FullSync records the last sync that downloaded every file and
+	// brought every target up to date, see indexUnchanged.
+	FullSync *FullSync `json:"fullSync,omitempty"`
+	// History holds the most recent sync results, oldest first.
+	History []SyncRecord `json:"history,omitempty"`
+	// Conditions summarize the state of the source, e.g. SourceReachable.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Health classifies the conditions as Healthy, Progressing or Degraded,
+	// see bundleHealth.
+	Health *Health `json:"health,omitempty"`
+}
+
+// SyncRecord is the result of a single sync.
+type SyncRecord struct {
+	Time    metav1.Time `json:"time"`
+	Outcome string      `json:"outcome"`
+	// Hash identifies the downloaded bundle version, see bundleSetHash.
+	Hash             string `json:"hash,omitempty"`
+	Files            int    `json:"files"`
+	Namespaces       int    `json:"namespaces"`
+	FailedNamespaces int    `json:"failedNamespaces,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// NamespaceStatus is the sync result for a single target namespace.
+type NamespaceStatus struct {
+	Namespace    string       `json:"namespace"`
+	ConfigMaps   int          `json:"configMaps"`
+	Synced       bool         `json:"synced"`
+	Error        string       `json:"error,omitempty"`
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// ExportStatus is the last upload of the bundle to a single export bucket.
+type ExportStatus struct {
+	URL string `json:"url"`
+	// Hash is the SHA-256 of the bundle last uploaded successfully.
+	Hash           string       `json:"hash,omitempty"`
+	Error          string       `json:"error,omitempty"`
+	LastExportTime *metav1.Time `json:"lastExportTime,omitempty"`
+}
+
+// ClusterStatus is the sync result for a single remote cluster.
+type ClusterStatus struct {
+	Name         string       `json:"name"`
+	Namespaces   int          `json:"namespaces"`
+	Synced       bool         `json:"synced"`
+	Error        string       `json:"error,omitempty"`
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// FileStatus maps a downloaded file to the managed ConfigMap it is rendered
+// into.
+type FileStatus struct {
+	Filename  string `json:"filename"`
+	ConfigMap string `json:"configMap"`
+	// Hash is the SHA-256 of the downloaded content.
+	Hash           string       `json:"hash"`
+	Certificates   int          `json:"certificates"`
+	LastChangeTime *metav1.Time `json:"lastChangeTime,omitempty"`
+	Error          string       `json:"error,omitempty"`
+}
+
+// statusConfigMapName returns the name of the status ConfigMap for a source.
+// The dot keeps it apart from the managed ConfigMaps named after files.
+func statusConfigMapName(source string) string {
+	return source + ".status"
+}
+
+// legacyStatusConfigMapName returns the name the status ConfigMap of a source
+// had before it took a dot, read until the status is next written.
+func legacyStatusConfigMapName(source string) string {
+	return source + "-status"
+}
+
+// getLegacyStatusConfigMap returns the legacy status ConfigMap of a source, or
+// nil if there is none. A ConfigMap of that name not labeled as the source's
+// status is a managed ConfigMap named after a file, and isn't returned.
+func (r *CABundleReconciler) getLegacyStatusConfigMap(ctx context.Context, src *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, client.ObjectKey{Name: legacyStatusConfigMapName(src.Name), Namespace: src.Namespace}, cm)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if cm.Labels[StatusForLabel] != src.Name {
+		return nil, nil
+	}
+	return cm, nil
+}
+
+// getStatus reads the current status of a source, returning an empty status
+// if none has been recorded yet.
+func (r *CABundleReconciler) getStatus(ctx context.Context, src *corev1.ConfigMap) (*BundleStatus, error) {
+	status := &BundleStatus{}
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, client.ObjectKey{Name: statusConfigMapName(src.Name), Namespace: src.Namespace}, cm)
+	if apierrors.IsNotFound(err) {
+		if cm, err = r.getLegacyStatusConfigMap(ctx, src); err != nil || cm == nil {
+			return status, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal([]byte(cm.Data[StatusKey]), status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// namespaceStatus returns the recorded status of a namespace, if any.
+func (s *BundleStatus) namespaceStatus(namespace string) *NamespaceStatus {
+	for i := range s.Namespaces {
+		if s.Namespaces[i].Namespace == namespace {
+			return &s.Namespaces[i]
+		}
+	}
+	return nil
+}
+
+// clusterStatus returns the recorded status of a remote cluster, if any.
+func (s *BundleStatus) clusterStatus(name string) *ClusterStatus {
+	for i := range s.Clusters {
+		if s.Clusters[i].Name == name {
+			return &s.Clusters[i]
+		}
+	}
+	return nil
+}
+
+// fileStatus returns the recorded status of a downloaded file, if any.
+func (s *BundleStatus) fileStatus(filename string) *FileStatus {
+	for i := range s.Files {
+		if s.Files[i].Filename == filename {
+			return &s.Files[i]
+		}
+	}
+	return nil
+}
+
+// fileStatuses describes every bundle file, keeping the last change time of
+// files whose content didn't change.
+func (r *CABundleReconciler) fileStatuses(cfg *BundleConfig, bundles []PEMFile, prev *BundleStatus) []FileStatus {
+	now := metav1.Now()
+	files := make([]FileStatus, 0, len(bundles))
+	for _, b := range bundles {
+		sum := sha256.Sum256(b.Content)
+		fs := FileStatus{
+			Filename:       b.Filename,
+			ConfigMap:      r.configMapName(b.Filename, cfg),
+			Hash:           hex.EncodeToString(sum[:]),
+			LastChangeTime: &now,
+		}
+		if certs, err := ParseCertificates(b.Content); err != nil {
+			fs.Error = err.Error()
+		} else {
+			fs.Certificates = len(certs)
+		}
+		if p := prev.fileStatus(b.Filename); p != nil && p.Hash == fs.Hash && p.LastChangeTime != nil {
+			fs.LastChangeTime = p.LastChangeTime
+		}
+		files = append(files, fs)
+	}
+	return files
+}
+
+// recordSync appends a sync result to the history, dropping the oldest
+// entries beyond limit.
+func (s *BundleStatus) recordSync(rec SyncRecord, limit int) {
+	s.History = append(s.History, rec)
+	if len(s.History) > limit {
+		s.History = slices.Clone(s.History[len(s.History)-limit:])
+	}
+}
+
+// failedFileStatuses returns the status of the files that failed to
+// download, carrying over what was last synced for them.
+func (r *CABundleReconciler) failedFileStatuses(cfg *BundleConfig, failed []FailedFile, prev *BundleStatus) []FileStatus {
+	files := make([]FileStatus, 0, len(failed))
+	for _, f := range failed {
+		fs := FileStatus{Filename: f.Filename, ConfigMap: r.configMapName(f.Filename, cfg)}
+		if p := prev.fileStatus(f.Filename); p != nil {
+			fs = *p
+		}
+		fs.Error = f.Err.Error()
+		files = append(files, fs)
+	}
+	return files
+}
+
+// degradedCondition returns the Degraded condition listing the failed
+// targets of a sync in which succeeded targets were synced.
+func degradedCondition(failures []string, succeeded int) metav1.Condition {
+	if len(failures) == 0 {
+		return metav1.Condition{
+			Type:    ConditionDegraded,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Synced",
+			Message: "All targets synced",
+		}
+	}
+
+	reason := "PartialFailure"
+	if succeeded == 0 {
+		reason = "SyncFailed"
+	}
+	return metav1.Condition{
+		Type:    ConditionDegraded,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: fmt.Sprintf("%d failed: %s", len(failures), strings.Join(failures, "; ")),
+	}
+}
+
+// updateStatus writes the status of a source into its status ConfigMap.
+func (r *CABundleReconciler) updateStatus(ctx context.Context, src *corev1.ConfigMap, status *BundleStatus) error {
+	// The source version of the full sync follows the health annotations,
+	// which aren't a change of the source.
+	version := src.ResourceVersion
+	if err := r.publishHealth(ctx, src, status); err != nil {
+		return err
+	}
+	if status.FullSync != nil && status.FullSync.SourceVersion == version {
+		status.FullSync.SourceVersion = src.ResourceVersion
+	}
+
+	out, err := yaml.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      statusConfigMapName(src.Name),
+			Namespace: src.Namespace,
+		},
+	}
+	var result controllerutil.OperationResult
+	err = retry.OnError(retry.DefaultRetry, isWriteConflict, func() error {
+		result, err = controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+			if cm.Labels == nil {
+				cm.Labels = map[string]string{}
+			}
+			cm.Labels[StatusForLabel] = src.Name
+			cm.Data = map[string]string{StatusKey: string(out)}
+			return controllerutil.SetOwnerReference(src, cm, r.Scheme)
+		})
+		return err
+	})
+	if err != nil || result != controllerutil.OperationResultCreated {
+		return err
+	}
+	// The status moved: drop the legacy ConfigMap it was read from.
+	legacy, err := r.getLegacyStatusConfigMap(ctx, src)
+	if err != nil || legacy == nil {
+		return err
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, legacy))
+}
+
+// staleCondition returns the Stale condition for the outcome of a download.
+// A failed download leaves the managed ConfigMaps untouched, serving the
+// content of the last successful sync.
+func staleCondition(err error, lastSync *metav1.Time) metav1.Condition {
+	if err == nil {
+		return metav1.Condition{
+			Type:    ConditionStale,
+			Status:  metav1.ConditionFalse,
+			Reason:  "UpToDate",
+			Message: "Managed ConfigMaps hold the current bundle",
+		}
+	}
+	message := fmt.Sprintf("Source unavailable, no bundle distributed yet: %v", err)
+	if lastSync != nil {
+		message = fmt.Sprintf("Source unavailable, serving the bundle synced at %s: %v", lastSync.UTC().Format(time.RFC3339), err)
+	}
+	return metav1.Condition{
+		Type:    ConditionStale,
+		Status:  metav1.ConditionTrue,
+		Reason:  "SourceUnavailable",
+		Message: message,
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("File status", func() {
+	r := &CABundleReconciler{}
+	cfg := &BundleConfig{}
+
+	It("describes every downloaded file", func() {
+		root := append(newTestCAPEM("Root A", time.Now().Add(time.Hour)), newTestCAPEM("Root B", time.Now().Add(time.Hour))...)
+		files := r.fileStatuses(cfg, []PEMFile{
+			{Filename: "Corp_Root.pem", Content: root},
+			{Filename: "broken.crt", Content: []byte("not a certificate")},
+		}, &BundleStatus{})
+
+		Expect(files).To(HaveLen(2))
+		Expect(files[0].ConfigMap).To(Equal("corp-root"))
+		Expect(files[0].Certificates).To(Equal(2))
+		Expect(files[0].Error).To(BeEmpty())
+		Expect(files[1].Error).NotTo(BeEmpty())
+	})
+
+	It("keeps the last change time of unchanged files", func() {
+		content := newTestCAPEM("Root A", time.Now().Add(time.Hour))
+		first := r.fileStatuses(cfg, []PEMFile{{Filename: "root.pem", Content: content}}, &BundleStatus{})
+		changed := metav1.NewTime(time.Now().Add(-time.Hour))
+		first[0].LastChangeTime = &changed
+
+		second := r.fileStatuses(cfg, []PEMFile{{Filename: "root.pem", Content: content}}, &BundleStatus{Files: first})
+		Expect(second[0].LastChangeTime).To(Equal(&changed))
+
+		third := r.fileStatuses(cfg, []PEMFile{{Filename: "root.pem", Content: newTestCAPEM("Root C", time.Now().Add(time.Hour))}}, &BundleStatus{Files: first})
+		Expect(third[0].LastChangeTime.Time).To(BeTemporally(">", changed.Time))
+	})
+
+	It("keeps the most recent sync results", func() {
+		status := &BundleStatus{}
+		for i := range 5 {
+			status.recordSync(SyncRecord{Outcome: SyncSucceeded, Files: i}, 3)
+		}
+		Expect(status.History).To(HaveLen(3))
+		Expect(status.History[0].Files).To(Equal(2))
+		Expect(status.History[2].Files).To(Equal(4))
+
+		status.recordSync(SyncRecord{Outcome: SyncFailed}, 0)
+		Expect(status.History).To(BeEmpty())
+	})
+
+	It("lists failed targets in the Degraded condition", func() {
+		Expect(degradedCondition(nil, 3).Status).To(Equal(metav1.ConditionFalse))
+
+		partial := degradedCondition([]string{"namespace apps: forbidden"}, 2)
+		Expect(partial.Status).To(Equal(metav1.ConditionTrue))
+		Expect(partial.Reason).To(Equal("PartialFailure"))
+		Expect(partial.Message).To(ContainSubstring("namespace apps: forbidden"))
+
+		Expect(degradedCondition([]string{"cluster edge: unreachable"}, 0).Reason).To(Equal("SyncFailed"))
+	})
+
+	It("reports the sooner of the planned sync and a requeue", func() {
+		r := &CABundleReconciler{}
+		key := types.NamespacedName{Namespace: "cert-manager", Name: "corp-roots"}
+		Expect(r.nextSyncTime(key, 0)).To(BeNil())
+
+		planned := time.Now().Add(time.Hour).Truncate(time.Second)
+		r.SetNextSync(key, planned)
+		Expect(r.nextSyncTime(key, 0).Time).To(BeTemporally("==", planned))
+		Expect(r.nextSyncTime(key, time.Minute).Time).To(BeTemporally("<", planned))
+		Expect(r.nextSyncTime(key, 2*time.Hour).Time).To(BeTemporally("==", planned))
+
+		r.SetNextSync(key, time.Time{})
+		Expect(r.nextSyncTime(key, 0)).To(BeNil())
+	})
+})
+
+var _ = Describe("Status ConfigMap", func() {
+	It("moves the status out of its legacy ConfigMap, leaving files of that name alone", func() {
+		ctx := context.Background()
+		src := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"}}
+		legacy := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "root-ca-status", Namespace: "cert-manager", Labels: map[string]string{StatusForLabel: "root-ca"}},
+			Data:       map[string]string{StatusKey: "lastSyncTime: \"2025-01-01T00:00:00Z\"\n"},
+		}
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().WithObjects(src, legacy).Build(), Scheme: clientgoscheme.Scheme}
+
+		status, err := r.getStatus(ctx, src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.LastSyncTime).NotTo(BeNil())
+
+		Expect(r.updateStatus(ctx, src, status)).To(Succeed())
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "cert-manager", Name: "root-ca.status"}, &corev1.ConfigMap{})).To(Succeed())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(legacy), &corev1.ConfigMap{})).NotTo(Succeed())
+
+		// A managed ConfigMap rendered from bundle-status.pem isn't a status.
+		managed := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "bundle-status", Namespace: "cert-manager"}}
+		other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "bundle", Namespace: "cert-manager"}}
+		r.Client = fake.NewClientBuilder().WithObjects(managed, other).Build()
+		status, err = r.getStatus(ctx, other)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(&BundleStatus{}))
+		Expect(r.updateStatus(ctx, other, status)).To(Succeed())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(managed), &corev1.ConfigMap{})).To(Succeed())
+	})
+})
@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Labels identifying the source a managed ConfigMap was rendered from.
+const (
+	AppLabel             = "app"
+	AppLabelValue        = "cabundle-operator"
+	SourceLabel          = "cabundle.io/source"
+	SourceNamespaceLabel = "cabundle.io/source-namespace"
+)
+
+// BundleSourceLabel set to "true" on a ConfigMap in the operator's namespace,
+// or one of the SourceNamespaces, makes it a bundle source, in addition to
+// the --configmap-name ConfigMap.
+const BundleSourceLabel = "cabundle.io/bundle-source"
+
+// AllSourceNamespaces among the SourceNamespaces accepts labeled sources in
+// every namespace.
+const AllSourceNamespaces = "*"
+
+// OptOutAnnotation on a Namespace excludes it from bundles distributed to all
+// namespaces. The value is "true" to opt out of every bundle, or a comma
+// separated list of source ConfigMap names.
+const OptOutAnnotation = "cabundle.io/opt-out"
+
+// RequestLabelPrefix followed by <namespace>.<name> of a source ConfigMap
+// labels a Namespace requesting that bundle, e.g.
+// bundles.cabundle.io/cert-manager.corp-roots: "true". Sources whose
+// namespace and name don't fit into a label name can't be requested, which
+// is recorded as a ReasonNotRequestable Event.
+const RequestLabelPrefix = "bundles.cabundle.io/"
+
+// managedLabels returns the labels set on every ConfigMap managed for the
+// bundle.
+func managedLabels(cfg *BundleConfig) map[string]string {
+	return map[string]string{
+		AppLabel:             AppLabelValue,
+		SourceLabel:          cfg.SourceName,
+		SourceNamespaceLabel: cfg.SourceNamespace,
+	}
+}
+
+// resolveTargetNamespaces returns the sorted set of namespaces the bundle is
+// distributed to.
+func (r *CABundleReconciler) resolveTargetNamespaces(ctx context.Context, cfg *BundleConfig) ([]string, error) {
+	if r.OnlyNamespace != "" {
+		return []string{r.OnlyNamespace}, nil
+	}
+	set := map[string]struct{}{}
+	for _, ns := range cfg.TargetNamespaces {
+		if !r.watched(ns) {
+			logf.FromContext(ctx).Info("Skipping target namespace outside the watched namespaces", "namespace", ns)
+			continue
+		}
+		set[ns] = struct{}{}
+	}
+	if !cfg.hasTargeting() {
+		set[r.TargetNamespace] = struct{}{}
+	}
+
+	candidates, err := r.candidateNamespaces(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range candidates {
+		if ns.DeletionTimestamp == nil && r.namespaceTargeted(&ns, cfg) {
+			set[ns.Name] = struct{}{}
+		}
+	}
+
+	namespaces := make([]string, 0, len(set))
+	for ns := range set {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// candidateNamespaces lists the namespaces the bundle may target from the
+// manager's cache, selected by the namespace selector and the request label
+// unless the bundle goes to all namespaces.
+func (r *CABundleReconciler) candidateNamespaces(ctx context.Context, cfg *BundleConfig) ([]corev1.Namespace, error) {
+	if cfg.AllNamespaces {
+		nsList := &corev1.NamespaceList{}
+		if err := r.List(ctx, nsList); err != nil {
+			return nil, err
+		}
+		return nsList.Items, nil
+	}
+
+	var selectors []client.ListOption
+	if key, ok := requestLabel(cfg); ok {
+		selectors = append(selectors, client.MatchingLabels{key: "true"})
+	} else {
+		logf.FromContext(ctx).Info("Namespaces can't request the bundle, its request label is too long", "label", key)
+		r.eventf(cfg, corev1.EventTypeWarning, ReasonNotRequestable,
+			"Namespaces can't request the bundle: %s isn't a valid label name", key)
+	}
+	if cfg.NamespaceSelector != nil {
+		selectors = append(selectors, client.MatchingLabelsSelector{Selector: cfg.NamespaceSelector})
+	}
+	var namespaces []corev1.Namespace
+	for _, selector := range selectors {
+		nsList := &corev1.NamespaceList{}
+		if err := r.List(ctx, nsList, selector); err != nil {
+			return nil, err
+		}
+		namespaces = append(namespaces, nsList.Items...)
+	}
+	return namespaces, nil
+}
+
+// requestLabel returns the label of namespaces requesting the bundle, and
+// whether its namespace and name fit into one. Namespaces hold no dots, so
+// sources named alike in different namespaces get different labels.
+func requestLabel(cfg *BundleConfig) (string, bool) {
+	key := RequestLabelPrefix + cfg.SourceNamespace + "." + cfg.SourceName
+	return key, len(validation.IsQualifiedName(key)) == 0
+}
+
+// ensureNamespace creates the target namespace, labeled with the source's
+// namespace_labels, if it doesn't exist and AllowNamespaceCreation is set.
+// Namespaces are never deleted.
+func (r *CABundleReconciler) ensureNamespace(ctx context.Context, namespace string, cfg *BundleConfig) error {
+	err := r.Get(ctx, client.ObjectKey{Name: namespace}, &corev1.Namespace{})
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	if !r.AllowNamespaceCreation {
+		return fmt.Errorf("namespace %s doesn't exist, and %s is ignored since the operator doesn't allow namespace creation", namespace, CreateNamespacesKey)
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: maps.Clone(cfg.NamespaceLabels)}}
+	if err := r.Create(ctx, ns); apierrors.IsAlreadyExists(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("creating namespace %s: %w", namespace, err)
+	}
+	logf.FromContext(ctx).Info("Created target namespace", "namespace", namespace, "dryRun", r.DryRun)
+	r.eventf(cfg, corev1.EventTypeNormal, ReasonNamespaceCreated, "Created target namespace %s", r.describeNamespace(namespace))
+	return nil
+}
+
+// hasTargeting reports whether the bundle configures any namespace targeting
+// beyond the reconciler's default target namespace.
+func (cfg *BundleConfig) hasTargeting() bool {
+	return len(cfg.TargetNamespaces) > 0 || cfg.NamespaceSelector != nil || cfg.AllNamespaces
+}
+
+// namespaceTargeted reports whether the bundle targets the namespace.
+func (r *CABundleReconciler) namespaceTargeted(ns *corev1.Namespace, cfg *BundleConfig) bool {
+	if r.OnlyNamespace != "" {
+		return ns.Name == r.OnlyNamespace
+	}
+	if !r.watched(ns.Name) {
+		return false
+	}
+	if slices.Contains(cfg.TargetNamespaces, ns.Name) || optedIn(ns, cfg) {
+		return true
+	}
+	if cfg.NamespaceSelector != nil && cfg.NamespaceSelector.Matches(labels.Set(ns.Labels)) {
+		return true
+	}
+	if cfg.AllNamespaces {
+		return !optedOut(ns, cfg)
+	}
+	if !cfg.hasTargeting() {
+		return ns.Name == r.TargetNamespace
+	}
+	return false
+}
+
+// optedIn reports whether the namespace requested the bundle through its
+// request label.
+func optedIn(ns *corev1.Namespace, cfg *BundleConfig) bool {
+	key, ok := requestLabel(cfg)
+	return ok && ns.Labels[key] == "true"
+}
+
+// optedOut reports whether the namespace opted out of the bundle.
+func optedOut(ns *corev1.Namespace, cfg *BundleConfig) bool {
+	v, ok := ns.Annotations[OptOutAnnotation]
+	if !ok {
+		return false
+	}
+	if all, err := strconv.ParseBool(v); err == nil {
+		return all
+	}
+	return slices.Contains(splitList(v), cfg.SourceName)
+}
+
+// cleanUpUntargetedNamespaces deletes the bundle's managed ConfigMaps from
+// namespaces that are no longer targeted, e.g. after a namespace lost the
+// label matched by the namespace selector.
+func (r *CABundleReconciler) cleanUpUntargetedNamespaces(ctx context.Context, cfg *BundleConfig, targets []string) error {
+	logger := logf.FromContext(ctx)
+	// Namespaces other than the only one are left alone.
+	if r.OnlyNamespace != "" || r.cleanupMode(cfg) == CleanupDisabled {
+		return nil
+	}
+
+	targeted := map[string]bool{}
+	for _, ns := range targets {
+		targeted[ns] = true
+	}
+
+	cmList := &corev1.ConfigMapList{}
+	if err := r.List(ctx, cmList, client.MatchingLabels{
+		AppLabel:             AppLabelValue,
+		SourceLabel:          cfg.SourceName,
+		SourceNamespaceLabel: cfg.SourceNamespace,
+	}); err != nil {
+		return err
+	}
+
+	for _, cm := range cmList.Items {
+		if targeted[cm.Namespace] {
+			continue
+		}
+		if r.skipCleanup(ctx, cfg, cm.Namespace, cm.Name, CleanupReasonUntargeted) {
+			recordCleanup(ctx, cfg, CleanupReasonUntargeted, false)
+			continue
+		}
+		logger.Info("Deleting ConfigMap from untargeted namespace", "name", cm.Name, "namespace", cm.Namespace, "reason", CleanupReasonUntargeted)
+		err := r.DeleteBundleConfigMap(ctx, cm.Namespace, cm.Name)
+		recordCleanup(ctx, cfg, CleanupReasonUntargeted, err == nil)
+		if err != nil {
+			return err
+		}
+		r.eventf(cfg, corev1.EventTypeNormal, ReasonDeleted, "Deleted ConfigMap %s from untargeted namespace", r.describeConfigMap(cm.Namespace, cm.Name))
+		noteDeleted(ctx, r.describeConfigMap(cm.Namespace, cm.Name))
+	}
+	return nil
+}
+
+// ListSources returns the bundle source ConfigMaps: the ConfigMap named
+// ConfigMapName in TargetNamespace and every ConfigMap labeled
+// cabundle.io/bundle-source=true in TargetNamespace and SourceNamespaces,
+// within OnlyNamespace if set.
+func (r *CABundleReconciler) ListSources(ctx context.Context) ([]corev1.ConfigMap, error) {
+	cmList := &corev1.ConfigMapList{}
+	if err := r.List(ctx, cmList, client.MatchingLabels{BundleSourceLabel: "true"}); err != nil {
+		return nil, err
+	}
+
+	sources := slices.DeleteFunc(cmList.Items, func(cm corev1.ConfigMap) bool {
+		return !r.isSource(&cm)
+	})
+	if !slices.ContainsFunc(sources, func(cm corev1.ConfigMap) bool {
+		return cm.Namespace == r.TargetNamespace && cm.Name == r.ConfigMapName
+	}) && (r.OnlyNamespace == "" || r.OnlyNamespace == r.TargetNamespace) {
+		cm := corev1.ConfigMap{}
+		err := r.Get(ctx, client.ObjectKey{Name: r.ConfigMapName, Namespace: r.TargetNamespace}, &cm)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		if err == nil {
+			sources = append(sources, cm)
+		}
+	}
+	return sources, nil
+}
+
+// mapNamespaceToSources enqueues every source, since namespace labels and
+// annotations may change the targeting of any bundle.
+func (r *CABundleReconciler) mapNamespaceToSources(ctx context.Context, _ client.Object) []reconcile.Request {
+	logger := logf.FromContext(ctx)
+
+	sources, err := r.ListSources(ctx)
+	if err != nil {
+		logger.Error(err, "unable to list bundle sources")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(sources))
+	for _, src := range sources {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&src)})
+	}
+	return requests
+}
+
+// namespaceTargetingChanged passes Namespace updates that change its labels
+// or annotations.
+var namespaceTargetingChanged = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !labels.Equals(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) ||
+			!labels.Equals(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations())
+	},
+}
@@ -0,0 +1,503 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("Namespace targeting", func() {
+	r := &CABundleReconciler{TargetNamespace: "cert-manager"}
+
+	namespace := func(name string, labels, annotations map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations}}
+	}
+
+	parse := func(data map[string]string) *BundleConfig {
+		data[BundleURLKey] = "https://pki.example.com/certs/"
+		cfg, err := ParseBundleConfig(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       data,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return cfg
+	}
+
+	It("defaults to the reconciler's target namespace", func() {
+		cfg := parse(map[string]string{})
+		Expect(r.namespaceTargeted(namespace("cert-manager", nil, nil), cfg)).To(BeTrue())
+		Expect(r.namespaceTargeted(namespace("apps", nil, nil), cfg)).To(BeFalse())
+	})
+
+	It("matches namespaces by label selector", func() {
+		cfg := parse(map[string]string{NamespaceSelectorKey: "trust.corp/inject=true"})
+		Expect(r.namespaceTargeted(namespace("apps", map[string]string{"trust.corp/inject": "true"}, nil), cfg)).To(BeTrue())
+		Expect(r.namespaceTargeted(namespace("cert-manager", nil, nil), cfg)).To(BeFalse())
+	})
+
+	It("honours opt-out in all-namespaces mode", func() {
+		cfg := parse(map[string]string{AllNamespacesKey: "true"})
+		Expect(r.namespaceTargeted(namespace("apps", nil, nil), cfg)).To(BeTrue())
+		Expect(r.namespaceTargeted(namespace("apps", nil, map[string]string{OptOutAnnotation: "true"}), cfg)).To(BeFalse())
+		Expect(r.namespaceTargeted(namespace("apps", nil, map[string]string{OptOutAnnotation: "other"}), cfg)).To(BeTrue())
+		Expect(r.namespaceTargeted(namespace("apps", nil, map[string]string{OptOutAnnotation: "corp-roots"}), cfg)).To(BeFalse())
+	})
+
+	It("adds namespaces that request the bundle by name", func() {
+		cfg := parse(map[string]string{TargetNamespacesKey: "istio-system"})
+		Expect(r.namespaceTargeted(namespace("apps", map[string]string{RequestLabelPrefix + "cert-manager.corp-roots": "true"}, nil), cfg)).To(BeTrue())
+		Expect(r.namespaceTargeted(namespace("apps", map[string]string{RequestLabelPrefix + "cert-manager.other": "true"}, nil), cfg)).To(BeFalse())
+		Expect(r.namespaceTargeted(namespace("apps", nil, map[string]string{"cabundle.io/inject": "corp-roots"}), cfg)).To(BeFalse())
+
+		By("telling apart sources named alike in different namespaces")
+		Expect(r.namespaceTargeted(namespace("apps", map[string]string{RequestLabelPrefix + "pki.corp-roots": "true"}, nil), cfg)).To(BeFalse())
+	})
+
+	It("records an Event for sources namespaces can't request", func() {
+		recorder := record.NewFakeRecorder(1)
+		resolver := &CABundleReconciler{Client: fake.NewClientBuilder().Build(), TargetNamespace: "cert-manager", Recorder: recorder}
+		cfg := parse(map[string]string{NamespaceSelectorKey: "trust.corp/inject=true"})
+		cfg.SourceName = strings.Repeat("corp-roots-", 6)
+		_, ok := requestLabel(cfg)
+		Expect(ok).To(BeFalse())
+
+		Expect(resolver.resolveTargetNamespaces(context.Background(), cfg)).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring(ReasonNotRequestable)))
+	})
+
+	It("applies per-target overrides", func() {
+		cfg := parse(map[string]string{TargetOverridesKey: `
+- namespace: istio-system
+  names:
+    ca-bundle: istio-ca-root-cert
+  labels:
+    istio.io/config: "true"
+  formats: [pem, jks]
+`})
+		Expect(cfg.ForTarget("", "apps")).To(BeIdenticalTo(cfg))
+
+		target := cfg.ForTarget("", "istio-system")
+		Expect(target.Formats).To(Equal([]string{FormatPEM, FormatJKS}))
+		Expect(r.configMapName("ca-bundle.pem", target)).To(Equal("istio-ca-root-cert"))
+		Expect(r.configMapName("corp-root.pem", target)).To(Equal("corp-root"))
+
+		desired, err := r.desiredConfigMap("istio-system", PEMFile{Filename: "ca-bundle.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}, target)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desired.Labels).To(HaveKeyWithValue("istio.io/config", "true"))
+		Expect(desired.Labels).To(HaveKeyWithValue(SourceLabel, "corp-roots"))
+	})
+
+	It("resolves namespaces selected by label or requesting the bundle", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			namespace("selected", map[string]string{"trust.corp/inject": "true"}, nil),
+			namespace("requested", map[string]string{RequestLabelPrefix + "cert-manager.corp-roots": "true"}, nil),
+			namespace("elsewhere", map[string]string{RequestLabelPrefix + "cert-manager.other": "true"}, nil),
+			namespace("annotated", nil, map[string]string{"cabundle.io/inject": "corp-roots"}),
+		).Build()
+		resolver := &CABundleReconciler{Client: c, TargetNamespace: "cert-manager"}
+
+		cfg := parse(map[string]string{NamespaceSelectorKey: "trust.corp/inject=true", TargetNamespacesKey: "istio-system"})
+		Expect(resolver.resolveTargetNamespaces(context.Background(), cfg)).To(Equal([]string{"istio-system", "requested", "selected"}))
+
+		cfg = parse(map[string]string{})
+		Expect(resolver.resolveTargetNamespaces(context.Background(), cfg)).To(Equal([]string{"cert-manager", "requested"}))
+	})
+
+	It("rejects overrides of reserved labels", func() {
+		_, err := ParseBundleConfig(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data: map[string]string{
+				BundleURLKey:       "https://pki.example.com/certs/",
+				TargetOverridesKey: "- namespace: apps\n  labels:\n    app: other\n",
+			},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects overrides renaming ConfigMaps alike", func() {
+		parseOverride := func(overrides string) error {
+			_, err := ParseBundleConfig(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+				Data: map[string]string{
+					BundleURLKey:       "https://pki.example.com/certs/",
+					AggregateKey:       "ca-bundle",
+					TargetOverridesKey: overrides,
+				},
+			})
+			return err
+		}
+
+		Expect(parseOverride("- namespace: apps\n  names:\n    root: trust\n    issuing: trust\n")).To(
+			MatchError(ContainSubstring(`issuing and root are both renamed to "trust"`)))
+		Expect(parseOverride("- namespace: apps\n  names:\n    root: ca-bundle\n")).To(
+			MatchError(ContainSubstring("aggregate")))
+		Expect(parseOverride("- namespace: apps\n  names:\n    root: corp-roots.status\n")).To(
+			MatchError(ContainSubstring("reserved")))
+		Expect(parseOverride("- namespace: apps\n  names:\n    root: issuing.rev-2\n")).To(
+			MatchError(ContainSubstring("reserved")))
+		Expect(parseOverride("- namespace: apps\n  names:\n    root: issuing\n    issuing: root\n")).To(Succeed())
+	})
+})
+
+var _ = Describe("Source discovery", func() {
+	source := func(ns, name string, labels map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, Labels: labels}}
+	}
+
+	It("lists the configured source and every labeled ConfigMap once", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			source("cert-manager", "periodic-cabundle-enqueue", map[string]string{BundleSourceLabel: "true"}),
+			source("pki", "corp-roots", map[string]string{BundleSourceLabel: "true"}),
+			source("pki", "unrelated", nil),
+		).Build()
+		r := &CABundleReconciler{Client: c, TargetNamespace: "cert-manager", ConfigMapName: "periodic-cabundle-enqueue",
+			SourceNamespaces: []string{"pki"}}
+
+		sources, err := r.ListSources(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, s := range sources {
+			names = append(names, s.Namespace+"/"+s.Name)
+		}
+		Expect(names).To(ConsistOf("cert-manager/periodic-cabundle-enqueue", "pki/corp-roots"))
+	})
+
+	It("tolerates a missing configured source", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			source("pki", "corp-roots", map[string]string{BundleSourceLabel: "true"}),
+		).Build()
+		r := &CABundleReconciler{Client: c, TargetNamespace: "cert-manager", ConfigMapName: "periodic-cabundle-enqueue",
+			SourceNamespaces: []string{"pki"}}
+
+		sources, err := r.ListSources(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources).To(HaveLen(1))
+	})
+
+	It("confines sources and targets to the only namespace", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			source("cert-manager", "periodic-cabundle-enqueue", nil),
+			source("dev", "corp-roots", map[string]string{BundleSourceLabel: "true"}),
+			source("pki", "corp-roots", map[string]string{BundleSourceLabel: "true"}),
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}},
+		).Build()
+		r := &CABundleReconciler{Client: c, TargetNamespace: "cert-manager", ConfigMapName: "periodic-cabundle-enqueue",
+			OnlyNamespace: "dev"}
+
+		sources, err := r.ListSources(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources).To(HaveLen(1))
+		Expect(sources[0].Namespace).To(Equal("dev"))
+
+		sources[0].Data = map[string]string{BundleURLKey: "https://pki.example.com/certs/", AllNamespacesKey: "true"}
+		cfg, err := ParseBundleConfig(&sources[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.resolveTargetNamespaces(context.Background(), cfg)).To(Equal([]string{"dev"}))
+	})
+
+	It("accepts labeled sources only in the source namespaces", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			source("cert-manager", "periodic-cabundle-enqueue", nil),
+			source("cert-manager", "partner-roots", map[string]string{BundleSourceLabel: "true"}),
+			source("pki", "corp-roots", map[string]string{BundleSourceLabel: "true"}),
+			source("apps", "corp-roots", map[string]string{BundleSourceLabel: "true"}),
+		).Build()
+		r := &CABundleReconciler{Client: c, TargetNamespace: "cert-manager", ConfigMapName: "periodic-cabundle-enqueue"}
+		names := func() []string {
+			sources, err := r.ListSources(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			var names []string
+			for _, s := range sources {
+				names = append(names, s.Namespace+"/"+s.Name)
+			}
+			return names
+		}
+
+		Expect(names()).To(ConsistOf("cert-manager/periodic-cabundle-enqueue", "cert-manager/partner-roots"),
+			"only the operator's namespace by default")
+		r.SourceNamespaces = []string{"pki"}
+		Expect(names()).To(ConsistOf("cert-manager/periodic-cabundle-enqueue", "cert-manager/partner-roots", "pki/corp-roots"))
+		r.SourceNamespaces = []string{AllSourceNamespaces}
+		Expect(names()).To(ConsistOf("cert-manager/periodic-cabundle-enqueue", "cert-manager/partner-roots", "pki/corp-roots",
+			"apps/corp-roots"))
+	})
+
+	It("confines sources and targets to the watched namespaces", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			source("cert-manager", "periodic-cabundle-enqueue", nil),
+			source("pki", "corp-roots", map[string]string{BundleSourceLabel: "true"}),
+			source("other", "corp-roots", map[string]string{BundleSourceLabel: "true"}),
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		).Build()
+		r := &CABundleReconciler{Client: c, TargetNamespace: "cert-manager", ConfigMapName: "periodic-cabundle-enqueue",
+			WatchNamespaces: []string{"cert-manager", "pki", "apps"}, SourceNamespaces: []string{AllSourceNamespaces}}
+
+		sources, err := r.ListSources(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, s := range sources {
+			names = append(names, s.Namespace+"/"+s.Name)
+		}
+		Expect(names).To(ConsistOf("cert-manager/periodic-cabundle-enqueue", "pki/corp-roots"))
+
+		src := source("pki", "corp-roots", nil)
+		src.Data = map[string]string{BundleURLKey: "https://pki.example.com/certs/", AllNamespacesKey: "true",
+			TargetNamespacesKey: "web"}
+		cfg, err := ParseBundleConfig(src)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.resolveTargetNamespaces(context.Background(), cfg)).To(Equal([]string{"apps"}))
+	})
+
+	It("doesn't sync ConfigMaps that aren't sources, whatever enqueued them", func() {
+		unrelated := source("apps", "settings", nil)
+		unrelated.Data = map[string]string{BundleURLKey: "https://pki.example.com/certs/", TargetNamespacesKey: "apps"}
+		managed := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "root",
+			Labels: map[string]string{AppLabel: AppLabelValue, SourceLabel: "settings", SourceNamespaceLabel: "apps"}}}
+		c := fake.NewClientBuilder().WithObjects(unrelated, managed,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}).Build()
+		r := &CABundleReconciler{Client: c, TargetNamespace: "cert-manager", ConfigMapName: "periodic-cabundle-enqueue"}
+
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(unrelated)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(context.Background(), client.ObjectKeyFromObject(managed), &corev1.ConfigMap{})).To(Succeed())
+		// Not even a status is recorded.
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "apps", Name: "settings.status"}, &corev1.ConfigMap{})).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("Namespace creation", func() {
+	ctx := context.Background()
+
+	parse := func(data map[string]string) (*BundleConfig, error) {
+		data[BundleURLKey] = "https://pki.example.com/certs/"
+		return ParseBundleConfig(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       data,
+		})
+	}
+
+	It("creates a missing target namespace with the configured labels", func() {
+		cfg, err := parse(map[string]string{CreateNamespacesKey: "true", NamespaceLabelsKey: "team=platform, env=ephemeral"})
+		Expect(err).NotTo(HaveOccurred())
+		recorder := record.NewFakeRecorder(10)
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().Build(), Recorder: recorder, AllowNamespaceCreation: true}
+
+		bundle := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+		Expect(r.syncNamespace(ctx, "preview-42", []PEMFile{bundle}, cfg)).To(Succeed())
+
+		ns := &corev1.Namespace{}
+		Expect(r.Get(ctx, client.ObjectKey{Name: "preview-42"}, ns)).To(Succeed())
+		Expect(ns.Labels).To(Equal(map[string]string{"team": "platform", "env": "ephemeral"}))
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "preview-42", Name: "root"}, &corev1.ConfigMap{})).To(Succeed())
+		Expect(recorder.Events).To(Receive(ContainSubstring("NamespaceCreated")))
+
+		// Existing namespaces are left alone.
+		Expect(r.syncNamespace(ctx, "preview-42", []PEMFile{bundle}, cfg)).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive(ContainSubstring("NamespaceCreated")))
+	})
+
+	It("leaves namespaces alone unless enabled", func() {
+		cfg, err := parse(map[string]string{})
+		Expect(err).NotTo(HaveOccurred())
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().Build()}
+
+		bundle := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+		Expect(r.syncNamespace(ctx, "preview-42", []PEMFile{bundle}, cfg)).To(Succeed())
+		Expect(r.Get(ctx, client.ObjectKey{Name: "preview-42"}, &corev1.Namespace{})).NotTo(Succeed())
+	})
+
+	It("creates no namespace unless the operator allows it", func() {
+		cfg, err := parse(map[string]string{CreateNamespacesKey: "true"})
+		Expect(err).NotTo(HaveOccurred())
+		r := &CABundleReconciler{Client: fake.NewClientBuilder().Build()}
+
+		bundle := PEMFile{Filename: "root.pem", Content: newTestCAPEM("Corp Root", time.Now().Add(time.Hour))}
+		Expect(r.syncNamespace(ctx, "preview-42", []PEMFile{bundle}, cfg)).To(MatchError(ContainSubstring("doesn't allow namespace creation")))
+		Expect(r.Get(ctx, client.ObjectKey{Name: "preview-42"}, &corev1.Namespace{})).NotTo(Succeed())
+	})
+
+	It("rejects invalid namespace labels", func() {
+		_, err := parse(map[string]string{NamespaceLabelsKey: "team=platform/infra"})
+		Expect(err).To(MatchError(ContainSubstring(NamespaceLabelsKey)))
+		for _, key := range []string{"pod-security.kubernetes.io/enforce", "kubernetes.io/metadata.name", "policy.k8s.io/tier"} {
+			_, err = parse(map[string]string{NamespaceLabelsKey: key + "=privileged"})
+			Expect(err).To(MatchError(ContainSubstring("reserved")), key)
+		}
+		_, err = parse(map[string]string{NamespaceLabelsKey: "example.com/kubernetes.io=yes"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("Namespace re-evaluation", func() {
+	ctx := context.Background()
+
+	It("follows namespaces gaining and losing the selector label", func() {
+		srv := newTestBundleServer(map[string][]byte{"root.pem": newTestCAPEM("Corp Root", time.Now().Add(time.Hour))})
+		defer srv.Close()
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       map[string]string{BundleURLKey: srv.URL, NamespaceSelectorKey: "trust.corp/inject=true"},
+		}
+		apps := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+		web := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"trust.corp/inject": "true"}}}
+		r := &CABundleReconciler{
+			Client:          fake.NewClientBuilder().WithObjects(src, apps, web).Build(),
+			Scheme:          clientgoscheme.Scheme,
+			TargetNamespace: "cert-manager",
+			ConfigMapName:   "corp-roots",
+		}
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)}
+		synced := func(ns string) bool {
+			return r.Get(ctx, client.ObjectKey{Namespace: ns, Name: "root"}, &corev1.ConfigMap{}) == nil
+		}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(synced("web")).To(BeTrue())
+		Expect(synced("apps")).To(BeFalse())
+
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(apps), apps)).To(Succeed())
+		apps.Labels = map[string]string{"trust.corp/inject": "true"}
+		Expect(r.Update(ctx, apps)).To(Succeed())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(web), web)).To(Succeed())
+		web.Labels = nil
+		Expect(r.Update(ctx, web)).To(Succeed())
+
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(synced("apps")).To(BeTrue())
+		Expect(synced("web")).To(BeFalse(), "the namespace no longer selected is cleaned up")
+	})
+
+	It("re-enqueues the sources when a namespace's labels or annotations change", func() {
+		old := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps", Labels: map[string]string{"team": "web"}}}
+		relabeled := old.DeepCopy()
+		relabeled.Labels["trust.corp/inject"] = "true"
+		annotated := old.DeepCopy()
+		annotated.Annotations = map[string]string{OptOutAnnotation: "true"}
+		touched := old.DeepCopy()
+		touched.ResourceVersion = "2"
+
+		Expect(namespaceTargetingChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: relabeled})).To(BeTrue())
+		Expect(namespaceTargetingChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: annotated})).To(BeTrue())
+		Expect(namespaceTargetingChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: touched})).To(BeFalse())
+		Expect(namespaceTargetingChanged.Create(event.CreateEvent{Object: old})).To(BeFalse())
+
+		c := fake.NewClientBuilder().WithObjects(
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "periodic-cabundle-enqueue", Namespace: "cert-manager"}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "partner-roots", Namespace: "cert-manager",
+				Labels: map[string]string{BundleSourceLabel: "true"}}},
+		).Build()
+		r := &CABundleReconciler{Client: c, TargetNamespace: "cert-manager", ConfigMapName: "periodic-cabundle-enqueue"}
+		var names []string
+		for _, req := range r.mapNamespaceToSources(ctx, relabeled) {
+			names = append(names, req.String())
+		}
+		Expect(names).To(ConsistOf("cert-manager/periodic-cabundle-enqueue", "cert-manager/partner-roots"))
+	})
+})
+
+var _ = Describe("All-namespaces mode", func() {
+	ctx := context.Background()
+
+	It("replicates into every namespace but the opted-out ones, cleaning up those opting out later", func() {
+		srv := newTestBundleServer(map[string][]byte{"root.pem": newTestCAPEM("Corp Root", time.Now().Add(time.Hour))})
+		defer srv.Close()
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       map[string]string{BundleURLKey: srv.URL, AllNamespacesKey: "true"},
+		}
+		apps := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+		// A ConfigMap of the same name the operator doesn't manage.
+		foreign := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "root", Namespace: "legacy"}, Data: map[string]string{"owner": "team"}}
+		r := &CABundleReconciler{
+			Client: fake.NewClientBuilder().WithObjects(src, apps, foreign,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "cert-manager"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Annotations: map[string]string{OptOutAnnotation: "true"}}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Annotations: map[string]string{OptOutAnnotation: "partner-roots"}}},
+			).Build(),
+			Scheme:          clientgoscheme.Scheme,
+			TargetNamespace: "cert-manager",
+			ConfigMapName:   "corp-roots",
+		}
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)}
+		synced := func(ns string) bool {
+			cm := &corev1.ConfigMap{}
+			return r.Get(ctx, client.ObjectKey{Namespace: ns, Name: "root"}, cm) == nil && cm.Labels[SourceLabel] == "corp-roots"
+		}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(synced("apps")).To(BeTrue())
+		Expect(synced("cert-manager")).To(BeTrue())
+		Expect(synced("web")).To(BeTrue(), "the namespace opted out of another bundle only")
+		Expect(synced("legacy")).To(BeFalse())
+
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(apps), apps)).To(Succeed())
+		apps.Annotations = map[string]string{OptOutAnnotation: "corp-roots"}
+		Expect(r.Update(ctx, apps)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "root"}, &corev1.ConfigMap{})).NotTo(Succeed())
+		Expect(synced("web")).To(BeTrue())
+
+		kept := &corev1.ConfigMap{}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(foreign), kept)).To(Succeed(), "cleanup only deletes managed ConfigMaps")
+		Expect(kept.Data).To(Equal(foreign.Data))
+	})
+
+	It("keeps the ConfigMaps of namespaces opting out while cleanup only reports", func() {
+		srv := newTestBundleServer(map[string][]byte{"root.pem": newTestCAPEM("Corp Root", time.Now().Add(time.Hour))})
+		defer srv.Close()
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corp-roots", Namespace: "cert-manager"},
+			Data:       map[string]string{BundleURLKey: srv.URL, AllNamespacesKey: "true", CleanupKey: CleanupReport},
+		}
+		apps := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+		r := &CABundleReconciler{
+			Client:          fake.NewClientBuilder().WithObjects(src, apps).Build(),
+			Scheme:          clientgoscheme.Scheme,
+			TargetNamespace: "cert-manager",
+			ConfigMapName:   "corp-roots",
+		}
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(apps), apps)).To(Succeed())
+		apps.Annotations = map[string]string{OptOutAnnotation: "true"}
+		Expect(r.Update(ctx, apps)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "root"}, &corev1.ConfigMap{})).To(Succeed())
+	})
+})
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the sync phases. It is a no-op unless tracing
+// is enabled, see tracing.Setup.
+var tracer = otel.Tracer("github.com/shanmugara/cabundle-operator/internal/controller")
+
+// endSpan records err, if any, on the span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	// spanRecorder records the spans of every spec once installed: the
+	// tracer is bound to the first global tracer provider.
+	spanRecorder     = tracetest.NewSpanRecorder()
+	installRecording sync.Once
+)
+
+// recordSpans installs spanRecorder as the global tracer provider.
+func recordSpans() {
+	installRecording.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+}
+
+var _ = Describe("Tracing", func() {
+	ctx := context.Background()
+
+	It("traces the phases of a sync below the reconcile span", func() {
+		recordSpans()
+		srv := newTestBundleServer(map[string][]byte{
+			"root.pem":    newTestCAPEM("Traced Root", time.Now().AddDate(1, 0, 0)),
+			"issuing.pem": newTestCAPEM("Traced Issuing", time.Now().AddDate(1, 0, 0)),
+		})
+		defer srv.Close()
+		src := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "traced-roots", Namespace: "cert-manager"},
+			Data:       map[string]string{BundleURLKey: srv.URL, TargetNamespacesKey: "apps"},
+		}
+		r := &CABundleReconciler{
+			Client: fake.NewClientBuilder().WithObjects(src,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}).Build(),
+			Scheme:          clientgoscheme.Scheme,
+			TargetNamespace: "cert-manager",
+			ConfigMapName:   "traced-roots",
+		}
+
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)})
+		Expect(err).NotTo(HaveOccurred())
+
+		var reconcile sdktrace.ReadOnlySpan
+		for _, span := range spanRecorder.Ended() {
+			for _, attr := range span.Attributes() {
+				if span.Name() == "Reconcile" && attr.Key == "source.name" && attr.Value.AsString() == "traced-roots" {
+					reconcile = span
+				}
+			}
+		}
+		Expect(reconcile).NotTo(BeNil())
+		var phases []string
+		for _, span := range spanRecorder.Ended() {
+			if span.SpanContext().TraceID() != reconcile.SpanContext().TraceID() || span == reconcile {
+				continue
+			}
+			Expect(span.Parent().SpanID()).To(Equal(reconcile.SpanContext().SpanID()), span.Name())
+			Expect(span.Status().Code).NotTo(Equal(codes.Error), span.Name())
+			phases = append(phases, span.Name())
+		}
+		Expect(phases).To(ConsistOf("FetchIndex", "DownloadFile", "DownloadFile", "Validate", "WriteConfigMap", "WriteConfigMap"))
+	})
+
+	It("records the error of a failed phase on its span", func() {
+		recordSpans()
+		srv := newTestBundleServer(nil)
+		srv.Close()
+		cfg := &BundleConfig{BundleURL: srv.URL}
+
+		_, err := listBundles(ctx, cfg)
+		Expect(err).To(HaveOccurred())
+		ended := spanRecorder.Ended()
+		last := ended[len(ended)-1]
+		Expect(last.Name()).To(Equal("FetchIndex"))
+		Expect(last.Status().Code).To(Equal(codes.Error))
+		Expect(last.Events()).NotTo(BeEmpty(), "the error is recorded")
+	})
+})
@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// SyncNowAnnotation on a source ConfigMap forces an immediate sync whenever
+// its value changes, e.g. `kubectl annotate cm corp-roots
+// cabundle.io/sync-now="$(date +%s)" --overwrite`.
+const SyncNowAnnotation = "cabundle.io/sync-now"
+
+// TriggerPath is where TriggerHandler is served on the metrics server,
+// behind its authentication and authorization.
+const TriggerPath = "/trigger/cabundle"
+
+// isSource reports whether the object is a bundle source, see ListSources.
+func (r *CABundleReconciler) isSource(obj client.Object) bool {
+	if r.OnlyNamespace != "" && obj.GetNamespace() != r.OnlyNamespace || !r.watched(obj.GetNamespace()) {
+		return false
+	}
+	if obj.GetNamespace() == r.TargetNamespace && obj.GetName() == r.ConfigMapName {
+		return true
+	}
+	return obj.GetLabels()[BundleSourceLabel] == "true" && r.sourceNamespaceAllowed(obj.GetNamespace())
+}
+
+// watched reports whether the namespace is cached by the manager, see
+// WatchNamespaces.
+func (r *CABundleReconciler) watched(namespace string) bool {
+	return len(r.WatchNamespaces) == 0 || slices.Contains(r.WatchNamespaces, namespace)
+}
+
+// sourceNamespaceAllowed reports whether labeled sources are accepted in the
+// namespace, see SourceNamespaces.
+func (r *CABundleReconciler) sourceNamespaceAllowed(namespace string) bool {
+	return namespace == r.TargetNamespace || namespace == r.OnlyNamespace ||
+		slices.Contains(r.SourceNamespaces, namespace) || slices.Contains(r.SourceNamespaces, AllSourceNamespaces)
+}
+
+// syncNowRequested passes updates of sources that changed their
+// SyncNowAnnotation, ApprovePlanAnnotation or AcknowledgeChangeAnnotation.
+func (r *CABundleReconciler) syncNowRequested() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !r.isSource(e.ObjectNew) {
+				return false
+			}
+			for _, key := range []string{SyncNowAnnotation, ApprovePlanAnnotation, AcknowledgeChangeAnnotation} {
+				v, ok := e.ObjectNew.GetAnnotations()[key]
+				if ok && v != e.ObjectOld.GetAnnotations()[key] {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// sourceChanged passes sources created, ConfigMaps becoming sources, and
+// sources whose data changed, so edits such as a new bundle_url are synced
+// right away rather than on the next scheduled sync. Changes to the
+// annotations alone, like the health published on every sync, don't pass.
+func (r *CABundleReconciler) sourceChanged() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return r.isSource(e.Object) },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !r.isSource(e.ObjectNew) {
+				return false
+			}
+			if !r.isSource(e.ObjectOld) {
+				return true
+			}
+			oldCM, ok := e.ObjectOld.(*corev1.ConfigMap)
+			newCM, ok2 := e.ObjectNew.(*corev1.ConfigMap)
+			if !ok || !ok2 {
+				return false
+			}
+			return !maps.Equal(oldCM.Data, newCM.Data) || !equality.Semantic.DeepEqual(oldCM.BinaryData, newCM.BinaryData)
+		},
+	}
+}
+
+// triggerResponse lists the sources a trigger enqueued.
+type triggerResponse struct {
+	Enqueued []string `json:"enqueued"`
+}
+
+// TriggerHandler enqueues a sync on POST, so publishing pipelines can push
+// new certificates out without waiting for the schedule. The source query
+// parameter (<namespace>/<name>) selects a single source, otherwise every
+// source is synced. Replicas that haven't been elected leader answer 503, as
+// nothing consumes their queue; clients retry against another replica.
+func (r *CABundleReconciler) TriggerHandler(elected <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		logger := logf.FromContext(ctx)
+
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		select {
+		case <-elected:
+		default:
+			http.Error(w, "not the leader", http.StatusServiceUnavailable)
+			return
+		}
+
+		var sources []corev1.ConfigMap
+		if v := req.URL.Query().Get("source"); v != "" {
+			ns, name, ok := strings.Cut(v, "/")
+			if !ok || ns == "" || name == "" {
+				http.Error(w, "source must be <namespace>/<name>", http.StatusBadRequest)
+				return
+			}
+			// Namespaces outside the cache can't hold sources, and reading
+			// them through it fails.
+			if !r.watched(ns) {
+				http.Error(w, "no such source", http.StatusNotFound)
+				return
+			}
+			cm := corev1.ConfigMap{}
+			err := r.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, &cm)
+			if client.IgnoreNotFound(err) != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err != nil || !r.isSource(&cm) {
+				http.Error(w, "no such source", http.StatusNotFound)
+				return
+			}
+			sources = append(sources, cm)
+		} else {
+			var err error
+			if sources, err = r.ListSources(ctx); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		resp := triggerResponse{Enqueued: []string{}}
+		for i := range sources {
+			if err := r.EventCh.Enqueue(ctx, &sources[i]); err != nil {
+				return
+			}
+			resp.Enqueued = append(resp.Enqueued, client.ObjectKeyFromObject(&sources[i]).String())
+		}
+		logger.Info("Sync triggered over HTTP", "sources", resp.Enqueued)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("Sync triggers", func() {
+	r := &CABundleReconciler{TargetNamespace: "cert-manager", ConfigMapName: "periodic-cabundle-enqueue", SourceNamespaces: []string{"pki"}}
+
+	cm := func(ns, name, syncNow string) *corev1.ConfigMap {
+		obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name}}
+		if syncNow != "" {
+			obj.Annotations = map[string]string{SyncNowAnnotation: syncNow}
+		}
+		return obj
+	}
+	updated := func(old, new *corev1.ConfigMap) bool {
+		return r.syncNowRequested().Update(event.UpdateEvent{ObjectOld: old, ObjectNew: new})
+	}
+
+	It("passes sources whose sync-now annotation changed", func() {
+		Expect(updated(cm("cert-manager", "periodic-cabundle-enqueue", ""),
+			cm("cert-manager", "periodic-cabundle-enqueue", "1700000000"))).To(BeTrue())
+		Expect(updated(cm("cert-manager", "periodic-cabundle-enqueue", "1700000000"),
+			cm("cert-manager", "periodic-cabundle-enqueue", "1700000001"))).To(BeTrue())
+		Expect(updated(cm("cert-manager", "periodic-cabundle-enqueue", "1700000000"),
+			cm("cert-manager", "periodic-cabundle-enqueue", "1700000000"))).To(BeFalse())
+	})
+
+	It("ignores ConfigMaps that aren't sources", func() {
+		Expect(updated(cm("apps", "settings", ""), cm("apps", "settings", "1700000000"))).To(BeFalse())
+
+		labeled := cm("pki", "corp-roots", "1700000000")
+		labeled.Labels = map[string]string{BundleSourceLabel: "true"}
+		Expect(updated(cm("pki", "corp-roots", ""), labeled)).To(BeTrue())
+		labeled.Namespace = "apps"
+		Expect(updated(cm("apps", "corp-roots", ""), labeled)).To(BeFalse(), "a namespace not allowed to hold sources")
+	})
+
+	It("passes sources created or whose data changed", func() {
+		changed := func(old, new *corev1.ConfigMap) bool {
+			return r.sourceChanged().Update(event.UpdateEvent{ObjectOld: old, ObjectNew: new})
+		}
+		source := func(data map[string]string, annotations map[string]string) *corev1.ConfigMap {
+			obj := cm("pki", "corp-roots", "")
+			obj.Labels = map[string]string{BundleSourceLabel: "true"}
+			obj.Data = data
+			obj.Annotations = annotations
+			return obj
+		}
+		old := source(map[string]string{BundleURLKey: "https://pki.example.com/"}, nil)
+
+		Expect(r.sourceChanged().Create(event.CreateEvent{Object: old})).To(BeTrue())
+		Expect(r.sourceChanged().Create(event.CreateEvent{Object: cm("apps", "settings", "")})).To(BeFalse())
+
+		Expect(changed(old, source(map[string]string{BundleURLKey: "https://pki.example.com/v2/"}, nil))).To(BeTrue())
+		Expect(changed(cm("pki", "corp-roots", ""), old)).To(BeTrue())
+		// The health published by every sync must not trigger another.
+		Expect(changed(old, source(old.Data, map[string]string{HealthAnnotation: HealthHealthy}))).To(BeFalse())
+		Expect(changed(old, cm("pki", "corp-roots", ""))).To(BeFalse())
+	})
+
+	It("enqueues the requested source over HTTP", func() {
+		src := cm("cert-manager", "periodic-cabundle-enqueue", "")
+		ch := make(chan event.GenericEvent, 2)
+		r := &CABundleReconciler{
+			Client:          fake.NewClientBuilder().WithObjects(src, cm("apps", "settings", "")).Build(),
+			TargetNamespace: "cert-manager",
+			ConfigMapName:   "periodic-cabundle-enqueue",
+			EventCh:         ch,
+		}
+		elected := make(chan struct{})
+		serve := func(method, target string) int {
+			rec := httptest.NewRecorder()
+			r.TriggerHandler(elected).ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+			return rec.Code
+		}
+
+		Expect(serve(http.MethodPost, TriggerPath)).To(Equal(http.StatusServiceUnavailable))
+		close(elected)
+		Expect(serve(http.MethodGet, TriggerPath)).To(Equal(http.StatusMethodNotAllowed))
+		Expect(serve(http.MethodPost, TriggerPath+"?source=apps/settings")).To(Equal(http.StatusNotFound))
+		Expect(ch).To(BeEmpty())
+
+		Expect(serve(http.MethodPost, TriggerPath+"?source=cert-manager/periodic-cabundle-enqueue")).To(Equal(http.StatusAccepted))
+		Expect(ch).To(HaveLen(1))
+		Expect(serve(http.MethodPost, TriggerPath)).To(Equal(http.StatusAccepted))
+		Expect(ch).To(HaveLen(2))
+	})
+
+	It("answers 404 for sources outside the watched namespaces", func() {
+		src := cm("pki", "corp-roots", "")
+		src.Labels = map[string]string{BundleSourceLabel: "true"}
+		ch := make(chan event.GenericEvent, 1)
+		r := &CABundleReconciler{
+			// The cache fails reads of namespaces it doesn't hold.
+			Client: fake.NewClientBuilder().WithObjects(src).WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if key.Namespace != "cert-manager" {
+						return fmt.Errorf("unable to get: %s because of unknown namespace for the cache", key)
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+			}).Build(),
+			TargetNamespace:  "cert-manager",
+			ConfigMapName:    "periodic-cabundle-enqueue",
+			SourceNamespaces: []string{"pki"},
+			WatchNamespaces:  []string{"cert-manager"},
+			EventCh:          ch,
+		}
+		elected := make(chan struct{})
+		close(elected)
+
+		rec := httptest.NewRecorder()
+		r.TriggerHandler(elected).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, TriggerPath+"?source=pki/corp-roots", nil))
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(rec.Body.String()).NotTo(ContainSubstring("unknown namespace"))
+		Expect(ch).To(BeEmpty())
+	})
+})
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ReasonTrustChanged is the reason of the Event describing the certificates
+// a managed ConfigMap update added or removed.
+const ReasonTrustChanged = "TrustChanged"
+
+// maxDiffMessage caps the diff summary so large changes fit into an Event.
+const maxDiffMessage = 900
+
+// trustDiff describes a change of a bundle at certificate granularity.
+type trustDiff struct {
+	// Added and Removed list the certificates only present in the new or
+	// old bundle by subject, see diffCertificates.
+	Added   []string
+	Removed []string
+	// ExpiryChanged lists subjects whose certificate was replaced by one
+	// with a different expiry, e.g. a renewed root.
+	ExpiryChanged []string
+}
+
+// empty reports whether the diff found no certificate changes.
+func (d trustDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.ExpiryChanged) == 0
+}
+
+// String summarizes the diff for Event messages.
+func (d trustDiff) String() string {
+	var parts []string
+	if len(d.Added) > 0 {
+		parts = append(parts, "added "+strings.Join(d.Added, "; "))
+	}
+	if len(d.Removed) > 0 {
+		parts = append(parts, "removed "+strings.Join(d.Removed, "; "))
+	}
+	if len(d.ExpiryChanged) > 0 {
+		parts = append(parts, "expiry changed "+strings.Join(d.ExpiryChanged, "; "))
+	}
+	out := strings.Join(parts, ", ")
+	if len(out) > maxDiffMessage {
+		out = out[:maxDiffMessage] + "..."
+	}
+	return out
+}
+
+// diffCertificates compares two bundles by certificate fingerprint. A
+// certificate replaced by one of the same subject is reported as an expiry
+// change rather than a removal and an addition. Subjects listed more than
+// once are told apart by a fingerprint prefix.
+func diffCertificates(oldCerts, newCerts []*x509.Certificate) trustDiff {
+	fingerprints := func(certs []*x509.Certificate) map[[32]byte]*x509.Certificate {
+		m := map[[32]byte]*x509.Certificate{}
+		for _, c := range certs {
+			m[sha256.Sum256(c.Raw)] = c
+		}
+		return m
+	}
+	oldSet, newSet := fingerprints(oldCerts), fingerprints(newCerts)
+
+	// Certificates only in one bundle, grouped by subject.
+	removed := map[string][][32]byte{}
+	for fp, c := range oldSet {
+		if _, ok := newSet[fp]; !ok {
+			removed[c.Subject.String()] = append(removed[c.Subject.String()], fp)
+		}
+	}
+	added := map[string][][32]byte{}
+	for fp, c := range newSet {
+		if _, ok := oldSet[fp]; !ok {
+			added[c.Subject.String()] = append(added[c.Subject.String()], fp)
+		}
+	}
+
+	// Subjects with several changed certificates are told apart.
+	ambiguous := func(subject string) bool {
+		return len(added[subject]) > 1 || len(removed[subject]) > 1
+	}
+	describe := func(subject string, fp [32]byte, tagged bool) string {
+		if tagged {
+			return fmt.Sprintf("%s [%s]", subject, hex.EncodeToString(fp[:4]))
+		}
+		return subject
+	}
+	byFingerprint := func(fps [][32]byte) {
+		sort.Slice(fps, func(i, j int) bool { return bytes.Compare(fps[i][:], fps[j][:]) < 0 })
+	}
+
+	var d trustDiff
+	for subject, oldFPs := range removed {
+		newFPs := added[subject]
+		byFingerprint(oldFPs)
+		byFingerprint(newFPs)
+		tag := ambiguous(subject)
+		for i, fp := range oldFPs {
+			if i < len(newFPs) {
+				old, c := oldSet[fp], newSet[newFPs[i]]
+				d.ExpiryChanged = append(d.ExpiryChanged, fmt.Sprintf("%s (%s -> %s)", describe(subject, newFPs[i], tag),
+					old.NotAfter.UTC().Format(time.RFC3339), c.NotAfter.UTC().Format(time.RFC3339)))
+				continue
+			}
+			d.Removed = append(d.Removed, describe(subject, fp, tag))
+		}
+	}
+	for subject, newFPs := range added {
+		byFingerprint(newFPs)
+		tag := ambiguous(subject)
+		for _, fp := range newFPs[min(len(removed[subject]), len(newFPs)):] {
+			d.Added = append(d.Added, describe(subject, fp, tag))
+		}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.ExpiryChanged)
+	return d
+}
+
+// configMapCertificates returns the certificates of a managed ConfigMap from
+// its PEM or DER rendering. ConfigMaps holding only truststores yield none.
+func configMapCertificates(cm *corev1.ConfigMap) []*x509.Certificate {
+	if content, ok := cm.Data[CAKey]; ok {
+		certs, _ := ParseCertificates([]byte(content))
+		return certs
+	}
+
+	var certs []*x509.Certificate
+	for key, der := range cm.BinaryData {
+		if !strings.HasSuffix(key, ".der") {
+			continue
+		}
+		if c, err := x509.ParseCertificate(der); err == nil {
+			certs = append(certs, c)
+		}
+	}
+	return certs
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/x509"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Trust diff", func() {
+	parse := func(pems ...[]byte) []*x509.Certificate {
+		var content []byte
+		for _, p := range pems {
+			content = append(content, p...)
+		}
+		certs, err := ParseCertificates(content)
+		Expect(err).NotTo(HaveOccurred())
+		return certs
+	}
+
+	It("reports added, removed and renewed subjects", func() {
+		kept := newTestCAPEM("Kept Root", time.Now().Add(time.Hour))
+		oldCerts := parse(kept,
+			newTestCAPEM("Retired Root", time.Now().Add(time.Hour)),
+			newTestCAPEM("Renewed Root", time.Now().Add(time.Hour)))
+		newCerts := parse(kept,
+			newTestCAPEM("New Root", time.Now().Add(time.Hour)),
+			newTestCAPEM("Renewed Root", time.Now().Add(48*time.Hour)))
+
+		d := diffCertificates(oldCerts, newCerts)
+		Expect(d.Added).To(Equal([]string{"CN=New Root,O=Corp"}))
+		Expect(d.Removed).To(Equal([]string{"CN=Retired Root,O=Corp"}))
+		Expect(d.ExpiryChanged).To(HaveLen(1))
+		Expect(d.ExpiryChanged[0]).To(HavePrefix("CN=Renewed Root,O=Corp ("))
+	})
+
+	It("tells apart certificates sharing a subject by fingerprint", func() {
+		oldCerts := parse(newTestCAPEM("Shared Root", time.Now().Add(time.Hour)))
+		newCerts := parse(
+			newTestCAPEM("Shared Root", time.Now().Add(24*time.Hour)),
+			newTestCAPEM("Shared Root", time.Now().Add(48*time.Hour)),
+			newTestCAPEM("Twin Root", time.Now().Add(time.Hour)),
+			newTestCAPEM("Twin Root", time.Now().Add(time.Hour)))
+
+		d := diffCertificates(oldCerts, newCerts)
+		Expect(d.ExpiryChanged).To(ConsistOf(MatchRegexp(`^CN=Shared Root,O=Corp \[[0-9a-f]{8}\] \(`)))
+		Expect(d.Added).To(HaveLen(3))
+		Expect(d.Added).To(ContainElement(MatchRegexp(`^CN=Shared Root,O=Corp \[[0-9a-f]{8}\]$`)))
+		Expect(d.Added).To(ContainElements(
+			MatchRegexp(`^CN=Twin Root,O=Corp \[[0-9a-f]{8}\]$`),
+			MatchRegexp(`^CN=Twin Root,O=Corp \[[0-9a-f]{8}\]$`)))
+		Expect(d.Added[1]).NotTo(Equal(d.Added[2]))
+		Expect(d.Removed).To(BeEmpty())
+
+		d = diffCertificates(newCerts, oldCerts)
+		Expect(d.ExpiryChanged).To(HaveLen(1))
+		Expect(d.Removed).To(HaveLen(3))
+		Expect(d.Added).To(BeEmpty())
+	})
+
+	It("reads certificates from the PEM or DER rendering", func() {
+		content := newTestCAPEM("Corp Root", time.Now().Add(time.Hour))
+		data, binaryData, err := renderFormats(content, []string{FormatDER}, DefaultTruststorePassword)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(configMapCertificates(&corev1.ConfigMap{Data: map[string]string{CAKey: string(content)}})).To(HaveLen(1))
+		Expect(configMapCertificates(&corev1.ConfigMap{Data: data, BinaryData: binaryData})).To(HaveLen(1))
+		Expect(diffCertificates(parse(content), parse(content)).empty()).To(BeTrue())
+	})
+})
@@ -0,0 +1,43 @@
+package controller
+
+import "time"
+
+// Tuning holds the settings of the reconciler that may change while it runs,
+// e.g. when the operator configuration file is reloaded. Zero durations keep
+// their defaults.
+type Tuning struct {
+	// CircuitBreakerThreshold is the number of consecutive failed downloads
+	// after which a source isn't downloaded from for CircuitBreakerCooldown.
+	// Zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+	// ErrorBackoffBase is the delay before a failed reconcile is retried,
+	// doubling with every consecutive failure up to ErrorBackoffMax. Zero
+	// leaves retries to the controller's rate limiter.
+	ErrorBackoffBase time.Duration
+	ErrorBackoffMax  time.Duration
+	// DownloadTimeout bounds the downloads of a sync, DegradedRetry is when
+	// a partially synced bundle is retried and FullSyncInterval how long
+	// syncs of an unchanged index may be skipped.
+	DownloadTimeout  time.Duration
+	DegradedRetry    time.Duration
+	FullSyncInterval time.Duration
+	// NotifyFailureThreshold is the number of consecutive failed syncs of a
+	// source after which a notification is sent, DefaultNotifyFailureThreshold
+	// if zero.
+	NotifyFailureThreshold int
+}
+
+// SetTuning replaces the tuning of the reconciler. Reconciles already running
+// finish with the previous tuning.
+func (r *CABundleReconciler) SetTuning(t Tuning) {
+	r.tuningValue.Store(&t)
+}
+
+// tuning returns the current tuning of the reconciler.
+func (r *CABundleReconciler) tuning() Tuning {
+	if t := r.tuningValue.Load(); t != nil {
+		return *t
+	}
+	return Tuning{}
+}
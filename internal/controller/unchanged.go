@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DefaultFullSyncInterval bounds how long syncs of an unchanged index may be
+// skipped, so managed ConfigMaps modified out of band are eventually
+// restored, unless the reconciler's Tuning sets its own FullSyncInterval.
+const DefaultFullSyncInterval = 24 * time.Hour
+
+// FullSync is a sync that downloaded every file and brought every target up
+// to date.
+type FullSync struct {
+	// IndexHash identifies the index listing synced, see bundleIndex.
+	IndexHash string `json:"indexHash"`
+	// SourceVersion is the resourceVersion of the source ConfigMap synced.
+	SourceVersion string      `json:"sourceVersion"`
+	Time          metav1.Time `json:"time"`
+}
+
+// indexUnchanged reports whether nothing changed since the last full sync:
+// neither the index listing, nor the source ConfigMap, nor the target
+// namespaces. Sources with remote clusters, a rollout or change in progress,
+// drifted ConfigMaps, refused content, or an expiry warning due are always
+// synced in full.
+func (r *CABundleReconciler) indexUnchanged(src *corev1.ConfigMap, cfg *BundleConfig, index *bundleIndex, namespaces []string, prev *BundleStatus) bool {
+	full := prev.FullSync
+	if index == nil || full == nil || full.IndexHash != index.Hash || full.SourceVersion != src.ResourceVersion {
+		return false
+	}
+	now := time.Now()
+	if now.Sub(full.Time.Time) > durationOr(r.tuning().FullSyncInterval, DefaultFullSyncInterval) {
+		return false
+	}
+	if len(cfg.RemoteClusters) > 0 || prev.Canary != nil || prev.PendingChange != nil || prev.Plan != nil || prev.UnacknowledgedChange != nil ||
+		meta.IsStatusConditionTrue(prev.Conditions, ConditionDrifted) || meta.IsStatusConditionTrue(prev.Conditions, ConditionPinMismatch) {
+		return false
+	}
+	if prev.SoonestExpiry != nil && !now.Before(prev.SoonestExpiry.Add(-cfg.ExpiryWarning)) {
+		return false
+	}
+
+	synced := make([]string, 0, len(prev.Namespaces))
+	for _, ns := range prev.Namespaces {
+		if !ns.Synced {
+			return false
+		}
+		synced = append(synced, ns.Namespace)
+	}
+	slices.Sort(synced)
+	targets := slices.Sorted(slices.Values(namespaces))
+	return slices.Equal(synced, targets)
+}
+
+// syncUnchanged completes the sync of a source whose index is unchanged
+// since the last full sync, skipping the downloads and the comparison of the
+// managed ConfigMaps. The certificate metrics and the debug endpoint are
+// refreshed from the managed ConfigMaps instead, as after a restart nothing
+// else would record them until the next full sync.
+func (r *CABundleReconciler) syncUnchanged(ctx context.Context, src *corev1.ConfigMap, cfg *BundleConfig, index *bundleIndex, prev *BundleStatus) (ctrl.Result, error) {
+	logf.FromContext(ctx).V(1).Info("Index unchanged since the last full sync, skipping downloads",
+		"url", cfg.sourceURL(), "fullSync", prev.FullSync.Time)
+	sourceReachable.WithLabelValues(src.Namespace + "/" + src.Name).Set(1)
+	if len(prev.Namespaces) > 0 {
+		stored := r.storedBundles(ctx, cfg, index, prev.Namespaces[0].Namespace)
+		r.debug.indexFetched(ctrl.Request{NamespacedName: client.ObjectKeyFromObject(src)}, stored)
+		r.recordCertExpiry(cfg, stored)
+	}
+
+	now := metav1.Now()
+	status := *prev
+	status.Conditions = slices.Clone(prev.Conditions)
+	status.LastSyncTime = &now
+	meta.SetStatusCondition(&status.Conditions, sourceReachableCondition(nil))
+	meta.SetStatusCondition(&status.Conditions, staleCondition(nil, nil))
+	if meta.IsStatusConditionTrue(status.Conditions, ConditionCircuitOpen) {
+		r.notifyUnservable(ctx, cfg, fmt.Sprintf("Downloads from %s succeeded again", cfg.sourceURL()), true)
+		meta.SetStatusCondition(&status.Conditions, circuitCondition(time.Time{}, 0))
+	}
+
+	var requeueAfter time.Duration
+	if status.SoonestExpiry != nil {
+		// Wake up for the expiry warning, which a full sync raises.
+		requeueAfter = time.Until(status.SoonestExpiry.Add(-cfg.ExpiryWarning)) + time.Second
+	}
+	status.NextSyncTime = r.nextSyncTime(client.ObjectKeyFromObject(src), requeueAfter)
+	if err := r.updateStatus(ctx, src, &status); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.recordSuccessfulSync(client.ObjectKeyFromObject(src), now.Time)
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// storedBundles reads the listed files back from their managed ConfigMaps in
+// a synced target namespace. Files without a ConfigMap, e.g. links not
+// holding certificates, are left out; ConfigMaps rendering neither PEM nor
+// DER have no content.
+func (r *CABundleReconciler) storedBundles(ctx context.Context, cfg *BundleConfig, index *bundleIndex, namespace string) []PEMFile {
+	target := cfg.ForTarget("", namespace)
+	var bundles []PEMFile
+	for _, file := range index.Files {
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: r.configMapName(file, target)}, cm); err != nil {
+			continue
+		}
+		content := []byte(cm.Data[CAKey])
+		if len(content) == 0 {
+			for i := 0; ; i++ {
+				der, ok := cm.BinaryData[fmt.Sprintf("ca-%d.der", i)]
+				if !ok {
+					break
+				}
+				content = append(content, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+			}
+		}
+		bundles = append(bundles, PEMFile{Filename: file, Content: content})
+	}
+	return bundles
+}
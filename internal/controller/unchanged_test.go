@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/pem"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Unchanged index", func() {
+	It("refreshes the certificate metrics and debug index from the managed ConfigMaps", func() {
+		ctx := context.Background()
+		root := newTestCAPEM("Unchanged Root", time.Now().Add(time.Hour))
+		issuing, _ := pem.Decode(newTestCAPEM("Unchanged Issuing", time.Now().Add(time.Hour)))
+
+		src := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unchanged-roots", Namespace: "cert-manager"}}
+		r := &CABundleReconciler{
+			Client: fake.NewClientBuilder().WithObjects(src,
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "unchanged-root", Namespace: "apps"},
+					Data:       map[string]string{CAKey: string(root)},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "unchanged-issuing", Namespace: "apps"},
+					BinaryData: map[string][]byte{"ca-0.der": issuing.Bytes},
+				},
+			).Build(),
+			Scheme: clientgoscheme.Scheme,
+		}
+		cfg := &BundleConfig{SourceName: src.Name, SourceNamespace: src.Namespace, ExpiryWarning: DefaultExpiryWarning}
+		index := &bundleIndex{Files: []string{"unchanged-root.pem", "unchanged-issuing.crt", "README"}}
+		prev := &BundleStatus{
+			FullSync:   &FullSync{Time: metav1.Now()},
+			Namespaces: []NamespaceStatus{{Namespace: "apps", Synced: true}},
+		}
+
+		_, err := r.syncUnchanged(ctx, src, cfg, index, prev)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, bundle := range []string{"unchanged-root", "unchanged-issuing"} {
+			Expect(certExpiry.DeletePartialMatch(prometheus.Labels{"bundle": bundle})).To(Equal(1), bundle)
+		}
+		Expect(r.debug.sources["cert-manager/unchanged-roots"].LastIndex).To(Equal([]string{"unchanged-root.pem", "unchanged-issuing.crt"}))
+	})
+})
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SourceReport is the result of ValidateSource: what a sync of the source
+// would distribute, and the policy violations found.
+type SourceReport struct {
+	Files      []FileReport
+	Violations []Violation
+}
+
+// FileReport is a bundle file of a SourceReport.
+type FileReport struct {
+	Filename     string
+	Size         int
+	Certificates []DistributedCertificate
+}
+
+// Violation is a policy violation of a bundle file, or of a certificate in it
+// if Subject is set.
+type Violation struct {
+	Filename string
+	Subject  string
+	Message  string
+}
+
+func (v Violation) String() string {
+	if v.Subject == "" {
+		return fmt.Sprintf("%s: %s", v.Filename, v.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", v.Filename, v.Subject, v.Message)
+}
+
+// ValidateSource fetches the index of a source and every bundle file listed,
+// without a cluster, and checks them against the policies a sync relies on:
+// every file downloads, matches the pinned checksums, and holds only valid CA
+// certificates that aren't expired, expiring within expiry_warning, or
+// distributed by another file.
+// An error is returned only if the source can't be listed at all.
+func ValidateSource(ctx context.Context, src *corev1.ConfigMap) (*SourceReport, error) {
+	cfg, err := ParseBundleConfig(src)
+	if err != nil {
+		return nil, err
+	}
+	index, err := listBundles(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	bundles, failed, err := downloadBundles(ctx, cfg, index)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SourceReport{}
+	for _, f := range failed {
+		report.Violations = append(report.Violations, Violation{Filename: f.Filename, Message: f.Err.Error()})
+	}
+	report.Violations = append(report.Violations, checksumViolations(cfg, bundles, failed)...)
+	now := time.Now()
+	seen := map[string]string{}
+	for _, b := range bundles {
+		file := FileReport{Filename: b.Filename, Size: len(b.Content)}
+		certs, err := ParseCertificates(b.Content)
+		if err != nil {
+			report.Violations = append(report.Violations, Violation{Filename: b.Filename, Message: err.Error()})
+		}
+		for _, c := range certs {
+			sum := sha256.Sum256(c.Raw)
+			fingerprint := hex.EncodeToString(sum[:])
+			file.Certificates = append(file.Certificates, DistributedCertificate{
+				Subject:     c.Subject.String(),
+				NotAfter:    c.NotAfter,
+				Fingerprint: fingerprint,
+			})
+
+			violation := func(format string, args ...any) {
+				report.Violations = append(report.Violations, Violation{
+					Filename: b.Filename, Subject: c.Subject.String(), Message: fmt.Sprintf(format, args...),
+				})
+			}
+			switch {
+			case !now.Before(c.NotAfter):
+				violation("expired on %s", c.NotAfter.UTC().Format(time.RFC3339))
+			case !now.Before(c.NotAfter.Add(-cfg.ExpiryWarning)):
+				violation("expires on %s, within %s of %s", c.NotAfter.UTC().Format(time.RFC3339), ExpiryWarningKey, cfg.ExpiryWarning)
+			case now.Before(c.NotBefore):
+				violation("not valid before %s", c.NotBefore.UTC().Format(time.RFC3339))
+			}
+			if !c.IsCA {
+				violation("not a CA certificate")
+			}
+			if other, ok := seen[fingerprint]; ok && other != b.Filename {
+				violation("also distributed by %s", other)
+			} else {
+				seen[fingerprint] = b.Filename
+			}
+		}
+		report.Files = append(report.Files, file)
+	}
+	return report, nil
+}
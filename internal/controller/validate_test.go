@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Validating a source", func() {
+	ctx := context.Background()
+
+	It("lists the bundle files and reports policy violations", func() {
+		files := map[string][]byte{
+			"/good.pem":   newTestCAPEM("Corp Root", time.Now().Add(365*24*time.Hour)),
+			"/soon.pem":   newTestCAPEM("Corp Old", time.Now().Add(24*time.Hour)),
+			"/broken.crt": []byte("not a certificate"),
+		}
+		files["/copy.pem"] = files["/good.pem"]
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/" {
+				var links []string
+				for name := range files {
+					links = append(links, `<a href="`+strings.TrimPrefix(name, "/")+`">x</a>`)
+				}
+				_, _ = w.Write([]byte("<html><body>" + strings.Join(links, "") + "</body></html>"))
+				return
+			}
+			_, _ = w.Write(files[req.URL.Path])
+		}))
+		DeferCleanup(srv.Close)
+
+		report, err := ValidateSource(ctx, &corev1.ConfigMap{Data: map[string]string{BundleURLKey: srv.URL}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Files).To(HaveLen(4))
+
+		var violations []string
+		for _, v := range report.Violations {
+			violations = append(violations, v.String())
+		}
+		Expect(violations).To(ConsistOf(
+			"broken.crt: no certificates found",
+			ContainSubstring("CN=Corp Old,O=Corp: expires on"),
+			MatchRegexp(`^(good|copy)\.pem: CN=Corp Root,O=Corp: also distributed by (good|copy)\.pem$`),
+		))
+	})
+
+	It("fails if the source can't be listed", func() {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		DeferCleanup(srv.Close)
+
+		_, err := ValidateSource(ctx, &corev1.ConfigMap{Data: map[string]string{BundleURLKey: srv.URL}})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,211 @@
+package nodeagent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Agent copies the aggregated CA bundle, mounted from its ConfigMap, into
+// the node's system trust directory and refreshes the node trust store.
+type Agent struct {
+	sourcePath    string
+	hostRoot      string
+	anchorsDir    string
+	anchorName    string
+	updateCommand []string
+	interval      time.Duration
+	// run runs the update command, runInRoot but in tests.
+	run func(root string, command []string) ([]byte, error)
+}
+
+// Option is a function which configures the [Agent].
+type Option func(a *Agent) error
+
+// New creates a new node agent and configures it using the provided options.
+func New(opts ...Option) (*Agent, error) {
+	a := &Agent{
+		hostRoot:      "/host",
+		anchorsDir:    "/etc/pki/ca-trust/source/anchors",
+		anchorName:    "cabundle-operator.crt",
+		updateCommand: []string{"/usr/bin/update-ca-trust", "extract"},
+		interval:      time.Minute,
+		run:           runInRoot,
+	}
+	for _, opt := range opts {
+		if err := opt(a); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.sourcePath == "" {
+		return nil, errors.New("source path is required")
+	}
+	return a, nil
+}
+
+// WithSourcePath configures the [Agent] to read the bundle from the given
+// file, usually a key of the mounted aggregated ConfigMap.
+func WithSourcePath(path string) Option {
+	opt := func(a *Agent) error {
+		a.sourcePath = path
+		return nil
+	}
+
+	return opt
+}
+
+// WithHostRoot configures the [Agent] with the path the node's root
+// filesystem is mounted at.
+func WithHostRoot(path string) Option {
+	opt := func(a *Agent) error {
+		a.hostRoot = path
+		return nil
+	}
+
+	return opt
+}
+
+// WithAnchorsDir configures the [Agent] with the node's trust anchors
+// directory, relative to the host root.
+func WithAnchorsDir(dir string) Option {
+	opt := func(a *Agent) error {
+		a.anchorsDir = dir
+		return nil
+	}
+
+	return opt
+}
+
+// WithUpdateCommand configures the [Agent] with the command run inside the
+// host root after the anchors changed. The executable must be an absolute
+// path on the host. An empty command disables it.
+func WithUpdateCommand(cmd []string) Option {
+	opt := func(a *Agent) error {
+		if len(cmd) > 0 && !filepath.IsAbs(cmd[0]) {
+			return errors.New("update command must be an absolute path")
+		}
+		a.updateCommand = cmd
+		return nil
+	}
+
+	return opt
+}
+
+// WithInterval configures the [Agent] with the interval the source is
+// checked for changes at.
+func WithInterval(interval time.Duration) Option {
+	opt := func(a *Agent) error {
+		if interval <= 0 {
+			return errors.New("interval must be positive")
+		}
+		a.interval = interval
+		return nil
+	}
+
+	return opt
+}
+
+// Start syncs the node trust store until the context is cancelled.
+func (a *Agent) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.sync(ctx); err != nil {
+			logger.Error(err, "failed to sync node trust store")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// sync writes the bundle into the anchors directory if it changed and runs
+// the update command.
+func (a *Agent) sync(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+
+	content, err := os.ReadFile(a.sourcePath)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(a.hostRoot, a.anchorsDir)
+	target := filepath.Join(dir, a.anchorName)
+	if existing, err := os.ReadFile(target); err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+
+	previous, err := os.ReadFile(target)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	logger.Info("Writing CA bundle to node trust store", "path", target)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := writeAnchor(target, content); err != nil {
+		return err
+	}
+
+	if err := a.update(ctx); err != nil {
+		// Put the previous anchor back, so the node keeps trusting what it
+		// did, and the next tick retries the new bundle.
+		if previous != nil {
+			err = errors.Join(err, writeAnchor(target, previous))
+		} else {
+			err = errors.Join(err, os.Remove(target))
+		}
+		return err
+	}
+	return nil
+}
+
+// Cleanup removes the bundle from the anchors directory and runs the update
+// command, so the node stops trusting it once the agent is uninstalled.
+func (a *Agent) Cleanup(ctx context.Context) error {
+	target := filepath.Join(a.hostRoot, a.anchorsDir, a.anchorName)
+	if err := os.Remove(target); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	log.FromContext(ctx).Info("Removed CA bundle from node trust store", "path", target)
+	return a.update(ctx)
+}
+
+// update runs the update command, if any.
+func (a *Agent) update(ctx context.Context) error {
+	if len(a.updateCommand) == 0 {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+	logger.Info("Updating node trust store", "command", a.updateCommand)
+	out, err := a.run(a.hostRoot, a.updateCommand)
+	if err != nil {
+		logger.Error(err, "trust store update command failed", "output", string(out))
+		return err
+	}
+	return nil
+}
+
+// writeAnchor atomically replaces the anchor file with content.
+func writeAnchor(target string, content []byte) error {
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
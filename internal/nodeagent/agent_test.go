@@ -0,0 +1,108 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Node agent", func() {
+	var (
+		agent   *Agent
+		source  string
+		anchor  string
+		updates int
+		failing bool
+	)
+
+	BeforeEach(func() {
+		dir := GinkgoT().TempDir()
+		source = filepath.Join(dir, "ca.crt")
+		Expect(os.WriteFile(source, []byte("new"), 0o644)).To(Succeed())
+
+		var err error
+		agent, err = New(
+			WithSourcePath(source),
+			WithHostRoot(filepath.Join(dir, "host")),
+			WithAnchorsDir("/etc/pki/ca-trust/source/anchors"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		anchor = filepath.Join(dir, "host", "etc/pki/ca-trust/source/anchors", "cabundle-operator.crt")
+
+		updates, failing = 0, false
+		agent.run = func(string, []string) ([]byte, error) {
+			updates++
+			if failing {
+				return []byte("update-ca-trust: failed"), errors.New("exit status 1")
+			}
+			return nil, nil
+		}
+	})
+
+	It("writes the bundle and updates the trust store only when it changed", func() {
+		Expect(agent.sync(context.Background())).To(Succeed())
+		Expect(os.ReadFile(anchor)).To(Equal([]byte("new")))
+		Expect(updates).To(Equal(1))
+
+		Expect(agent.sync(context.Background())).To(Succeed())
+		Expect(updates).To(Equal(1))
+	})
+
+	It("keeps the previous anchor when the update fails", func() {
+		Expect(os.MkdirAll(filepath.Dir(anchor), 0o755)).To(Succeed())
+		Expect(os.WriteFile(anchor, []byte("old"), 0o644)).To(Succeed())
+
+		failing = true
+		Expect(agent.sync(context.Background())).NotTo(Succeed())
+		Expect(os.ReadFile(anchor)).To(Equal([]byte("old")))
+
+		By("retrying the new bundle on the next tick")
+		failing = false
+		Expect(agent.sync(context.Background())).To(Succeed())
+		Expect(os.ReadFile(anchor)).To(Equal([]byte("new")))
+		Expect(updates).To(Equal(2))
+	})
+
+	It("doesn't leave a new anchor behind when the first update fails", func() {
+		failing = true
+		Expect(agent.sync(context.Background())).NotTo(Succeed())
+		Expect(anchor).NotTo(BeAnExistingFile())
+	})
+
+	It("removes the anchor and updates the trust store on cleanup", func() {
+		Expect(agent.sync(context.Background())).To(Succeed())
+
+		Expect(agent.Cleanup(context.Background())).To(Succeed())
+		Expect(anchor).NotTo(BeAnExistingFile())
+		Expect(updates).To(Equal(2))
+
+		By("doing nothing once it is gone")
+		Expect(agent.Cleanup(context.Background())).To(Succeed())
+		Expect(updates).To(Equal(2))
+	})
+
+	It("requires an absolute update command", func() {
+		_, err := New(WithSourcePath(source), WithUpdateCommand([]string{"update-ca-trust"}))
+		Expect(err).To(MatchError(ContainSubstring("absolute path")))
+	})
+})
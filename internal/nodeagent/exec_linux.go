@@ -0,0 +1,20 @@
+//go:build linux
+
+package nodeagent
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// runInRoot runs the command chrooted into the host root filesystem. The
+// command path is resolved inside the host root, so it must be absolute.
+func runInRoot(root string, command []string) ([]byte, error) {
+	cmd := &exec.Cmd{
+		Path:        command[0],
+		Args:        command,
+		Dir:         "/",
+		SysProcAttr: &syscall.SysProcAttr{Chroot: root},
+	}
+	return cmd.CombinedOutput()
+}
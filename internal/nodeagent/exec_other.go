@@ -0,0 +1,10 @@
+//go:build !linux
+
+package nodeagent
+
+import "errors"
+
+// runInRoot is only supported on Linux nodes.
+func runInRoot(string, []string) ([]byte, error) {
+	return nil, errors.New("updating the node trust store is only supported on linux")
+}
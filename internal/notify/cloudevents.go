@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// CloudEvents types emitted, see CloudEventsFor.
+const (
+	CloudEventBundleSynced       = "io.cabundle.bundle.synced"
+	CloudEventCertificateAdded   = "io.cabundle.certificate.added"
+	CloudEventCertificateRemoved = "io.cabundle.certificate.removed"
+	CloudEventSyncFailed         = "io.cabundle.sync.failed"
+)
+
+// CloudEventsContentType is the content type of a CloudEvent in structured
+// mode with the JSON event format.
+const CloudEventsContentType = "application/cloudevents+json"
+
+// CloudEvent is a CloudEvents 1.0 event in the JSON event format.
+type CloudEvent struct {
+	SpecVersion     string `json:"specversion"`
+	ID              string `json:"id"`
+	Source          string `json:"source"`
+	Type            string `json:"type"`
+	Subject         string `json:"subject,omitempty"`
+	Time            string `json:"time"`
+	DataContentType string `json:"datacontenttype"`
+	Data            any    `json:"data"`
+}
+
+// CloudEventsFor returns the CloudEvents describing n: a bundle synced or a
+// sync failed, and one event per certificate a changed bundle added or
+// removed. Other notifications have no CloudEvents.
+func CloudEventsFor(n Notification) []CloudEvent {
+	newEvent := func(typ, subject string, data any) CloudEvent {
+		return CloudEvent{
+			SpecVersion:     "1.0",
+			ID:              uuid.NewString(),
+			Source:          "/cabundle-operator/" + n.Source,
+			Type:            typ,
+			Subject:         subject,
+			Time:            n.Time.UTC().Format(time.RFC3339Nano),
+			DataContentType: "application/json",
+			Data:            data,
+		}
+	}
+	switch n.Event {
+	case BundleSynced:
+		return []CloudEvent{newEvent(CloudEventBundleSynced, n.Hash, n)}
+	case SyncFailed:
+		return []CloudEvent{newEvent(CloudEventSyncFailed, "", n)}
+	case BundleChanged:
+		var events []CloudEvent
+		for _, subject := range n.Added {
+			events = append(events, newEvent(CloudEventCertificateAdded, subject, certificateData(n, subject)))
+		}
+		for _, subject := range n.Removed {
+			events = append(events, newEvent(CloudEventCertificateRemoved, subject, certificateData(n, subject)))
+		}
+		return events
+	}
+	return nil
+}
+
+// certificateData is the data of the certificate events.
+func certificateData(n Notification, subject string) map[string]any {
+	return map[string]any{
+		"source":      n.Source,
+		"sourceURL":   n.SourceURL,
+		"certificate": subject,
+		"configMaps":  n.ConfigMaps,
+	}
+}
+
+// CloudEventsHTTP is a sink POSTing the CloudEvents of a notification in
+// structured mode to URL, one request per event.
+type CloudEventsHTTP struct {
+	URL    string
+	Client *http.Client
+}
+
+func (c *CloudEventsHTTP) Send(ctx context.Context, n Notification) error {
+	header := http.Header{"Content-Type": {CloudEventsContentType}}
+	var errs []error
+	for _, e := range CloudEventsFor(n) {
+		if err := postJSONWithHeader(ctx, c.Client, c.URL, header, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Type, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CloudEventsKafka is a sink writing the CloudEvents of a notification in
+// structured mode to a Kafka topic, keyed by source so the events of a source
+// keep their order.
+type CloudEventsKafka struct {
+	Writer *kafka.Writer
+}
+
+// NewCloudEventsKafka returns a sink writing to topic on brokers.
+func NewCloudEventsKafka(brokers []string, topic string) *CloudEventsKafka {
+	return &CloudEventsKafka{Writer: &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Topic:                  topic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: false,
+	}}
+}
+
+func (c *CloudEventsKafka) Send(ctx context.Context, n Notification) error {
+	var msgs []kafka.Message
+	for _, e := range CloudEventsFor(n) {
+		value, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, kafka.Message{
+			Key:     []byte(n.Source),
+			Value:   value,
+			Headers: []kafka.Header{{Key: "content-type", Value: []byte(CloudEventsContentType)}},
+		})
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	if err := c.Writer.WriteMessages(ctx, msgs...); err != nil {
+		return fmt.Errorf("writing CloudEvents to Kafka topic %s: %w", c.Writer.Topic, err)
+	}
+	return nil
+}
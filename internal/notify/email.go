@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EmailEvents are the events emailed by default: the ones a responsible team
+// has to act on.
+var EmailEvents = []Event{CertificateExpiring, SyncFailing, BundleUnservable, UpstreamChanged}
+
+// Email is a sink sending the notification as a plain text email over SMTP to
+// To and the contacts of the source.
+type Email struct {
+	// Addr is the SMTP server as host:port. STARTTLS is used if the server
+	// offers it, and required to authenticate with Username and Password.
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Events are the events emailed, EmailEvents if empty.
+	Events []Event
+}
+
+func (e *Email) Send(ctx context.Context, n Notification) error {
+	events := e.Events
+	if len(events) == 0 {
+		events = EmailEvents
+	}
+	if !slices.Contains(events, n.Event) {
+		return nil
+	}
+	to := slices.Clone(e.To)
+	for _, c := range n.Contacts {
+		if !slices.Contains(to, c) {
+			to = append(to, c)
+		}
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, _ := net.SplitHostPort(e.Addr)
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	// net/smtp takes no context; the send is abandoned, not aborted, once
+	// ctx is done.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(e.Addr, auth, e.From, to, e.message(n, to))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("sending notification email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("sending notification email: %w", ctx.Err())
+	}
+}
+
+// message renders n as an RFC 5322 message.
+func (e *Email) message(n Notification, to []string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: [cabundle-operator] %s: %s\r\n", n.Event, n.Source)
+	fmt.Fprintf(&b, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(n.Text(), "\n", "\r\n"))
+	if n.SourceURL != "" {
+		fmt.Fprintf(&b, "\r\nSource URL: %s", n.SourceURL)
+	}
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
@@ -0,0 +1,224 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Event is what a Notification is about.
+type Event string
+
+const (
+	// BundleChanged is sent when the certificates distributed for a source
+	// changed.
+	BundleChanged Event = "BundleChanged"
+	// SyncFailing is sent when syncs of a source failed repeatedly.
+	SyncFailing Event = "SyncFailing"
+	// ConfigMapsDeleted is sent when cleanup deleted managed ConfigMaps.
+	ConfigMapsDeleted Event = "ConfigMapsDeleted"
+	// CertificateExpiring is sent when a distributed certificate entered the
+	// expiry warning period of its source, and again once it expired.
+	CertificateExpiring Event = "CertificateExpiring"
+	// BundleUnservable is sent when downloads from a source were suspended
+	// after repeated failures, so its bundle can't be refreshed, and
+	// resolved once a sync succeeded again.
+	BundleUnservable Event = "BundleUnservable"
+	// UpstreamChanged is sent when a new version of the bundle of a source
+	// with the alert change policy awaits acknowledgement.
+	UpstreamChanged Event = "UpstreamChanged"
+
+	// BundleSynced and SyncFailed are sent after every sync that
+	// distributed the bundle or failed. Only CloudEvents sinks emit them,
+	// for the others they would be noise.
+	BundleSynced Event = "BundleSynced"
+	SyncFailed   Event = "SyncFailed"
+)
+
+// lifecycle reports whether e is only emitted as a CloudEvent.
+func (e Event) lifecycle() bool {
+	return e == BundleSynced || e == SyncFailed
+}
+
+// Severity ranks notifications; critical ones page on-call, see PagerDuty
+// and Opsgenie.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Notification is the payload sent to the sinks, serialized as JSON for
+// generic webhooks.
+type Notification struct {
+	Event    Event    `json:"event"`
+	Severity Severity `json:"severity"`
+	// Resolved is set when the condition reported by an earlier
+	// notification of the event and source ended.
+	Resolved bool `json:"resolved,omitempty"`
+	// Source is the source ConfigMap as <namespace>/<name>.
+	Source    string    `json:"source"`
+	SourceURL string    `json:"sourceURL,omitempty"`
+	Time      time.Time `json:"time"`
+	Message   string    `json:"message"`
+	// ConfigMaps lists the managed ConfigMaps changed or deleted, as
+	// <namespace>/<name>.
+	ConfigMaps []string `json:"configMaps,omitempty"`
+	// Added and Removed list the subjects of the certificates a change
+	// added to or removed from the bundle.
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	// Outcome and Hash describe a sync, see SyncRecord.
+	Outcome string `json:"outcome,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+	// ConsecutiveFailures and Error describe a failing sync.
+	ConsecutiveFailures int    `json:"consecutiveFailures,omitempty"`
+	Error               string `json:"error,omitempty"`
+	// Contacts are the email addresses of the team responsible for the
+	// source, see the notify_email source key.
+	Contacts []string `json:"contacts,omitempty"`
+}
+
+// Text renders the notification as a line of text for chat sinks.
+func (n Notification) Text() string {
+	var b strings.Builder
+	event := string(n.Event)
+	if n.Resolved {
+		event += " resolved"
+	}
+	fmt.Fprintf(&b, "[%s] %s: %s", event, n.Source, n.Message)
+	if len(n.Added) > 0 {
+		fmt.Fprintf(&b, "\nAdded: %s", strings.Join(n.Added, "; "))
+	}
+	if len(n.Removed) > 0 {
+		fmt.Fprintf(&b, "\nRemoved: %s", strings.Join(n.Removed, "; "))
+	}
+	if len(n.ConfigMaps) > 0 {
+		fmt.Fprintf(&b, "\nConfigMaps: %s", strings.Join(n.ConfigMaps, ", "))
+	}
+	if n.Error != "" {
+		fmt.Fprintf(&b, "\nError: %s", n.Error)
+	}
+	return b.String()
+}
+
+// Key identifies the condition reported by notifications of the event and
+// source, e.g. to resolve an alert.
+func (n Notification) Key() string {
+	return "cabundle-operator/" + string(n.Event) + "/" + n.Source
+}
+
+// pages reports whether n opens or resolves an alert.
+func (n Notification) pages() bool {
+	return n.Severity == SeverityCritical
+}
+
+// Sink delivers notifications to a single destination.
+type Sink interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// Webhook is a sink POSTing the notification as JSON to URL.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w *Webhook) Send(ctx context.Context, n Notification) error {
+	if n.Event.lifecycle() {
+		return nil
+	}
+	return postJSON(ctx, w.Client, w.URL, n)
+}
+
+// Slack is a sink posting the notification as a message to a Slack incoming
+// webhook URL.
+type Slack struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *Slack) Send(ctx context.Context, n Notification) error {
+	if n.Event.lifecycle() {
+		return nil
+	}
+	return postJSON(ctx, s.Client, s.URL, map[string]string{"text": n.Text()})
+}
+
+// postJSON POSTs body as JSON to target, failing on a non-2xx response. The URL
+// isn't part of errors since it usually embeds a secret token.
+func postJSON(ctx context.Context, c *http.Client, target string, body any) error {
+	return postJSONWithHeader(ctx, c, target, nil, body)
+}
+
+// postJSONWithHeader is postJSON setting additional request headers.
+func postJSONWithHeader(ctx context.Context, c *http.Client, target string, header http.Header, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return errors.New("invalid notification URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("sending notification: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sending notification: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// DefaultTimeout bounds the delivery of a notification to a sink.
+const DefaultTimeout = 10 * time.Second
+
+// Notifier sends notifications to every sink in the background, so a slow
+// sink never holds up a sync. Delivery failures are logged.
+type Notifier struct {
+	Sinks   []Sink
+	Timeout time.Duration
+}
+
+// Notify sends n to the sinks without waiting for them.
+func (nf *Notifier) Notify(ctx context.Context, n Notification) {
+	logger := logf.FromContext(ctx)
+	timeout := nf.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	for _, s := range nf.Sinks {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+			defer cancel()
+			if err := s.Send(ctx, n); err != nil {
+				logger.Error(err, "unable to send notification", "event", n.Event, "sink", fmt.Sprintf("%T", s))
+			}
+		}()
+	}
+}
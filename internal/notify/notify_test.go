@@ -0,0 +1,252 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sinks", func() {
+	ctx := context.Background()
+
+	It("posts JSON to webhooks and text to Slack", func() {
+		var mu sync.Mutex
+		received := map[string][]byte{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var body json.RawMessage
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			mu.Lock()
+			received[req.URL.Path] = body
+			mu.Unlock()
+		}))
+		defer server.Close()
+
+		n := Notification{Event: SyncFailing, Source: "cert-manager/root-ca", Message: "Sync failed 3 times in a row", Error: "connection refused"}
+		Expect((&Webhook{URL: server.URL + "/hook"}).Send(ctx, n)).To(Succeed())
+		Expect((&Slack{URL: server.URL + "/slack"}).Send(ctx, n)).To(Succeed())
+
+		var payload Notification
+		Expect(json.Unmarshal(received["/hook"], &payload)).To(Succeed())
+		Expect(payload).To(Equal(n))
+		Expect(string(received["/slack"])).To(MatchJSON(`{"text":"[SyncFailing] cert-manager/root-ca: Sync failed 3 times in a row\nError: connection refused"}`))
+	})
+
+	It("emails the events a team acts on over SMTP", func() {
+		server := newFakeSMTPServer()
+		defer server.Close()
+		email := &Email{Addr: server.Addr(), From: "cabundle@example.com", To: []string{"pki@example.com"}}
+
+		n := Notification{
+			Event: CertificateExpiring, Source: "cert-manager/root-ca", Message: "CN=Corp Root expires soon",
+			Contacts: []string{"sre@example.com", "pki@example.com"},
+		}
+		Expect(email.Send(ctx, n)).To(Succeed())
+		Expect(email.Send(ctx, Notification{Event: BundleChanged})).To(Succeed())
+
+		mails := server.Mails()
+		Expect(mails).To(HaveLen(1))
+		Expect(mails[0].To).To(Equal([]string{"pki@example.com", "sre@example.com"}))
+		Expect(mails[0].Data).To(ContainSubstring("Subject: [cabundle-operator] CertificateExpiring: cert-manager/root-ca\r\n"))
+		Expect(mails[0].Data).To(ContainSubstring("[CertificateExpiring] cert-manager/root-ca: CN=Corp Root expires soon"))
+	})
+
+	It("pages PagerDuty and Opsgenie for critical notifications only", func() {
+		type request struct {
+			Path, Authorization string
+			Body                map[string]any
+		}
+		var mu sync.Mutex
+		var requests []request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var body map[string]any
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			mu.Lock()
+			requests = append(requests, request{Path: req.URL.RequestURI(), Authorization: req.Header.Get("Authorization"), Body: body})
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+		pagerDuty := &PagerDuty{RoutingKey: "routing-key", URL: server.URL + "/v2/enqueue"}
+		opsgenie := &Opsgenie{APIKey: "api-key", URL: server.URL + "/v2/alerts"}
+
+		unservable := Notification{
+			Event: BundleUnservable, Severity: SeverityCritical, Source: "cert-manager/root-ca",
+			Message: "Downloads suspended",
+		}
+		resolved := unservable
+		resolved.Resolved = true
+		for _, n := range []Notification{{Event: BundleChanged, Severity: SeverityInfo}, unservable, resolved} {
+			Expect(pagerDuty.Send(ctx, n)).To(Succeed())
+			Expect(opsgenie.Send(ctx, n)).To(Succeed())
+		}
+
+		Expect(requests).To(HaveLen(4))
+		const key = "cabundle-operator/BundleUnservable/cert-manager/root-ca"
+		Expect(requests[0].Path).To(Equal("/v2/enqueue"))
+		Expect(requests[0].Body).To(HaveKeyWithValue("event_action", "trigger"))
+		Expect(requests[0].Body).To(HaveKeyWithValue("dedup_key", key))
+		Expect(requests[0].Body).To(HaveKeyWithValue("routing_key", "routing-key"))
+		Expect(requests[0].Body["payload"]).To(HaveKeyWithValue("summary", "cert-manager/root-ca: Downloads suspended"))
+		Expect(requests[1].Path).To(Equal("/v2/alerts"))
+		Expect(requests[1].Authorization).To(Equal("GenieKey api-key"))
+		Expect(requests[1].Body).To(HaveKeyWithValue("alias", key))
+		Expect(requests[1].Body).To(HaveKeyWithValue("priority", "P1"))
+		Expect(requests[2].Body).To(HaveKeyWithValue("event_action", "resolve"))
+		Expect(requests[2].Body).To(HaveKeyWithValue("dedup_key", key))
+		Expect(requests[3].Path).To(Equal("/v2/alerts/" + url.PathEscape(key) + "/close?identifierType=alias"))
+	})
+
+	It("emits CloudEvents for synced bundles and added and removed certificates", func() {
+		var mu sync.Mutex
+		var events []CloudEvent
+		var contentTypes []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var e CloudEvent
+			Expect(json.NewDecoder(req.Body).Decode(&e)).To(Succeed())
+			mu.Lock()
+			events = append(events, e)
+			contentTypes = append(contentTypes, req.Header.Get("Content-Type"))
+			mu.Unlock()
+		}))
+		defer server.Close()
+		sink := &CloudEventsHTTP{URL: server.URL}
+		webhook := &Webhook{URL: server.URL}
+
+		now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+		synced := Notification{Event: BundleSynced, Source: "cert-manager/root-ca", Time: now, Hash: "abc123", Outcome: "Succeeded"}
+		changed := Notification{
+			Event: BundleChanged, Source: "cert-manager/root-ca", Time: now,
+			Added: []string{"CN=New Root,O=Corp"}, Removed: []string{"CN=Old Root,O=Corp"},
+		}
+		Expect(sink.Send(ctx, synced)).To(Succeed())
+		Expect(sink.Send(ctx, changed)).To(Succeed())
+		Expect(sink.Send(ctx, Notification{Event: ConfigMapsDeleted})).To(Succeed())
+		// Lifecycle events are CloudEvents only.
+		Expect(webhook.Send(ctx, synced)).To(Succeed())
+
+		Expect(events).To(HaveLen(3))
+		Expect(contentTypes).To(HaveEach(CloudEventsContentType))
+		Expect(events[0].SpecVersion).To(Equal("1.0"))
+		Expect(events[0].ID).NotTo(BeEmpty())
+		Expect(events[0].Type).To(Equal(CloudEventBundleSynced))
+		Expect(events[0].Source).To(Equal("/cabundle-operator/cert-manager/root-ca"))
+		Expect(events[0].Subject).To(Equal("abc123"))
+		Expect(events[0].Time).To(Equal("2026-10-17T09:00:00Z"))
+		Expect(events[1].Type).To(Equal(CloudEventCertificateAdded))
+		Expect(events[1].Subject).To(Equal("CN=New Root,O=Corp"))
+		Expect(events[2].Type).To(Equal(CloudEventCertificateRemoved))
+		Expect(events[2].Subject).To(Equal("CN=Old Root,O=Corp"))
+	})
+
+	It("fails on an error response without revealing the URL", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		err := (&Slack{URL: server.URL + "/services/secret-token"}).Send(ctx, Notification{})
+		Expect(err).To(MatchError(ContainSubstring("403")))
+		Expect(err.Error()).NotTo(ContainSubstring("secret-token"))
+	})
+})
+
+// fakeSMTPServer accepts mail over plain SMTP without authentication.
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	mails    []fakeMail
+}
+
+type fakeMail struct {
+	To   []string
+	Data string
+}
+
+func newFakeSMTPServer() *fakeSMTPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	s := &fakeSMTPServer{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(textproto.NewConn(conn))
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) serve(c *textproto.Conn) {
+	defer c.Close()
+	var mail fakeMail
+	_ = c.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO", "MAIL", "RSET", "NOOP":
+			_ = c.PrintfLine("250 OK")
+		case "RCPT":
+			mail.To = append(mail.To, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			_ = c.PrintfLine("250 OK")
+		case "DATA":
+			_ = c.PrintfLine("354 Go ahead")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.Data = strings.ReplaceAll(string(data), "\n", "\r\n")
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+			mail = fakeMail{}
+			_ = c.PrintfLine("250 OK")
+		case "QUIT":
+			_ = c.PrintfLine("221 Bye")
+			return
+		default:
+			_ = c.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) Addr() string { return s.listener.Addr().String() }
+
+func (s *fakeSMTPServer) Close() { _ = s.listener.Close() }
+
+func (s *fakeSMTPServer) Mails() []fakeMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.mails)
+}
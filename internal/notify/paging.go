@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// DefaultPagerDutyURL is the PagerDuty Events API v2 endpoint.
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty is a sink triggering a PagerDuty incident for critical
+// notifications through the Events API v2, and resolving it once the
+// condition ended. Other notifications are ignored.
+type PagerDuty struct {
+	// RoutingKey is the integration key of the PagerDuty service.
+	RoutingKey string
+	// URL is the Events API endpoint, DefaultPagerDutyURL if empty.
+	URL    string
+	Client *http.Client
+}
+
+func (p *PagerDuty) Send(ctx context.Context, n Notification) error {
+	if !n.pages() {
+		return nil
+	}
+	event := map[string]any{
+		"routing_key":  p.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    n.Key(),
+	}
+	if n.Resolved {
+		event["event_action"] = "resolve"
+	} else {
+		event["payload"] = map[string]any{
+			"summary":        n.Source + ": " + n.Message,
+			"source":         n.Source,
+			"severity":       "critical",
+			"component":      "cabundle-operator",
+			"class":          string(n.Event),
+			"timestamp":      n.Time,
+			"custom_details": n,
+		}
+	}
+	target := p.URL
+	if target == "" {
+		target = DefaultPagerDutyURL
+	}
+	return postJSON(ctx, p.Client, target, event)
+}
+
+// DefaultOpsgenieURL is the Opsgenie Alert API endpoint; EU accounts use
+// https://api.eu.opsgenie.com/v2/alerts.
+const DefaultOpsgenieURL = "https://api.opsgenie.com/v2/alerts"
+
+// Opsgenie is a sink creating a P1 Opsgenie alert for critical notifications,
+// and closing it once the condition ended. Other notifications are ignored.
+type Opsgenie struct {
+	APIKey string
+	// URL is the Alert API endpoint, DefaultOpsgenieURL if empty.
+	URL    string
+	Client *http.Client
+}
+
+func (o *Opsgenie) Send(ctx context.Context, n Notification) error {
+	if !n.pages() {
+		return nil
+	}
+	target := o.URL
+	if target == "" {
+		target = DefaultOpsgenieURL
+	}
+	header := http.Header{"Authorization": {"GenieKey " + o.APIKey}}
+	if n.Resolved {
+		return postJSONWithHeader(ctx, o.Client, target+"/"+url.PathEscape(n.Key())+"/close?identifierType=alias", header,
+			map[string]string{"source": "cabundle-operator", "note": n.Message})
+	}
+	return postJSONWithHeader(ctx, o.Client, target, header, map[string]any{
+		"message":     truncate(n.Source+": "+n.Message, 130),
+		"alias":       n.Key(),
+		"description": n.Text(),
+		"priority":    "P1",
+		"source":      "cabundle-operator",
+		"tags":        []string{"cabundle-operator", string(n.Event)},
+		"entity":      n.Source,
+	})
+}
+
+// truncate shortens s to at most n bytes, the limit of Opsgenie messages.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
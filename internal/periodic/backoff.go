@@ -0,0 +1,41 @@
+package periodic
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// backoff stretches the schedule of sources failing repeatedly, see
+// [WithBackoff].
+type backoff struct {
+	threshold int
+	max       time.Duration
+	failures  func(types.NamespacedName) int
+}
+
+// next returns the next sync of the source after now and whether it was
+// stretched. A nil backoff keeps to the schedule.
+func (b *backoff) next(ctx context.Context, key types.NamespacedName, schedule Schedule, now time.Time) (time.Time, bool) {
+	next := schedule.Next(now)
+	if b == nil {
+		return next, false
+	}
+	failures := b.failures(key)
+	if failures < b.threshold {
+		return next, false
+	}
+
+	delay := next.Sub(now)
+	stretched := delay
+	for i := b.threshold; i <= failures && stretched < b.max; i++ {
+		stretched *= 2
+	}
+	stretched = max(min(stretched, b.max), delay)
+
+	log.FromContext(ctx).Info("Backing off failing source", "source", key,
+		"consecutiveFailures", failures, "delay", stretched.String())
+	return now.Add(stretched), stretched > delay
+}
@@ -0,0 +1,17 @@
+package periodic
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// skippedTicks counts the ticks of a source skipped because its previous
+// sync was still running.
+var skippedTicks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cabundle_periodic_skipped_ticks_total",
+	Help: "Periodic syncs skipped because the previous sync of the source was still running.",
+}, []string{"source"})
+
+func init() {
+	metrics.Registry.MustRegister(skippedTicks)
+}
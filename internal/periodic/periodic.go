@@ -2,33 +2,53 @@ package periodic
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-// Runner is a periodic runner which enqueues Pods for reconciliation on regular
-// intervals.
+// Runner is a periodic runner which enqueues the bundle sources for
+// reconciliation on regular intervals.
 type Runner struct {
-	client          client.Client
+	client client.Client
+	// mu guards interval, jitter and schedule, changed by Reconfigure while
+	// the Runner runs. generation counts the changes.
+	mu              sync.RWMutex
+	generation      int
 	interval        time.Duration
+	jitter          float64
+	stagger         bool
+	schedule        Schedule
 	TargetNamespace string
 	configMapName   string
 	eventCh         chan event.GenericEvent
+	sources         SourceLister
+	inFlight        func(types.NamespacedName) bool
+	backoff         *backoff
+	nextSync        func(types.NamespacedName, time.Time)
+	now             func() time.Time
+	// primed is set once the sources were listed the first time.
+	primed bool
 }
 
+// SourceLister returns the source ConfigMaps the [Runner] enqueues.
+type SourceLister func(ctx context.Context) ([]corev1.ConfigMap, error)
+
 // Option is a function which configures the [Runner].
 type Option func(c *Runner) error
 
 // New creates a new periodic runner and configures it using the provided
 // options.
 func New(opts ...Option) (*Runner, error) {
-	r := &Runner{}
+	r := &Runner{now: time.Now}
 	for _, opt := range opts {
 		if err := opt(r); err != nil {
 			return nil, err
@@ -38,6 +58,24 @@ func New(opts ...Option) (*Runner, error) {
 	return r, nil
 }
 
+// Reconfigure applies [WithInterval], [WithSchedule] and [WithJitter] options
+// to the running [Runner], e.g. from the reloaded operator configuration
+// file. The next syncs of every source are planned again on the next refresh.
+// If an option fails, the [Runner] is left unchanged.
+func (r *Runner) Reconfigure(opts ...Option) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interval, jitter, schedule := r.interval, r.jitter, r.schedule
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			r.interval, r.jitter, r.schedule = interval, jitter, schedule
+			return err
+		}
+	}
+	r.generation++
+	return nil
+}
+
 // WithClient configures the [Runner] with the given client.
 func WithClient(c client.Client) Option {
 	opt := func(r *Runner) error {
@@ -58,6 +96,53 @@ func WithInterval(interval time.Duration) Option {
 	return opt
 }
 
+// WithSchedule configures the [Runner] to tick on a cron schedule instead of
+// the interval. An empty spec keeps the interval.
+func WithSchedule(spec string) Option {
+	opt := func(r *Runner) error {
+		if spec == "" {
+			r.schedule = nil
+			return nil
+		}
+		s, err := ParseSchedule(spec)
+		if err != nil {
+			return err
+		}
+		r.schedule = s
+		return nil
+	}
+
+	return opt
+}
+
+// WithJitter configures the [Runner] to stretch every interval by a random
+// fraction of up to maxFactor, so replicas and sources don't sync in
+// lockstep.
+func WithJitter(maxFactor float64) Option {
+	opt := func(r *Runner) error {
+		if maxFactor < 0 {
+			return fmt.Errorf("jitter must not be negative, got %v", maxFactor)
+		}
+		r.jitter = maxFactor
+		return nil
+	}
+
+	return opt
+}
+
+// WithStagger configures the [Runner] to spread sources sharing an interval
+// across it, each at an offset derived from its name, rather than syncing
+// them together. The sources found at startup first sync in their slot too;
+// sources added later still sync right away. Cron schedules aren't affected.
+func WithStagger(enabled bool) Option {
+	opt := func(r *Runner) error {
+		r.stagger = enabled
+		return nil
+	}
+
+	return opt
+}
+
 // WithTargetNamespace configures the [Runner] to watch the given namespace.
 func WithTargetNamespace(ns string) Option {
 	opt := func(r *Runner) error {
@@ -80,7 +165,8 @@ func WithConfigMapName(name string) Option {
 }
 
 // WithEventChannel configures the [Runner] to use the given channel for
-// enqueuing.
+// enqueuing. The channel may be shared with other producers; the [Runner]
+// never closes it and stops sending when its context is done.
 func WithEventChannel(ch chan event.GenericEvent) Option {
 	opt := func(r *Runner) error {
 		r.eventCh = ch
@@ -90,41 +176,206 @@ func WithEventChannel(ch chan event.GenericEvent) Option {
 	return opt
 }
 
+// WithSources configures the [Runner] to enqueue every source returned by
+// the lister instead of only the operator configuration ConfigMap.
+func WithSources(l SourceLister) Option {
+	opt := func(r *Runner) error {
+		r.sources = l
+		return nil
+	}
+
+	return opt
+}
+
+// WithInFlight configures the [Runner] to skip the ticks of sources the
+// function reports a sync running for, instead of queueing another one
+// behind it.
+func WithInFlight(f func(types.NamespacedName) bool) Option {
+	opt := func(r *Runner) error {
+		r.inFlight = f
+		return nil
+	}
+
+	return opt
+}
+
+// WithBackoff configures the [Runner] to stretch the schedule of sources
+// that failed at least threshold consecutive syncs, doubling the delay with
+// every further failure up to maxDelay. The normal cadence resumes after the
+// first success. A threshold of zero disables backoff.
+func WithBackoff(threshold int, maxDelay time.Duration, failures func(types.NamespacedName) int) Option {
+	opt := func(r *Runner) error {
+		if threshold < 0 || maxDelay < 0 {
+			return fmt.Errorf("backoff threshold and maximum must not be negative")
+		}
+		if threshold > 0 {
+			r.backoff = &backoff{threshold: threshold, max: maxDelay, failures: failures}
+		}
+		return nil
+	}
+
+	return opt
+}
+
 // Start implements the
-// [sigs.k8s.io/controller-runtime/pkg/manager.Runnable] interface.
+// [sigs.k8s.io/controller-runtime/pkg/manager.Runnable] interface. Every
+// source is enqueued on its own schedule, see [Runner.scheduleFor].
 func (r *Runner) Start(ctx context.Context) error {
-	ticker := time.NewTicker(r.interval)
 	logger := log.FromContext(ctx)
-	defer ticker.Stop()
-	defer close(r.eventCh)
+
+	due := map[types.NamespacedName]*sourceTimer{}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			if err := r.genericEventChannel(ctx); err != nil {
-				logger.Error(err, "failed to enqueue pods")
+		case <-timer.C:
+			if err := r.enqueueDue(ctx, due); err != nil {
+				logger.Error(err, "failed to enqueue sources")
 			}
+			timer.Reset(r.untilNext(due))
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
-// genericEventChannel enqueues a generic event to trigger reconciliation
-func (r *Runner) genericEventChannel(ctx context.Context) error {
+// WithNextSync configures the [Runner] to report the next planned sync of
+// every source, and a zero time once a source is gone.
+func WithNextSync(f func(types.NamespacedName, time.Time)) Option {
+	opt := func(r *Runner) error {
+		r.nextSync = f
+		return nil
+	}
+
+	return opt
+}
+
+// NeedLeaderElection implements the
+// [sigs.k8s.io/controller-runtime/pkg/manager.LeaderElectionRunnable]
+// interface, so only the elected replica enqueues and writes ConfigMaps.
+func (r *Runner) NeedLeaderElection() bool {
+	return true
+}
+
+// sourceTimer is the next sync of a source and the schedule spec it was
+// computed from.
+type sourceTimer struct {
+	spec string
+	next time.Time
+	// generation is the Runner's generation next was computed with.
+	generation int
+	// backedOff is set while next is stretched by the backoff.
+	backedOff bool
+}
+
+// enqueueDue refreshes the source list and enqueues every source whose sync
+// is due or that is seen for the first time.
+func (r *Runner) enqueueDue(ctx context.Context, due map[types.NamespacedName]*sourceTimer) error {
 	logger := log.FromContext(ctx)
-	logger.Info("Enqueuing periodic event")
 
-	cm := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      r.configMapName,
-			Namespace: r.TargetNamespace,
-		},
+	sources, err := r.listSources(ctx)
+	if err != nil {
+		return err
 	}
 
-	event := event.GenericEvent{
-		Object: cm,
+	r.mu.RLock()
+	generation := r.generation
+	r.mu.RUnlock()
+
+	now := r.now()
+	seen := map[types.NamespacedName]bool{}
+	var enqueued int
+	for i := range sources {
+		src := &sources[i]
+		key := types.NamespacedName{Namespace: src.Namespace, Name: src.Name}
+		seen[key] = true
+
+		spec, schedule, err := r.scheduleFor(src)
+		t, ok := due[key]
+		if !ok || t.spec != spec || t.generation != generation {
+			if err != nil {
+				logger.Error(err, "invalid source schedule, using the default", "source", key)
+			}
+			if ok {
+				t.spec, t.next, t.generation, t.backedOff = spec, schedule.Next(now), generation, false
+				continue
+			}
+			// Sources are synced as soon as they are seen, so the operator
+			// converges right after startup and new sources don't wait for
+			// their first interval. Staggered sources found at startup keep
+			// to their slots instead, so they don't all sync together after
+			// a restart.
+			t = &sourceTimer{spec: spec, generation: generation}
+			due[key] = t
+			if _, staggered := schedule.(staggeredSchedule); staggered && !r.primed {
+				t.next = schedule.Next(now)
+				continue
+			}
+		} else if t.backedOff && r.backoff.failures(key) == 0 {
+			// A sync triggered in between succeeded.
+			t.next, t.backedOff = schedule.Next(now), false
+			continue
+		} else if t.next.After(now) {
+			continue
+		}
+		if r.inFlight != nil && r.inFlight(key) {
+			logger.V(1).Info("Skipping tick of source with a sync running", "source", key)
+			skippedTicks.WithLabelValues(key.String()).Inc()
+			t.next, t.backedOff = r.backoff.next(ctx, key, schedule, now)
+			continue
+		}
+
+		select {
+		case r.eventCh <- event.GenericEvent{Object: src}:
+		case <-ctx.Done():
+			return nil
+		}
+		enqueued++
+		t.next, t.backedOff = r.backoff.next(ctx, key, schedule, now)
+	}
+	for key, t := range due {
+		if !seen[key] {
+			delete(due, key)
+		}
+		if r.nextSync != nil {
+			if seen[key] {
+				r.nextSync(key, t.next)
+			} else {
+				r.nextSync(key, time.Time{})
+			}
+		}
+	}
+
+	r.primed = true
+
+	if enqueued > 0 {
+		logger.Info("Enqueuing periodic event", "sources", enqueued)
 	}
-	r.eventCh <- event
 	return nil
 }
+
+// untilNext returns the time until the earliest due source, waking up at
+// least every sourceRefresh to pick up new and changed sources.
+func (r *Runner) untilNext(due map[types.NamespacedName]*sourceTimer) time.Duration {
+	wait := sourceRefresh
+	for _, t := range due {
+		if d := t.next.Sub(r.now()); d < wait {
+			wait = d
+		}
+	}
+	return max(wait, 0)
+}
+
+// listSources returns the sources to enqueue.
+func (r *Runner) listSources(ctx context.Context) ([]corev1.ConfigMap, error) {
+	if r.sources != nil {
+		return r.sources(ctx)
+	}
+	return []corev1.ConfigMap{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.configMapName,
+			Namespace: r.TargetNamespace,
+		},
+	}}, nil
+}
@@ -0,0 +1,410 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package periodic
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var epoch = time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func source(name string, data map[string]string) corev1.ConfigMap {
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cabundle", Name: name},
+		Data:       data,
+	}
+}
+
+var _ = Describe("Runner schedules", func() {
+	DescribeTable("scheduleFor",
+		func(runnerSchedule string, data map[string]string, wantSpec string, wantErr bool, wantNext time.Time) {
+			r, err := New(WithInterval(time.Hour), WithSchedule(runnerSchedule))
+			Expect(err).NotTo(HaveOccurred())
+			src := source("root", data)
+
+			spec, schedule, err := r.scheduleFor(&src)
+			Expect(spec).To(Equal(wantSpec))
+			Expect(err != nil).To(Equal(wantErr))
+			Expect(schedule.Next(epoch)).To(Equal(wantNext))
+		},
+		Entry("defaults to the runner interval", "", nil, "", false, epoch.Add(time.Hour)),
+		Entry("defaults to the runner schedule", "CRON_TZ=UTC 30 * * * *", nil, "", false, epoch.Add(30*time.Minute)),
+		Entry("uses the source schedule", "",
+			map[string]string{SyncScheduleKey: "CRON_TZ=UTC 0 2 * * *"}, "CRON_TZ=UTC 0 2 * * *", false, epoch.Add(16*time.Hour)),
+		Entry("prefers the source schedule over its interval", "",
+			map[string]string{SyncScheduleKey: "CRON_TZ=UTC 0 2 * * *", SyncIntervalKey: "5m"}, "CRON_TZ=UTC 0 2 * * *", false, epoch.Add(16*time.Hour)),
+		Entry("uses the source interval", "",
+			map[string]string{SyncIntervalKey: " 30m "}, "30m", false, epoch.Add(30*time.Minute)),
+		Entry("falls back on an invalid source schedule", "",
+			map[string]string{SyncScheduleKey: "every day"}, "every day", true, epoch.Add(time.Hour)),
+		Entry("falls back on an unparsable source interval", "",
+			map[string]string{SyncIntervalKey: "often"}, "often", true, epoch.Add(time.Hour)),
+		Entry("falls back on a source interval below the minimum", "",
+			map[string]string{SyncIntervalKey: "5s"}, "5s", true, epoch.Add(time.Hour)),
+	)
+
+	DescribeTable("staggeredSchedule.Next",
+		func(at time.Duration, want time.Duration) {
+			s := staggeredSchedule{interval: time.Hour, offset: 15 * time.Minute}
+			Expect(s.Next(epoch.Add(at))).To(Equal(epoch.Add(want)))
+		},
+		Entry("before the offset", time.Duration(0), 15*time.Minute),
+		Entry("just before the offset", 15*time.Minute-time.Second, 15*time.Minute),
+		Entry("at the offset", 15*time.Minute, 75*time.Minute),
+		Entry("after the offset", 20*time.Minute, 75*time.Minute),
+		Entry("in a later interval", 3*time.Hour+40*time.Minute, 4*time.Hour+15*time.Minute),
+	)
+
+	It("delays a staggered fire by up to the jitter", func() {
+		s := staggeredSchedule{interval: time.Hour, offset: 15 * time.Minute, jitter: 0.1}
+		for range 20 {
+			next := s.Next(epoch)
+			Expect(next).To(BeTemporally(">=", epoch.Add(15*time.Minute)))
+			Expect(next).To(BeTemporally("<", epoch.Add(21*time.Minute)))
+		}
+	})
+
+	It("spreads sources across the interval when staggering", func() {
+		r, err := New(WithInterval(time.Hour), WithStagger(true))
+		Expect(err).NotTo(HaveOccurred())
+		a, b := source("a", nil), source("b", nil)
+
+		_, sa, _ := r.scheduleFor(&a)
+		_, sb, _ := r.scheduleFor(&b)
+		Expect(sa.Next(epoch)).NotTo(Equal(sb.Next(epoch)))
+		_, again, _ := r.scheduleFor(&a)
+		Expect(again.Next(epoch)).To(Equal(sa.Next(epoch)))
+	})
+
+	DescribeTable("backoff.next",
+		func(b *backoff, wantDelay time.Duration, wantStretched bool) {
+			key := types.NamespacedName{Namespace: "cabundle", Name: "root"}
+			next, stretched := b.next(context.Background(), key, intervalSchedule{interval: time.Minute}, epoch)
+			Expect(next).To(Equal(epoch.Add(wantDelay)))
+			Expect(stretched).To(Equal(wantStretched))
+		},
+		Entry("keeps to the schedule without backoff", (*backoff)(nil), time.Minute, false),
+		Entry("keeps to the schedule below the threshold",
+			&backoff{threshold: 3, max: time.Hour, failures: func(types.NamespacedName) int { return 2 }}, time.Minute, false),
+		Entry("doubles the delay at the threshold",
+			&backoff{threshold: 3, max: time.Hour, failures: func(types.NamespacedName) int { return 3 }}, 2*time.Minute, true),
+		Entry("doubles the delay with every further failure",
+			&backoff{threshold: 3, max: time.Hour, failures: func(types.NamespacedName) int { return 5 }}, 8*time.Minute, true),
+		Entry("caps the delay at the maximum",
+			&backoff{threshold: 1, max: 5 * time.Minute, failures: func(types.NamespacedName) int { return 10 }}, 5*time.Minute, true),
+		Entry("never shortens the schedule",
+			&backoff{threshold: 1, max: 30 * time.Second, failures: func(types.NamespacedName) int { return 10 }}, time.Minute, false),
+	)
+
+	It("rejects a negative backoff and disables it at a zero threshold", func() {
+		_, err := New(WithBackoff(-1, time.Hour, nil))
+		Expect(err).To(HaveOccurred())
+		_, err = New(WithBackoff(3, -time.Hour, nil))
+		Expect(err).To(HaveOccurred())
+
+		r, err := New(WithBackoff(0, time.Hour, func(types.NamespacedName) int { return 10 }))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.backoff).To(BeNil())
+	})
+})
+
+var _ = Describe("Runner enqueueDue", func() {
+	var (
+		ctx      context.Context
+		r        *Runner
+		now      time.Time
+		sources  []corev1.ConfigMap
+		events   chan event.GenericEvent
+		due      map[types.NamespacedName]*sourceTimer
+		next     map[types.NamespacedName]time.Time
+		running  map[types.NamespacedName]bool
+		failures map[types.NamespacedName]int
+	)
+	key := func(name string) types.NamespacedName {
+		return types.NamespacedName{Namespace: "cabundle", Name: name}
+	}
+	enqueued := func() []string {
+		var names []string
+		for {
+			select {
+			case ev := <-events:
+				names = append(names, ev.Object.GetName())
+			default:
+				return names
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = epoch
+		sources = []corev1.ConfigMap{source("a", nil), source("b", map[string]string{SyncIntervalKey: "10m"})}
+		events = make(chan event.GenericEvent, 10)
+		due = map[types.NamespacedName]*sourceTimer{}
+		next = map[types.NamespacedName]time.Time{}
+		running = map[types.NamespacedName]bool{}
+		failures = map[types.NamespacedName]int{}
+
+		var err error
+		r, err = New(
+			WithInterval(time.Hour),
+			WithEventChannel(events),
+			WithSources(func(context.Context) ([]corev1.ConfigMap, error) { return sources, nil }),
+			WithInFlight(func(k types.NamespacedName) bool { return running[k] }),
+			WithBackoff(2, time.Hour, func(k types.NamespacedName) int { return failures[k] }),
+			WithNextSync(func(k types.NamespacedName, t time.Time) { next[k] = t }),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		r.now = func() time.Time { return now }
+	})
+
+	It("enqueues new sources and plans their next sync", func() {
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("a", "b"))
+		Expect(next).To(Equal(map[types.NamespacedName]time.Time{
+			key("a"): epoch.Add(time.Hour),
+			key("b"): epoch.Add(10 * time.Minute),
+		}))
+	})
+
+	It("keeps staggered sources found at startup to their slots", func() {
+		r.stagger = true
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(BeEmpty())
+		for _, k := range []types.NamespacedName{key("a"), key("b")} {
+			Expect(next[k]).To(BeTemporally(">", epoch))
+		}
+		Expect(next[key("a")]).To(BeTemporally("<=", epoch.Add(time.Hour)))
+		Expect(next[key("b")]).To(BeTemporally("<=", epoch.Add(10*time.Minute)))
+
+		sources = append(sources, source("c", nil))
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("c"))
+
+		now = next[key("b")]
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ContainElement("b"))
+	})
+
+	It("enqueues only the sources that are due", func() {
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		enqueued()
+
+		now = epoch.Add(5 * time.Minute)
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(BeEmpty())
+
+		now = epoch.Add(10 * time.Minute)
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("b"))
+		Expect(next[key("b")]).To(Equal(epoch.Add(20 * time.Minute)))
+		Expect(r.untilNext(due)).To(Equal(sourceRefresh))
+	})
+
+	It("skips the tick of a source with a sync running", func() {
+		running[key("a")] = true
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("b"))
+		Expect(next[key("a")]).To(Equal(epoch.Add(time.Hour)))
+	})
+
+	It("replans a source whose schedule changed without enqueuing it", func() {
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		enqueued()
+
+		now = epoch.Add(time.Minute)
+		sources[1] = source("b", map[string]string{SyncIntervalKey: "30m"})
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(BeEmpty())
+		Expect(next[key("b")]).To(Equal(epoch.Add(31 * time.Minute)))
+	})
+
+	It("replans every source when reconfigured", func() {
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		enqueued()
+
+		Expect(r.Reconfigure(WithInterval(2 * time.Hour))).To(Succeed())
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(BeEmpty())
+		Expect(next[key("a")]).To(Equal(epoch.Add(2 * time.Hour)))
+		Expect(next[key("b")]).To(Equal(epoch.Add(10 * time.Minute)))
+	})
+
+	It("backs off failing sources until they succeed", func() {
+		failures[key("b")] = 3
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		enqueued()
+		Expect(next[key("b")]).To(Equal(epoch.Add(40 * time.Minute)))
+
+		failures[key("b")] = 0
+		now = epoch.Add(time.Minute)
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(BeEmpty())
+		Expect(next[key("b")]).To(Equal(epoch.Add(11 * time.Minute)))
+	})
+
+	It("stops backing off a source whose schedule changed", func() {
+		failures[key("b")] = 3
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		enqueued()
+
+		failures[key("b")] = 0
+		now = epoch.Add(time.Minute)
+		sources[1] = source("b", map[string]string{SyncIntervalKey: "30m"})
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(next[key("b")]).To(Equal(epoch.Add(31 * time.Minute)))
+
+		// The replanned sync isn't pushed back by every tick.
+		now = epoch.Add(2 * time.Minute)
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		now = epoch.Add(31 * time.Minute)
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("b"))
+	})
+
+	It("forgets removed sources", func() {
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		enqueued()
+
+		sources = sources[:1]
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(due).NotTo(HaveKey(key("b")))
+		Expect(next[key("b")]).To(BeZero())
+	})
+
+	It("syncs a source on its cron schedule", func() {
+		// The epoch is a Thursday.
+		sources = []corev1.ConfigMap{source("weekdays", map[string]string{SyncScheduleKey: "CRON_TZ=UTC 0 2 * * 1-5"})}
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("weekdays"))
+		friday := time.Date(2026, 1, 2, 2, 0, 0, 0, time.UTC)
+		Expect(next[key("weekdays")]).To(Equal(friday))
+
+		now = friday
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("weekdays"))
+		Expect(next[key("weekdays")]).To(Equal(time.Date(2026, 1, 5, 2, 0, 0, 0, time.UTC)), "the weekend is skipped")
+	})
+
+	It("rejects an invalid runner schedule and keeps the running one", func() {
+		_, err := New(WithSchedule("weekdays at 02:00"))
+		Expect(err).To(MatchError(ContainSubstring("invalid schedule")))
+
+		Expect(r.Reconfigure(WithSchedule("CRON_TZ=UTC 30 * * * *"))).To(Succeed())
+		Expect(r.Reconfigure(WithInterval(time.Minute), WithSchedule("often"))).NotTo(Succeed())
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(next[key("a")]).To(Equal(epoch.Add(30 * time.Minute)))
+		Expect(next[key("b")]).To(Equal(epoch.Add(10*time.Minute)), "sources with their own interval keep it")
+	})
+
+	It("keeps a timer per source, falling back to the default on an invalid schedule until it is fixed", func() {
+		sources = append(sources, source("c", map[string]string{SyncScheduleKey: "every day"}))
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("a", "b", "c"))
+		Expect(due).To(HaveLen(3))
+		Expect(next[key("c")]).To(Equal(epoch.Add(time.Hour)))
+
+		now = epoch.Add(time.Minute)
+		sources[2] = source("c", map[string]string{SyncScheduleKey: "CRON_TZ=UTC 15 * * * *"})
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(BeEmpty())
+		Expect(next[key("c")]).To(Equal(epoch.Add(15 * time.Minute)))
+		Expect(next[key("a")]).To(Equal(epoch.Add(time.Hour)), "the other timers are left alone")
+		Expect(next[key("b")]).To(Equal(epoch.Add(10 * time.Minute)))
+
+		now = epoch.Add(15 * time.Minute)
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("b", "c"))
+	})
+
+	It("counts the skipped ticks of a source with a sync running", func() {
+		running[key("a")] = true
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		now = epoch.Add(time.Hour)
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ConsistOf("b", "b"))
+		Expect(testutil.ToFloat64(skippedTicks.WithLabelValues("cabundle/a"))).To(BeNumerically(">=", 2))
+
+		running[key("a")] = false
+		now = epoch.Add(2 * time.Hour)
+		Expect(r.enqueueDue(ctx, due)).To(Succeed())
+		Expect(enqueued()).To(ContainElement("a"))
+	})
+})
+
+var _ = Describe("Runner Start", func() {
+	It("only runs on the elected leader", func() {
+		r, err := New(WithInterval(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		var runnable manager.Runnable = r
+		elected, ok := runnable.(manager.LeaderElectionRunnable)
+		Expect(ok).To(BeTrue(), "the manager starts runnables without it on every replica")
+		Expect(elected.NeedLeaderElection()).To(BeTrue())
+	})
+
+	It("enqueues every source as soon as it starts", func() {
+		events := make(chan event.GenericEvent, 10)
+		r, err := New(
+			WithInterval(time.Hour),
+			WithEventChannel(events),
+			WithSources(func(context.Context) ([]corev1.ConfigMap, error) {
+				return []corev1.ConfigMap{source("a", nil), source("b", map[string]string{SyncScheduleKey: "@yearly"})}, nil
+			}),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- r.Start(ctx) }()
+
+		var names []string
+		for range 2 {
+			var ev event.GenericEvent
+			Eventually(events).Should(Receive(&ev))
+			names = append(names, ev.Object.GetName())
+		}
+		Expect(names).To(ConsistOf("a", "b"))
+		Consistently(events, 100*time.Millisecond).ShouldNot(Receive())
+
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("leaves the shared channel open when it stops", func() {
+		events := make(chan event.GenericEvent, 1)
+		r, err := New(WithInterval(time.Hour), WithEventChannel(events),
+			WithSources(func(context.Context) ([]corev1.ConfigMap, error) { return nil, nil }))
+		Expect(err).NotTo(HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(r.Start(ctx)).To(Succeed())
+
+		// Another producer still sends; on a closed channel this panics.
+		src := source("trigger", nil)
+		Expect(func() { events <- event.GenericEvent{Object: &src} }).NotTo(Panic())
+	})
+})
@@ -0,0 +1,118 @@
+package periodic
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Keys of a source ConfigMap overriding the [Runner]'s schedule for it.
+const (
+	SyncIntervalKey = "sync_interval"
+	SyncScheduleKey = "sync_schedule"
+)
+
+// sourceRefresh is how often the [Runner] re-lists its sources, so new ones
+// and schedule changes are picked up between syncs.
+const sourceRefresh = time.Minute
+
+// MinInterval is the shortest accepted sync interval.
+const MinInterval = 10 * time.Second
+
+// Schedule returns the next sync time after the given time.
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// ParseSchedule parses a standard five field cron expression, e.g.
+// "0 2 * * 1-5" for weekdays at 02:00, or a descriptor such as "@daily".
+// Times are in the operator's local time zone, UTC in the container image,
+// unless prefixed with CRON_TZ=<zone>.
+func ParseSchedule(spec string) (Schedule, error) {
+	s, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return s, nil
+}
+
+// intervalSchedule fires every interval, stretched by up to jitter.
+type intervalSchedule struct {
+	interval time.Duration
+	jitter   float64
+}
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	if s.jitter == 0 {
+		return t.Add(s.interval)
+	}
+	return t.Add(wait.Jitter(s.interval, s.jitter))
+}
+
+// staggeredSchedule fires every interval at a fixed offset into it, so
+// sources sharing an interval are spread across it instead of firing
+// together, and keep their slot across restarts. Every fire is delayed by up
+// to jitter of the interval.
+type staggeredSchedule struct {
+	interval time.Duration
+	offset   time.Duration
+	jitter   float64
+}
+
+func (s staggeredSchedule) Next(t time.Time) time.Time {
+	next := t.Add(-s.offset).Truncate(s.interval).Add(s.interval + s.offset)
+	if s.jitter == 0 {
+		return next
+	}
+	return next.Add(time.Duration(rand.Float64() * s.jitter * float64(s.interval)))
+}
+
+// every returns the schedule firing every interval for the source, offset
+// by a hash of its name when staggering.
+func (r *Runner) every(src *corev1.ConfigMap, interval time.Duration) Schedule {
+	if !r.stagger {
+		return intervalSchedule{interval: interval, jitter: r.jitter}
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(src.Namespace + "/" + src.Name))
+	return staggeredSchedule{interval: interval, offset: time.Duration(h.Sum64() % uint64(interval)), jitter: r.jitter}
+}
+
+// scheduleFor returns the schedule of the source and the spec it was parsed
+// from: its sync_schedule or sync_interval, else the [Runner]'s own. An
+// invalid spec is returned with the [Runner]'s schedule and the error.
+func (r *Runner) scheduleFor(src *corev1.ConfigMap) (string, Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var err error
+	if spec := strings.TrimSpace(src.Data[SyncScheduleKey]); spec != "" {
+		var s Schedule
+		if s, err = ParseSchedule(spec); err == nil {
+			return spec, s, nil
+		}
+		return spec, r.defaultSchedule(src), err
+	}
+	if spec := strings.TrimSpace(src.Data[SyncIntervalKey]); spec != "" {
+		d, err := time.ParseDuration(spec)
+		if err == nil && d >= MinInterval {
+			return spec, r.every(src, d), nil
+		}
+		return spec, r.defaultSchedule(src), fmt.Errorf("invalid sync_interval %q: must be a duration of at least %s", spec, MinInterval)
+	}
+	return "", r.defaultSchedule(src), nil
+}
+
+// defaultSchedule returns the schedule of a source without its own. Cron
+// schedules fire at the times they name and aren't staggered.
+func (r *Runner) defaultSchedule(src *corev1.ConfigMap) Schedule {
+	if r.schedule != nil {
+		return r.schedule
+	}
+	return r.every(src, r.interval)
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/shanmugara/cabundle-operator/internal/controller"
+)
+
+// Pod annotations controlling the injection.
+const (
+	// InjectAnnotation set to "true" requests the CA bundle to be mounted.
+	InjectAnnotation = "cabundle.io/inject"
+	// InjectSourceAnnotation overrides the bundle source, as name or
+	// namespace/name, whose aggregated bundle is mounted. It must be labeled
+	// cabundle.io/bundle-source=true.
+	InjectSourceAnnotation = "cabundle.io/inject-source"
+	// InjectJavaOptsAnnotation set to "true" additionally points the JVM at
+	// the JKS truststore through JAVA_OPTS.
+	InjectJavaOptsAnnotation = "cabundle.io/inject-java-opts"
+	// InjectTruststoreSecretAnnotation overrides the Secret in the pod's
+	// namespace holding the truststore password under TruststorePasswordKey.
+	InjectTruststoreSecretAnnotation = "cabundle.io/inject-truststore-secret"
+)
+
+// TruststorePasswordKey is the key of the truststore password in the
+// Secret named by InjectTruststoreSecretAnnotation.
+const TruststorePasswordKey = "password"
+
+const (
+	volumeName            = "cabundle"
+	truststorePasswordEnv = "CABUNDLE_TRUSTSTORE_PASSWORD"
+)
+
+// podlog is for logging in this package.
+var podlog = logf.Log.WithName("pod-resource")
+
+// SetupPodWebhookWithManager registers the webhook for Pod in the manager.
+func SetupPodWebhookWithManager(mgr ctrl.Manager, defaulter *PodCustomDefaulter) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&corev1.Pod{}).
+		WithDefaulter(defaulter).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=ignore,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod-v1.cabundle.io,admissionReviewVersions=v1
+
+// PodCustomDefaulter mounts the aggregated CA bundle ConfigMap of a bundle
+// source into pods annotated with cabundle.io/inject: "true".
+type PodCustomDefaulter struct {
+	// Reader reads the bundle sources.
+	Reader client.Reader
+	// Source is the bundle source whose aggregate_configmap is mounted by
+	// default.
+	Source types.NamespacedName
+	// MountPath is the directory the ConfigMap is mounted at.
+	MountPath string
+	// TruststoreSecret, if set, is the Secret in the pod's namespace the
+	// JKS truststore password is read from. Without it the JVM loads the
+	// truststore without checking its integrity.
+	TruststoreSecret string
+}
+
+var _ admission.CustomDefaulter = &PodCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Pod.
+func (d *PodCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return fmt.Errorf("expected a Pod object but got %T", obj)
+	}
+
+	if inject, _ := strconv.ParseBool(pod.Annotations[InjectAnnotation]); !inject {
+		return nil
+	}
+
+	cfg, err := d.sourceConfig(ctx, pod.Annotations[InjectSourceAnnotation])
+	if err != nil {
+		return err
+	}
+	if cfg.AggregateName == "" {
+		return fmt.Errorf("bundle source %s/%s has no %s to mount", cfg.SourceNamespace, cfg.SourceName, controller.AggregateKey)
+	}
+	// The override of the pod's namespace may rename the aggregate and
+	// change the formats rendered there.
+	cfg = cfg.ForTarget("", pod.Namespace)
+	cmName := cfg.AggregateName
+	if renamed, ok := cfg.Names[cfg.AggregateName]; ok {
+		cmName = renamed
+	}
+	podlog.Info("Injecting CA bundle", "pod", pod.GenerateName+pod.Name, "namespace", pod.Namespace,
+		"source", cfg.SourceNamespace+"/"+cfg.SourceName, "configmap", cmName)
+
+	injectVolume(&pod.Spec, cmName)
+
+	var env []corev1.EnvVar
+	if slices.Contains(cfg.Formats, controller.FormatPEM) {
+		env = append(env, corev1.EnvVar{Name: "SSL_CERT_FILE", Value: path.Join(d.MountPath, controller.CAKey)})
+	}
+	if javaOpts, _ := strconv.ParseBool(pod.Annotations[InjectJavaOptsAnnotation]); javaOpts {
+		if slices.Contains(cfg.Formats, controller.FormatJKS) {
+			env = append(env, d.javaOpts(pod.Annotations[InjectTruststoreSecretAnnotation])...)
+		} else {
+			podlog.Info("Not setting JAVA_OPTS, the bundle source doesn't render a JKS truststore",
+				"pod", pod.GenerateName+pod.Name, "namespace", pod.Namespace)
+		}
+	}
+
+	for i := range pod.Spec.InitContainers {
+		d.injectContainer(&pod.Spec.InitContainers[i], env)
+	}
+	for i := range pod.Spec.Containers {
+		d.injectContainer(&pod.Spec.Containers[i], env)
+	}
+
+	return nil
+}
+
+// sourceConfig reads the bundle config of the source named by the
+// InjectSourceAnnotation value, or of Source if it is empty.
+func (d *PodCustomDefaulter) sourceConfig(ctx context.Context, override string) (*controller.BundleConfig, error) {
+	key := d.Source
+	if v := strings.TrimSpace(override); v != "" {
+		if ns, name, ok := strings.Cut(v, "/"); ok {
+			key = types.NamespacedName{Namespace: ns, Name: name}
+		} else {
+			key.Name = v
+		}
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := d.Reader.Get(ctx, key, cm); err != nil {
+		return nil, fmt.Errorf("reading bundle source %s: %w", key, err)
+	}
+	if key != d.Source && cm.Labels[controller.BundleSourceLabel] != "true" {
+		return nil, fmt.Errorf("ConfigMap %s is not a bundle source", key)
+	}
+	return controller.ParseBundleConfig(cm)
+}
+
+// javaOpts returns the env vars pointing the JVM at the JKS truststore,
+// reading its password from the Secret named by the
+// InjectTruststoreSecretAnnotation value, or TruststoreSecret.
+func (d *PodCustomDefaulter) javaOpts(secretOverride string) []corev1.EnvVar {
+	opts := "-Djavax.net.ssl.trustStore=" + path.Join(d.MountPath, controller.JKSKey)
+
+	secret := d.TruststoreSecret
+	if v := strings.TrimSpace(secretOverride); v != "" {
+		secret = v
+	}
+	if secret == "" {
+		return []corev1.EnvVar{{Name: "JAVA_OPTS", Value: opts}}
+	}
+	return []corev1.EnvVar{
+		{
+			Name: truststorePasswordEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+					Key:                  TruststorePasswordKey,
+				},
+			},
+		},
+		// Kubernetes expands $(VAR) references to env vars defined earlier.
+		{Name: "JAVA_OPTS", Value: opts + " -Djavax.net.ssl.trustStorePassword=$(" + truststorePasswordEnv + ")"},
+	}
+}
+
+// injectVolume adds the ConfigMap volume unless the pod already has it.
+func injectVolume(spec *corev1.PodSpec, cmName string) {
+	for _, v := range spec.Volumes {
+		if v.Name == volumeName {
+			return
+		}
+	}
+
+	// Optional, as the bundle may not have been distributed to the pod's
+	// namespace yet, which must not keep the pod from starting.
+	optional := true
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: cmName},
+				Optional:             &optional,
+			},
+		},
+	})
+}
+
+// injectContainer mounts the bundle volume and sets env vars the container
+// doesn't already define.
+func (d *PodCustomDefaulter) injectContainer(c *corev1.Container, env []corev1.EnvVar) {
+	mounted := false
+	for _, m := range c.VolumeMounts {
+		if m.Name == volumeName {
+			mounted = true
+			break
+		}
+	}
+	if !mounted {
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: d.MountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	for _, e := range env {
+		defined := false
+		for _, existing := range c.Env {
+			if existing.Name == e.Name {
+				defined = true
+				break
+			}
+		}
+		if !defined {
+			c.Env = append(c.Env, e)
+		}
+	}
+}
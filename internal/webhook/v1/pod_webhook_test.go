@@ -0,0 +1,197 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/shanmugara/cabundle-operator/internal/controller"
+)
+
+var _ = Describe("Pod Webhook", func() {
+	var (
+		pod       *corev1.Pod
+		defaulter *PodCustomDefaulter
+	)
+
+	BeforeEach(func() {
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "apps"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "app:latest"}},
+			},
+		}
+		source := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "periodic-cabundle-enqueue", Namespace: "cert-manager"},
+			Data:       map[string]string{controller.BundleURLKey: "https://pki.example.com/", controller.AggregateKey: "ca-bundle"},
+		}
+		corpRoots := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "corp-roots",
+				Namespace: "pki",
+				Labels:    map[string]string{controller.BundleSourceLabel: "true"},
+			},
+			Data: map[string]string{controller.BundleURLKey: "https://pki.example.com/", controller.AggregateKey: "corp-roots", controller.FormatsKey: "pem,jks"},
+		}
+		jksOnly := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "java-roots",
+				Namespace: "cert-manager",
+				Labels:    map[string]string{controller.BundleSourceLabel: "true"},
+			},
+			Data: map[string]string{controller.BundleURLKey: "https://pki.example.com/", controller.AggregateKey: "java-roots", controller.FormatsKey: "jks"},
+		}
+		unlabeled := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "apps"},
+			Data:       map[string]string{controller.BundleURLKey: "https://pki.example.com/", controller.AggregateKey: "app-config"},
+		}
+		defaulter = &PodCustomDefaulter{
+			Reader:    fake.NewClientBuilder().WithObjects(source, corpRoots, jksOnly, unlabeled).Build(),
+			Source:    types.NamespacedName{Namespace: "cert-manager", Name: "periodic-cabundle-enqueue"},
+			MountPath: "/etc/cabundle",
+		}
+	})
+
+	It("leaves pods without the inject annotation untouched", func() {
+		Expect(defaulter.Default(context.Background(), pod)).To(Succeed())
+		Expect(pod.Spec.Volumes).To(BeEmpty())
+		Expect(pod.Spec.Containers[0].VolumeMounts).To(BeEmpty())
+	})
+
+	It("mounts the bundle and sets SSL_CERT_FILE for annotated pods", func() {
+		pod.Annotations = map[string]string{InjectAnnotation: "true"}
+		Expect(defaulter.Default(context.Background(), pod)).To(Succeed())
+
+		Expect(pod.Spec.Volumes).To(HaveLen(1))
+		Expect(pod.Spec.Volumes[0].ConfigMap.Name).To(Equal("ca-bundle"))
+		Expect(pod.Spec.Volumes[0].ConfigMap.Optional).To(HaveValue(BeTrue()))
+		Expect(pod.Spec.Containers[0].VolumeMounts).To(ContainElement(HaveField("MountPath", "/etc/cabundle")))
+		Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "SSL_CERT_FILE", Value: "/etc/cabundle/ca.crt"}))
+
+		By("being idempotent")
+		Expect(defaulter.Default(context.Background(), pod)).To(Succeed())
+		Expect(pod.Spec.Volumes).To(HaveLen(1))
+		Expect(pod.Spec.Containers[0].VolumeMounts).To(HaveLen(1))
+	})
+
+	It("honours the source override and JAVA_OPTS annotations", func() {
+		pod.Annotations = map[string]string{
+			InjectAnnotation:         "true",
+			InjectSourceAnnotation:   "pki/corp-roots",
+			InjectJavaOptsAnnotation: "true",
+		}
+		pod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "SSL_CERT_FILE", Value: "/custom.pem"}}
+		Expect(defaulter.Default(context.Background(), pod)).To(Succeed())
+
+		Expect(pod.Spec.Volumes[0].ConfigMap.Name).To(Equal("corp-roots"))
+		Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "SSL_CERT_FILE", Value: "/custom.pem"}))
+		Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+			Name: "JAVA_OPTS", Value: "-Djavax.net.ssl.trustStore=/etc/cabundle/truststore.jks",
+		}))
+	})
+
+	It("reads the truststore password from a Secret", func() {
+		defaulter.TruststoreSecret = "truststore"
+		pod.Annotations = map[string]string{
+			InjectAnnotation:         "true",
+			InjectSourceAnnotation:   "pki/corp-roots",
+			InjectJavaOptsAnnotation: "true",
+		}
+		Expect(defaulter.Default(context.Background(), pod)).To(Succeed())
+
+		env := pod.Spec.Containers[0].Env
+		Expect(env).To(HaveLen(3))
+		Expect(env[1].ValueFrom.SecretKeyRef.Name).To(Equal("truststore"))
+		Expect(env[1].ValueFrom.SecretKeyRef.Key).To(Equal(TruststorePasswordKey))
+		Expect(env[2]).To(Equal(corev1.EnvVar{
+			Name: "JAVA_OPTS",
+			Value: "-Djavax.net.ssl.trustStore=/etc/cabundle/truststore.jks " +
+				"-Djavax.net.ssl.trustStorePassword=$(" + env[1].Name + ")",
+		}))
+
+		By("preferring the Secret named by the pod")
+		pod.Annotations[InjectTruststoreSecretAnnotation] = "app-truststore"
+		pod.Spec.Containers[0].Env = nil
+		Expect(defaulter.Default(context.Background(), pod)).To(Succeed())
+		Expect(pod.Spec.Containers[0].Env[1].ValueFrom.SecretKeyRef.Name).To(Equal("app-truststore"))
+	})
+
+	It("only sets env vars for the formats the source renders", func() {
+		pod.Annotations = map[string]string{InjectAnnotation: "true", InjectJavaOptsAnnotation: "true"}
+		Expect(defaulter.Default(context.Background(), pod)).To(Succeed())
+		Expect(pod.Spec.Containers[0].Env).To(ConsistOf(HaveField("Name", "SSL_CERT_FILE")))
+
+		pod.Annotations[InjectSourceAnnotation] = "java-roots"
+		pod.Spec.Volumes = nil
+		pod.Spec.Containers[0].Env = nil
+		Expect(defaulter.Default(context.Background(), pod)).To(Succeed())
+		Expect(pod.Spec.Volumes[0].ConfigMap.Name).To(Equal("java-roots"))
+		Expect(pod.Spec.Containers[0].Env).To(ConsistOf(HaveField("Name", "JAVA_OPTS")))
+	})
+
+	It("honours the target override of the pod's namespace", func() {
+		overridden := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mesh-roots",
+				Namespace: "cert-manager",
+				Labels:    map[string]string{controller.BundleSourceLabel: "true"},
+			},
+			Data: map[string]string{
+				controller.BundleURLKey: "https://pki.example.com/",
+				controller.AggregateKey: "ca-bundle",
+				controller.FormatsKey:   "pem,jks",
+				controller.TargetOverridesKey: `- namespace: apps
+  names:
+    ca-bundle: istio-ca-root-cert
+  formats: [jks]
+`,
+			},
+		}
+		defaulter.Reader = fake.NewClientBuilder().WithObjects(overridden).Build()
+		pod.Annotations = map[string]string{
+			InjectAnnotation:         "true",
+			InjectSourceAnnotation:   "cert-manager/mesh-roots",
+			InjectJavaOptsAnnotation: "true",
+		}
+		Expect(defaulter.Default(context.Background(), pod)).To(Succeed())
+		Expect(pod.Spec.Volumes[0].ConfigMap.Name).To(Equal("istio-ca-root-cert"))
+		Expect(pod.Spec.Containers[0].Env).To(ConsistOf(HaveField("Name", "JAVA_OPTS")))
+
+		By("leaving pods in other namespaces on the source's defaults")
+		other := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "web", Annotations: pod.Annotations},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:latest"}}},
+		}
+		Expect(defaulter.Default(context.Background(), other)).To(Succeed())
+		Expect(other.Spec.Volumes[0].ConfigMap.Name).To(Equal("ca-bundle"))
+		Expect(other.Spec.Containers[0].Env).To(ConsistOf(HaveField("Name", "SSL_CERT_FILE"), HaveField("Name", "JAVA_OPTS")))
+	})
+
+	It("refuses ConfigMaps that aren't bundle sources", func() {
+		pod.Annotations = map[string]string{InjectAnnotation: "true", InjectSourceAnnotation: "apps/app-config"}
+		Expect(defaulter.Default(context.Background(), pod)).To(MatchError(ContainSubstring("not a bundle source")))
+		Expect(pod.Spec.Volumes).To(BeEmpty())
+	})
+})